@@ -0,0 +1,142 @@
+package doc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fextra/internal/cfbtest"
+)
+
+// testStream 生成内容随seed变化的流数据，不同流之间读错位置时内容不同
+func testStream(size int, seed byte) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i*7) + seed
+	}
+	return data
+}
+
+func writeCFB(t *testing.T, streams []cfbtest.Stream, opts cfbtest.Options) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.doc")
+	if err := os.WriteFile(path, cfbtest.Build(streams, opts), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadStreamSectorMath(t *testing.T) {
+	streams := []cfbtest.Stream{
+		{Name: "WordDocument", Data: testStream(5000, 1)},
+		{Name: "1Table", Data: testStream(300, 2)}, // 位于迷你流
+		{Name: "Data", Data: testStream(9000, 3)},
+		{Name: "CompObj", Data: testStream(70, 4)},
+	}
+	cases := []struct {
+		name string
+		opts cfbtest.Options
+	}{
+		{"v3", cfbtest.Options{SectorSize: 512}},
+		{"v3 fragmented", cfbtest.Options{SectorSize: 512, Fragment: true}},
+		{"v4", cfbtest.Options{SectorSize: 4096}},
+		{"v4 fragmented", cfbtest.Options{SectorSize: 4096, Fragment: true}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			checkStreams(t, writeCFB(t, streams, tc.opts), streams)
+		})
+	}
+}
+
+// TestReadStreamMiniFATChain MiniFAT占用多个不连续的扇区时按FAT链读取
+func TestReadStreamMiniFATChain(t *testing.T) {
+	streams := []cfbtest.Stream{{Name: "WordDocument", Data: testStream(4096, 1)}}
+	// 每个流占5个迷你扇区，共200个迷你扇区，512字节扇区的MiniFAT需要2个扇区
+	for i := 0; i < 40; i++ {
+		streams = append(streams, cfbtest.Stream{Name: fmt.Sprintf("Stream%d", i), Data: testStream(300, byte(i+2))})
+	}
+	checkStreams(t, writeCFB(t, streams, cfbtest.Options{SectorSize: 512, Fragment: true}), streams)
+}
+
+// checkStreams 打开path并校验WordDocument流及各流的内容
+func checkStreams(t *testing.T, path string, streams []cfbtest.Stream) {
+	t.Helper()
+	d, err := OpenDocParse(path)
+	if err != nil {
+		t.Fatalf("OpenDocParse: %v", err)
+	}
+	defer d.Close()
+
+	if !bytes.Equal(d.WordDocumentStream, streams[0].Data) {
+		t.Errorf("WordDocumentStream: got %d bytes, want %d bytes of the stream", len(d.WordDocumentStream), len(streams[0].Data))
+	}
+	for _, s := range streams {
+		got, err := d.ReadStream(s.Name)
+		if err != nil {
+			t.Errorf("ReadStream(%s): %v", s.Name, err)
+			continue
+		}
+		if !bytes.Equal(got, s.Data) {
+			t.Errorf("ReadStream(%s): content differs from the stream written", s.Name)
+		}
+	}
+}
+
+// word97Document 构造只包含FIB及正文的Word 97 WordDocument流，正文以8位压缩片段保存在0x400处，
+// CLX位于表流的fcClx处，fWhichTblStm指向1Table
+func word97Document(text string, fcClx, lcbClx uint32) []byte {
+	le := binary.LittleEndian
+	wd := make([]byte, 0x400+len(text))
+	le.PutUint16(wd[0x00:], 0xA5EC)
+	le.PutUint16(wd[0x02:], 0x00C1)
+	le.PutUint16(wd[0x0A:], 0x0200)
+	off := 32
+	le.PutUint16(wd[off:], 14) // csw
+	off += 2 + 28
+	le.PutUint16(wd[off:], 22) // cslw
+	le.PutUint32(wd[off+2+3*4:], uint32(len(text)))
+	off += 2 + 88
+	le.PutUint16(wd[off:], 0x5D) // cbRgFcLcb
+	le.PutUint32(wd[off+2+66*4:], fcClx)
+	le.PutUint32(wd[off+2+67*4:], lcbClx)
+	copy(wd[0x400:], text)
+	return wd
+}
+
+// TestParseFibClxFragmentedTable 表流的扇区不连续时按FAT链读取CLX
+func TestParseFibClxFragmentedTable(t *testing.T) {
+	const text = "fragmented table stream\r"
+	le := binary.LittleEndian
+	clx := []byte{0x02, 0, 0, 0, 0}
+	le.PutUint32(clx[1:], 2*4+8)
+	clx = le.AppendUint32(clx, 0)
+	clx = le.AppendUint32(clx, uint32(len(text)))
+	clx = le.AppendUint16(clx, 0)
+	clx = le.AppendUint32(clx, 0x40000000|0x400*2)
+	clx = le.AppendUint16(clx, 0)
+
+	// CLX位于表流的第10个扇区之后，与其他流交错分配时不在起始扇区之后的连续位置
+	table := testStream(6000, 2)
+	const fcClx = 5000
+	copy(table[fcClx:], clx)
+	streams := []cfbtest.Stream{
+		{Name: "WordDocument", Data: word97Document(text, fcClx, uint32(len(clx)))},
+		{Name: "1Table", Data: table},
+		{Name: "Data", Data: testStream(9000, 3)},
+	}
+	for _, opts := range []cfbtest.Options{{SectorSize: 512}, {SectorSize: 512, Fragment: true}} {
+		text, err := (&OfficeDocParser{}).Parse(writeCFB(t, streams, opts))
+		if err != nil {
+			t.Fatalf("fragment=%v: Parse: %v", opts.Fragment, err)
+		}
+		if !strings.Contains(string(text), "fragmented table stream") {
+			t.Errorf("fragment=%v: got %q", opts.Fragment, text)
+		}
+	}
+}
@@ -4,23 +4,31 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/hex"
-	"errors"
+	"fextra/internal"
 	"fextra/pkg/logger"
 	"fextra/pkg/office/doc/fib"
+	"fextra/pkg/office/officeenc"
 	"fmt"
+	"io"
 	"os"
+	"slices"
 	"strings"
-	"unicode/utf16"
-	"unicode/utf8"
 
+	"golang.org/x/text/encoding"
 	"golang.org/x/text/encoding/simplifiedchinese"
 )
 
 const (
 	DocSignature    = "d0cf11e0a1b11ae1"
-	DocHeaderOffset = 512
+	DocHeaderOffset = 512 // 文件头大小，v4文件的文件头之后补零至4096字节的整个扇区
 )
 
+// sectorOffset 返回扇区在文件中的偏移：文件头占用第一个扇区（v3为512字节，v4为4096字节），
+// 0号扇区从其后开始，因此偏移为(扇区ID+1)*扇区大小
+func sectorOffset(sector uint32, sectorSize int) int64 {
+	return (int64(sector) + 1) * int64(sectorSize)
+}
+
 // 文件头结构 (512字节)
 type FileHeader struct {
 	Signature            [8]byte     // 文件标识：0xD0CF11E0A1B11AE1 [1,8](@ref)
@@ -93,70 +101,24 @@ type DocParse struct {
 	MainCharactorNum    uint32 // 主要字符数
 	CLXOffset           uint32 // CLX偏移量
 	CLXSize             uint32 // CLX大小
+
+	MaxParagraphs int               // 最多提取的段落数，为0时不限制
+	CodePage      encoding.Encoding // 压缩文本片段使用的单字节代码页，为nil时按FibBase.Language选择
 }
 
-type OfficeDocParser struct{}
+type OfficeDocParser struct {
+	Limit    int               // 最多提取的段落数，用于生成预览，为0时不限制
+	CodePage encoding.Encoding // 压缩(8-bit)文本片段的代码页，为nil时中文文档按GBK、其他按Windows-1252解码
+}
 
 func decodeText(data []byte, encodingFlag byte) string {
 	if encodingFlag == 0x00 { // ANSI编码（GBK中文）
 		decoder := simplifiedchinese.GBK.NewDecoder()
 		result, _ := decoder.String(string(data))
-		return result
+		return internal.ReplaceInvalid(result)
 	} else { // UTF-16LE
-		runes := make([]rune, len(data)/2)
-		for i := 0; i < len(runes); i++ {
-			runes[i] = rune(binary.LittleEndian.Uint16(data[2*i:]))
-		}
-		return string(runes)
-	}
-}
-
-// 解码UTF-16字节流为字符串（支持代理对和字节序处理）
-func decodeUTF16(data []byte, byteOrder binary.ByteOrder) string {
-	// 1. 字节序检测与BOM处理
-	var bomSize int
-	if len(data) >= 2 {
-		switch {
-		case data[0] == 0xFE && data[1] == 0xFF:
-			byteOrder = binary.BigEndian // 大端序标识
-			bomSize = 2
-		case data[0] == 0xFF && data[1] == 0xFE:
-			byteOrder = binary.LittleEndian // 小端序标识
-			bomSize = 2
-		}
-	}
-	if byteOrder == nil {
-		byteOrder = binary.LittleEndian // DOC默认小端序
+		return officeenc.DecodeUTF16(data, binary.LittleEndian)
 	}
-
-	// 2. 将字节流转换为uint16序列
-	u16s := make([]uint16, (len(data)-bomSize)/2)
-	for i := 0; i < len(u16s); i++ {
-		u16s[i] = byteOrder.Uint16(data[bomSize+2*i:])
-	}
-
-	// 3. 处理UTF-16代理对（4字节字符）
-	var runes []rune
-	for i := 0; i < len(u16s); {
-		switch {
-		case utf16.IsSurrogate(int32(u16s[i])):
-			if i+1 < len(u16s) {
-				// 解码代理对（如中文/emoji）
-				r := utf16.DecodeRune(rune(u16s[i]), rune(u16s[i+1]))
-				runes = append(runes, r)
-				i += 2 // 跳过已处理的代理对
-			} else {
-				// 代理对不完整
-				runes = append(runes, utf8.RuneError)
-				i++
-			}
-		default:
-			// 基本平面字符（2字节）
-			runes = append(runes, rune(u16s[i]))
-			i++
-		}
-	}
-	return string(runes)
 }
 
 func (h *FileHeader) Printf() {
@@ -224,16 +186,68 @@ func (d *DocParse) Close() {
 	}
 }
 
+// Reset 关闭文件并清空上一个文件的解析结果，保留FAT、目录项及WordDocument流已分配的内存，
+// 批量解析时配合对象池复用
+func (d *DocParse) Reset() {
+	d.Close()
+	*d.FileHeader = FileHeader{}
+	clear(d.DirEntry)
+	d.DirEntry = d.DirEntry[:0]
+	d.FIB = nil
+	d.DIFAT = d.DIFAT[:0]
+	d.FAT = d.FAT[:0]
+	d.MiniFAT = d.MiniFAT[:0]
+	d.WordDocumentStream = d.WordDocumentStream[:0]
+	d.SectorSize = 0
+	d.IsMiniFAT = false
+	d.Table1SectorStartID, d.Table1SectorSize = 0, 0
+	d.Table0SectorStartID, d.Table0SectorSize = 0, 0
+	d.MainCharactorNum, d.CLXOffset, d.CLXSize = 0, 0, 0
+	d.MaxParagraphs = 0
+	d.CodePage = nil
+}
+
+// Validate 校验文件头签名及版本、扇区大小字段，避免损坏的文件导致超大的扇区大小用于定位和内存分配
+// 规范要求：MajorVersion=3时扇区为512字节(SectorShift=9)，MajorVersion=4时为4096字节(SectorShift=12)，迷你扇区固定64字节
+func (h *FileHeader) Validate() error {
+	// 验证签名 (偏移0x0000)
+	if hex.EncodeToString(h.Signature[:]) != DocSignature {
+		return fmt.Errorf("无效的OLE签名: %w", internal.ErrInvalidSignature)
+	}
+
+	switch {
+	case h.MajorVersion == 3 && h.SectorShift == 9:
+	case h.MajorVersion == 4 && h.SectorShift == 12:
+	default:
+		return fmt.Errorf("无效的OLE文件头: MajorVersion=%d, SectorShift=%d: %w", h.MajorVersion, h.SectorShift, internal.ErrUnsupportedFormat)
+	}
+	if h.MiniSectorShift != 6 {
+		return fmt.Errorf("无效的OLE文件头: MiniSectorShift=%d: %w", h.MiniSectorShift, internal.ErrUnsupportedFormat)
+	}
+	return nil
+}
+
+// CheckHeader 读取并校验OLE复合文档文件头，供交由第三方库解析的xls/ppt在解析前检查
+func CheckHeader(r io.Reader) error {
+	var header FileHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return fmt.Errorf("读取OLE文件头失败: %w", err)
+	}
+	return header.Validate()
+}
+
 func (d *DocParse) ParseHeader() error {
 	file := d.File
-	header := &FileHeader{}
+	header := d.FileHeader
+	if header == nil {
+		header = &FileHeader{}
+	}
 	if err := binary.Read(file, binary.LittleEndian, header); err != nil {
 		return err
 	}
 
-	// 验证签名 (偏移0x0000)
-	if hex.EncodeToString(header.Signature[:]) != DocSignature {
-		return errors.New("无效的OLE签名")
+	if err := header.Validate(); err != nil {
+		return err
 	}
 
 	header.Printf()
@@ -243,9 +257,23 @@ func (d *DocParse) ParseHeader() error {
 }
 
 func (d *DocParse) GetWordDocumentStream(e *PDirectoryEntry) error {
-	var textBuilder bytes.Buffer
-
 	entry := e.Entry
+
+	// 小于迷你流截断大小的WordDocument流保存在根存储的迷你流中，起始扇区为MiniFAT中的序号
+	// 根存储为0号目录项，读取到WordDocument时已加载
+	if entry.StreamSize < uint64(d.miniStreamCutoff()) {
+		logger.Logger.Printf("WordDocument位于迷你流，起始迷你扇区: %d, stream大小: %d\n", entry.StartSectorID, entry.StreamSize)
+		stream, err := d.readMiniStream(entry)
+		if err != nil {
+			return err
+		}
+		d.WordDocumentStream = append(d.WordDocumentStream[:0], stream...)
+		return nil
+	}
+
+	// 复用上一次解析分配的内存
+	textBuilder := bytes.NewBuffer(d.WordDocumentStream[:0])
+
 	currentSector := entry.StartSectorID
 
 	logger.Logger.Printf("开始提取文本流，扇区大小：%d, 起始扇区: %d, stream大小: %d\n", d.SectorSize, currentSector, entry.StreamSize)
@@ -255,8 +283,7 @@ func (d *DocParse) GetWordDocumentStream(e *PDirectoryEntry) error {
 		if pos >= entry.StreamSize {
 			break
 		}
-		// 计算扇区物理位置：文件头后偏移 = 512 + 扇区ID * 扇区大小
-		sectorPos := int64(DocHeaderOffset + int(currentSector)*int(d.SectorSize))
+		sectorPos := sectorOffset(currentSector, d.SectorSize)
 		logger.DebugLogger.Printf("文件读取偏移: 0x%x(扇区id:%d), 读取长度：%d, 剩余长度：%d\n", sectorPos, currentSector, pos, entry.StreamSize-pos)
 
 		_, err := d.File.Seek(sectorPos, 0)
@@ -310,48 +337,59 @@ func (d *DocParse) GetDirEntries() error {
 	header := d.FileHeader
 	file := d.File
 
-	dirSectorPos := DocHeaderOffset + int64(header.DirectoryStart)*int64(d.SectorSize)
-	logger.Logger.Printf("扇区大小：%d, 扇区数量: %d, 开始扇区: 0x%x, 目录扇区起始偏移: 0x%x\n",
-		int64(d.SectorSize), header.DirectorySectorCnt, header.DirectoryStart, dirSectorPos)
-
-	_, err := file.Seek(dirSectorPos, 0)
+	// 目录可能占用多个扇区，按FAT链依次读取（MajorVersion=3时DirectorySectorCnt为0，无法据此计算）
+	dirSectors, err := d.TraverseFAT(header.DirectoryStart)
 	if err != nil {
 		return err
 	}
+	logger.Logger.Printf("扇区大小：%d, 目录扇区数量: %d, 开始扇区: 0x%x\n",
+		int64(d.SectorSize), len(dirSectors), header.DirectoryStart)
 
-	direntryCount := 0
-	if header.MajorVersion == 3 {
-		direntryCount = d.SectorSize / 128
-	} else {
-		direntryCount = int(header.DirectorySectorCnt+1) * (d.SectorSize / 128)
-	}
-
-	for i := 0; i < direntryCount; i++ {
-		entry := &DirectoryEntry{}
-		if err := binary.Read(file, binary.LittleEndian, entry); err != nil {
-			break
-		}
-		if entry.NameLen > 64 {
-			logger.Logger.Printf("目录项名称长度超过64字节")
-			return nil
+	entriesPerSector := d.SectorSize / 128
+	for _, sector := range dirSectors {
+		dirSectorPos := sectorOffset(sector, d.SectorSize)
+		if _, err := file.Seek(dirSectorPos, 0); err != nil {
+			return err
 		}
 
-		name := decodeUTF16(entry.Name[:entry.NameLen], binary.LittleEndian)
-		pd := &PDirectoryEntry{
-			Name:  name,
-			Type:  entry.ObjectType,
-			Entry: entry,
+		// 先读入整个扇区，UpdateDirectoryInfo读取流时会移动文件偏移
+		sectorData := make([]byte, d.SectorSize)
+		if _, err := io.ReadFull(file, sectorData); err != nil {
+			return err
 		}
-		d.DirEntry = append(d.DirEntry, pd)
+		sectorReader := bytes.NewReader(sectorData)
 
-		d.UpdateDirectoryInfo(pd)
+		for i := 0; i < entriesPerSector; i++ {
+			entry := &DirectoryEntry{}
+			if err := binary.Read(sectorReader, binary.LittleEndian, entry); err != nil {
+				break
+			}
+			if entry.NameLen > 64 {
+				logger.Logger.Printf("目录项名称长度超过64字节")
+				return nil
+			}
+			// MajorVersion=3时流大小的高32位无意义，可能为任意值
+			if header.MajorVersion == 3 {
+				entry.StreamSize &= 0xFFFFFFFF
+			}
 
-		logger.Logger.Printf("目录项名称: %s, 长度： %d, 类型: %d, 起始扇区: %d, 大小: %d\n",
-			name, entry.NameLen, entry.ObjectType, entry.StartSectorID, entry.StreamSize)
+			name := officeenc.DecodeUTF16(entry.Name[:entry.NameLen], binary.LittleEndian)
+			pd := &PDirectoryEntry{
+				Name:  name,
+				Type:  entry.ObjectType,
+				Entry: entry,
+			}
+			d.DirEntry = append(d.DirEntry, pd)
+
+			d.UpdateDirectoryInfo(pd)
+
+			logger.Logger.Printf("目录项名称: %s, 长度： %d, 类型: %d, 起始扇区: %d, 大小: %d\n",
+				name, entry.NameLen, entry.ObjectType, entry.StartSectorID, entry.StreamSize)
+		}
 	}
 
 	if len(d.DirEntry) == 0 {
-		return errors.New("no directory entry found")
+		return fmt.Errorf("no directory entry found: %w", internal.ErrStreamNotFound)
 	}
 	return nil
 }
@@ -368,7 +406,7 @@ func (d *DocParse) GetRootEntrySectorStartID() (uint32, bool) {
 // 也就是解析FIB
 func (d *DocParse) ParseWordDocument() error {
 	if len(d.WordDocumentStream) == 0 {
-		return fmt.Errorf("no worddocument found\n")
+		return fmt.Errorf("no worddocument found: %w\n", internal.ErrStreamNotFound)
 	}
 
 	// 解析FIB文件格式
@@ -379,31 +417,138 @@ func (d *DocParse) ParseWordDocument() error {
 
 	d.FIB = fib
 
+	// fEncrypted: 文档已加密或XOR混淆，文本无法直接读取
+	if d.FIB.Base.Flags&0x0100 != 0 {
+		return fmt.Errorf("文档已加密: %w", internal.ErrEncrypted)
+	}
+
+	// Word 6.0/95的文本在WordDocument流中，不需要表流中的CLX
+	if d.FIB.IsWord6() {
+		return nil
+	}
+
 	// 验证CLX偏移是否有效
 	if d.FIB.FcClx == 0 || d.FIB.LcbClx == 0 {
-		return fmt.Errorf("未找到有效的CLX偏移信息")
+		return fmt.Errorf("未找到有效的CLX偏移信息: %w", internal.ErrStreamNotFound)
 	}
 	return nil
 }
 
 func (d *DocParse) ParseFibClx() ([]byte, error) {
-	var tableOffset uint32
-	var tableSize uint64
-	tableOffset = DocHeaderOffset + d.Table0SectorStartID*uint32(d.SectorSize)
-	tableSize = d.Table0SectorSize
+	if d.FIB.IsWord6() {
+		return d.FIB.ParseWord6Text(d.WordDocumentStream, d.MaxParagraphs, d.CodePage)
+	}
+
+	// fWhichTblStm决定使用0Table还是1Table，表流按FAT链（或迷你流）读取，其扇区不一定连续
+	tableName := "0Table"
 	if d.FIB.Base != nil && d.FIB.Base.Flags&0x0200 != 0 {
-		tableOffset = DocHeaderOffset + d.Table1SectorStartID*uint32(d.SectorSize)
-		tableSize = d.Table1SectorSize
+		tableName = "1Table"
+	}
+	table, err := d.ReadStream(tableName)
+	if err != nil {
+		return []byte{}, err
 	}
 
-	logger.DebugLogger.Printf("flag: %v, tableOffset: 0x%x, tableSize: 0x%x\n",
-		d.FIB.Base.Flags&0x0200, tableOffset, tableSize)
-	return d.FIB.ParseFibClx(d.File, d.WordDocumentStream, tableOffset, tableSize)
+	logger.DebugLogger.Printf("flag: %v, table: %s, tableSize: 0x%x\n",
+		d.FIB.Base.Flags&0x0200, tableName, len(table))
+	return d.FIB.ParseFibClxLimit(table, d.WordDocumentStream, d.MaxParagraphs, d.CodePage)
 }
 
 // 定位
 func (d *DocParse) ExtractText() ([]byte, error) {
-	return d.ParseFibClx()
+	text, err := d.ParseFibClx()
+	return cleanControlChars(stripFieldCodes(text)), err
+}
+
+// 正文中的结构控制字符，见规范2.4.3 Overview of Tables及2.8.1 Special Characters
+const (
+	cellMark          = 0x07 // 单元格结束标记，单元格最后的标记之后紧跟一个行结束标记
+	lineBreak         = 0x0B // 手动换行
+	pageBreak         = 0x0C // 分页符/分节符
+	paragraphMark     = 0x0D // 段落结束
+	nonBreakingHyphen = 0x1E // 不间断连字符
+	optionalHyphen    = 0x1F // 可选连字符，仅在行尾断词时显示
+)
+
+// cleanControlChars 将结构控制字符替换为对应的可见文本：段落、换行、分页转换为换行，
+// 单元格以制表符分隔、表格行以换行结束，不间断连字符转换为"-"，可选连字符及图片、脚注等锚点字符删除
+//
+// 提取时已按段落属性（sprmPFTtp）将行结束标记替换为fib.RowEndMark的，其余0x07均为单元格结束标记，
+// 空单元格输出空字段；否则（如Word 6.0文档）只能将连续两个0x07视为最后一个单元格及行结束
+func cleanControlChars(text []byte) []byte {
+	marked := bytes.IndexByte(text, fib.RowEndMark) >= 0
+	out := text[:0]
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == cellMark && marked:
+			// 行内最后一个单元格不输出分隔符，由随后的行结束标记输出换行
+			if i+1 >= len(text) || text[i+1] != fib.RowEndMark {
+				out = append(out, '\t')
+			}
+		case c == fib.RowEndMark:
+			out = append(out, '\n')
+		case c == cellMark:
+			if i+1 < len(text) && text[i+1] == cellMark {
+				out = append(out, '\n')
+				i++
+			} else {
+				out = append(out, '\t')
+			}
+		case c == paragraphMark || c == lineBreak || c == pageBreak:
+			out = append(out, '\n')
+		case c == nonBreakingHyphen:
+			out = append(out, '-')
+		case c == optionalHyphen || c < 0x20 && c != '\t' && c != '\n':
+			// 删除可选连字符及其他控制字符（如0x01图片、0x02脚注引用、0x05批注引用、0x08绘图对象）
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// 域字符，见规范2.8.25 PlcFld：域由0x13开始，0x14分隔域代码与域结果，0x15结束
+const (
+	fieldBegin     = 0x13
+	fieldSeparator = 0x14
+	fieldEnd       = 0x15
+)
+
+// stripFieldCodes 删除域代码（如 HYPERLINK "..."、PAGEREF、TOC），保留域结果
+// 域可以嵌套，嵌套在外层域代码中的内容一并删除；没有分隔符的域只有域代码，整体删除
+func stripFieldCodes(text []byte) []byte {
+	if bytes.IndexByte(text, fieldBegin) < 0 {
+		return text
+	}
+
+	out := text[:0]
+	var inCode []bool // 各层域是否处于域代码部分
+	hidden := 0       // 处于域代码部分的层数
+	for _, c := range text {
+		switch c {
+		case fieldBegin:
+			inCode = append(inCode, true)
+			hidden++
+		case fieldSeparator:
+			if n := len(inCode); n > 0 && inCode[n-1] {
+				inCode[n-1] = false
+				hidden--
+			}
+		case fieldEnd:
+			if n := len(inCode); n > 0 {
+				if inCode[n-1] {
+					hidden--
+				}
+				inCode = inCode[:n-1]
+			}
+		default:
+			if hidden == 0 {
+				out = append(out, c)
+			}
+		}
+	}
+	return out
 }
 
 func (d *DocParse) ExtractEntry(entry *DirectoryEntry, sectorSize uint64, isMini bool) ([]byte, error) {
@@ -417,8 +562,7 @@ func (d *DocParse) ExtractEntry(entry *DirectoryEntry, sectorSize uint64, isMini
 		if pos >= entry.StreamSize {
 			break
 		}
-		// 计算扇区物理位置：文件头后偏移 = 512 + 扇区ID * 扇区大小
-		sectorPos := int64(512 + int(currentSector)*int(sectorSize))
+		sectorPos := sectorOffset(currentSector, int(sectorSize))
 		logger.DebugLogger.Printf("文件读取偏移: 0x%x, 读取长度：%d, 剩余长度：%d\n", sectorPos, pos, entry.StreamSize-pos)
 		_, err := d.File.Seek(sectorPos, 0)
 		if err != nil {
@@ -451,7 +595,7 @@ func (d *DocParse) ExtractEntry(entry *DirectoryEntry, sectorSize uint64, isMini
 
 func (d *DocParse) LoadFAT() error {
 	file := d.File
-	fat := make([]uint32, 0)
+	fat := d.FAT[:0]
 	entriesPerSector := d.SectorSize / 4 // 每个扇区的FAT条目数
 
 	// 使用DIFAT中的扇区ID读取所有FAT扇区
@@ -459,7 +603,7 @@ func (d *DocParse) LoadFAT() error {
 		if fatSectorID == 0xFFFFFFFF {
 			continue // 跳过空条目
 		}
-		sectorPos := int64(DocHeaderOffset) + int64(fatSectorID)*int64(d.SectorSize)
+		sectorPos := sectorOffset(fatSectorID, d.SectorSize)
 		_, err := file.Seek(sectorPos, 0)
 		if err != nil {
 			return err
@@ -488,22 +632,28 @@ func (d *DocParse) LoadMiniFAT() error {
 
 	sectorNum := header.MiniFATSectorCnt
 	currentSector := header.MiniFATStart
-	miniFAT := make([]uint32, header.MiniFATSectorCnt*(uint32(d.SectorSize)/4)) //每个条目4字节
 	logger.Logger.Printf("Mini扇区 ====> 数量：%d  大小: %d, 起始分区id: %d\n", sectorNum, d.SectorSize, currentSector)
 
-	sectorPos := int64(512 + int(currentSector)*d.SectorSize)
-	logger.Logger.Printf("Mini扇区起始偏移: 0x%x\n", sectorPos)
-
-	_, err := file.Seek(sectorPos, 0)
+	// MiniFAT保存在FAT链中，扇区不一定连续
+	chain, err := d.TraverseFAT(currentSector)
 	if err != nil {
-		return err
+		return fmt.Errorf("读取MiniFAT扇区链失败: %w", err)
+	}
+	if uint32(len(chain)) > sectorNum {
+		chain = chain[:sectorNum]
 	}
 
-	// 读取Mini FAT表（每个条目4字节）
-	for i := range miniFAT {
-		if err := binary.Read(file, binary.LittleEndian, &miniFAT[i]); err != nil {
+	entriesPerSector := d.SectorSize / 4 // 每个条目4字节
+	miniFAT := d.MiniFAT[:0]
+	for _, sector := range chain {
+		if _, err := file.Seek(sectorOffset(sector, d.SectorSize), 0); err != nil {
 			return err
 		}
+		entries := make([]uint32, entriesPerSector)
+		if err := binary.Read(file, binary.LittleEndian, &entries); err != nil {
+			return err
+		}
+		miniFAT = append(miniFAT, entries...)
 	}
 	d.MiniFAT = miniFAT
 	logger.DebugLogger.Printf("迷你扇区细节[%d]： %v\n", len(miniFAT), miniFAT)
@@ -515,7 +665,7 @@ func (d *DocParse) LoadDIFAT() error {
 	file := d.File
 
 	// 1. 处理头部109个DIFAT条目
-	difat := make([]uint32, 0, 109+int(header.DIFATSectorCnt)*d.SectorSize/4)
+	difat := slices.Grow(d.DIFAT[:0], 109+int(header.DIFATSectorCnt)*d.SectorSize/4)
 	for _, sector := range header.DiFAT {
 		if sector != 0xFFFFFFFF { // 0xFFFFFFFF表示空条目
 			difat = append(difat, sector)
@@ -525,7 +675,7 @@ func (d *DocParse) LoadDIFAT() error {
 	// 2. 处理额外的DIFAT扇区
 	currentSector := header.DiFATSectorStart
 	for i := uint32(0); i < header.DIFATSectorCnt; i++ {
-		sectorPos := DocHeaderOffset + int64(currentSector)*int64(d.SectorSize)
+		sectorPos := sectorOffset(currentSector, d.SectorSize)
 		_, err := file.Seek(sectorPos, 0)
 		if err != nil {
 			return err
@@ -559,7 +709,11 @@ func (d *DocParse) TraverseFAT(startSector uint32) ([]uint32, error) {
 
 	for current != 0xFFFFFFFE { // 0xFFFFFFFE表示链结束
 		if int(current) >= len(d.FAT) {
-			return nil, fmt.Errorf("无效的FAT索引%d", current)
+			return nil, fmt.Errorf("无效的FAT索引%d: %w", current, internal.ErrTruncated)
+		}
+		// 链长度超过FAT条目数时存在循环
+		if len(chain) >= len(d.FAT) {
+			return nil, fmt.Errorf("FAT扇区链存在循环: %w", internal.ErrTruncated)
 		}
 		chain = append(chain, current)
 		current = d.FAT[current] // 获取下一扇区
@@ -573,7 +727,7 @@ func (d *DocParse) TraverseMiniFAT(startSector uint32) ([]uint32, error) {
 
 	for current != 0xFFFFFFFE {
 		if int(current) >= len(d.MiniFAT) {
-			return nil, fmt.Errorf("无效的MiniFAT索引%d", current)
+			return nil, fmt.Errorf("无效的MiniFAT索引%d: %w", current, internal.ErrTruncated)
 		}
 		chain = append(chain, current)
 		current = d.MiniFAT[current]
@@ -634,35 +788,187 @@ func ExtractDoc(fn string) ([]byte, error) {
 }
 */
 
-func (p *OfficeDocParser) Parse(filePath string) ([]byte, error) {
+// OpenDocParse 打开doc文件并加载文件头、FAT/MiniFAT及目录项，调用方负责Close
+func OpenDocParse(filePath string) (*DocParse, error) {
 	docparser, err := NewDocParse(filePath)
 	if err != nil {
-		return []byte{}, fmt.Errorf("创建DocParse实例失败: %w\n", err)
+		return nil, fmt.Errorf("创建DocParse实例失败: %w\n", err)
 	}
-	defer docparser.Close()
 
+	if err = docparser.load(); err != nil {
+		docparser.Close()
+		return nil, err
+	}
+	return docparser, nil
+}
+
+// docParsePool 复用DocParse，批量解析时避免每个文件重新分配FAT、目录项等
+var docParsePool = internal.NewPool(func() *DocParse {
+	return &DocParse{FileHeader: &FileHeader{}}
+})
+
+// Open 在已Reset的DocParse上打开新文件并加载，失败时关闭文件
+func (d *DocParse) Open(filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("文件 %s 打开失败: %w", filePath, err)
+	}
+	d.File = file
+
+	if err = d.load(); err != nil {
+		d.Close()
+		return err
+	}
+	return nil
+}
+
+// load 依次加载文件头、DIFAT、FAT、MiniFAT及目录项
+func (d *DocParse) load() error {
 	// 1. 解析文件头
-	if err = docparser.ParseHeader(); err != nil {
-		return []byte{}, fmt.Errorf("解析文件头失败: %w\n", err)
+	if err := d.ParseHeader(); err != nil {
+		return fmt.Errorf("解析文件头失败: %w\n", err)
 	}
 
 	// 2. 解析difat表
-	if err = docparser.LoadDIFAT(); err != nil {
-		return []byte{}, fmt.Errorf("加载DIFAT表失败: %w\n", err)
+	if err := d.LoadDIFAT(); err != nil {
+		return fmt.Errorf("加载DIFAT表失败: %w\n", err)
 	}
 
 	// 3. 加载FAT表
-	if err = docparser.LoadFAT(); err != nil {
-		return []byte{}, fmt.Errorf("加载FAT表失败: %w\n", err)
+	if err := d.LoadFAT(); err != nil {
+		return fmt.Errorf("加载FAT表失败: %w\n", err)
 	}
 
-	if err = docparser.LoadMiniFAT(); err != nil {
-		return []byte{}, fmt.Errorf("加载MiniFAT表失败: %w\n", err)
+	if err := d.LoadMiniFAT(); err != nil {
+		return fmt.Errorf("加载MiniFAT表失败: %w\n", err)
 	}
 
-	if err = docparser.GetDirEntries(); err != nil {
-		return []byte{}, fmt.Errorf("获取目录项失败: %w\n", err)
+	if err := d.GetDirEntries(); err != nil {
+		return fmt.Errorf("获取目录项失败: %w\n", err)
+	}
+	return nil
+}
+
+// ListStreams 返回文件中所有的OLE流（如WordDocument、1Table、Data、ObjectPool下的流），用于调试或自定义提取
+// 返回的名称已去除结尾的NUL，可直接传给ReadStream
+func (d *DocParse) ListStreams() []PDirectoryEntry {
+	var streams []PDirectoryEntry
+	for _, entry := range d.DirEntry {
+		if entry.Type == 0x02 {
+			stream := *entry
+			stream.Name = strings.TrimRight(stream.Name, "\x00")
+			streams = append(streams, stream)
+		}
+	}
+	return streams
+}
+
+// ReadStream 按名称读取OLE流的完整内容，小于迷你流截断大小的流从迷你流中读取
+func (d *DocParse) ReadStream(name string) ([]byte, error) {
+	for _, entry := range d.DirEntry {
+		if entry.Type != 0x02 || strings.TrimRight(entry.Name, "\x00") != name {
+			continue
+		}
+		return d.ReadEntry(entry)
+	}
+	return nil, fmt.Errorf("流 %s 不存在: %w", name, internal.ErrStreamNotFound)
+}
+
+// ReadEntry 读取流目录项的完整内容，小于迷你流截断大小的流从迷你流中读取
+func (d *DocParse) ReadEntry(entry *PDirectoryEntry) ([]byte, error) {
+	if entry.Entry.StreamSize < uint64(d.miniStreamCutoff()) {
+		return d.readMiniStream(entry.Entry)
+	}
+	return d.ExtractEntry(entry.Entry, uint64(d.SectorSize), false)
+}
+
+// Children 返回存储目录项（根存储为DirEntry[0]）下的直接子项，按目录树中序遍历
+// 不同存储下的子项可以同名（如MSG各收件人存储中的属性流），需按存储分别查找时使用
+func (d *DocParse) Children(storage *PDirectoryEntry) []*PDirectoryEntry {
+	var children []*PDirectoryEntry
+	visited := make(map[uint32]bool)
+	var walk func(id uint32)
+	walk = func(id uint32) {
+		// 0xFFFFFFFF表示无兄弟/子项，损坏的文件中可能出现环
+		if id == 0xFFFFFFFF || int(id) >= len(d.DirEntry) || visited[id] {
+			return
+		}
+		visited[id] = true
+		entry := d.DirEntry[id]
+		walk(entry.Entry.LeftSiblingID)
+		children = append(children, entry)
+		walk(entry.Entry.RightSiblingID)
+	}
+	walk(storage.Entry.ChildID)
+	return children
+}
+
+// miniStreamCutoff 迷你流截断大小，文件头未设置时使用规范默认值4096
+func (d *DocParse) miniStreamCutoff() uint32 {
+	if d.FileHeader.MiniStreamCutoffSize == 0 {
+		return 4096
+	}
+	return d.FileHeader.MiniStreamCutoffSize
+}
+
+// readMiniStream 通过MiniFAT链从根存储的迷你流中读取数据
+func (d *DocParse) readMiniStream(entry *DirectoryEntry) ([]byte, error) {
+	var root *DirectoryEntry
+	for _, e := range d.DirEntry {
+		if e.CheckRootEntry() {
+			root = e.Entry
+			break
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("未找到根存储，无法读取迷你流: %w", internal.ErrStreamNotFound)
+	}
+
+	miniStream, err := d.ExtractEntry(root, uint64(d.SectorSize), false)
+	if err != nil {
+		return nil, err
+	}
+
+	chain, err := d.TraverseMiniFAT(entry.StartSectorID)
+	if err != nil {
+		return nil, err
+	}
+
+	miniSectorSize := uint64(1) << d.FileHeader.MiniSectorShift
+	var buf bytes.Buffer
+	remaining := entry.StreamSize
+	for _, sector := range chain {
+		if remaining == 0 {
+			break
+		}
+		start := uint64(sector) * miniSectorSize
+		n := min(miniSectorSize, remaining)
+		if start+n > uint64(len(miniStream)) {
+			return buf.Bytes(), fmt.Errorf("迷你扇区 %d 超出迷你流范围: %w", sector, internal.ErrTruncated)
+		}
+		buf.Write(miniStream[start : start+n])
+		remaining -= n
+	}
+	return buf.Bytes(), nil
+}
+
+// ParsePreview 仅提取DOC前limit个段落的文本
+func (p *OfficeDocParser) ParsePreview(filePath string, limit int) ([]byte, error) {
+	preview := *p
+	preview.Limit = limit
+	return preview.Parse(filePath)
+}
+
+func (p *OfficeDocParser) Parse(filePath string) ([]byte, error) {
+	docparser := docParsePool.Get()
+	defer docParsePool.Put(docparser)
+
+	err := docparser.Open(filePath)
+	if err != nil {
+		return []byte{}, err
 	}
+	docparser.MaxParagraphs = p.Limit
+	docparser.CodePage = p.CodePage
 
 	if err = docparser.ParseWordDocument(); err != nil {
 		return []byte{}, fmt.Errorf("解析WordDocument失败: %w\n", err)
@@ -3,12 +3,14 @@ package clx
 import (
 	"encoding/binary"
 	"errors"
+	"fextra/internal"
 	"fextra/pkg/logger"
+	"fextra/pkg/office/officeenc"
 	"fmt"
 	"math"
 	"sort"
-	"unicode/utf16"
 
+	"golang.org/x/text/encoding"
 	"golang.org/x/text/encoding/simplifiedchinese"
 	"golang.org/x/text/transform"
 )
@@ -75,7 +77,8 @@ type Pcdt struct {
 
 // GetText 根据字符位置(cp)从WordDocument流提取文本
 // 参考: 2.4.1 Retrieving Text规范
-func (pcdt *Pcdt) GetText(cp uint32, length uint32, wordDocStream []byte) (string, error) {
+// codePage 为压缩(8-bit)文本片段使用的单字节代码页，为nil时按GBK解码
+func (pcdt *Pcdt) GetText(cp uint32, length uint32, wordDocStream []byte, codePage encoding.Encoding) (string, error) {
 	// 步骤1: 验证参数有效性
 	if length == 0 {
 		return "", errors.New("提取长度(length)不能为0")
@@ -121,60 +124,56 @@ func (pcdt *Pcdt) GetText(cp uint32, length uint32, wordDocStream []byte) (strin
 		return "", fmt.Errorf("提取长度%d超出当前Pcd条目范围(最大%d字符)", length, maxCharsInEntry)
 	}
 
-	// 步骤4: 计算文本流偏移
-	fc := pcd.Fc()
-	var textOffset uint32
-
-	if pcd.IsCompressed() {
-		// 压缩文本: 8-bit ANSI
-		textOffset = fc/2 + charOffset
-	} else {
-		// 未压缩文本: 16-bit Unicode
-		textOffset = fc + 2*charOffset
-	}
-
-	logger.Logger.Printf("pcd text offset: %d\n", textOffset)
-	// 步骤5: 验证偏移有效性
-	if textOffset >= uint32(len(wordDocStream)) {
-		return "", fmt.Errorf("文本偏移%d超出WordDocument流长度%d", textOffset, len(wordDocStream))
-	}
+	// 步骤4: 按该片段自身的fCompressed标志解码，同一文档中压缩与未压缩的片段可以交替出现
+	// （如快速保存的中英文混排文档），不能按第一个片段推断整个文档
+	logger.DebugLogger.Printf("pcd[%d] fc: 0x%x, compressed: %v, charOffset: %d, length: %d\n", i, pcd.Fc(), pcd.IsCompressed(), charOffset, length)
+	return pcd.Text(charOffset, length, wordDocStream, codePage)
+}
 
-	// 步骤6: 提取并解码文本
-	if pcd.IsCompressed() {
-		// 压缩文本: 8-bit ANSI (通常为GBK编码)
-		byteLength := length
-		if textOffset+byteLength > uint32(len(wordDocStream)) {
-			return "", fmt.Errorf("压缩文本数据不足(需要%d字节, 实际剩余%d字节)", byteLength, len(wordDocStream)-int(textOffset))
+// Text 提取片段内从第charOffset个字符开始的length个字符。
+// 压缩片段(fCompressed=1)每个字符1字节，位于WordDocument流的fc/2处，按codePage解码，为nil时按GBK解码；
+// 未压缩片段每个字符2字节(UTF-16LE)，位于fc处
+func (p *Pcd) Text(charOffset uint32, length uint32, wordDocStream []byte, codePage encoding.Encoding) (string, error) {
+	streamLen := uint64(len(wordDocStream))
+	if p.IsCompressed() {
+		// 压缩文本: 8-bit ANSI，代码页由文档语言决定
+		textOffset := uint64(p.Fc()/2) + uint64(charOffset)
+		end := textOffset + uint64(length)
+		if textOffset >= streamLen {
+			return "", fmt.Errorf("文本偏移%d超出WordDocument流长度%d: %w", textOffset, streamLen, internal.ErrTruncated)
 		}
-		// 使用GBK解码ANSI文本
-		decoder := simplifiedchinese.GBK.NewDecoder()
-		result, _, err := transform.Bytes(decoder, wordDocStream[textOffset:textOffset+byteLength])
-		if err != nil {
-			// 解码失败时返回原始字节的字符串表示
-			return string(wordDocStream[textOffset : textOffset+byteLength]), fmt.Errorf("GBK解码失败: %w", err)
+		if end > streamLen {
+			return "", fmt.Errorf("压缩文本数据不足(需要%d字节, 实际剩余%d字节): %w", length, streamLen-textOffset, internal.ErrTruncated)
 		}
-		return string(result), nil
-	} else {
-		// 未压缩文本: 16-bit Unicode (UTF-16LE)
-		byteLength := length * 2
-		if textOffset+byteLength > uint32(len(wordDocStream)) {
-			return "", fmt.Errorf("未压缩文本数据不足(需要%d字节, 实际剩余%d字节)", byteLength, len(wordDocStream)-int(textOffset))
+		if codePage == nil {
+			codePage = simplifiedchinese.GBK
 		}
-		// 转换字节为uint16切片
-		utf16Chars := make([]uint16, length)
-		for j := uint32(0); j < length; j++ {
-			utf16Chars[j] = binary.LittleEndian.Uint16(wordDocStream[textOffset+j*2:])
+		result, _, err := transform.Bytes(codePage.NewDecoder(), wordDocStream[textOffset:end])
+		if err != nil {
+			// 解码失败时返回原始字节的字符串表示，无效字节按internal.Replacement替换
+			return internal.ReplaceInvalid(string(wordDocStream[textOffset:end])), fmt.Errorf("ANSI文本解码失败: %w", err)
 		}
-		// 解码UTF-16为字符串
-		return string(utf16.Decode(utf16Chars)), nil
+		return internal.ReplaceInvalid(string(result)), nil
+	}
+
+	// 未压缩文本: 16-bit Unicode (UTF-16LE)
+	textOffset := uint64(p.Fc()) + 2*uint64(charOffset)
+	end := textOffset + 2*uint64(length)
+	if textOffset >= streamLen {
+		return "", fmt.Errorf("文本偏移%d超出WordDocument流长度%d: %w", textOffset, streamLen, internal.ErrTruncated)
+	}
+	if end > streamLen {
+		return "", fmt.Errorf("未压缩文本数据不足(需要%d字节, 实际剩余%d字节): %w", 2*uint64(length), streamLen-textOffset, internal.ErrTruncated)
 	}
+	// 解码UTF-16LE为字符串，不成对的代理按internal.Replacement替换
+	return officeenc.DecodeUTF16(wordDocStream[textOffset:end], binary.LittleEndian), nil
 }
 
 // 解析Pcdt结构
 // 参考: 2.9.178 Pcdt规范
 func parsePcdt(data []byte) (*Pcdt, error) {
 	if len(data) < 5 {
-		return nil, errors.New("Pcdt数据不足(至少需要5字节)")
+		return nil, fmt.Errorf("Pcdt数据不足(至少需要5字节): %w", internal.ErrTruncated)
 	}
 
 	pcdt := &Pcdt{
@@ -192,7 +191,7 @@ func parsePcdt(data []byte) (*Pcdt, error) {
 		return nil, errors.New("Pcdt.Lcb不能为0")
 	}
 	if 5+pcdt.Lcb > uint32(len(data)) {
-		return nil, fmt.Errorf("Pcdt数据截断 (需要%d字节, 实际%d字节)", 5+pcdt.Lcb, len(data))
+		return nil, fmt.Errorf("Pcdt数据截断 (需要%d字节, 实际%d字节): %w", 5+pcdt.Lcb, len(data), internal.ErrTruncated)
 	}
 
 	// 解析PlcPcd结构
@@ -213,7 +212,7 @@ func parsePcdt(data []byte) (*Pcdt, error) {
 	for i := 0; i < acpCount; i++ {
 		offset := i * 4
 		if offset+4 > len(plcData) {
-			return nil, fmt.Errorf("aCP[%d]数据不足", i)
+			return nil, fmt.Errorf("aCP[%d]数据不足: %w", i, internal.ErrTruncated)
 		}
 		pcdt.PlcPcd.ACP[i] = binary.LittleEndian.Uint32(plcData[offset:])
 	}
@@ -224,7 +223,7 @@ func parsePcdt(data []byte) (*Pcdt, error) {
 	for i := 0; i < pcdCount; i++ {
 		offset := acpCount*4 + i*8
 		if offset+8 > len(plcData) {
-			return nil, fmt.Errorf("aPcd[%d]数据不足", i)
+			return nil, fmt.Errorf("aPcd[%d]数据不足: %w", i, internal.ErrTruncated)
 		}
 		pcdt.PlcPcd.APcd[i] = Pcd{
 			Flags:        binary.LittleEndian.Uint16(plcData[offset:]),
@@ -235,3 +234,33 @@ func parsePcdt(data []byte) (*Pcdt, error) {
 
 	return pcdt, nil
 }
+
+// CharFcs 返回[cp, cp+length)内值为c的字符在WordDocument流中的偏移，范围须位于同一片段内（与GetText相同）；
+// 用于将提取出的控制字符对应到按文件偏移（FC）记录的段落属性
+func (pcdt *Pcdt) CharFcs(cp uint32, length uint32, wordDocStream []byte, c uint16) []uint32 {
+	acp, apcd := pcdt.PlcPcd.ACP, pcdt.PlcPcd.APcd
+	i := sort.Search(len(acp), func(j int) bool { return acp[j] > cp }) - 1
+	if i < 0 || i >= len(apcd) || cp+length > acp[i+1] {
+		return nil
+	}
+
+	pcd := apcd[i]
+	charOffset := uint64(cp - acp[i])
+	var fcs []uint32
+	if pcd.IsCompressed() {
+		start := uint64(pcd.Fc()/2) + charOffset
+		for j := uint64(0); j < uint64(length) && start+j < uint64(len(wordDocStream)); j++ {
+			if uint16(wordDocStream[start+j]) == c {
+				fcs = append(fcs, uint32(start+j))
+			}
+		}
+		return fcs
+	}
+	start := uint64(pcd.Fc()) + 2*charOffset
+	for j := uint64(0); j < uint64(length) && start+2*j+2 <= uint64(len(wordDocStream)); j++ {
+		if binary.LittleEndian.Uint16(wordDocStream[start+2*j:]) == c {
+			fcs = append(fcs, uint32(start+2*j))
+		}
+	}
+	return fcs
+}
@@ -0,0 +1,80 @@
+package clx
+
+import (
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// TestGetTextMixedPieces 压缩与未压缩的片段交替出现时，每个片段按自身的fCompressed标志解码
+func TestGetTextMixedPieces(t *testing.T) {
+	pieces := []struct {
+		text       string
+		fc         uint32 // 文本在WordDocument流中的字节偏移
+		compressed bool
+	}{
+		{"Hello ", 0x100, true},
+		{"世界，", 0x200, false},
+		{"café\r", 0x300, true},
+		{"Ωmega\r", 0x400, false},
+	}
+
+	le := binary.LittleEndian
+	wd := make([]byte, 0x500)
+	var acp []uint32
+	var cp uint32
+	var pcds []byte
+	for _, p := range pieces {
+		acp = append(acp, cp)
+		fcCompressed := p.fc
+		if p.compressed {
+			encoded, err := charmap.Windows1252.NewEncoder().Bytes([]byte(p.text))
+			if err != nil {
+				t.Fatal(err)
+			}
+			copy(wd[p.fc:], encoded)
+			cp += uint32(len(encoded))
+			fcCompressed = 0x40000000 | p.fc*2
+		} else {
+			units := utf16.Encode([]rune(p.text))
+			for i, u := range units {
+				le.PutUint16(wd[p.fc+uint32(i)*2:], u)
+			}
+			cp += uint32(len(units))
+		}
+		pcds = le.AppendUint16(pcds, 0)
+		pcds = le.AppendUint32(pcds, fcCompressed)
+		pcds = le.AppendUint16(pcds, 0)
+	}
+	acp = append(acp, cp)
+
+	data := []byte{PcdtClxtIdentifier, 0, 0, 0, 0}
+	le.PutUint32(data[1:], uint32(len(acp)*4+len(pcds)))
+	for _, v := range acp {
+		data = le.AppendUint32(data, v)
+	}
+	data = append(data, pcds...)
+
+	clx, err := ParseClx(data)
+	if err != nil {
+		t.Fatalf("ParseClx: %v", err)
+	}
+	pcdt := &clx.Pcdt
+	for i, p := range pieces {
+		got, err := pcdt.GetText(acp[i], acp[i+1]-acp[i], wd, charmap.Windows1252)
+		if err != nil {
+			t.Errorf("piece %d: %v", i, err)
+			continue
+		}
+		if got != p.text {
+			t.Errorf("piece %d: got %q, want %q", i, got, p.text)
+		}
+	}
+
+	// 片段中间开始的范围同样按该片段的标志解码
+	if got, err := pcdt.GetText(acp[1]+1, 2, wd, charmap.Windows1252); err != nil || got != "界，" {
+		t.Errorf("mid-piece: got %q, %v, want %q", got, err, "界，")
+	}
+}
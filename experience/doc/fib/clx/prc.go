@@ -1,7 +1,7 @@
 package clx
 
 import (
-	"errors"
+	"fextra/internal"
 	"fmt"
 )
 
@@ -16,7 +16,7 @@ type RgPrc struct {
 // 返回解析后的Prc、占用字节数和错误信息
 func ParsePrc(data []byte) (RgPrc, int, error) {
 	if len(data) < 2 {
-		return RgPrc{}, 0, errors.New("PRC数据不足")
+		return RgPrc{}, 0, fmt.Errorf("PRC数据不足: %w", internal.ErrTruncated)
 	}
 
 	prc := RgPrc{
@@ -32,7 +32,7 @@ func ParsePrc(data []byte) (RgPrc, int, error) {
 	dataLen := int(data[1])
 	dataEnd := 2 + dataLen
 	if dataEnd > len(data) {
-		return RgPrc{}, 0, fmt.Errorf("PRC数据截断 (需要%d字节, 实际%d字节)", dataEnd, len(data))
+		return RgPrc{}, 0, fmt.Errorf("PRC数据截断 (需要%d字节, 实际%d字节): %w", dataEnd, len(data), internal.ErrTruncated)
 	}
 
 	// 复制数据内容
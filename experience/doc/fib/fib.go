@@ -5,23 +5,35 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"os"
+	"strings"
 	"unsafe"
 
+	"fextra/internal"
 	"fextra/pkg/logger"
 	"fextra/pkg/office/doc/fib/clx"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
 )
 
+// wIdentWord Word 97及以后版本FibBase.WIdent的固定值，表示Word二进制文件
+const wIdentWord = 0xA5EC
+
+// wIdentWord6 Word 6.0/95文档FibBase.WIdent的值
+const wIdentWord6 = 0xA5DC
+
 type FibBase struct {
 	// 0x000-0x001: 文件标识
-	WIdent uint16 // 必须是0xA5EC(word)
+	WIdent uint16 // 必须是0xA5EC(word)，Word 6.0/95为0xA5DC
 
 	/*
 		1. Check the value of FIB.cswNew.
 		2. If the value is 0, nFib is specified by FibBase.nFib.
 		3. Otherwise, the value is not 0 and nFib is specified by FibRgCswNew.nFibNew.
 	*/
-	NFib uint16 // 文件格式的版本号  0x006A=Word6, 0x00C1=Word97
+	NFib uint16 // 文件格式的版本号  0x0065=Word6, 0x0068=Word95, 0x00C1=Word97
 
 	Unused uint16 // 保留
 
@@ -80,8 +92,16 @@ type FibRgLw97 struct {
 }
 
 // ================================================
+// FibRgLw97中各文档部分字符数量的索引，各部分在CP空间中按此顺序依次排列，见规范2.4.1 Retrieving Text
 const (
-	CcpTextIndex = 3 //主文档中的字符数量
+	CcpTextIndex    = 3  //主文档中的字符数量
+	CcpFtnIndex     = 4  // 脚注
+	CcpHddIndex     = 5  // 页眉页脚
+	CcpMcrIndex     = 6  // 保留，必须为0
+	CcpAtnIndex     = 7  // 批注
+	CcpEdnIndex     = 8  // 尾注
+	CcpTxbxIndex    = 9  // 文本框
+	CcpHdrTxbxIndex = 10 // 页眉页脚中的文本框
 )
 const (
 	FcClxIndex  = 66 // clx offset，在FibRgFclcb97中的索引
@@ -115,9 +135,47 @@ type Fib struct {
 	CswNew      uint16 // depend on nFib
 	FibRgCswNew []FibRgCswNew
 
-	CcpText uint32 // 主文本字符数量
-	FcClx   uint32 // Table Stream中文本偏移位置
-	LcbClx  uint32 // Table Stream中文本大小
+	CcpText    uint32 // 主文本字符数量
+	CcpFtn     uint32 // 脚注字符数量
+	CcpHdd     uint32 // 页眉页脚字符数量
+	CcpMcr     uint32 // 保留
+	CcpAtn     uint32 // 批注字符数量
+	CcpEdn     uint32 // 尾注字符数量
+	CcpTxbx    uint32 // 文本框字符数量
+	CcpHdrTxbx uint32 // 页眉页脚中文本框字符数量
+	FcClx      uint32 // Table Stream中文本偏移位置
+	LcbClx     uint32 // Table Stream中文本大小
+	FcMin      uint32 // Word 6.0/95正文在WordDocument流中的起始偏移
+
+	FcPlcfBtePapx  uint32 // Table Stream中PlcBtePapx（段落属性页索引）偏移
+	LcbPlcfBtePapx uint32 // Table Stream中PlcBtePapx大小
+}
+
+// Subdocument 文档的一个部分（正文、脚注、页眉页脚等）在CP空间中的范围
+type Subdocument struct {
+	Name string // 部分名称，正文为空
+	Cp   uint32 // 起始字符位置
+	Ccp  uint32 // 字符数量
+}
+
+// Subdocuments 按CP空间中的顺序返回各文档部分，正文之后依次为脚注、页眉页脚、批注、尾注及文本框
+func (f *Fib) Subdocuments() []Subdocument {
+	parts := []Subdocument{
+		{"", 0, f.CcpText},
+		{"脚注", 0, f.CcpFtn},
+		{"页眉页脚", 0, f.CcpHdd},
+		{"", 0, f.CcpMcr},
+		{"批注", 0, f.CcpAtn},
+		{"尾注", 0, f.CcpEdn},
+		{"文本框", 0, f.CcpTxbx},
+		{"页眉页脚文本框", 0, f.CcpHdrTxbx},
+	}
+	var cp uint32
+	for i := range parts {
+		parts[i].Cp = cp
+		cp += parts[i].Ccp
+	}
+	return parts
 }
 
 func (fb *FibBase) Printf() {
@@ -176,8 +234,23 @@ func (f *Fib) parseFibCslw() error {
 	for i := range cslw {
 		cslw[i] = binary.LittleEndian.Uint32(buf[4*i:])
 		logger.DebugLogger.Printf("%d(0x%x) \n", i, cslw[i])
-		if i == CcpTextIndex {
+		switch i {
+		case CcpTextIndex:
 			f.CcpText = cslw[i]
+		case CcpFtnIndex:
+			f.CcpFtn = cslw[i]
+		case CcpHddIndex:
+			f.CcpHdd = cslw[i]
+		case CcpMcrIndex:
+			f.CcpMcr = cslw[i]
+		case CcpAtnIndex:
+			f.CcpAtn = cslw[i]
+		case CcpEdnIndex:
+			f.CcpEdn = cslw[i]
+		case CcpTxbxIndex:
+			f.CcpTxbx = cslw[i]
+		case CcpHdrTxbxIndex:
+			f.CcpHdrTxbx = cslw[i]
 		}
 	}
 	logger.DebugLogger.Printf("\n====> end\n")
@@ -218,6 +291,8 @@ func (f *Fib) parseFibFclcb(nfib uint16) error {
 		f.LcbClx = fclcb[LcbClxIndex]
 		logger.Logger.Printf("提取CLX偏移: 0x%x, 大小: %d字节\n", f.FcClx, f.LcbClx)
 	}
+	f.FcPlcfBtePapx = fclcb[FcPlcfBtePapxIndex]
+	f.LcbPlcfBtePapx = fclcb[LcbPlcfBtePapxIndex]
 	logger.DebugLogger.Printf("\n====> end\n")
 	return nil
 }
@@ -247,35 +322,62 @@ func (f *Fib) ParseFibBase() error {
 	fibBase := &FibBase{}
 	//fibBase := make([]uint8, 32)
 	if err := binary.Read(f.Reader, binary.LittleEndian, fibBase); err != nil {
-		return err
+		return fmt.Errorf("读取FibBase失败: %w: %w", internal.ErrTruncated, err)
 	}
 	f.Base = fibBase
 	fibBase.Printf()
+	// Word 6.0/95的标识为0xA5DC，只在nFib为这些版本时接受
+	if fibBase.WIdent != wIdentWord && (fibBase.WIdent != wIdentWord6 || !f.IsWord6()) {
+		return fmt.Errorf("无效的FIB标识: 0x%x: %w", fibBase.WIdent, internal.ErrInvalidSignature)
+	}
 	return nil
 }
 
-func (f *Fib) ParseFibClx(r *os.File, wd []byte, offset uint32, size uint64) ([]byte, error) {
-	clxOffset := offset + f.FcClx
-	logger.DebugLogger.Printf("clxoffset: 0x%x\n", clxOffset)
-	_, err := r.Seek(int64(clxOffset), 0)
-	if err != nil {
-		return []byte{}, err
+// ParseFibClx 按表流（0Table或1Table）中的CLX提取文本，table为表流的完整内容
+func (f *Fib) ParseFibClx(table, wd []byte) ([]byte, error) {
+	return f.ParseFibClxLimit(table, wd, 0, nil)
+}
+
+// langChinese lid的低10位为主语言标识，中文为0x04（0x0804简体、0x0404繁体等）
+const langChinese = 0x04
+
+// 使用繁体中文的地区：台湾、香港、澳门，其余中文地区（中国大陆、新加坡）使用简体中文
+const (
+	lidChineseTaiwan   = 0x0404
+	lidChineseHongKong = 0x0C04
+	lidChineseMacau    = 0x1404
+)
+
+// CodePage 按FibBase.Language推测压缩文本片段的代码页：繁体中文文档为Big5（代码页950），
+// 其他中文文档为GBK（代码页936），非中文文档为Windows-1252
+func (f *Fib) CodePage() encoding.Encoding {
+	if f.Base == nil || f.Base.Language&0x03FF != langChinese {
+		return charmap.Windows1252
 	}
+	switch f.Base.Language {
+	case lidChineseTaiwan, lidChineseHongKong, lidChineseMacau:
+		return traditionalchinese.Big5
+	}
+	return simplifiedchinese.GBK
+}
 
-	buf := make([]byte, f.LcbClx)
-	if _, err = io.ReadFull(r, buf); err != nil {
-		return []byte{}, err
+// ParseFibClxLimit 与ParseFibClx相同，maxParagraphs>0时提取到第maxParagraphs个段落结束后停止
+// codePage 为压缩文本片段使用的代码页，为nil时按文档语言选择（见CodePage）
+// 正文之后依次提取脚注、页眉页脚、批注、尾注及文本框，每部分之前输出"=== 名称 ==="标题
+func (f *Fib) ParseFibClxLimit(table, wd []byte, maxParagraphs int, codePage encoding.Encoding) ([]byte, error) {
+	logger.DebugLogger.Printf("fcClx: 0x%x, lcbClx: 0x%x, table size: 0x%x\n", f.FcClx, f.LcbClx, len(table))
+	if uint64(f.FcClx)+uint64(f.LcbClx) > uint64(len(table)) {
+		return []byte{}, fmt.Errorf("CLX超出表流范围(fcClx=0x%x, lcbClx=0x%x, 表流大小0x%x): %w",
+			f.FcClx, f.LcbClx, len(table), internal.ErrTruncated)
 	}
 
-	// 此处偏移已经定位到clx，直接按照clx进行解析
-	clxData, err := clx.ParseClx(buf)
+	clxData, err := clx.ParseClx(table[f.FcClx : f.FcClx+f.LcbClx])
 	if err != nil {
 		return []byte{}, err
 	}
 	pcdt := &clxData.Pcdt
 
 	// 提取pcdt中的纯文本内容
-	var textBuilder bytes.Buffer
 	acp := pcdt.PlcPcd.ACP
 	apcd := pcdt.PlcPcd.APcd
 
@@ -284,29 +386,105 @@ func (f *Fib) ParseFibClx(r *os.File, wd []byte, offset uint32, size uint64) ([]
 		logger.DebugLogger.Printf("FcCompressed: 0x%x, Flags: 0x%x, Prm: 0x%x, 0x%x, %v\n", v.FcCompressed, v.Flags, v.Prm, v.Fc(), v.IsCompressed())
 	}
 
-	for i := 0; i < len(apcd); i++ {
-		startCp := acp[i]
-		endCp := acp[i+1]
-		length := endCp - startCp
+	if codePage == nil {
+		codePage = f.CodePage()
+	}
+	// 表格行结束标记与单元格结束标记同为0x07，按段落属性中的sprmPFTtp区分
+	rowEnds := f.rowEndRanges(table, wd)
+	return f.extractSubdocuments(acp, func(cp, length uint32) (string, error) {
+		text, err := pcdt.GetText(cp, length, wd, codePage)
+		if err != nil || len(rowEnds) == 0 || !strings.ContainsRune(text, cellMark) {
+			return text, err
+		}
+		return markRowEnds(text, pcdt.CharFcs(cp, length, wd, cellMark), rowEnds), nil
+	}, maxParagraphs)
+}
 
-		if length == 0 {
+// extractSubdocuments 依次提取各文档部分的文本，acp为片段在CP空间中的边界，
+// getText返回片段内[cp, cp+length)的文本；maxParagraphs>0时提取到第maxParagraphs个段落结束后停止
+func (f *Fib) extractSubdocuments(acp []uint32, getText func(cp, length uint32) (string, error), maxParagraphs int) ([]byte, error) {
+	var textBuilder bytes.Buffer
+	paragraphs := 0
+	for _, part := range f.Subdocuments() {
+		if part.Ccp == 0 {
 			continue
 		}
 
-		logger.DebugLogger.Printf("startcp: %d, endcp: %d, length: %d, charnum: %d, data len: %d\n",
-			startCp, endCp, length, size, len(buf))
+		var partBuilder strings.Builder
+		done := false
+		for i := 0; i+1 < len(acp) && !done; i++ {
+			// 取片段与当前部分的交集
+			startCp := max(acp[i], part.Cp)
+			endCp := min(acp[i+1], part.Cp+part.Ccp)
+			if startCp >= endCp {
+				continue
+			}
+			length := endCp - startCp
+
+			logger.DebugLogger.Printf("startcp: %d, endcp: %d, length: %d\n", startCp, endCp, length)
+
+			segment, err := getText(startCp, length)
+			if err != nil {
+				if part.Name != "" {
+					// 正文之外的部分提取失败不影响正文
+					logger.Logger.Printf("提取%s失败(索引%d): %v", part.Name, i, err)
+					break
+				}
+				return []byte{}, fmt.Errorf("提取文本片段失败(索引%d): %w", i, err)
+			}
+			logger.DebugLogger.Printf("content[%d]:\n%s\n", len(segment), segment)
+			if maxParagraphs > 0 {
+				// 段落以\r结束，达到段落数后截断当前片段并停止
+				if end := paragraphEnd(segment, maxParagraphs-paragraphs); end >= 0 {
+					segment = segment[:end]
+					done = true
+				}
+				paragraphs += strings.Count(segment, "\r")
+			}
+			partBuilder.WriteString(segment)
+		}
 
-		segment, err := pcdt.GetText(startCp, f.CcpText, wd)
-		if err != nil {
-			return []byte{}, fmt.Errorf("提取文本片段失败(索引%d): %w", i, err)
+		writeSubdocument(&textBuilder, part.Name, partBuilder.String())
+		if done {
+			break
 		}
-		logger.DebugLogger.Printf("content[%d]:\n%s\n", len(segment), segment)
-		textBuilder.WriteString(segment)
 	}
 
 	return textBuilder.Bytes(), nil
 }
 
+// subdocumentSpace 页眉页脚等部分中只起分隔作用的字符：空白、段落标记及脚注分隔符(0x03、0x04)
+const subdocumentSpace = " \t\r\n\v\f\x03\x04"
+
+// writeSubdocument 写入一个文档部分的文本，正文之外的部分以"=== 名称 ==="标题开始，没有可见文本时跳过
+func writeSubdocument(buf *bytes.Buffer, name string, text string) {
+	if name == "" {
+		buf.WriteString(text)
+		return
+	}
+	if strings.Trim(text, subdocumentSpace) == "" {
+		return
+	}
+	if buf.Len() > 0 {
+		buf.WriteString("\r")
+	}
+	buf.WriteString("=== " + name + " ===\r")
+	buf.WriteString(text)
+}
+
+// paragraphEnd 返回s中第n个段落标记(\r)之后的位置，不足n个时返回-1
+func paragraphEnd(s string, n int) int {
+	pos := 0
+	for i := 0; i < n; i++ {
+		idx := strings.IndexByte(s[pos:], '\r')
+		if idx < 0 {
+			return -1
+		}
+		pos += idx + 1
+	}
+	return pos
+}
+
 func NewFib(data []byte) *Fib {
 	return &Fib{
 		Reader: bytes.NewReader(data),
@@ -320,6 +498,14 @@ func ParseFIB(data []byte) (*Fib, error) {
 		return nf, err
 	}
 
+	// Word 6.0/95的FIB为固定布局，更早的版本不支持
+	if nf.IsWord6() {
+		return nf, nf.parseWord6(data)
+	}
+	if nf.Base.NFib < NFibWord97 {
+		return nf, fmt.Errorf("nFib 0x%04x: %w", nf.Base.NFib, ErrUnsupportedDocVersion)
+	}
+
 	if err := nf.parseFibCsw(); err != nil {
 		return nf, err
 	}
@@ -0,0 +1,29 @@
+package fib
+
+import (
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+func TestCodePage(t *testing.T) {
+	for _, tt := range []struct {
+		lid  uint16
+		want encoding.Encoding
+	}{
+		{0x0804, simplifiedchinese.GBK},   // 中国大陆
+		{0x1004, simplifiedchinese.GBK},   // 新加坡
+		{0x0404, traditionalchinese.Big5}, // 台湾
+		{0x0C04, traditionalchinese.Big5}, // 香港
+		{0x1404, traditionalchinese.Big5}, // 澳门
+		{0x0409, charmap.Windows1252},     // 英语
+	} {
+		f := &Fib{Base: &FibBase{Language: tt.lid}}
+		if got := f.CodePage(); got != tt.want {
+			t.Errorf("lid 0x%04X: got %v, want %v", tt.lid, got, tt.want)
+		}
+	}
+}
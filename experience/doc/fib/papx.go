@@ -0,0 +1,158 @@
+package fib
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+// 段落属性，见规范2.6.2 Paragraph Properties及2.9.175 PapxFkp
+const (
+	FcPlcfBtePapxIndex  = 26 // PlcBtePapx偏移，在FibRgFclcb97中的索引
+	LcbPlcfBtePapxIndex = 27 // PlcBtePapx大小，单位bytes
+
+	fkpPageSize   = 512    // PapxFkp固定占用一个512字节的页
+	sprmPFTtp     = 0x2417 // 段落为表格行结束标记（TTP）
+	sprmTDefTable = 0xD608 // 表格列定义，操作数长度为2字节
+)
+
+// RowEndMark 表格行结束标记（位于带sprmPFTtp的段落中的0x07）在提取结果中替换为该字符，
+// 以便与单元格结束标记区分；Word文本中不使用0x06
+const RowEndMark = 0x06
+
+// cellMark 单元格及表格行结束标记
+const cellMark = 0x07
+
+// fcRange WordDocument流中[start, end)的范围
+type fcRange struct {
+	start, end uint32
+}
+
+// rowEndRanges 读取表流中的PlcBtePapx及WordDocument流中的各PapxFkp，
+// 返回带有sprmPFTtp的段落在WordDocument流中的范围，按起始偏移排序；结构无效时返回nil
+func (f *Fib) rowEndRanges(table, wd []byte) []fcRange {
+	fc, lcb := uint64(f.FcPlcfBtePapx), uint64(f.LcbPlcfBtePapx)
+	if lcb < 12 || (lcb-4)%8 != 0 || fc+lcb > uint64(len(table)) {
+		return nil
+	}
+	plc := table[fc : fc+lcb]
+	n := int((lcb - 4) / 8)
+
+	var ranges []fcRange
+	for i := 0; i < n; i++ {
+		pn := uint64(binary.LittleEndian.Uint32(plc[4*(n+1)+4*i:]) & 0x003FFFFF)
+		offset := pn * fkpPageSize
+		if offset+fkpPageSize > uint64(len(wd)) {
+			continue
+		}
+		ranges = appendTtpRanges(ranges, wd[offset:offset+fkpPageSize])
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+	return ranges
+}
+
+// appendTtpRanges 解析一个PapxFkp页：末字节为段落数crun，之后依次为crun+1个FC及crun个13字节的BxPap，
+// BxPap首字节为PapxInFkp相对页首的偏移/2，为0时段落使用默认属性
+func appendTtpRanges(ranges []fcRange, page []byte) []fcRange {
+	crun := int(page[fkpPageSize-1])
+	if 4*(crun+1)+13*crun > fkpPageSize-1 {
+		return ranges
+	}
+	for i := 0; i < crun; i++ {
+		bOffset := int(page[4*(crun+1)+13*i]) * 2
+		if bOffset == 0 || !isTtp(papxGrpprl(page, bOffset)) {
+			continue
+		}
+		ranges = append(ranges, fcRange{
+			start: binary.LittleEndian.Uint32(page[4*i:]),
+			end:   binary.LittleEndian.Uint32(page[4*(i+1):]),
+		})
+	}
+	return ranges
+}
+
+// papxGrpprl 返回PapxInFkp中istd之后的Prl序列：cb不为0时GrpPrlAndIstd长2*cb-1字节，
+// 为0时下一字节cb'给出长度2*cb'
+func papxGrpprl(page []byte, offset int) []byte {
+	if offset >= fkpPageSize-1 {
+		return nil
+	}
+	start, size := offset+1, 2*int(page[offset])-1
+	if page[offset] == 0 {
+		start, size = offset+2, 2*int(page[offset+1])
+	}
+	if size < 2 || start+size > fkpPageSize-1 {
+		return nil
+	}
+	return page[start+2 : start+size]
+}
+
+// isTtp grpprl中是否有值为1的sprmPFTtp；操作数长度由sprm的spra（高3位）决定，见规范2.2.5.1 Sprm
+func isTtp(grpprl []byte) bool {
+	for p := 0; p+2 <= len(grpprl); {
+		sprm := binary.LittleEndian.Uint16(grpprl[p:])
+		p += 2
+		var size int
+		switch sprm >> 13 {
+		case 0, 1:
+			size = 1
+		case 2, 4, 5:
+			size = 2
+		case 3:
+			size = 4
+		case 7:
+			size = 3
+		case 6:
+			if p >= len(grpprl) {
+				return false
+			}
+			if sprm == sprmTDefTable {
+				if p+2 > len(grpprl) {
+					return false
+				}
+				size = 2 + int(binary.LittleEndian.Uint16(grpprl[p:])) - 1
+			} else {
+				size = 1 + int(grpprl[p])
+			}
+		}
+		if p+size > len(grpprl) {
+			return false
+		}
+		if sprm == sprmPFTtp && grpprl[p] == 1 {
+			return true
+		}
+		p += size
+	}
+	return false
+}
+
+// containsFc fc是否位于某个范围内，ranges按起始偏移排序
+func containsFc(ranges []fcRange, fc uint32) bool {
+	i := sort.Search(len(ranges), func(j int) bool { return ranges[j].start > fc }) - 1
+	return i >= 0 && fc < ranges[i].end
+}
+
+// markRowEnds 将text中位于表格行结束段落内的0x07替换为RowEndMark，
+// fcs为text中各0x07在WordDocument流中的偏移，数量不一致时原样返回
+func markRowEnds(text string, fcs []uint32, rowEnds []fcRange) string {
+	if len(fcs) == 0 {
+		return text
+	}
+	out := []byte(text)
+	k := 0
+	for i, c := range out {
+		if c != cellMark {
+			continue
+		}
+		if k >= len(fcs) {
+			return text
+		}
+		if containsFc(rowEnds, fcs[k]) {
+			out[i] = RowEndMark
+		}
+		k++
+	}
+	if k != len(fcs) {
+		return text
+	}
+	return string(out)
+}
@@ -0,0 +1,115 @@
+package fib
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"fextra/internal"
+	"fextra/pkg/logger"
+	"fextra/pkg/office/doc/fib/clx"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
+)
+
+// FibBase.NFib的取值，Word 97及以后的版本固定为0x00C1（更新的版本号见FibRgCswNew.nFibNew）
+const (
+	NFibWord6  = 0x0065 // Word 6.0
+	NFibWord95 = 0x0068 // Word 95 (7.0)
+	NFibWord97 = 0x00C1 // Word 97
+)
+
+// ErrUnsupportedDocVersion Word 6.0之前（Word 2.0、Word for DOS等）或无法识别的nFib，FIB布局未知，无法提取文本
+var ErrUnsupportedDocVersion = fmt.Errorf("不支持的Word版本: %w", internal.ErrUnsupportedFormat)
+
+// Word 6.0/95的FIB没有csw、cslw、cbRgFcLcb计数，各字段位于固定偏移
+const (
+	word6FcMinOffset   = 0x18  // 正文在WordDocument流中的起始偏移
+	word6CcpTextOffset = 0x34  // 自此依次为ccpText、ccpFtn、ccpHdd、ccpMcr、ccpAtn、ccpEdn、ccpTxbx、ccpHdrTxbx
+	word6FcClxOffset   = 0x160 // CLX在WordDocument流中的偏移，其后为lcbClx
+	word6FibSize       = 0x168 // 读取以上字段需要的FIB长度
+)
+
+// fComplex FibBase.Flags中的快速保存标志，Word 6.0/95快速保存的文档按CLX中的片段表排列文本
+const flagComplex = 0x0004
+
+// IsWord6 是否为Word 6.0/95文档，其文本及CLX均保存在WordDocument流中，没有0Table/1Table流
+func (f *Fib) IsWord6() bool {
+	return f.Base != nil && f.Base.NFib >= NFibWord6 && f.Base.NFib <= NFibWord95
+}
+
+// parseWord6 读取Word 6.0/95 FIB中正文偏移、各部分字符数及CLX位置
+func (f *Fib) parseWord6(data []byte) error {
+	if len(data) < word6FibSize {
+		return fmt.Errorf("Word 6.0/95 FIB长度%d: %w", len(data), internal.ErrTruncated)
+	}
+	f.FcMin = binary.LittleEndian.Uint32(data[word6FcMinOffset:])
+	ccp := make([]uint32, 8)
+	for i := range ccp {
+		ccp[i] = binary.LittleEndian.Uint32(data[word6CcpTextOffset+4*i:])
+	}
+	f.CcpText, f.CcpFtn, f.CcpHdd, f.CcpMcr = ccp[0], ccp[1], ccp[2], ccp[3]
+	f.CcpAtn, f.CcpEdn, f.CcpTxbx, f.CcpHdrTxbx = ccp[4], ccp[5], ccp[6], ccp[7]
+	f.FcClx = binary.LittleEndian.Uint32(data[word6FcClxOffset:])
+	f.LcbClx = binary.LittleEndian.Uint32(data[word6FcClxOffset+4:])
+	logger.Logger.Printf("Word 6.0/95文档，nFib: 0x%x, fcMin: 0x%x, ccpText: %d, CLX偏移: 0x%x, 大小: %d字节\n",
+		f.Base.NFib, f.FcMin, f.CcpText, f.FcClx, f.LcbClx)
+	return nil
+}
+
+// ParseWord6Text 提取Word 6.0/95文档的文本，输出格式与ParseFibClxLimit相同。
+// 这些版本的文本均为单字节（中文版为代码页的双字节编码），按codePage解码，为nil时按文档语言选择（见CodePage）；
+// 快速保存的文档按WordDocument流中CLX的片段表读取，片段的fc为字节偏移，其余文档的文本从fcMin开始连续存放
+func (f *Fib) ParseWord6Text(wd []byte, maxParagraphs int, codePage encoding.Encoding) ([]byte, error) {
+	if codePage == nil {
+		codePage = f.CodePage()
+	}
+
+	var total uint32
+	for _, part := range f.Subdocuments() {
+		total += part.Ccp
+	}
+
+	if f.Base.Flags&flagComplex == 0 || f.LcbClx == 0 {
+		return f.extractSubdocuments([]uint32{0, total}, func(cp, length uint32) (string, error) {
+			return decodeWord6(wd, uint64(f.FcMin)+uint64(cp), length, codePage)
+		}, maxParagraphs)
+	}
+
+	end := uint64(f.FcClx) + uint64(f.LcbClx)
+	if end > uint64(len(wd)) {
+		return []byte{}, fmt.Errorf("CLX超出WordDocument流长度%d: %w", len(wd), internal.ErrTruncated)
+	}
+	clxData, err := clx.ParseClx(wd[f.FcClx:end])
+	if err != nil {
+		return []byte{}, err
+	}
+	acp := clxData.Pcdt.PlcPcd.ACP
+	apcd := clxData.Pcdt.PlcPcd.APcd
+	if len(acp) != len(apcd)+1 {
+		return []byte{}, fmt.Errorf("ACP数组长度(%d)必须比APcd数组长度(%d)多1，可能是CLX结构损坏", len(acp), len(apcd))
+	}
+
+	return f.extractSubdocuments(acp, func(cp, length uint32) (string, error) {
+		i := sort.Search(len(acp), func(j int) bool { return acp[j] > cp }) - 1
+		if i < 0 || i >= len(apcd) {
+			return "", fmt.Errorf("找不到对应的Pcd条目，cp=%d", cp)
+		}
+		return decodeWord6(wd, uint64(apcd[i].FcCompressed)+uint64(cp-acp[i]), length, codePage)
+	}, maxParagraphs)
+}
+
+// decodeWord6 按codePage解码WordDocument流中从offset开始的length个字节
+func decodeWord6(wd []byte, offset uint64, length uint32, codePage encoding.Encoding) (string, error) {
+	end := offset + uint64(length)
+	if end > uint64(len(wd)) {
+		return "", fmt.Errorf("文本数据不足(偏移%d, 需要%d字节, 流长度%d字节): %w", offset, length, len(wd), internal.ErrTruncated)
+	}
+	result, _, err := transform.Bytes(codePage.NewDecoder(), wd[offset:end])
+	if err != nil {
+		// 解码失败时返回原始字节的字符串表示，无效字节按internal.Replacement替换
+		return internal.ReplaceInvalid(string(wd[offset:end])), fmt.Errorf("ANSI文本解码失败: %w", err)
+	}
+	return internal.ReplaceInvalid(string(result)), nil
+}
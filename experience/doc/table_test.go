@@ -0,0 +1,69 @@
+package doc
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"fextra/internal/cfbtest"
+)
+
+// TestTableEmptyCells 表格行结束标记按段落属性中的sprmPFTtp识别，空单元格的0x07不被当作行结束
+func TestTableEmptyCells(t *testing.T) {
+	// 一行三个单元格"A"、""、"B"，随后为行结束标记及表格后的段落
+	const text = "A\x07\x07B\x07\x07\rafter\r"
+	le := binary.LittleEndian
+	clx := []byte{0x02, 0, 0, 0, 0}
+	le.PutUint32(clx[1:], 2*4+8)
+	clx = le.AppendUint32(clx, 0)
+	clx = le.AppendUint32(clx, uint32(len(text)))
+	clx = le.AppendUint16(clx, 0)
+	clx = le.AppendUint32(clx, 0x40000000|0x400*2)
+	clx = le.AppendUint16(clx, 0)
+
+	// PlcBtePapx：所有段落位于WordDocument流第3页的PapxFkp中
+	const fcClx, fcPlcfBtePapx = 0x20, 0x100
+	table := make([]byte, fcPlcfBtePapx)
+	copy(table[fcClx:], clx)
+	table = le.AppendUint32(table, 0x400)
+	table = le.AppendUint32(table, 0x400+uint32(len(text)))
+	table = le.AppendUint32(table, 3)
+
+	wd := word97Document(text, fcClx, uint32(len(clx)))
+	le.PutUint32(wd[154+26*4:], fcPlcfBtePapx)
+	le.PutUint32(wd[154+27*4:], 12)
+	page := make([]byte, 512)
+	bounds := []uint32{0x400, 0x402, 0x403, 0x405, 0x406, 0x400 + uint32(len(text))}
+	for i, fc := range bounds {
+		le.PutUint32(page[4*i:], fc)
+	}
+	// 单元格段落：sprmPFInTable；行结束段落：sprmPFInTable及sprmPFTtp（cb为0的长格式）
+	copy(page[0x100:], []byte{3, 0, 0, 0x16, 0x24, 1})
+	copy(page[0x120:], []byte{0, 4, 0, 0, 0x16, 0x24, 1, 0x17, 0x24, 1})
+	for i, bOffset := range []byte{0x80, 0x80, 0x80, 0x90, 0} {
+		page[24+13*i] = bOffset
+	}
+	page[511] = 5
+	wd = append(wd, make([]byte, 0x600-len(wd))...)
+	wd = append(wd, page...)
+
+	streams := []cfbtest.Stream{
+		{Name: "WordDocument", Data: wd},
+		{Name: "1Table", Data: table},
+	}
+	got, err := (&OfficeDocParser{}).Parse(writeCFB(t, streams, cfbtest.Options{}))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !strings.Contains(string(got), "A\t\tB\n\nafter") {
+		t.Errorf("got %q, want row \"A\\t\\tB\" followed by \"after\"", got)
+	}
+}
+
+// TestCleanControlCharsWithoutTtp 没有段落属性时连续两个0x07视为最后一个单元格及行结束
+func TestCleanControlCharsWithoutTtp(t *testing.T) {
+	got := string(cleanControlChars([]byte("A\x07B\x07\x07C\x07D\x07\x07")))
+	if got != "A\tB\nC\tD\n" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,78 @@
+package doc
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"fextra/internal"
+	"fextra/internal/cfbtest"
+	"fextra/pkg/office/doc/fib"
+)
+
+// word6Document 按Word 6.0 FIB的固定布局构造WordDocument流：wIdent为0xA5DC，正文从fcMin=0x200开始
+func word6Document(flags uint16, ccpText uint32) []byte {
+	wd := make([]byte, 0x400)
+	le := binary.LittleEndian
+	le.PutUint16(wd[0x00:], 0xA5DC)
+	le.PutUint16(wd[0x02:], fib.NFibWord6)
+	le.PutUint16(wd[0x06:], 0x0409)
+	le.PutUint16(wd[0x0A:], flags)
+	le.PutUint32(wd[0x18:], 0x200)
+	le.PutUint32(wd[0x34:], ccpText)
+	return wd
+}
+
+func parseWord6(t *testing.T, wd []byte) string {
+	t.Helper()
+	path := writeCFB(t, []cfbtest.Stream{{Name: "WordDocument", Data: wd}}, cfbtest.Options{})
+	text, err := (&OfficeDocParser{}).Parse(path)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return string(text)
+}
+
+func TestWord6Text(t *testing.T) {
+	wd := word6Document(0, 13)
+	copy(wd[0x200:], "Hello Word 6\r")
+	if text := parseWord6(t, wd); !strings.Contains(text, "Hello Word 6") {
+		t.Errorf("got %q, want the text at fcMin", text)
+	}
+}
+
+// TestWord6FastSaved 快速保存的文档按WordDocument流中CLX的片段表读取，片段的fc为字节偏移
+func TestWord6FastSaved(t *testing.T) {
+	wd := word6Document(0x0004, 12)
+	copy(wd[0x200:], "Hello ")
+	copy(wd[0x300:], "World\r")
+
+	le := binary.LittleEndian
+	clx := []byte{0x02, 0, 0, 0, 0}
+	le.PutUint32(clx[1:], 3*4+2*8)
+	for _, cp := range []uint32{0, 6, 12} {
+		clx = le.AppendUint32(clx, cp)
+	}
+	for _, fc := range []uint32{0x200, 0x300} {
+		clx = le.AppendUint16(clx, 0)
+		clx = le.AppendUint32(clx, fc)
+		clx = le.AppendUint16(clx, 0)
+	}
+	copy(wd[0x380:], clx)
+	le.PutUint32(wd[0x160:], 0x380)
+	le.PutUint32(wd[0x164:], uint32(len(clx)))
+
+	if text := parseWord6(t, wd); !strings.Contains(text, "Hello World") {
+		t.Errorf("got %q, want the pieces in CP order", text)
+	}
+}
+
+// TestWord6IdentRequiresWord6NFib 0xA5DC只在nFib为Word 6.0/95时接受
+func TestWord6IdentRequiresWord6NFib(t *testing.T) {
+	wd := word6Document(0, 0)
+	binary.LittleEndian.PutUint16(wd[0x02:], fib.NFibWord97)
+	if _, err := fib.ParseFIB(wd); !errors.Is(err, internal.ErrInvalidSignature) {
+		t.Errorf("got %v, want ErrInvalidSignature", err)
+	}
+}
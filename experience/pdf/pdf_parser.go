@@ -3,14 +3,18 @@ package pdf
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	ledongthucpdf "github.com/ledongthuc/pdf"
 	pdfcpu "github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
 	rscpdf "github.com/rsc/pdf"
 	"github.com/saintfish/chardet"
 	"golang.org/x/text/encoding"
@@ -19,59 +23,292 @@ import (
 	"golang.org/x/text/encoding/unicode"
 	"golang.org/x/text/transform"
 
+	"fextra/internal"
 	"fextra/pkg/compressfile"
 	"fextra/pkg/logger"
 )
 
 // OfficePdfParser PDF文档解析器
-type OfficePdfParser struct{}
+type OfficePdfParser struct {
+	MaxOutputBytes     int  // 最大输出字节数，达到后停止遍历页面并截断，为0时不限制
+	Limit              int  // 最多提取的页数，用于生成预览，为0时不限制
+	ExtractAttachments bool // 是否提取嵌入的附件（/Names /EmbeddedFiles）并将其文本追加到页面文本之后，预览时不提取
+}
 
 // Parse 解析PDF文件并提取文本内容
 func (p *OfficePdfParser) Parse(filePath string) ([]byte, error) {
+	text, _, err := p.parse(filePath)
+	return internal.TruncateOutput(text, p.MaxOutputBytes), err
+}
+
+// ParseWithInfo 提取PDF文本并返回编码信息，仅二进制解析方案会做编码检测，其余方案输出即为UTF-8
+func (p *OfficePdfParser) ParseWithInfo(filePath string) ([]byte, internal.ExtractInfo, error) {
+	text, info, err := p.parse(filePath)
+	return internal.TruncateOutput(text, p.MaxOutputBytes), info, err
+}
+
+// ParseLimit 按指定的最大输出字节数提取PDF文本，达到上限后不再解析剩余页面
+func (p *OfficePdfParser) ParseLimit(filePath string, maxBytes int) ([]byte, error) {
+	limited := *p
+	limited.MaxOutputBytes = maxBytes
+	return limited.Parse(filePath)
+}
+
+// ParsePreview 仅提取PDF前limit页的文本
+func (p *OfficePdfParser) ParsePreview(filePath string, limit int) ([]byte, error) {
+	preview := *p
+	preview.Limit = limit
+	return preview.Parse(filePath)
+}
+
+// pageLimit 返回需要遍历的页数，设置了预览页数时不超过预览页数
+func (p *OfficePdfParser) pageLimit(numPage int) int {
+	if p.Limit > 0 {
+		return min(numPage, p.Limit)
+	}
+	return numPage
+}
+
+// reachedLimit 判断已提取的文本是否超过输出上限
+func (p *OfficePdfParser) reachedLimit(n int) bool {
+	return p.MaxOutputBytes > 0 && n > p.MaxOutputBytes
+}
+
+// parse 提取页面文本，页面均没有文本且注册了OCR引擎时识别页面中的图片，开启ExtractAttachments时追加附件的文本；
+// 最终没有文本时返回ErrNoContent
+func (p *OfficePdfParser) parse(filePath string) ([]byte, internal.ExtractInfo, error) {
+	text, info, err := p.parsePages(filePath)
+	if err != nil {
+		return text, info, err
+	}
+
+	if len(bytes.TrimSpace(text)) == 0 && internal.OCR() != nil {
+		text = append(text, p.recognizePages(filePath)...)
+	}
+
+	if p.ExtractAttachments && p.Limit == 0 && !p.reachedLimit(len(text)) {
+		attachments, err := p.parseAttachments(filePath)
+		if err != nil {
+			// 附件提取失败不影响页面文本
+			logger.Logger.Printf("提取PDF附件失败: %v", err)
+		} else if len(attachments) > 0 {
+			text = append(text, "\n\n"...)
+			text = append(text, attachments...)
+		}
+	}
+	if len(bytes.TrimSpace(text)) == 0 {
+		return text, info, errNoTextLayer
+	}
+	return text, info, nil
+}
+
+// errNoTextLayer 页面中没有可提取的文本，通常为扫描件
+var errNoTextLayer = fmt.Errorf("PDF页面中没有文本层: %w", internal.ErrNoContent)
+
+// ParseTo 提取PDF文本写入w，输出与Parse一致：ledongthuc/pdf可用时每提取一页即写入，
+// 否则由其余方案提取后一次写入；设置了MaxOutputBytes或Limit时先完整提取再写入
+func (p *OfficePdfParser) ParseTo(filePath string, w io.Writer) error {
+	if p.MaxOutputBytes > 0 || p.Limit > 0 {
+		text, err := p.Parse(filePath)
+		if len(text) > 0 {
+			if _, werr := w.Write(text); werr != nil {
+				return werr
+			}
+		}
+		return err
+	}
+
+	out := &countingWriter{w: w}
+	err := p.writeWithStandardLib(filePath, out)
+	if out.err != nil {
+		return out.err
+	}
+	if err != nil || out.n == 0 {
+		text, _, err := p.parseFallback(filePath, err)
+		if err != nil {
+			return err
+		}
+		if _, err := out.Write(text); err != nil {
+			return err
+		}
+	}
+
+	if !out.text && internal.OCR() != nil {
+		out.Write(p.recognizePages(filePath))
+	}
+
+	if p.ExtractAttachments {
+		attachments, err := p.parseAttachments(filePath)
+		if err != nil {
+			// 附件提取失败不影响页面文本
+			logger.Logger.Printf("提取PDF附件失败: %v", err)
+		} else if len(attachments) > 0 {
+			io.WriteString(out, "\n\n")
+			out.Write(attachments)
+		}
+	}
+	if out.err != nil {
+		return out.err
+	}
+	if !out.text {
+		return errNoTextLayer
+	}
+	return nil
+}
+
+// errOCRLimit OCR输出达到上限，停止提取图片
+var errOCRLimit = errors.New("OCR输出达到上限")
+
+// recognizePages 使用注册的OCR引擎逐页识别页面中的图片（扫描件每页通常为一张图片），页之间以分页分隔隔开；
+// 提取或识别失败时记录日志并返回已识别的文本
+func (p *OfficePdfParser) recognizePages(filePath string) []byte {
+	engine := internal.OCR()
+	file, err := os.Open(filePath)
+	if err != nil {
+		logger.Logger.Printf("无法打开文件: %v", err)
+		return nil
+	}
+	defer file.Close()
+
+	// 预览时仅识别前Limit页
+	var selectedPages []string
+	if p.Limit > 0 {
+		selectedPages = []string{fmt.Sprintf("1-%d", p.Limit)}
+	}
+
+	var (
+		textBuffer bytes.Buffer
+		pageNr     int
+		pages      int // 已输出的页数
+		images     []model.Image
+	)
+	separator := internal.Sections().PageSeparator
+	// flush 识别当前页的图片，同一页的图片按对象编号排序
+	flush := func() {
+		if pageNr == 0 {
+			return
+		}
+		if pages > 0 {
+			textBuffer.WriteString(separator)
+		}
+		pages++
+		sort.Slice(images, func(i, j int) bool { return images[i].ObjNr < images[j].ObjNr })
+		for _, img := range images {
+			data, err := io.ReadAll(img)
+			if err != nil {
+				logger.Logger.Printf("读取第%d页图片失败: %v", pageNr, err)
+				continue
+			}
+			text, err := engine.Image(data)
+			if err != nil {
+				logger.Logger.Printf("OCR识别第%d页图片失败: %v", pageNr, err)
+				continue
+			}
+			if text = strings.TrimSpace(text); text != "" {
+				textBuffer.WriteString(text + "\n")
+			}
+		}
+		images = images[:0]
+	}
+
+	err = pdfcpu.ExtractImages(file, selectedPages, func(img model.Image, _ bool, _ int) error {
+		if img.PageNr != pageNr {
+			flush()
+			if p.reachedLimit(textBuffer.Len()) {
+				return errOCRLimit
+			}
+			pageNr = img.PageNr
+		}
+		if img.Reader != nil && !img.Thumb {
+			images = append(images, img)
+		}
+		return nil
+	}, nil)
+	if err == nil {
+		flush()
+	} else if err != errOCRLimit {
+		logger.Logger.Printf("pdfcpu提取图片失败: %v", err)
+	}
+	return textBuffer.Bytes()
+}
+
+// countingWriter 统计写入的字节数，并记录第一次写入错误
+type countingWriter struct {
+	w    io.Writer
+	n    int
+	text bool // 是否写入过空白字符以外的内容
+	err  error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += n
+	c.text = c.text || len(bytes.TrimSpace(p)) > 0
+	c.err = err
+	return n, err
+}
+
+// parsePages 依次尝试各解析方案提取文本，二进制解析方案会同时返回检测到的编码
+func (p *OfficePdfParser) parsePages(filePath string) ([]byte, internal.ExtractInfo, error) {
 	// 尝试ledongthuc/pdf解析
 	extractedText, err := p.parseWithStandardLib(filePath)
 	if err == nil && len(extractedText) > 0 {
-		return extractedText, nil
+		return extractedText, internal.ExtractInfo{}, nil
 	}
+	return p.parseFallback(filePath, err)
+}
 
+// parseFallback ledongthuc/pdf解析失败或未提取到文本时，依次尝试其余解析方案，stdErr为ledongthuc/pdf的错误
+func (p *OfficePdfParser) parseFallback(filePath string, stdErr error) ([]byte, internal.ExtractInfo, error) {
 	// ledongthuc/pdf解析失败，尝试rsc/pdf解析
-	logger.Logger.Printf("ledongthuc/pdf解析失败: %v，尝试rsc/pdf解析", err)
+	logger.Logger.Printf("ledongthuc/pdf解析失败: %v，尝试rsc/pdf解析", stdErr)
 	rscText, err := p.parseWithRscPdf(filePath)
 	if err == nil && len(rscText) > 0 {
-		return rscText, nil
+		return rscText, internal.ExtractInfo{}, nil
 	}
 
 	// rsc/pdf解析失败，尝试pdfcpu解析
 	logger.Logger.Printf("rsc/pdf解析失败: %v，尝试pdfcpu解析", err)
 	pdfcpuText, err := p.parseWithPdfcpu(filePath)
 	if err == nil && len(pdfcpuText) > 0 {
-		return pdfcpuText, nil
+		return pdfcpuText, internal.ExtractInfo{}, nil
 	}
 
 	// pdfcpu解析失败，尝试二进制解析方案
 	logger.Logger.Printf("pdfcpu解析失败: %v，尝试二进制解析", err)
-	binaryText, err := p.parseBinaryPDF(filePath)
+	binaryText, info, err := p.parseBinaryPDF(filePath)
 	if err != nil {
-		return []byte{}, fmt.Errorf("所有提取方案均失败: %v", err)
+		return []byte{}, info, fmt.Errorf("所有提取方案均失败: %v", err)
 	}
 
-	return binaryText, nil
+	return binaryText, info, nil
 }
 
 // 使用标准库解析PDF (ledongthuc/pdf)
 func (p *OfficePdfParser) parseWithStandardLib(filePath string) ([]byte, error) {
+	var textBuilder bytes.Buffer
+	err := p.writeWithStandardLib(filePath, &textBuilder)
+	return textBuilder.Bytes(), err
+}
+
+// writeWithStandardLib 使用ledongthuc/pdf逐页提取文本并写入w
+func (p *OfficePdfParser) writeWithStandardLib(filePath string, w io.Writer) error {
 	f, r, err := ledongthucpdf.Open(filePath)
 	if err != nil {
-		return []byte{}, err
+		return err
 	}
 	defer f.Close()
 
-	var textBuilder bytes.Buffer
-	pageCount := r.NumPage()
+	var written, pages int // pages为已输出的页数
+	separator := internal.Sections().PageSeparator
+	pageCount := p.pageLimit(r.NumPage())
 
 	for i := 1; i <= pageCount; i++ {
 		page := r.Page(i)
-		if !page.V.IsNull() {
+		if page.V.IsNull() {
 			logger.Logger.Printf("获取第%d页失败", i)
 			continue
 		}
@@ -82,11 +319,23 @@ func (p *OfficePdfParser) parseWithStandardLib(filePath string) ([]byte, error)
 			continue
 		}
 
-		textBuilder.WriteString(content)
-		textBuilder.WriteString("\f")
+		if pages > 0 {
+			content = separator + content
+		}
+		pages++
+		n, err := io.WriteString(w, content)
+		if err != nil {
+			return err
+		}
+		written += n
+
+		if p.reachedLimit(written) {
+			logger.Logger.Printf("输出已达到上限 %d 字节，停止于第%d页", p.MaxOutputBytes, i)
+			break
+		}
 	}
 
-	return textBuilder.Bytes(), nil
+	return nil
 }
 
 // 使用rsc/pdf库解析PDF
@@ -97,18 +346,25 @@ func (p *OfficePdfParser) parseWithRscPdf(filePath string) ([]byte, error) {
 	}
 	defer file.Close()
 
-	// 解析PDF文件
-	pdfReader, err := rscpdf.NewReader(file, 10*1024*1024)
+	stat, err := file.Stat()
+	if err != nil {
+		return []byte{}, fmt.Errorf("无法获取文件信息: %v", err)
+	}
+
+	// 解析PDF文件，rsc/pdf从size处向前查找%%EOF及xref，必须为文件的实际大小
+	pdfReader, err := rscpdf.NewReader(file, stat.Size())
 	if err != nil {
 		return []byte{}, fmt.Errorf("解析PDF失败: %v", err)
 	}
 
 	var textBuilder bytes.Buffer
+	separator := internal.Sections().PageSeparator
 
 	// 遍历所有页面
-	for pageNum := 1; pageNum <= pdfReader.NumPage(); pageNum++ {
+	pageCount := p.pageLimit(pdfReader.NumPage())
+	for pageNum := 1; pageNum <= pageCount; pageNum++ {
 		page := pdfReader.Page(pageNum)
-		if page.V.IsNull == nil {
+		if page.V.IsNull() {
 			logger.Logger.Printf("无法获取第%d页", pageNum)
 			continue
 		}
@@ -120,12 +376,18 @@ func (p *OfficePdfParser) parseWithRscPdf(filePath string) ([]byte, error) {
 			continue
 		}
 
+		if textBuilder.Len() > 0 {
+			textBuilder.WriteString(separator)
+		}
 		for _, text := range content.Text {
 			textBuilder.WriteString(text.S)
 			textBuilder.WriteString("\n")
 		}
 
-		textBuilder.WriteString("\f")
+		if p.reachedLimit(textBuilder.Len()) {
+			logger.Logger.Printf("输出已达到上限 %d 字节，停止于第%d页", p.MaxOutputBytes, pageNum)
+			break
+		}
 	}
 
 	return textBuilder.Bytes(), nil
@@ -134,14 +396,19 @@ func (p *OfficePdfParser) parseWithRscPdf(filePath string) ([]byte, error) {
 // 使用pdfcpu库解析PDF
 func (p *OfficePdfParser) parseWithPdfcpu(filePath string) ([]byte, error) {
 	// 创建临时目录
-	tmpDir, err := os.MkdirTemp("", "pdf_extract_")
+	tmpDir, err := internal.MkdirTemp("pdf_extract_")
 	if err != nil {
 		return []byte{}, fmt.Errorf("创建临时目录失败: %v", err)
 	}
 	defer os.RemoveAll(tmpDir) // 确保程序退出时清理临时目录
 	logger.Logger.Printf("临时目录: %s", tmpDir)
 
-	if err = pdfcpu.ExtractContentFile(filePath, tmpDir, nil, nil); err != nil {
+	// 预览时仅提取前Limit页
+	var selectedPages []string
+	if p.Limit > 0 {
+		selectedPages = []string{fmt.Sprintf("1-%d", p.Limit)}
+	}
+	if err = pdfcpu.ExtractContentFile(filePath, tmpDir, selectedPages, nil); err != nil {
 		return []byte{}, fmt.Errorf("pdfcpu提取文本失败: %v", err)
 	}
 
@@ -155,11 +422,60 @@ func (p *OfficePdfParser) parseWithPdfcpu(filePath string) ([]byte, error) {
 	return content, nil
 }
 
+// parseAttachments 将嵌入的附件写入临时目录后逐个解析，输出格式与压缩包一致
+func (p *OfficePdfParser) parseAttachments(filePath string) ([]byte, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开文件: %v", err)
+	}
+	defer file.Close()
+
+	attachments, err := pdfcpu.ExtractAttachmentsRaw(file, "", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(attachments) == 0 {
+		return nil, nil
+	}
+
+	tmpDir, err := internal.MkdirTemp("pdf_attach_")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// 附件名来自文档，只保留文件名部分防止路径遍历，同名附件追加序号
+	used := make(map[string]bool)
+	for _, a := range attachments {
+		name := filepath.Base(filepath.Clean("/" + a.FileName))
+		if name == "/" || name == "." {
+			name = "attachment"
+		}
+		ext := filepath.Ext(name)
+		base := strings.TrimSuffix(name, ext)
+		for i := 1; used[name]; i++ {
+			name = fmt.Sprintf("%s_%d%s", base, i, ext)
+		}
+		used[name] = true
+
+		if err := compressfile.WriteDstFile(io.NopCloser(a), filepath.Join(tmpDir, name), 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	content, cnt, err := compressfile.WalkDir(tmpDir)
+	if err != nil {
+		return content, err
+	}
+	logger.Logger.Printf("PDF附件解析完成，共解析 %d 个附件", cnt)
+	return content, nil
+}
+
 // 基于二进制解析PDF文本内容
-func (p *OfficePdfParser) parseBinaryPDF(filePath string) ([]byte, error) {
+func (p *OfficePdfParser) parseBinaryPDF(filePath string) ([]byte, internal.ExtractInfo, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return []byte{}, fmt.Errorf("无法打开文件: %v", err)
+		return []byte{}, internal.ExtractInfo{}, fmt.Errorf("无法打开文件: %v", err)
 	}
 	defer file.Close()
 
@@ -167,13 +483,13 @@ func (p *OfficePdfParser) parseBinaryPDF(filePath string) ([]byte, error) {
 	header := make([]byte, 4)
 	_, err = file.Read(header)
 	if err != nil || !bytes.Equal(header, []byte("%PDF")) {
-		return []byte{}, fmt.Errorf("不是有效的PDF文件")
+		return []byte{}, internal.ExtractInfo{}, fmt.Errorf("不是有效的PDF文件: %w", internal.ErrInvalidSignature)
 	}
 
 	// 重置文件指针
 	_, err = file.Seek(0, io.SeekStart)
 	if err != nil {
-		return []byte{}, err
+		return []byte{}, internal.ExtractInfo{}, err
 	}
 
 	// 使用正则表达式提取文本流内容
@@ -183,6 +499,9 @@ func (p *OfficePdfParser) parseBinaryPDF(filePath string) ([]byte, error) {
 	streamRegex := regexp.MustCompile(`stream(.*?)endstream`)
 
 	for scanner.Scan() {
+		if p.reachedLimit(contentBuffer.Len()) {
+			break
+		}
 		line := scanner.Text()
 		// 提取文本对象
 		matches := textRegex.FindAllStringSubmatch(line, -1)
@@ -210,13 +529,13 @@ func (p *OfficePdfParser) parseBinaryPDF(filePath string) ([]byte, error) {
 	}
 
 	if err := scanner.Err(); err != nil {
-		return []byte{}, fmt.Errorf("文件扫描错误: %v", err)
+		return []byte{}, internal.ExtractInfo{}, fmt.Errorf("文件扫描错误: %v", err)
 	}
 
 	// 检测并解码文本内容
-	extractedText, err := p.detectAndDecodeText(contentBuffer.Bytes())
+	extractedText, info, err := p.detectAndDecodeText(contentBuffer.Bytes())
 	if err != nil {
-		return []byte{}, err
+		return []byte{}, internal.ExtractInfo{}, err
 	}
 
 	// 清理提取的文本
@@ -224,11 +543,11 @@ func (p *OfficePdfParser) parseBinaryPDF(filePath string) ([]byte, error) {
 	extractedText = strings.ReplaceAll(extractedText, "\n", " ")
 	extractedText = regexp.MustCompile(`\s+`).ReplaceAllString(extractedText, " ")
 
-	return []byte(extractedText), nil
+	return []byte(extractedText), info, nil
 }
 
-// detectAndDecodeText 检测文本编码并解码为UTF-8
-func (p *OfficePdfParser) detectAndDecodeText(rawData []byte) (string, error) {
+// detectAndDecodeText 检测文本编码并解码为UTF-8，同时返回检测到的编码及语言
+func (p *OfficePdfParser) detectAndDecodeText(rawData []byte) (string, internal.ExtractInfo, error) {
 	// 检测文本编码
 	detector := chardet.NewTextDetector()
 	result, err := detector.DetectBest(rawData)
@@ -236,6 +555,7 @@ func (p *OfficePdfParser) detectAndDecodeText(rawData []byte) (string, error) {
 		logger.Logger.Printf("编码检测失败: %v，使用默认UTF-8编码", err)
 		result = &chardet.Result{Charset: "UTF-8", Confidence: 1.0}
 	}
+	info := internal.ExtractInfo{Charset: result.Charset, Language: result.Language}
 
 	// 根据检测结果选择解码器
 	var decoder encoding.Encoding
@@ -258,8 +578,8 @@ func (p *OfficePdfParser) detectAndDecodeText(rawData []byte) (string, error) {
 	// 解码为UTF-8
 	decodedBytes, _, err := transform.Bytes(decoder.NewDecoder(), rawData)
 	if err != nil {
-		return "", fmt.Errorf("文本解码失败: %v", err)
+		return "", info, fmt.Errorf("文本解码失败: %v", err)
 	}
 
-	return string(decodedBytes), nil
+	return internal.ReplaceInvalid(string(decodedBytes)), info, nil
 }
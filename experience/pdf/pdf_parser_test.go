@@ -0,0 +1,94 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fextra/internal"
+)
+
+// buildPDF 构造每页一行Helvetica文本的PDF，xref按各对象的实际偏移生成
+func buildPDF(pages []string) []byte {
+	n := len(pages)
+	// 对象编号：1 Catalog，2 Pages，3 Font，之后每页依次为Page及其内容流
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+	var kids []string
+	for i, text := range pages {
+		pageID, contentID := 4+2*i, 5+2*i
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageID))
+		stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", contentID),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), n)
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func writePDF(t *testing.T, pages []string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.pdf")
+	if err := os.WriteFile(path, buildPDF(pages), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestTwoPagesInOrder 两页文本按页序输出，相邻两页之间只有一个分页符
+func TestTwoPagesInOrder(t *testing.T) {
+	path := writePDF(t, []string{"First page", "Second page"})
+	separator := internal.Sections().PageSeparator
+
+	parsers := []struct {
+		name  string
+		parse func(string) ([]byte, error)
+	}{
+		{"ledongthuc", (&OfficePdfParser{}).parseWithStandardLib},
+		{"rsc", (&OfficePdfParser{}).parseWithRscPdf},
+		{"Parse", (&OfficePdfParser{}).Parse},
+	}
+	for _, tc := range parsers {
+		t.Run(tc.name, func(t *testing.T) {
+			text, err := tc.parse(path)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			pages := strings.Split(string(text), separator)
+			if len(pages) != 2 {
+				t.Fatalf("got %d pages in %q, want 2", len(pages), text)
+			}
+			for i, want := range []string{"First page", "Second page"} {
+				if got := normalizeSpace(pages[i]); got != normalizeSpace(want) {
+					t.Errorf("page %d: got %q, want %q", i+1, pages[i], want)
+				}
+			}
+		})
+	}
+}
+
+// normalizeSpace 去除空白，rsc/pdf按字形输出文本，每个字形之后为换行
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
@@ -0,0 +1,41 @@
+package ppt
+
+import (
+	"bytes"
+	"testing"
+
+	"fextra/internal/cfbtest"
+
+	"github.com/richardlehane/mscfb"
+)
+
+func TestGetPptDocumentStreamSectorMath(t *testing.T) {
+	document := make([]byte, 10000)
+	for i := range document {
+		document[i] = byte(i * 7)
+	}
+	streams := []cfbtest.Stream{
+		{Name: "Current User", Data: []byte("current user")},
+		{Name: "PowerPoint Document", Data: document},
+		{Name: "Pictures", Data: bytes.Repeat([]byte{0xAB}, 5000)},
+	}
+
+	for _, opts := range []cfbtest.Options{
+		{SectorSize: 512},
+		{SectorSize: 512, Fragment: true},
+		{SectorSize: 4096},
+		{SectorSize: 4096, Fragment: true},
+	} {
+		reader, err := mscfb.New(bytes.NewReader(cfbtest.Build(streams, opts)))
+		if err != nil {
+			t.Fatalf("%+v: mscfb.New: %v", opts, err)
+		}
+		d := &PptParse{File: reader, RootNode: &PPTNode{}}
+		if err := d.GetPptDocumentStream(); err != nil {
+			t.Fatalf("%+v: GetPptDocumentStream: %v", opts, err)
+		}
+		if !bytes.Equal(d.PptDocumentStream, document) || d.StreamLen != len(document) {
+			t.Errorf("%+v: PowerPoint Document stream differs from the stream written", opts)
+		}
+	}
+}
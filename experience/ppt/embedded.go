@@ -0,0 +1,245 @@
+package ppt
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+
+	"fextra/internal"
+	"fextra/pkg/logger"
+	"fextra/pkg/office/officeenc"
+	"fextra/pkg/office/oleobj"
+)
+
+/*
+	除PowerPoint Document流中的幻灯片文本外，以下内容也可能包含文本：
+	- ExOleObjStg记录（0x1011）：嵌入的OLE对象（如Word表格、Excel工作表、docx），
+	  recInstance为1时内容经zlib压缩，前4字节为解压后的大小
+	- Pictures流：图片（OfficeArtBlip记录），其中EMF、WMF图元文件的文本绘制记录保存了文字，
+	  图元文件内容前为OfficeArtMetafileHeader，compression为0时经zlib压缩
+*/
+
+const (
+	RT_ExOleObjStg = 0x1011
+
+	// 图元文件类型的OfficeArtBlip记录
+	RT_BlipEMF = 0xF01A
+	RT_BlipWMF = 0xF01B
+
+	// OfficeArtBlip的recInstance，取值为单UID的实例时后续只有一个16字节的UID
+	blipInstanceEMF = 0x3D4
+	blipInstanceWMF = 0x216
+
+	metafileHeaderLen   = 34   // OfficeArtMetafileHeader长度
+	metafileCompressed  = 0x00 // OfficeArtMetafileHeader.compression：DEFLATE压缩
+	maxEmbeddedDataSize = 256 << 20
+)
+
+// 图元文件中的文本记录
+const (
+	emrExtTextOutW = 84     // EMF EMR_EXTTEXTOUTW
+	wmfTextOut     = 0x0521 // WMF META_TEXTOUT
+	wmfExtTextOut  = 0x0A32 // WMF META_EXTTEXTOUT
+
+	wmfPlaceableKey = 0x9AC6CDD7
+	etoOpaque       = 0x0002
+	etoClipped      = 0x0004
+)
+
+// readOleObject 读取ExOleObjStg记录中的OLE复合文档，按recInstance解压
+func readOleObject(header RecordHeader, data []byte) ([]byte, error) {
+	if header.RecInstance != 1 {
+		return data, nil
+	}
+	if len(data) < 4 {
+		return nil, fmt.Errorf("ExOleObjStg解压大小: %w", internal.ErrTruncated)
+	}
+	size := binary.LittleEndian.Uint32(data)
+	if size > maxEmbeddedDataSize {
+		return nil, fmt.Errorf("ExOleObjStg解压后大小 %d 超过上限", size)
+	}
+	return inflate(data[4:], int(size))
+}
+
+// inflate 解压zlib数据，size为声明的解压后大小，最多解压size字节；
+// 声明的大小来自文件，不按其预先分配，缓冲区随解压出的数据增长
+func inflate(data []byte, size int) ([]byte, error) {
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("创建zlib reader失败: %w", err)
+	}
+	defer reader.Close()
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, io.LimitReader(reader, int64(size))); err != nil {
+		return buf.Bytes(), fmt.Errorf("解压失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeEmbedded 依次解析嵌入的OLE对象，解析失败的对象只记录日志
+func (d *PptParse) writeEmbedded(textBuffer *bytes.Buffer) {
+	for i, obj := range d.oleObjects {
+		data, err := readOleObject(obj.header, obj.data)
+		if err != nil {
+			logger.Logger.Printf("读取第%d个嵌入对象失败: %v", i+1, err)
+			continue
+		}
+		content, err := oleobj.Parse(data)
+		if err != nil {
+			logger.Logger.Printf("解析第%d个嵌入对象失败: %v", i+1, err)
+			continue
+		}
+		if text := bytes.TrimSpace(content); len(text) > 0 {
+			textBuffer.WriteString(fmt.Sprintf("=== 嵌入对象 ===\n%s\n\n", text))
+		}
+	}
+}
+
+// writePictureText 提取Pictures流中EMF、WMF图片的文本，每张图片的文本输出为一段
+func (d *PptParse) writePictureText(textBuffer *bytes.Buffer) {
+	for _, file := range d.File.File {
+		if file.Name != "Pictures" || len(file.Path) > 0 {
+			continue
+		}
+		stream := make([]byte, file.Size)
+		if _, err := io.ReadFull(file, stream); err != nil {
+			logger.Logger.Printf("读取Pictures流失败: %v", err)
+			return
+		}
+		for pos := 0; pos+RecordHeaderLen <= len(stream); {
+			header, dataPos, _ := parseRecordHeader(stream, pos)
+			end := dataPos + int(header.RecLen)
+			if end > len(stream) || end < dataPos {
+				logger.Logger.Printf("Pictures流记录超出流边界，偏移: 0x%x", pos)
+				return
+			}
+			if header.RecType == RT_BlipEMF || header.RecType == RT_BlipWMF {
+				text, err := blipText(header, stream[dataPos:end])
+				if err != nil {
+					logger.Logger.Printf("解析Pictures流中的图元文件失败，偏移: 0x%x: %v", pos, err)
+				} else if text != "" {
+					textBuffer.WriteString(fmt.Sprintf("=== 图片文本 ===\n%s\n\n", text))
+				}
+			}
+			pos = end
+		}
+		return
+	}
+}
+
+// blipText 解压图元文件类型的OfficeArtBlip并提取其中的文本
+func blipText(header RecordHeader, data []byte) (string, error) {
+	pos := 16
+	if header.RecInstance != blipInstanceEMF && header.RecInstance != blipInstanceWMF {
+		pos += 16
+	}
+	if pos+metafileHeaderLen > len(data) {
+		return "", fmt.Errorf("图元文件头: %w", internal.ErrTruncated)
+	}
+	size := binary.LittleEndian.Uint32(data[pos:])
+	compression := data[pos+32]
+	metafile := data[pos+metafileHeaderLen:]
+	if compression == metafileCompressed {
+		if size > maxEmbeddedDataSize {
+			return "", fmt.Errorf("图元文件解压后大小 %d 超过上限", size)
+		}
+		var err error
+		if metafile, err = inflate(metafile, int(size)); err != nil {
+			return "", err
+		}
+	}
+
+	var lines []string
+	if header.RecType == RT_BlipEMF {
+		lines = emfText(metafile)
+	} else {
+		lines = wmfText(metafile)
+	}
+	var text []string
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			text = append(text, line)
+		}
+	}
+	return strings.Join(text, "\n"), nil
+}
+
+// emfText 提取EMF中EMR_EXTTEXTOUTW记录的文本：
+// 记录头(8) Bounds(16) iGraphicsMode(4) exScale(4) eyScale(4) Reference(8) Chars(4) offString(4)，
+// offString为文本相对记录起始的偏移
+func emfText(data []byte) []string {
+	var lines []string
+	for pos := 0; pos+8 <= len(data); {
+		recType := binary.LittleEndian.Uint32(data[pos:])
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		if size < 8 || size > len(data)-pos {
+			break
+		}
+		record := data[pos : pos+size]
+		if recType == emrExtTextOutW && len(record) >= 52 {
+			chars := int(binary.LittleEndian.Uint32(record[44:]))
+			offset := int(binary.LittleEndian.Uint32(record[48:]))
+			if offset >= 0 && chars >= 0 && offset <= len(record) && chars <= (len(record)-offset)/2 {
+				lines = append(lines, officeenc.DecodeUTF16(record[offset:offset+chars*2], binary.LittleEndian))
+			}
+		}
+		pos += size
+	}
+	return lines
+}
+
+// wmfText 提取WMF中META_TEXTOUT、META_EXTTEXTOUT记录的文本，文本为ANSI编码，按UTF-8输出并替换无效字节；
+// 记录大小以16位字为单位，可能以22字节的可放置文件头开始
+func wmfText(data []byte) []string {
+	pos := 0
+	if len(data) >= 22 && binary.LittleEndian.Uint32(data) == wmfPlaceableKey {
+		pos = 22
+	}
+	if pos+18 > len(data) {
+		return nil
+	}
+	pos += int(binary.LittleEndian.Uint16(data[pos+2:])) * 2 // META_HEADER.HeaderSize
+
+	var lines []string
+	for pos+6 <= len(data) {
+		size := int(binary.LittleEndian.Uint32(data[pos:])) * 2
+		function := binary.LittleEndian.Uint16(data[pos+4:])
+		if size < 6 || size > len(data)-pos {
+			break
+		}
+		record := data[pos+6 : pos+size]
+		var text []byte
+		switch function {
+		case wmfTextOut:
+			if len(record) >= 2 {
+				n := int(binary.LittleEndian.Uint16(record))
+				if 2+n <= len(record) {
+					text = record[2 : 2+n]
+				}
+			}
+		case wmfExtTextOut:
+			if len(record) >= 8 {
+				n := int(binary.LittleEndian.Uint16(record[4:]))
+				start := 8
+				if opts := binary.LittleEndian.Uint16(record[6:]); opts&(etoOpaque|etoClipped) != 0 {
+					start += 8
+				}
+				if start+n <= len(record) {
+					text = record[start : start+n]
+				}
+			}
+		}
+		if len(text) > 0 {
+			lines = append(lines, string(internal.ReplaceInvalidBytes(text)))
+		}
+		if function == 0 {
+			break
+		}
+		pos += size
+	}
+	return lines
+}
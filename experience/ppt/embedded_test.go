@@ -0,0 +1,36 @@
+package ppt
+
+import (
+	"bytes"
+	"compress/zlib"
+	"runtime"
+	"testing"
+)
+
+// TestInflateDeclaredSize 声明的解压后大小远大于实际数据时不按声明大小分配内存
+func TestInflateDeclaredSize(t *testing.T) {
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+	w.Write([]byte("embedded object"))
+	w.Close()
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	data, err := inflate(compressed.Bytes(), maxEmbeddedDataSize)
+	runtime.ReadMemStats(&after)
+	if err != nil {
+		t.Fatalf("inflate: %v", err)
+	}
+	allocated := after.TotalAlloc - before.TotalAlloc
+	if string(data) != "embedded object" {
+		t.Errorf("got %q", data)
+	}
+	if allocated > 1<<20 {
+		t.Errorf("inflate allocated %d bytes for 15 bytes of output", allocated)
+	}
+
+	// 只解压声明的大小
+	if data, _ := inflate(compressed.Bytes(), 8); string(data) != "embedded" {
+		t.Errorf("got %q, want output cut at the declared size", data)
+	}
+}
@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"fextra/internal"
 	"fextra/pkg/logger"
+	"fextra/pkg/office/doc"
+	"fextra/pkg/office/officeenc"
 	"fmt"
+	"io"
 	"os"
+	"slices"
 	"strings"
-	"unicode/utf16"
 
 	"github.com/richardlehane/mscfb"
 )
@@ -24,12 +28,28 @@ const (
 	RT_TextCharsAtom = 0x0FA0
 	RT_CStringAtom   = 0x0FBA
 
+	RT_SlideListWithText = 0x0FF0 // 幻灯片文本列表容器，实例0为幻灯片，1为母版，2为备注
+	RT_SlidePersistAtom  = 0x03F3 // 列表中每张幻灯片的起始记录
+	RT_Slide             = 0x03EE // 幻灯片容器，较新版本保存的文件中幻灯片文本位于此处而非文本列表
+
 	RT_TextHeaderAtom   = 0x003F
 	RT_TextSpecInfoAtom = 0x0040
 	RT_TextRulerAtom    = 0x0050
 	RT_TextStyleAtom    = 0x0053
 )
 
+// TextHeaderAtom给出其后文本记录所属的占位符类型，见规范2.13.33 TextTypeEnum
+const (
+	Tx_TYPE_TITLE       = 0
+	Tx_TYPE_BODY        = 1
+	Tx_TYPE_NOTES       = 2
+	Tx_TYPE_OTHER       = 4
+	Tx_TYPE_CENTERBODY  = 5
+	Tx_TYPE_CENTERTITLE = 6
+	Tx_TYPE_HALFBODY    = 7
+	Tx_TYPE_QUARTERBODY = 8
+)
+
 var (
 	// 文本记录类型集合
 	extTextRecordTypes = map[uint16]bool{
@@ -58,43 +78,66 @@ type PptParse struct {
 	RecordNum         int      // PptDocumentStream的记录数量
 	RootNode          *PPTNode // 记录树的根节点
 	CurrentNode       *PPTNode // 当前解析节点
+
+	MaxSlides    int // 最多提取的幻灯片数，为0时不限制
+	slideCount   int // 幻灯片列表中已遇到的幻灯片数
+	slideRecords int // 已遇到的幻灯片容器数
+	slideListEnd int // 当前幻灯片文本列表的结束偏移
+
+	sections    internal.SectionFormatter // 幻灯片分节格式
+	slideIndex  int                       // 当前所在幻灯片的编号，不在幻灯片中时为0
+	sectionOpen bool                      // 是否已输出当前幻灯片的节标题
+	pendingSep  bool                      // 上一节已结束，之后再输出文本时先写入节分隔
+
+	textType      uint32 // 最近一个TextHeaderAtom给出的文本类型
+	hasTextHeader bool   // textType是否有效，文本记录使用后即失效
+
+	oleObjects []oleObject // 文档流中的ExOleObjStg记录，幻灯片文本之后解析
+}
+
+// oleObject 嵌入的OLE对象记录，data引用文档流缓冲区
+type oleObject struct {
+	header RecordHeader
+	data   []byte
 }
 
-type OfficePptParser struct{}
-
-// 解码UTF-16字节流为字符串
-func decodeUTF16(data []byte, byteOrder binary.ByteOrder) string {
-	var bomSize int
-	if len(data) >= 2 {
-		if data[0] == 0xFF && data[1] == 0xFE {
-			byteOrder = binary.LittleEndian
-			bomSize = 2
-		} else if data[0] == 0xFE && data[1] == 0xFF {
-			byteOrder = binary.BigEndian
-			bomSize = 2
+type OfficePptParser struct {
+	Limit int // 最多提取的幻灯片数，用于生成预览，为0时不限制
+}
+
+// decodeTextAtom 按记录类型解码文本记录：
+// TextBytesAtom每个字节为UTF-16字符的低字节（高字节为0），TextCharsAtom为UTF-16LE，
+// CStringAtom为UTF-16LE且可能以空字符结尾
+func decodeTextAtom(recType uint16, data []byte) string {
+	switch recType {
+	case RT_TextBytesAtom:
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
 		}
+		return string(runes)
+	case RT_CStringAtom:
+		return strings.TrimRight(officeenc.DecodeUTF16(data, binary.LittleEndian), "\x00")
+	default:
+		return officeenc.DecodeUTF16(data, binary.LittleEndian)
 	}
-	if byteOrder == nil {
-		byteOrder = binary.LittleEndian
-	}
+}
 
-	u16s := make([]uint16, (len(data)-bomSize)/2)
-	for i := 0; i < len(u16s); i++ {
-		u16s[i] = byteOrder.Uint16(data[bomSize+2*i:])
+// textTypeLabel 文本所属占位符的输出标题，标题与正文分开输出，未关联TextHeaderAtom时使用通用标题
+func textTypeLabel(textType uint32, hasHeader bool) string {
+	if !hasHeader {
+		return "文本内容"
 	}
-
-	var runes []rune
-	for i := 0; i < len(u16s); {
-		if utf16.IsSurrogate(rune(u16s[i])) && i+1 < len(u16s) {
-			r := utf16.DecodeRune(rune(u16s[i]), rune(u16s[i+1]))
-			runes = append(runes, r)
-			i += 2
-		} else {
-			runes = append(runes, rune(u16s[i]))
-			i++
-		}
+	switch textType {
+	case Tx_TYPE_TITLE, Tx_TYPE_CENTERTITLE:
+		return "标题"
+	case Tx_TYPE_BODY, Tx_TYPE_CENTERBODY, Tx_TYPE_HALFBODY, Tx_TYPE_QUARTERBODY:
+		return "正文"
+	case Tx_TYPE_NOTES:
+		return "备注"
+	default:
+		return "文本内容"
 	}
-	return string(runes)
 }
 
 func NewPptParse(file *os.File) (*PptParse, error) {
@@ -112,6 +155,32 @@ func NewPptParse(file *os.File) (*PptParse, error) {
 	}, nil
 }
 
+// pptParsePool 复用PptParse，批量解析时避免每个文件重新分配文档流缓冲区
+var pptParsePool = internal.NewPool(func() *PptParse {
+	return &PptParse{RootNode: &PPTNode{}}
+})
+
+// Reset 清空上一个文件的解析状态，保留文档流缓冲区已分配的内存
+func (d *PptParse) Reset() {
+	d.File = nil
+	d.PptDocumentStream = d.PptDocumentStream[:0]
+	d.StreamLen = 0
+	d.StreamOffset = 0
+	d.RecordNum = 0
+	*d.RootNode = PPTNode{}
+	d.CurrentNode = nil
+	d.MaxSlides = 0
+	d.slideCount = 0
+	d.slideRecords = 0
+	d.slideListEnd = 0
+	d.slideIndex = 0
+	d.sectionOpen = false
+	d.pendingSep = false
+	d.textType = 0
+	d.hasTextHeader = false
+	d.oleObjects = d.oleObjects[:0]
+}
+
 func (d *PptParse) GetPptDocumentStream() error {
 	if d.File == nil {
 		return errors.New("mscfb file is nil")
@@ -121,10 +190,10 @@ func (d *PptParse) GetPptDocumentStream() error {
 	for _, file := range d.File.File {
 		logger.Logger.Printf("file name: %s", file.Name)
 		if file.Name == "PowerPoint Document" {
-			buf = make([]byte, file.Size)
+			buf = slices.Grow(d.PptDocumentStream[:0], int(file.Size))[:file.Size]
 			n, err := file.Read(buf)
 			if err != nil {
-				return fmt.Errorf("failed to open PowerPoint Document stream: %v", err)
+				return fmt.Errorf("failed to open PowerPoint Document stream: %w", err)
 			}
 			logger.Logger.Printf("read %d bytes： %v", n, buf[:32])
 			d.PptDocumentStream = buf
@@ -133,18 +202,21 @@ func (d *PptParse) GetPptDocumentStream() error {
 		}
 	}
 
-	return fmt.Errorf("PowerPoint Document stream not found")
+	return fmt.Errorf("PowerPoint Document stream not found: %w", internal.ErrStreamNotFound)
 }
 
 func (d *PptParse) parseTextRecords() ([]byte, error) {
 	if len(d.PptDocumentStream) == 0 {
-		return nil, errors.New("PPT文档流为空")
+		return nil, fmt.Errorf("PPT文档流为空: %w", internal.ErrStreamNotFound)
 	}
 
 	var textBuffer bytes.Buffer
+	d.sections = internal.Sections()
 	// 从根节点开始解析记录树
 	d.CurrentNode = d.RootNode
-	if _, err := d.parseRecordToNode(&textBuffer); err != nil {
+	_, err := d.parseRecordToNode(&textBuffer, d.StreamLen)
+	d.endSlide()
+	if err != nil {
 		return textBuffer.Bytes(), err
 	}
 
@@ -154,6 +226,29 @@ func (d *PptParse) parseTextRecords() ([]byte, error) {
 	return textBuffer.Bytes(), nil
 }
 
+// beginSlide 开始第index张幻灯片，节标题在该幻灯片输出第一段文本时写入，没有文本的幻灯片不输出
+func (d *PptParse) beginSlide(index int) {
+	d.endSlide()
+	d.slideIndex = index
+}
+
+// endSlide 结束当前幻灯片，已输出节标题时在之后再输出文本时写入节分隔，最后一节之后不输出
+func (d *PptParse) endSlide() {
+	if d.sectionOpen {
+		d.pendingSep = true
+	}
+	d.slideIndex = 0
+	d.sectionOpen = false
+}
+
+// writeSeparator 上一节已结束时写入节分隔
+func (d *PptParse) writeSeparator(textBuffer *bytes.Buffer) {
+	if d.pendingSep {
+		textBuffer.WriteString(d.sections.Separator)
+		d.pendingSep = false
+	}
+}
+
 // 遍历节点树，用于调试或打印结构
 func (d *PptParse) traverseNode(node *PPTNode, depth int) {
 	indent := strings.Repeat("  ", depth)
@@ -164,12 +259,40 @@ func (d *PptParse) traverseNode(node *PPTNode, depth int) {
 	}
 }
 
+// ExtractText 提取幻灯片文本，未限制幻灯片数时再追加嵌入的OLE对象及Pictures流中图元文件的文本；
+// 文档流中既没有幻灯片也没有任何文本时返回ErrNoContent
 func (d *PptParse) ExtractText() ([]byte, error) {
 	if err := d.GetPptDocumentStream(); err != nil {
 		return nil, err
 	}
 
-	return d.parseTextRecords()
+	content, err := d.parseTextRecords()
+	if err != nil {
+		return content, err
+	}
+
+	if d.MaxSlides == 0 {
+		var extra bytes.Buffer
+		d.writeEmbedded(&extra)
+		d.writePictureText(&extra)
+		if extra.Len() > 0 {
+			textBuffer := bytes.NewBuffer(content)
+			d.writeSeparator(textBuffer)
+			textBuffer.Write(extra.Bytes())
+			content = textBuffer.Bytes()
+		}
+	}
+	if len(content) == 0 && d.slideCount == 0 && d.slideRecords == 0 {
+		return content, fmt.Errorf("PPT中没有幻灯片: %w", internal.ErrNoContent)
+	}
+	return content, nil
+}
+
+// ParsePreview 仅提取PPT前limit张幻灯片的文本
+func (p *OfficePptParser) ParsePreview(filePath string, limit int) ([]byte, error) {
+	preview := *p
+	preview.Limit = limit
+	return preview.Parse(filePath)
 }
 
 func (p *OfficePptParser) Parse(filePath string) ([]byte, error) {
@@ -179,11 +302,23 @@ func (p *OfficePptParser) Parse(filePath string) ([]byte, error) {
 	}
 	defer file.Close()
 
-	parser, err := NewPptParse(file)
-	if err != nil {
+	if err := doc.CheckHeader(file); err != nil {
+		return nil, fmt.Errorf("初始化PPT解析器失败: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
 		return nil, fmt.Errorf("初始化PPT解析器失败: %w", err)
 	}
 
+	cfb, err := mscfb.New(file)
+	if err != nil {
+		return nil, fmt.Errorf("初始化PPT解析器失败: 文件打开失败: %w", err)
+	}
+
+	parser := pptParsePool.Get()
+	defer pptParsePool.Put(parser)
+	parser.File = cfb
+	parser.MaxSlides = p.Limit
+
 	content, err := parser.ExtractText()
 	if err != nil {
 		return content, fmt.Errorf("提取文本失败: %w", err)
@@ -205,7 +340,7 @@ type RecordHeader struct {
 func parseRecordHeader(stream []byte, pos int) (RecordHeader, int, error) {
 	// 检查流边界
 	if pos+RecordHeaderLen > len(stream) {
-		return RecordHeader{}, pos, fmt.Errorf("记录头超出流边界，需要%d字节，剩余%d字节", RecordHeaderLen, len(stream)-pos)
+		return RecordHeader{}, pos, fmt.Errorf("记录头超出流边界，需要%d字节，剩余%d字节: %w", RecordHeaderLen, len(stream)-pos, internal.ErrTruncated)
 	}
 
 	// 读取前2字节(16位)，包含RecVer和RecInstance
@@ -224,26 +359,26 @@ func parseRecordHeader(stream []byte, pos int) (RecordHeader, int, error) {
 	}, pos + RecordHeaderLen, nil
 }
 
+// parseContainer 解析容器记录的子记录，解析范围限制在容器内，避免重复解析容器之后的记录
 func (d *PptParse) parseContainer(textBuffer *bytes.Buffer, offset int, recordEnd int) error {
-	for d.StreamOffset < recordEnd { // 递归解析子记录
-		if err := d.parseRecord(textBuffer); err != nil {
-			return fmt.Errorf("解析子记录失败: %w", err)
-		}
+	if err := d.parseRecord(textBuffer, recordEnd); err != nil {
+		return fmt.Errorf("解析子记录失败: %w", err)
 	}
 	return nil
 }
 
-func (d *PptParse) parseRecord(textBuffer *bytes.Buffer) error {
+func (d *PptParse) parseRecord(textBuffer *bytes.Buffer, end int) error {
 	// 创建根节点并开始解析
 	d.CurrentNode = d.RootNode
-	_, err := d.parseRecordToNode(textBuffer)
+	_, err := d.parseRecordToNode(textBuffer, end)
 	return err
 }
 
-func (d *PptParse) parseRecordToNode(textBuffer *bytes.Buffer) (*PPTNode, error) {
+// parseRecordToNode 依次解析end之前的记录
+func (d *PptParse) parseRecordToNode(textBuffer *bytes.Buffer, end int) (*PPTNode, error) {
 	stream := d.PptDocumentStream
 
-	for d.StreamOffset+RecordHeaderLen < d.StreamLen {
+	for d.StreamOffset+RecordHeaderLen <= end {
 		// 解析记录头
 		header, newPos, err := parseRecordHeader(stream, d.StreamOffset)
 		if err != nil {
@@ -261,8 +396,8 @@ func (d *PptParse) parseRecordToNode(textBuffer *bytes.Buffer) (*PPTNode, error)
 
 		// 边界检查
 		if recordEnd > len(stream) {
-			return nil, fmt.Errorf("记录超出流边界，类型: 0x%04x, 版本: 0x%x, 预期长度: %d, 剩余字节: %d",
-				header.RecType, header.RecVer, header.RecLen, d.StreamLen-d.StreamOffset)
+			return nil, fmt.Errorf("记录超出流边界，类型: 0x%04x, 版本: 0x%x, 预期长度: %d, 剩余字节: %d: %w",
+				header.RecType, header.RecVer, header.RecLen, d.StreamLen-d.StreamOffset, internal.ErrTruncated)
 		}
 
 		d.RecordNum++
@@ -272,22 +407,69 @@ func (d *PptParse) parseRecordToNode(textBuffer *bytes.Buffer) (*PPTNode, error)
 		// 读取记录数据
 		node.Data = stream[d.StreamOffset:recordEnd]
 
+		if header.RecType == RT_SlideListWithText && header.RecInstance == 0 {
+			d.slideListEnd = recordEnd
+		}
+		// 预览时文本列表中超出数量的幻灯片跳过列表的其余部分，
+		// 超出数量的幻灯片容器结束整个文档流的解析（其后为其余幻灯片及备注）
+		if header.RecType == RT_SlidePersistAtom && d.StreamOffset < d.slideListEnd {
+			d.slideCount++
+			if d.MaxSlides > 0 && d.slideCount > d.MaxSlides {
+				d.StreamOffset = d.slideListEnd
+				return nil, nil
+			}
+			d.beginSlide(d.slideCount)
+		}
+		if header.RecType == RT_Slide {
+			d.slideRecords++
+			if d.MaxSlides > 0 && d.slideRecords > d.MaxSlides {
+				logger.Logger.Printf("已提取 %d 张幻灯片，停止解析", d.MaxSlides)
+				d.StreamOffset = d.StreamLen
+				return nil, nil
+			}
+			d.beginSlide(d.slideRecords)
+		}
+
 		// 1. 处理容器记录（如RT_Document=0x03E8）
 		if header.RecVer == 0xF { // 容器记录由RecVer=0xF标识
 			// 递归解析子记录
 			if err := d.parseContainer(textBuffer, d.StreamOffset, recordEnd); err != nil {
 				return nil, fmt.Errorf("解析容器记录失败: %w", err)
 			}
+			// 幻灯片文本列表及幻灯片容器结束时结束当前幻灯片
+			if header.RecType == RT_Slide || header.RecType == RT_SlideListWithText && header.RecInstance == 0 {
+				d.endSlide()
+			}
+			// 预览已达到幻灯片数量
+			if d.StreamOffset >= d.StreamLen {
+				return nil, nil
+			}
+		} else if header.RecType == RT_TextHeaderAtom && len(node.Data) >= 4 {
+			// 2. 记录其后文本记录所属的占位符类型
+			d.textType = binary.LittleEndian.Uint32(node.Data)
+			d.hasTextHeader = true
 		} else if extTextRecordTypes[header.RecType] {
-			// 2. 处理文本记录
-			text := decodeUTF16(node.Data, binary.LittleEndian)
-			text = strings.TrimSpace(text)
+			// 3. 处理文本记录，TextChars/TextBytes与之前的TextHeaderAtom关联，CString不属于占位符文本
+			label := textTypeLabel(0, false)
+			if header.RecType != RT_CStringAtom {
+				label = textTypeLabel(d.textType, d.hasTextHeader)
+				d.hasTextHeader = false
+			}
+			text := strings.TrimSpace(decodeTextAtom(header.RecType, node.Data))
 
 			logger.DebugLogger.Printf("解析文本记录, stream偏移：0x%x, 类型: 0x%04x, 版本: 0x%x, 长度: 0x%x字节, 文本内容: %s",
 				d.StreamOffset, header.RecType, header.RecVer, header.RecLen, text)
 			if text != "" {
-				textBuffer.WriteString(fmt.Sprintf("=== 文本内容 ===\n%s\n\n", text))
+				d.writeSeparator(textBuffer)
+				if d.slideIndex > 0 && !d.sectionOpen {
+					textBuffer.WriteString(d.sections.FormatHeader(internal.SectionSlide, d.slideIndex, ""))
+					d.sectionOpen = true
+				}
+				textBuffer.WriteString(fmt.Sprintf("=== %s ===\n%s\n\n", label, text))
 			}
+		} else if header.RecType == RT_ExOleObjStg {
+			// 4. 嵌入的OLE对象，幻灯片文本提取完成后再解析
+			d.oleObjects = append(d.oleObjects, oleObject{header: header, data: node.Data})
 		} else {
 			logger.DebugLogger.Printf("忽略未知记录类型: 0x%04x, stream偏移：0x%x,版本: 0x%x, 长度: 0x%x字节",
 				header.RecType, d.StreamOffset, header.RecVer, header.RecLen)
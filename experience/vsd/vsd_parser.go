@@ -2,12 +2,13 @@ package vsd
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 
-	// 复用项目已有Office解析库
 	"fextra/pkg/logger"
-	// 文档转换工具
+	"fextra/pkg/office/pdf"
+	"fextra/pkg/office/soffice"
 )
 
 type OfficeVsdParser struct{}
@@ -18,7 +19,10 @@ func (p *OfficeVsdParser) Parse(filePath string) ([]byte, error) {
 		return []byte(content), nil
 	}
 
-	logger.Logger.Printf("标准库解析VSD文件失败: %v", err)
+	// 未配置LibreOffice是预期情况，直接使用二进制解析
+	if !errors.Is(err, soffice.ErrDisabled) {
+		logger.Logger.Printf("标准库解析VSD文件失败: %v", err)
+	}
 
 	content, err = BinaryExtractText(filePath)
 	if err == nil && content != "" {
@@ -31,22 +35,24 @@ func (p *OfficeVsdParser) Parse(filePath string) ([]byte, error) {
 }
 
 // StdLibExtractText 从VSD文件中提取文本内容
+// VSD为二进制格式，配置了LibreOffice（soffice.SetBinary）时先转换为PDF再提取文本，未配置时返回soffice.ErrDisabled
 func StdLibExtractText(filePath string) (string, error) {
-	// 使用docconv库解析VSD文件
-	// 内部会调用libreoffice或catdoc等外部工具
-	/*
-		text, meta, err := docconv.Convert(filePath)
-		if err != nil {
-			logger.Logger.Printf("VSD文件解析失败: %v", err)
-			// 尝试备选方案: 直接读取二进制内容中的文本片段
-			return BinaryExtractText(filePath), err
-		}
+	if !soffice.Enabled() {
+		return "", soffice.ErrDisabled
+	}
+
+	pdfPath, cleanup, err := soffice.Convert(filePath, "pdf")
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
 
-		// 合并元数据和正文内容
-		fullText := fmt.Sprintf("Title: %s\nSubject: %s\nCreator: %s\n\n%s",
-			meta.Title, meta.Subject, meta.Creator, text)
-	*/
-	return "", nil
+	pdfParser := &pdf.OfficePdfParser{}
+	content, err := pdfParser.Parse(pdfPath)
+	if err != nil {
+		return "", fmt.Errorf("解析转换后的PDF失败: %w", err)
+	}
+	return string(content), nil
 }
 
 // BinaryExtractText 二进制文件文本提取备选方案
@@ -0,0 +1,58 @@
+package xls
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"fextra/internal/cfbtest"
+	"fextra/pkg/office/doc"
+
+	"github.com/extrame/ole2"
+)
+
+// extrame/xls通过ole2读取Workbook流，ole2只支持512字节的扇区
+func TestWorkbookStreamSectorMath(t *testing.T) {
+	workbook := make([]byte, 9000)
+	for i := range workbook {
+		workbook[i] = byte(i * 7)
+	}
+	summary := bytes.Repeat([]byte{0x5A}, 200) // 位于迷你流
+	streams := []cfbtest.Stream{
+		{Name: "Workbook", Data: workbook},
+		{Name: "\x05SummaryInformation", Data: summary},
+	}
+
+	for _, opts := range []cfbtest.Options{{SectorSize: 512}, {SectorSize: 512, Fragment: true}} {
+		data := cfbtest.Build(streams, opts)
+		if err := doc.CheckHeader(bytes.NewReader(data)); err != nil {
+			t.Fatalf("%+v: header: %v", opts, err)
+		}
+
+		ole, err := ole2.Open(bytes.NewReader(data), "utf-8")
+		if err != nil {
+			t.Fatalf("%+v: ole2.Open: %v", opts, err)
+		}
+		dir, err := ole.ListDir()
+		if err != nil {
+			t.Fatalf("%+v: ListDir: %v", opts, err)
+		}
+		got := make(map[string][]byte)
+		for _, file := range dir {
+			if file.Type != ole2.USERSTREAM {
+				continue
+			}
+			content, err := io.ReadAll(ole.OpenFile(file, dir[0]))
+			if err != nil {
+				t.Fatalf("%+v: read %s: %v", opts, file.Name(), err)
+			}
+			got[file.Name()] = content
+		}
+		// ole2按整个扇区读取，流之后可能带有扇区剩余部分的填充
+		for _, s := range streams {
+			if !bytes.HasPrefix(got[s.Name], s.Data) {
+				t.Errorf("%+v: %q differs from the stream written (%d bytes read)", opts, s.Name, len(got[s.Name]))
+			}
+		}
+	}
+}
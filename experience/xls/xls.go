@@ -3,14 +3,24 @@ package xls
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"iter"
+	"os"
+
+	"fextra/internal"
+	"fextra/pkg/logger"
+	"fextra/pkg/office/doc"
+	"fextra/pkg/office/spreadsheet"
 
 	exls "github.com/extrame/xls"
 )
 
-type OfficeXlsParser struct{}
+type OfficeXlsParser struct {
+	Limit int // 最多提取的非空行数（所有工作表合计），用于生成预览，为0时不限制
+}
 
 func (p *OfficeXlsParser) Parse(filePath string) ([]byte, error) {
-	content, err := ExtractTextFromXLS(filePath)
+	content, err := extractTextFromXLS(filePath, p.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -18,51 +28,173 @@ func (p *OfficeXlsParser) Parse(filePath string) ([]byte, error) {
 	return []byte(content), nil
 }
 
+// ParsePreview 仅提取XLS前limit个非空行的文本
+func (p *OfficeXlsParser) ParsePreview(filePath string, limit int) ([]byte, error) {
+	preview := *p
+	preview.Limit = limit
+	return preview.Parse(filePath)
+}
+
 func ExtractTextFromXLS(filePath string) ([]byte, error) {
-	// 打开文件并指定编码
-	file, err := exls.Open(filePath, "utf-8")
+	return extractTextFromXLS(filePath, 0)
+}
+
+// extractTextFromXLS 提取XLS文本，limit>0时输出limit个非空行后停止
+func extractTextFromXLS(filePath string, limit int) ([]byte, error) {
+	book, err := openWorkbook(filePath)
 	if err != nil {
-		return []byte{}, fmt.Errorf("文件打开失败: %v", err)
+		return []byte{}, err
 	}
+	defer book.Close()
 
 	var content bytes.Buffer
+	if _, err := spreadsheet.Render(&content, book, limit); err != nil {
+		return []byte{}, err
+	}
+	return content.Bytes(), nil
+}
+
+// ParseTo 逐个工作表将XLS文本写入w
+func (p *OfficeXlsParser) ParseTo(filePath string, w io.Writer) error {
+	book, err := openWorkbook(filePath)
+	if err != nil {
+		return err
+	}
+	defer book.Close()
+
+	_, err = spreadsheet.Render(w, book, p.Limit)
+	return err
+}
 
-	// 遍历所有工作表
-	for sheetIndex := 0; sheetIndex < file.NumSheets(); sheetIndex++ {
-		sheet := file.GetSheet(sheetIndex)
+// OpenSpreadsheet 打开XLS文件，按工作表、行读取单元格文本
+func (p *OfficeXlsParser) OpenSpreadsheet(filePath string) (spreadsheet.Workbook, error) {
+	book, err := openWorkbook(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+// openWorkbook 打开XLS工作簿，没有工作表时返回ErrNoContent
+func openWorkbook(filePath string) (*workbook, error) {
+	// 先校验OLE文件头，避免损坏的扇区大小字段导致第三方库异常分配
+	if err := checkHeader(filePath); err != nil {
+		return nil, err
+	}
+
+	// 第三方库按需读取工作表，文件需保持打开直至Close
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("文件打开失败: %w", err)
+	}
+	wb, err := exls.OpenReader(file, "utf-8")
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("文件打开失败: %w", err)
+	}
+	if wb.NumSheets() == 0 {
+		file.Close()
+		return nil, fmt.Errorf("XLS中没有工作表: %w", internal.ErrNoContent)
+	}
+	return &workbook{file: file, wb: wb, decode: stringDecoder(wb)}, nil
+}
+
+// workbook XLS工作簿
+type workbook struct {
+	file   *os.File
+	wb     *exls.WorkBook
+	decode func(string) string
+}
+
+func (b *workbook) Sheets() []spreadsheet.Sheet {
+	var sheets []spreadsheet.Sheet
+	for sheetIndex := 0; sheetIndex < b.wb.NumSheets(); sheetIndex++ {
+		sheet := b.wb.GetSheet(sheetIndex)
 		if sheet == nil {
 			continue // 跳过空工作表
 		}
+		sheets = append(sheets, &worksheet{sheet: sheet, decode: b.decode})
+	}
+	return sheets
+}
+
+func (b *workbook) Close() error {
+	return b.file.Close()
+}
 
-		// 添加工作表标题
-		content.WriteString(fmt.Sprintf("\n--- 工作表 %d: %s ---\n", sheetIndex+1, sheet.Name))
+// worksheet XLS工作表
+type worksheet struct {
+	sheet  *exls.WorkSheet
+	decode func(string) string
+}
+
+func (s *worksheet) Name() string {
+	return s.decode(s.sheet.Name)
+}
 
+func (s *worksheet) Rows() iter.Seq[[]string] {
+	return func(yield func([]string) bool) {
+		var cells []string
 		// 遍历行 (MaxRow+1 兼容空行)
-		for rowIndex := 0; rowIndex <= int(sheet.MaxRow); rowIndex++ {
-			row := sheet.Row(rowIndex)
+		for rowIndex := 0; rowIndex <= int(s.sheet.MaxRow); rowIndex++ {
+			row := sheetRow(s.sheet, rowIndex)
 			if row == nil {
 				continue // 跳过空行
 			}
 
-			// 构建当前行文本
-			var rowText bytes.Buffer
+			cells = cells[:0]
 			for colIndex := 0; colIndex < row.LastCol(); colIndex++ {
-				cell := row.Col(colIndex)
-				if cell != "" { // 跳过空单元格
-					rowText.WriteString(cell)
-					if colIndex < row.LastCol()-1 {
-						rowText.WriteString("\t") // 单元格分隔符
-					}
-				}
+				cells = append(cells, s.decode(row.Col(colIndex)))
 			}
-
-			// 添加非空行内容
-			if rowText.Len() > 0 {
-				content.Write(rowText.Bytes())
-				content.WriteString("\n")
+			if !yield(cells) {
+				return
 			}
 		}
 	}
+}
 
-	return content.Bytes(), nil
+// sheetRow 返回工作表的第i行，没有该行时返回nil（第三方库对不存在的行会发生空指针异常）
+func sheetRow(sheet *exls.WorkSheet, i int) (row *exls.Row) {
+	defer func() {
+		if recover() != nil {
+			row = nil
+		}
+	}()
+	return sheet.Row(i)
+}
+
+// stringDecoder 返回工作簿字符串的解码函数
+// BIFF8的字符串为UTF-16LE（或压缩的Latin-1），第三方库已解码为UTF-8；
+// BIFF5（Excel 5.0/95）的字符串为CODEPAGE记录声明的单/双字节编码，第三方库按原始字节返回，需在此转换为UTF-8
+func stringDecoder(file *exls.WorkBook) func(string) string {
+	if !file.Is5ver {
+		return func(s string) string { return s }
+	}
+
+	codePage := internal.CodePageEncoding(file.Codepage)
+	if codePage == nil {
+		logger.Logger.Printf("BIFF5工作簿使用不支持的代码页%d，按原始字节输出", file.Codepage)
+		return internal.ReplaceInvalid
+	}
+	logger.Logger.Printf("BIFF5工作簿，代码页: %d", file.Codepage)
+
+	decoder := codePage.NewDecoder()
+	return func(s string) string {
+		decoded, err := decoder.String(s)
+		if err != nil {
+			return internal.ReplaceInvalid(s)
+		}
+		return internal.ReplaceInvalid(decoded)
+	}
+}
+
+// checkHeader 校验XLS的OLE复合文档文件头
+func checkHeader(filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("文件打开失败: %w", err)
+	}
+	defer file.Close()
+
+	return doc.CheckHeader(file)
 }
@@ -2,29 +2,72 @@ package xlsb
 
 import (
 	"archive/zip"
+	"bufio"
 	"bytes"
 	"encoding/binary"
+	"encoding/xml"
+	"fextra/internal"
 	"fextra/pkg/logger"
+	"fextra/pkg/office/officeenc"
+	"fextra/pkg/office/ooxmlcrypt"
+	"fextra/pkg/office/spreadsheet"
 	"fmt"
 	"io"
+	"iter"
+	"math"
+	"path"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
-
-	"golang.org/x/text/encoding/unicode"
 )
 
+/*
+	XLSB为ZIP格式，各部件为二进制记录流（MS-XLSB）。记录头为变长编码：
+	记录类型1～2字节、记录长度1～4字节，每字节低7位有效，最高位为1表示后面还有字节。
+	xl/workbook.bin中的BrtBundleSh按顺序给出工作表名称及其关系ID，关系ID经xl/_rels/workbook.bin.rels对应到工作表部件；
+	工作表中BrtRowHdr给出之后单元格所在的行，单元格记录以8字节的Cell结构（列号4字节、样式及标志4字节）开始，之后为值
+*/
+
 // XLSB记录类型常量定义
 const (
-	BRT_CellBlank uint32 = 1  // 空白单元格
-	BRT_CellRk    uint32 = 2  // 数值型单元格
-	BRT_CellBool  uint32 = 4  // 布尔型单元格
-	BRT_CellIstr  uint32 = 6  // 内联字符串单元格
-	BRT_CellIsst  uint32 = 7  // 共享字符串单元格
-	BRT_SstItem   uint32 = 19 // 共享字符串项
-
-	BRT_CellFormula uint32 = 3 // 公式单元格
+	BRT_RowHdr     uint32 = 0   // 行头，之后的单元格属于该行
+	BRT_CellBlank  uint32 = 1   // 空白单元格
+	BRT_CellRk     uint32 = 2   // 数值型单元格（RK压缩）
+	BRT_CellError  uint32 = 3   // 错误值单元格
+	BRT_CellBool   uint32 = 4   // 布尔型单元格
+	BRT_CellReal   uint32 = 5   // 数值型单元格（8字节浮点数）
+	BRT_CellIstr   uint32 = 6   // 内联字符串单元格
+	BRT_CellIsst   uint32 = 7   // 共享字符串单元格
+	BRT_FmlaString uint32 = 8   // 公式单元格，缓存值为字符串
+	BRT_FmlaNum    uint32 = 9   // 公式单元格，缓存值为数值
+	BRT_FmlaBool   uint32 = 10  // 公式单元格，缓存值为布尔值
+	BRT_FmlaError  uint32 = 11  // 公式单元格，缓存值为错误值
+	BRT_SstItem    uint32 = 19  // 共享字符串项
+	BRT_BundleSh   uint32 = 156 // 工作表信息（workbook.bin）
 )
 
+// cellHeaderLen 单元格记录开头Cell结构的长度
+const cellHeaderLen = 8
+
+// maxColumns 工作表的最大列数
+const maxColumns = 16384
+
+// errorValues 错误值代码对应的文本
+var errorValues = map[byte]string{
+	0x00: "#NULL!",
+	0x07: "#DIV/0!",
+	0x0F: "#VALUE!",
+	0x17: "#REF!",
+	0x1D: "#NAME?",
+	0x24: "#NUM!",
+	0x2A: "#N/A",
+	0x2B: "#GETTING_DATA",
+}
+
+// sheetFilePattern 工作表部件的文件名
+var sheetFilePattern = regexp.MustCompile(`^sheet(\d+)\.bin$`)
+
 // SharedStringTable 共享字符串表
 type SharedStringTable struct {
 	items []string
@@ -32,360 +75,412 @@ type SharedStringTable struct {
 
 // OfficeXlsbParser XLSB解析器
 type OfficeXlsbParser struct {
-	sharedStrings *SharedStringTable
+	Limit int // 最多提取的非空行数（所有工作表合计），用于生成预览，为0时不限制
+}
+
+// ParsePreview 仅提取XLSB前limit行的文本
+func (p *OfficeXlsbParser) ParsePreview(filePath string, limit int) ([]byte, error) {
+	preview := *p
+	preview.Limit = limit
+	return preview.Parse(filePath)
 }
 
 // Parse 解析XLSB文件并提取文本内容
 func (p *OfficeXlsbParser) Parse(filePath string) ([]byte, error) {
-	// 初始化共享字符串表
-	p.sharedStrings = &SharedStringTable{items: make([]string, 0)}
-
-	// 打开ZIP格式的XLSB文件
-	zipReader, err := zip.OpenReader(filePath)
+	book, err := p.OpenSpreadsheet(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("打开XLSB文件失败: %w", err)
+		return nil, err
 	}
-	defer zipReader.Close()
+	defer book.Close()
 
 	var textBuilder bytes.Buffer
+	if _, err := spreadsheet.Render(&textBuilder, book, p.Limit); err != nil {
+		return nil, err
+	}
+	return textBuilder.Bytes(), nil
+}
 
-	// 1. 解析共享字符串表
-	if err := p.parseSharedStrings(zipReader); err != nil {
-		logger.Logger.Printf("解析共享字符串表失败: %v", err)
-		// 非致命错误，继续解析工作表
+// OpenSpreadsheet 打开XLSB文件，按工作表、行读取单元格文本，没有工作表时返回ErrNoContent
+func (p *OfficeXlsbParser) OpenSpreadsheet(filePath string) (spreadsheet.Workbook, error) {
+	zipReader, err := ooxmlcrypt.OpenReader(filePath, "XLSB")
+	if err != nil {
+		return nil, err
 	}
 
-	// 2. 解析所有工作表
+	book := &workbook{zip: zipReader, sharedStrings: &SharedStringTable{}}
+	files := make(map[string]*zip.File, len(zipReader.File))
 	for _, file := range zipReader.File {
-		if strings.HasPrefix(file.Name, "xl/worksheets/") && strings.HasSuffix(file.Name, ".bin") {
-			if err := p.parseWorksheet(file, &textBuilder); err != nil {
-				logger.Logger.Printf("解析工作表 %s 失败: %v", file.Name, err)
-			}
+		files[file.Name] = file
+	}
+
+	// 解析共享字符串表，失败时不影响其他单元格
+	if file := files["xl/sharedStrings.bin"]; file != nil {
+		if err := book.sharedStrings.read(file); err != nil {
+			logger.Logger.Printf("解析共享字符串表失败: %v", err)
 		}
 	}
 
-	return textBuilder.Bytes(), nil
+	book.sheets, err = readSheetList(files)
+	if err != nil {
+		logger.Logger.Printf("读取工作表列表失败，按文件名排序: %v", err)
+		book.sheets = listSheetFiles(zipReader.File)
+	}
+	if len(book.sheets) == 0 {
+		zipReader.Close()
+		return nil, fmt.Errorf("XLSB中没有工作表: %w", internal.ErrNoContent)
+	}
+	for _, sheet := range book.sheets {
+		sheet.sharedStrings = book.sharedStrings
+	}
+	return book, nil
 }
 
-// parseSharedStrings 解析共享字符串表
-func (p *OfficeXlsbParser) parseSharedStrings(zipReader *zip.ReadCloser) error {
-	for _, file := range zipReader.File {
-		logger.Logger.Printf("工作表名: %s", file.Name)
-		if file.Name == "xl/sharedStrings.bin" {
-			f, err := file.Open()
-			if err != nil {
-				return err
-			}
-			defer f.Close()
-
-			// 解析共享字符串二进制内容
-			return p.parseSstBinary(f)
-		}
-	}
-	return nil // 没有共享字符串表
+// workbook XLSB工作簿
+type workbook struct {
+	zip           *ooxmlcrypt.ReadCloser
+	sharedStrings *SharedStringTable
+	sheets        []*worksheet
 }
 
-// parseWorksheet 解析单个工作表
-func (p *OfficeXlsbParser) parseWorksheet(file *zip.File, textBuilder *bytes.Buffer) error {
-	f, err := file.Open()
-	if err != nil {
-		return err
+func (b *workbook) Sheets() []spreadsheet.Sheet {
+	sheets := make([]spreadsheet.Sheet, len(b.sheets))
+	for i, sheet := range b.sheets {
+		sheets[i] = sheet
 	}
-	defer f.Close()
+	return sheets
+}
 
-	// 写入工作表名称
-	sheetName := strings.TrimSuffix(strings.TrimPrefix(file.Name, "xl/worksheets/"), ".bin")
-	textBuilder.WriteString("工作表: " + sheetName + "\n")
+func (b *workbook) Close() error {
+	return b.zip.Close()
+}
 
-	// 解析工作表二进制内容
-	return p.parseWorksheetBinary(f, textBuilder)
+// worksheet XLSB工作表
+type worksheet struct {
+	name          string
+	file          *zip.File
+	sharedStrings *SharedStringTable
 }
 
-// parseSstBinary 解析共享字符串二进制数据
-func (p *OfficeXlsbParser) parseSstBinary(reader io.Reader) error {
-	// XLSB记录头: 4字节类型 + 4字节大小
-	var recordHeader [8]byte
+func (s *worksheet) Name() string {
+	return s.name
+}
 
-	for {
-		// 读取记录头
-		n, err := reader.Read(recordHeader[:])
+// Rows 按BrtRowHdr分行，行内单元格按列号放置
+func (s *worksheet) Rows() iter.Seq[[]string] {
+	return func(yield func([]string) bool) {
+		f, err := s.file.Open()
 		if err != nil {
+			logger.Logger.Printf("打开工作表 %s 失败: %v", s.file.Name, err)
+			return
+		}
+		defer f.Close()
+
+		reader := bufio.NewReader(f)
+		var cells []string
+		for {
+			recordType, data, err := readRecord(reader)
 			if err == io.EOF {
 				break
 			}
-			return fmt.Errorf("读取SST记录头失败: %w", err)
-		}
-		if n < 8 {
-			return fmt.Errorf("SST记录头不完整")
-		}
-
-		// 解析记录类型和大小
-		recordType := binary.LittleEndian.Uint32(recordHeader[:4])
-		recordSize := binary.LittleEndian.Uint32(recordHeader[4:8])
-
-		// 读取记录数据
-		recordData := make([]byte, recordSize)
-		if _, err := io.ReadFull(reader, recordData); err != nil {
-			return fmt.Errorf("读取SST记录数据失败: %w", err)
-		}
-
-		// 处理共享字符串项记录
-		if recordType == BRT_SstItem {
-			str, err := parseXLUnicodeString(recordData)
 			if err != nil {
-				logger.Logger.Printf("解析共享字符串失败: %v", err)
-				continue
-			}
-			p.sharedStrings.items = append(p.sharedStrings.items, str)
-		}
-	}
-
-	logger.Logger.Printf("已解析共享字符串: %d 项", len(p.sharedStrings.items))
-	return nil
-}
-
-// parseWorksheetBinary 解析工作表二进制数据
-func (p *OfficeXlsbParser) parseWorksheetBinary(reader io.Reader, textBuilder *bytes.Buffer) error {
-	// XLSB记录头: 4字节类型 + 4字节大小
-	var recordHeader [8]byte
-	var currentRow uint32
-	var currentRowCells []string
-
-	for {
-		// 读取记录头
-		n, err := reader.Read(recordHeader[:])
-		if err != nil {
-			if err == io.EOF {
+				logger.Logger.Printf("解析工作表 %s 失败: %v", s.file.Name, err)
 				break
 			}
-			return fmt.Errorf("读取工作表记录头失败: %w", err)
-		}
-		if n < 8 {
-			return fmt.Errorf("工作表记录头不完整")
-		}
 
-		// 解析记录类型和大小
-		recordType := binary.LittleEndian.Uint32(recordHeader[:4])
-		recordSize := binary.LittleEndian.Uint32(recordHeader[4:8])
-
-		// 读取记录数据
-		recordData := make([]byte, recordSize)
-		if _, err := io.ReadFull(reader, recordData); err != nil {
-			return fmt.Errorf("读取工作表记录数据失败: %w", err)
-		}
-
-		logger.Logger.Printf("记录类型: %d, 记录大小: %d", recordType, recordSize)
-		// 处理不同类型的记录
-		switch recordType {
-		case BRT_CellRk:
-			if err := p.handleCellRk(recordData, &currentRow, &currentRowCells, textBuilder); err != nil {
-				logger.Logger.Printf("处理RK单元格失败: %v", err)
+			if recordType == BRT_RowHdr {
+				if len(cells) > 0 && !yield(cells) {
+					return
+				}
+				cells = cells[:0]
 				continue
 			}
-		case BRT_CellBool:
-			if err := p.handleCellBool(recordData, &currentRow, &currentRowCells, textBuilder); err != nil {
-				logger.Logger.Printf("处理布尔单元格失败: %v", err)
+			value, ok := s.cellValue(recordType, data)
+			if !ok || len(data) < cellHeaderLen {
 				continue
 			}
-
-		case BRT_CellIstr:
-			if err := p.handleCellIstr(recordData, &currentRow, &currentRowCells, textBuilder); err != nil {
-				logger.Logger.Printf("处理内联字符串单元格失败: %v", err)
+			col := int(binary.LittleEndian.Uint32(data))
+			if col < 0 || col >= maxColumns {
+				logger.Logger.Printf("单元格列号越界: %d", col)
 				continue
 			}
-		case BRT_CellFormula:
-			/*
-				// 公式单元格(简化处理)
-				if len(recordData) < 16 {
-					logger.Logger.Printf("公式单元格数据不完整")
-					continue
-				}
-				row := binary.LittleEndian.Uint32(recordData[0:4])
-				col := binary.LittleEndian.Uint32(recordData[4:8])
-				// 提取公式缓存值
-				// 公式记录结构: 行(4) + 列(4) + 选项(4) + 公式长度(4) + 公式数据 + 缓存值
-				if len(recordData) > 20 {
-					// 简单判断是否包含缓存值(实际需根据选项判断)
-					cacheType := recordData[16]
-					switch cacheType {
-					case 0x00:
-						// 无缓存值
-						value = "[公式]"
-					case 0x01:
-						// 数值缓存
-						cacheValue := binary.LittleEndian.Float64(recordData[20:28])
-						value = strconv.FormatFloat(cacheValue, 'f', -1, 64)
-					case 0x02:
-						// 字符串缓存
-						strData := recordData[20:]
-						value, _ = parseXLUnicodeString(strData)
-					default:
-						value = "[公式]"
-					}
-				} else {
-					value = "[公式]"
-				}
-				// 处理行切换
-				if row != currentRow && len(currentRowCells) > 0 {
-					textBuilder.WriteString(strings.Join(currentRowCells, "\t") + "\n")
-					currentRowCells = make([]string, 0)
-				}
-				currentRow = row
-				// 处理列索引
-				if int(col) >= len(currentRowCells) {
-					need := int(col) - len(currentRowCells) + 1
-					currentRowCells = append(currentRowCells, make([]string, need)...)
-				}
-				currentRowCells[col] = value
-			*/
-		case BRT_CellIsst:
-			// 共享字符串单元格
-			if len(recordData) < 12 {
-				logger.Logger.Printf("单元格记录数据不完整")
-				continue
+			for len(cells) <= col {
+				cells = append(cells, "")
 			}
+			cells[col] = value
+		}
+		if len(cells) > 0 {
+			yield(cells)
+		}
+	}
+}
 
-			// 解析行号和列号 (前8字节)
-			row := binary.LittleEndian.Uint32(recordData[0:4])
-			col := binary.LittleEndian.Uint32(recordData[4:8])
-			isst := binary.LittleEndian.Uint32(recordData[8:12])
-
-			// 切换行时输出之前的行数据
-			if row != currentRow && len(currentRowCells) > 0 {
-				textBuilder.WriteString(strings.Join(currentRowCells, "\t") + "\n")
-				currentRowCells = make([]string, 0)
-			}
-			currentRow = row
-
-			var value string
-			// 获取共享字符串
-			// 通过isst索引获取共享字符串
-			if int(isst) < len(p.sharedStrings.items) {
-				value = p.sharedStrings.items[isst]
-			} else {
-				logger.Logger.Printf("共享字符串索引越界: %d", isst)
-				value = ""
-			}
-			// 将单元格值添加到行数据
-			currentRowCells = append(currentRowCells, value)
+// cellValue 返回单元格记录的值，非单元格记录或数据不完整时ok为false
+func (s *worksheet) cellValue(recordType uint32, data []byte) (string, bool) {
+	if len(data) < cellHeaderLen {
+		return "", false
+	}
+	value := data[cellHeaderLen:]
+	switch recordType {
+	case BRT_CellRk:
+		if len(value) < 4 {
+			return "", false
+		}
+		return formatNumber(decodeRk(binary.LittleEndian.Uint32(value))), true
+	case BRT_CellReal, BRT_FmlaNum:
+		if len(value) < 8 {
+			return "", false
+		}
+		return formatNumber(math.Float64frombits(binary.LittleEndian.Uint64(value))), true
+	case BRT_CellBool, BRT_FmlaBool:
+		if len(value) < 1 {
+			return "", false
+		}
+		return strconv.FormatBool(value[0] != 0), true
+	case BRT_CellError, BRT_FmlaError:
+		if len(value) < 1 {
+			return "", false
+		}
+		return errorValues[value[0]], true
+	case BRT_CellIstr, BRT_FmlaString:
+		str, _, err := readWideString(value)
+		if err != nil {
+			logger.Logger.Printf("解析内联字符串失败: %v", err)
+			return "", false
+		}
+		return str, true
+	case BRT_CellIsst:
+		if len(value) < 4 {
+			return "", false
+		}
+		isst := binary.LittleEndian.Uint32(value)
+		if int(isst) >= len(s.sharedStrings.items) {
+			logger.Logger.Printf("共享字符串索引越界: %d", isst)
+			return "", true
+		}
+		return s.sharedStrings.items[isst], true
+	}
+	return "", false
+}
 
-			if len(currentRowCells) > 0 {
-				textBuilder.WriteString(strings.Join(currentRowCells, "\t") + "\n")
-			}
-			for int(col) >= len(currentRowCells) {
-				currentRowCells = append(currentRowCells, "")
-			}
-			currentRowCells[col] = value
+// read 解析共享字符串表中的BrtSSTItem记录：1字节标志（富文本、扩展信息）之后为字符串
+func (sst *SharedStringTable) read(file *zip.File) error {
+	f, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
 
-		case BRT_CellBlank:
-			// 空白单元格，暂不处理
+	reader := bufio.NewReader(f)
+	for {
+		recordType, data, err := readRecord(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("读取SST记录失败: %w", err)
+		}
+		if recordType != BRT_SstItem {
 			continue
 		}
+		var str string
+		if len(data) > 0 {
+			if str, _, err = readWideString(data[1:]); err != nil {
+				logger.Logger.Printf("解析共享字符串失败: %v", err)
+			}
+		}
+		// 解析失败时保留空项，避免之后的序号错位
+		sst.items = append(sst.items, str)
 	}
 
-	// 输出最后一行数据
-	if len(currentRowCells) > 0 {
-		textBuilder.WriteString(strings.Join(currentRowCells, "\t") + "\n")
-	}
-
+	logger.Logger.Printf("已解析共享字符串: %d 项", len(sst.items))
 	return nil
 }
 
-// parseXLUnicodeString 解析XLUnicodeString结构
-func parseXLUnicodeString(data []byte) (string, error) {
-	if len(data) < 3 {
-		return "", fmt.Errorf("字符串数据不完整")
+// readRecord 读取一条记录，返回记录类型及内容
+func readRecord(r *bufio.Reader) (uint32, []byte, error) {
+	recordType, err := readVarint(r, 2)
+	if err != nil {
+		return 0, nil, err
+	}
+	size, err := readVarint(r, 4)
+	if err != nil {
+		return 0, nil, unexpectedEOF(err)
+	}
+	// 记录长度来自文件，按实际读取的数据分配，避免损坏的长度导致过大的分配
+	data, err := io.ReadAll(io.LimitReader(r, int64(size)))
+	if err != nil {
+		return 0, nil, fmt.Errorf("记录 %d 数据: %w", recordType, err)
 	}
+	if len(data) < int(size) {
+		return 0, nil, fmt.Errorf("记录 %d 数据: %w", recordType, internal.ErrTruncated)
+	}
+	return recordType, data, nil
+}
 
-	// 解析字符串长度和编码标志
-	cch := binary.LittleEndian.Uint16(data[0:2])
-	highByte := (data[2] >> 7) & 0x01
-	rgbStart := 3
+// readVarint 读取最多maxBytes字节的变长整数，每字节低7位有效
+func readVarint(r *bufio.Reader, maxBytes int) (uint32, error) {
+	var value uint32
+	for i := 0; i < maxBytes; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			if i > 0 {
+				return 0, unexpectedEOF(err)
+			}
+			return 0, err
+		}
+		value |= uint32(b&0x7F) << (7 * i)
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	return value, nil
+}
 
-	// 计算字符串字节长度
-	var strLen int
-	if highByte == 1 {
-		strLen = int(cch) * 2
-	} else {
-		strLen = int(cch)
+func unexpectedEOF(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return internal.ErrTruncated
 	}
+	return err
+}
 
-	// 验证缓冲区长度
-	if rgbStart+strLen > len(data) {
-		return "", fmt.Errorf("字符串长度不足, 需要%d字节, 实际%d字节", rgbStart+strLen, len(data))
+// readWideString 读取XLWideString：4字节字符数及UTF-16LE字符，返回字符串及其后的数据
+func readWideString(data []byte) (string, []byte, error) {
+	if len(data) < 4 {
+		return "", nil, fmt.Errorf("字符串长度: %w", internal.ErrTruncated)
+	}
+	cch := binary.LittleEndian.Uint32(data)
+	data = data[4:]
+	if uint64(cch)*2 > uint64(len(data)) {
+		return "", nil, fmt.Errorf("字符串长度不足, 需要%d字节, 实际%d字节: %w", uint64(cch)*2, len(data), internal.ErrTruncated)
 	}
+	return officeenc.DecodeUTF16(data[:cch*2], binary.LittleEndian), data[cch*2:], nil
+}
 
-	// 提取字符串内容
-	if highByte == 1 {
-		// 双字节Unicode (UTF-16LE)
-		utf16Bytes := data[rgbStart : rgbStart+strLen]
-		decoder := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder()
-		return decoder.String(string(utf16Bytes))
+// decodeRk 解码RkNumber：最低位表示值需除以100，次低位表示高30位为整数，否则为64位浮点数的高30位
+func decodeRk(rk uint32) float64 {
+	var value float64
+	if rk&0x02 != 0 {
+		value = float64(int32(rk) >> 2)
 	} else {
-		// 单字节字符
-		return string(data[rgbStart : rgbStart+strLen]), nil
+		value = math.Float64frombits(uint64(rk&0xFFFFFFFC) << 32)
 	}
+	if rk&0x01 != 0 {
+		value /= 100
+	}
+	return value
 }
 
-// 数值型单元格处理函数
-func (p *OfficeXlsbParser) handleCellRk(recordData []byte, currentRow *uint32, currentRowCells *[]string, textBuilder *bytes.Buffer) error {
-	if len(recordData) < 12 {
-		logger.Logger.Printf("RK单元格记录数据不完整")
-		return nil
-	}
-	row := binary.LittleEndian.Uint32(recordData[0:4])
-	rkValue := binary.LittleEndian.Uint32(recordData[8:12])
-	value := strconv.FormatFloat(float64(rkValue)/65536.0, 'f', -1, 64)
-	if row != *currentRow && len(*currentRowCells) > 0 {
-		textBuilder.WriteString(strings.Join(*currentRowCells, "\t") + "\n")
-		*currentRowCells = make([]string, 0)
-	}
-	*currentRow = row
-	*currentRowCells = append(*currentRowCells, value)
-	return nil
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
 }
 
-// 布尔型单元格处理函数
-func (p *OfficeXlsbParser) handleCellBool(recordData []byte, currentRow *uint32, currentRowCells *[]string, textBuilder *bytes.Buffer) error {
-	if len(recordData) < 9 {
-		logger.Logger.Printf("布尔单元格记录数据不完整")
-		return nil
-	}
-	row := binary.LittleEndian.Uint32(recordData[0:4])
-	boolValue := recordData[8] != 0
-	value := strconv.FormatBool(boolValue)
-	if row != *currentRow && len(*currentRowCells) > 0 {
-		textBuilder.WriteString(strings.Join(*currentRowCells, "\t") + "\n")
-		*currentRowCells = make([]string, 0)
-	}
-	*currentRow = row
-	*currentRowCells = append(*currentRowCells, value)
-	return nil
+// relationships 关系部件
+type relationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
 }
 
-// 内联字符串单元格处理函数
-func (p *OfficeXlsbParser) handleCellIstr(recordData []byte, currentRow *uint32, currentRowCells *[]string, textBuilder *bytes.Buffer) error {
-	if len(recordData) < 9 {
-		logger.Logger.Printf("内联字符串单元格数据不完整")
-		return nil
+// readSheetList 按workbook.bin中BrtBundleSh的顺序返回工作表，名称为工作表标签名
+func readSheetList(files map[string]*zip.File) ([]*worksheet, error) {
+	workbookFile, relsFile := files["xl/workbook.bin"], files["xl/_rels/workbook.bin.rels"]
+	if workbookFile == nil || relsFile == nil {
+		return nil, fmt.Errorf("缺少workbook.bin或其关系部件: %w", internal.ErrStreamNotFound)
 	}
-	row := binary.LittleEndian.Uint32(recordData[0:4])
-	col := binary.LittleEndian.Uint32(recordData[4:8])
-	strData := recordData[8:]
-	value, err := parseXLUnicodeString(strData)
+
+	relsData, err := readZipFile(relsFile)
 	if err != nil {
-		logger.Logger.Printf("解析内联字符串失败: %v", err)
-		value = ""
+		return nil, err
 	}
-	if row != *currentRow && len(*currentRowCells) > 0 {
-		textBuilder.WriteString(strings.Join(*currentRowCells, "\t") + "\n")
-		*currentRowCells = make([]string, 0)
+	var rels relationships
+	if err := xml.Unmarshal(relsData, &rels); err != nil {
+		return nil, fmt.Errorf("解析workbook.bin.rels失败: %w", err)
 	}
-	*currentRow = row
-	if int(col) >= len(*currentRowCells) {
-		need := int(col) - len(*currentRowCells) + 1
-		*currentRowCells = append(*currentRowCells, make([]string, need)...)
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		target := rel.Target
+		if path.IsAbs(target) {
+			target = target[1:]
+		} else {
+			target = path.Join("xl", target)
+		}
+		targets[rel.ID] = target
 	}
-	(*currentRowCells)[col] = value
-	return nil
+
+	f, err := workbookFile.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var sheets []*worksheet
+	reader := bufio.NewReader(f)
+	for {
+		recordType, data, err := readRecord(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return sheets, err
+		}
+		if recordType != BRT_BundleSh {
+			continue
+		}
+		// hsState(4) iTabID(4) strRelID strName
+		if len(data) < 8 {
+			return sheets, fmt.Errorf("BrtBundleSh: %w", internal.ErrTruncated)
+		}
+		var relID string
+		rest := data[8:]
+		if len(rest) >= 4 && binary.LittleEndian.Uint32(rest) == math.MaxUint32 {
+			rest = rest[4:] // 关系ID为空
+		} else if relID, rest, err = readWideString(rest); err != nil {
+			return sheets, err
+		}
+		name, _, err := readWideString(rest)
+		if err != nil {
+			return sheets, err
+		}
+		file := files[targets[relID]]
+		if file == nil {
+			// 宏表、对话框表等没有对应的工作表部件
+			logger.Logger.Printf("工作表 %s 的部件不存在: %s", name, targets[relID])
+			continue
+		}
+		sheets = append(sheets, &worksheet{name: name, file: file})
+	}
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("workbook.bin中没有工作表: %w", internal.ErrStreamNotFound)
+	}
+	return sheets, nil
+}
+
+// listSheetFiles 按编号返回xl/worksheets/sheetN.bin，名称为去除扩展名的文件名
+func listSheetFiles(zipFiles []*zip.File) []*worksheet {
+	var sheets []*worksheet
+	numbers := make(map[*worksheet]int)
+	for _, file := range zipFiles {
+		if path.Dir(file.Name) != "xl/worksheets" {
+			continue
+		}
+		matches := sheetFilePattern.FindStringSubmatch(path.Base(file.Name))
+		if matches == nil {
+			continue
+		}
+		sheet := &worksheet{name: strings.TrimSuffix(path.Base(file.Name), ".bin"), file: file}
+		numbers[sheet], _ = strconv.Atoi(matches[1])
+		sheets = append(sheets, sheet)
+	}
+	sort.Slice(sheets, func(i, j int) bool { return numbers[sheets[i]] < numbers[sheets[j]] })
+	return sheets
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
 }
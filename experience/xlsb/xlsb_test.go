@@ -0,0 +1,137 @@
+package xlsb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"fextra/internal/ziptest"
+)
+
+// record 按MS-XLSB的变长记录头编码一条记录
+func record(recordType uint32, data []byte) []byte {
+	out := appendVarint(nil, recordType)
+	out = appendVarint(out, uint32(len(data)))
+	return append(out, data...)
+}
+
+func appendVarint(out []byte, v uint32) []byte {
+	for v >= 0x80 {
+		out = append(out, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(out, byte(v))
+}
+
+func wideString(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	out := binary.LittleEndian.AppendUint32(nil, uint32(len(units)))
+	for _, u := range units {
+		out = binary.LittleEndian.AppendUint16(out, u)
+	}
+	return out
+}
+
+// cell 列号col的单元格记录，value为Cell结构之后的值
+func cell(recordType uint32, col uint32, value []byte) []byte {
+	data := binary.LittleEndian.AppendUint32(nil, col)
+	data = append(data, 0, 0, 0, 0)
+	return record(recordType, append(data, value...))
+}
+
+func TestReadRecordMultiByteHeader(t *testing.T) {
+	payload := bytes.Repeat([]byte{0xAB}, 300) // 长度需要2字节
+	var stream []byte
+	stream = append(stream, record(BRT_BundleSh, payload)...) // 类型需要2字节
+	stream = append(stream, record(BRT_SstItem, []byte{1, 2})...)
+
+	r := bufio.NewReader(bytes.NewReader(stream))
+	recordType, data, err := readRecord(r)
+	if err != nil || recordType != BRT_BundleSh || !bytes.Equal(data, payload) {
+		t.Fatalf("first record: type %d, %d bytes, err %v", recordType, len(data), err)
+	}
+	recordType, data, err = readRecord(r)
+	if err != nil || recordType != BRT_SstItem || !bytes.Equal(data, []byte{1, 2}) {
+		t.Fatalf("second record: type %d, data %v, err %v", recordType, data, err)
+	}
+	if _, _, err = readRecord(r); err == nil {
+		t.Error("expected io.EOF after the last record")
+	}
+
+	truncated := record(BRT_SstItem, payload)[:100]
+	if _, _, err := readRecord(bufio.NewReader(bytes.NewReader(truncated))); err == nil {
+		t.Error("expected an error for a truncated record")
+	}
+}
+
+func TestOpenSpreadsheet(t *testing.T) {
+	var workbookBin []byte
+	for i, name := range []string{"数据", "Second"} {
+		data := make([]byte, 8)
+		data = append(data, wideString("rId"+string(rune('1'+i)))...)
+		workbookBin = append(workbookBin, record(BRT_BundleSh, append(data, wideString(name)...))...)
+	}
+	rels := `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Target="worksheets/sheet2.bin"/>` +
+		`<Relationship Id="rId2" Target="/xl/worksheets/sheet1.bin"/>` +
+		`</Relationships>`
+
+	var sst []byte
+	for _, s := range []string{"共享", strings.Repeat("x", 100)} {
+		sst = append(sst, record(BRT_SstItem, append([]byte{0}, wideString(s)...))...)
+	}
+
+	var first []byte
+	first = append(first, record(BRT_RowHdr, make([]byte, 4))...)
+	first = append(first, cell(BRT_CellIstr, 0, wideString("内联"))...)
+	first = append(first, cell(BRT_CellIsst, 2, binary.LittleEndian.AppendUint32(nil, 0))...)
+	first = append(first, record(BRT_RowHdr, make([]byte, 4))...)
+	first = append(first, cell(BRT_CellRk, 1, binary.LittleEndian.AppendUint32(nil, 42<<2|0x02))...)
+	first = append(first, cell(BRT_CellBool, 2, []byte{1})...)
+	first = append(first, cell(BRT_CellIsst, 3, binary.LittleEndian.AppendUint32(nil, 1))...)
+
+	var second []byte
+	second = append(second, record(BRT_RowHdr, make([]byte, 4))...)
+	second = append(second, cell(BRT_CellError, 0, []byte{0x07})...)
+
+	data := ziptest.Build([]ziptest.File{
+		{Name: "xl/workbook.bin", Data: string(workbookBin)},
+		{Name: "xl/_rels/workbook.bin.rels", Data: rels},
+		{Name: "xl/sharedStrings.bin", Data: string(sst)},
+		{Name: "xl/worksheets/sheet1.bin", Data: string(second)},
+		{Name: "xl/worksheets/sheet2.bin", Data: string(first)},
+	})
+	filePath := filepath.Join(t.TempDir(), "book.xlsb")
+	if err := os.WriteFile(filePath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	book, err := (&OfficeXlsbParser{}).OpenSpreadsheet(filePath)
+	if err != nil {
+		t.Fatalf("OpenSpreadsheet: %v", err)
+	}
+	defer book.Close()
+
+	want := map[string][]string{
+		"数据":     {"内联\t\t共享", "\t42\ttrue\t" + strings.Repeat("x", 100)},
+		"Second": {"#DIV/0!"},
+	}
+	sheets := book.Sheets()
+	if len(sheets) != 2 || sheets[0].Name() != "数据" || sheets[1].Name() != "Second" {
+		t.Fatalf("unexpected sheets: %d", len(sheets))
+	}
+	for _, sheet := range sheets {
+		var rows []string
+		for cells := range sheet.Rows() {
+			rows = append(rows, strings.Join(cells, "\t"))
+		}
+		if strings.Join(rows, "\n") != strings.Join(want[sheet.Name()], "\n") {
+			t.Errorf("%s: got %q, want %q", sheet.Name(), rows, want[sheet.Name()])
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fextra/internal"
+)
+
+// corpus testdata下每种已注册格式的样本，want为提取结果中应只出现一次的文本，absent为不应出现的文本；
+// 二进制样本由testdata/generate.go生成
+var corpus = []struct {
+	file     string
+	fileType int
+	want     []string
+	absent   []string
+}{
+	// 纯文本
+	{"sample.txt", internal.FileTypeTXT, []string{"纯文本样例 plain text sample", "第二行"}, nil},
+	{"sample.csv", internal.FileTypeCSV, []string{"苹果", "42", "带,逗号"}, nil},
+	{"sample.tsv", internal.FileTypeTSV, []string{"香蕉", "13"}, nil},
+	{"sample.xml", internal.FileTypeXML, []string{"XML样例", "第一条"}, nil},
+	{"sample.json", internal.FileTypeJSON, []string{"JSON样例", "beta"}, nil},
+	{"sample.md", internal.FileTypeMD, []string{"Markdown样例", "加粗", "列表项"}, nil},
+	{"sample.html", internal.FileTypeHTML, []string{"网页标题", "网页正文段落"}, []string{"script text", "color"}},
+	{"sample.mht", internal.FileTypeMHT, []string{"归档网页正文"}, []string{"iVBORw0KGgo", "=E5"}},
+	{"sample.eml", internal.FileTypeEML, []string{"邮件样例", "张三", "邮件正文"}, nil},
+	{"sample.svg", internal.FileTypeSVG, []string{"矢量图文字"}, nil},
+
+	// 文档
+	{"sample.doc", internal.FileTypeDOC, []string{"Word 97 sample, 中文文档正文"}, nil},
+	{"sample.docx", internal.FileTypeDOCX, []string{"DOCX标题", "Word文档正文"}, nil},
+	{"sample.ppt", internal.FileTypePPT, []string{"演示文稿样例", "Slide body text"}, nil},
+	{"sample.pptx", internal.FileTypePPTX, []string{"PPTX第一页", "PPTX第二页"}, nil},
+	{"sample.xls", internal.FileTypeXLS, []string{"名称\t数量\n苹果\t42\n"}, nil},
+	{"sample.xlsx", internal.FileTypeXLSX, []string{"名称\t数量\n苹果\t42\n"}, nil},
+	{"sample.xlsb", internal.FileTypeXLSB, []string{"名称\t数量\n苹果\t42\n"}, nil},
+	{"sample.ods", internal.FileTypeODS, []string{"名称\t数量\n苹果\t42\n"}, nil},
+	{"sample.odt", internal.FileTypeODT, []string{"ODT标题", "ODT正文 段落"}, nil},
+	{"sample.odp", internal.FileTypeODP, []string{"ODP第一页", "ODP第二页"}, nil},
+	{"sample.rtf", internal.FileTypeRTF, []string{"RTF sample paragraph", "Bold second paragraph"}, []string{"Times New Roman", "Sample Writer"}},
+	{"sample.pdf", internal.FileTypePDF, []string{"PDF sample page"}, nil},
+	{"sample.vsdx", internal.FileTypeVSDX, []string{"VSDX形状文字"}, nil},
+	{"sample.vsd", internal.FileTypeVSD, []string{"Visio drawing sample"}, nil},
+	{"sample.epub", internal.FileTypeEPUB, []string{"EPUB第一章", "EPUB第二章"}, nil},
+	{"sample.pages", internal.FileTypePAGES, []string{"Pages preview"}, nil},
+	{"sample.key", internal.FileTypeKEY, []string{"Keynote preview"}, nil},
+	{"sample.numbers", internal.FileTypeNUMBERS, []string{"Numbers preview"}, nil},
+	{"flatopc.xml", internal.FileTypeFLATOPC, []string{"Flat OPC样例"}, nil},
+	{"sample.msg", internal.FileTypeMSG, []string{"主题: Outlook邮件样例", "发件人: 张三 <zhangsan@example.com>",
+		"收件人: 李四 <lisi@example.com>", "抄送: 王五 <wangwu@example.com>", "MSG正文第一行\n第二行"}, nil},
+	{"sample.djvu", internal.FileTypeDJVU, []string{"DjVu文本层"}, nil},
+
+	// 图片元数据
+	{"sample.jpg", internal.FileTypeJPEG, []string{"JPEG图片描述", "JPEG comment"}, nil},
+	{"sample.png", internal.FileTypePNG, []string{"PNG title", "PNG图片说明"}, nil},
+	{"sample.tif", internal.FileTypeTIF, []string{"TIFF图片描述"}, nil},
+	{"sample.webp", internal.FileTypeWebP, []string{"WebP标题"}, nil},
+
+	// 压缩包
+	{"sample.zip", internal.FileTypeZIP, []string{"ZIP成员文本", "ZIP表格"}, nil},
+	{"sample.jar", internal.FileTypeJAR, []string{"JAR成员文本"}, nil},
+	{"sample.war", internal.FileTypeWAR, []string{"WAR应用"}, nil},
+	{"sample.tar", internal.FileTypeTAR, []string{"TAR成员文本"}, nil},
+	{"sample.txt.gz", internal.FileTypeGZ, []string{"GZ压缩文本"}, nil},
+	{"sample.tar.gz", internal.FileTypeTARGZ, []string{"TAR.GZ成员文本"}, nil},
+	{"sample.txt.bz2", internal.FileTypeBZ2, []string{"BZ2压缩文本"}, nil},
+	{"sample.tar.bz2", internal.FileTypeTARBZ2, []string{"TAR.BZ2成员文本"}, nil},
+	{"sample.txt.xz", internal.FileTypeXZ, []string{"XZ压缩文本"}, nil},
+	{"sample.tar.xz", internal.FileTypeTARXZ, []string{"TAR.XZ成员文本"}, nil},
+	{"sample.txt.zst", internal.FileTypeZST, []string{"ZST压缩文本"}, nil},
+	{"sample.txt.lz4", internal.FileTypeLZ4, []string{"LZ4压缩文本"}, nil},
+	{"sample.txt.sz", internal.FileTypeSNAPPY, []string{"Snappy压缩文本"}, nil},
+	{"sample.7z", internal.FileType7Z, []string{"7Z member text"}, nil},
+	{"sample.rar", internal.FileTypeRAR, []string{"RAR member text"}, nil},
+}
+
+// uncovered 已注册但没有样本的格式：DOC_OTHER（如WordPerfect）需要LibreOffice转换
+var uncovered = map[int]bool{
+	internal.FileTypeDOCOTHER: true,
+}
+
+// TestExtractCorpus 每个样本按文件名识别为预期的类型，提取结果中预期的文本各出现一次
+func TestExtractCorpus(t *testing.T) {
+	for _, tc := range corpus {
+		t.Run(tc.file, func(t *testing.T) {
+			path := filepath.Join("testdata", tc.file)
+			if got := internal.SniffFileType(path); got != tc.fileType {
+				t.Fatalf("file type: got %s, want %s", internal.FileType(got), internal.FileType(tc.fileType))
+			}
+			text, err := internal.Extract(path, internal.ExtractOptions{Strict: true})
+			if err != nil {
+				t.Fatalf("Extract: %v", err)
+			}
+			for _, want := range tc.want {
+				if n := strings.Count(string(text), want); n != 1 {
+					t.Errorf("%q appears %d times in %q", want, n, text)
+				}
+			}
+			for _, unwanted := range tc.absent {
+				if strings.Contains(string(text), unwanted) {
+					t.Errorf("unexpected %q in %q", unwanted, text)
+				}
+			}
+		})
+	}
+}
+
+// TestCorpusCoversParsers 每个注册了解析器的文件类型都有样本，每个样本都存在
+func TestCorpusCoversParsers(t *testing.T) {
+	covered := make(map[int]bool)
+	for _, tc := range corpus {
+		covered[tc.fileType] = true
+		if _, err := os.Stat(filepath.Join("testdata", tc.file)); err != nil {
+			t.Errorf("%s: %v", tc.file, err)
+		}
+	}
+	for fileType := 1; fileType < 1000; fileType++ {
+		if _, err := internal.GetParserStrict(fileType); err != nil || covered[fileType] || uncovered[fileType] {
+			continue
+		}
+		t.Errorf("%s: no sample in testdata", internal.FileType(fileType))
+	}
+}
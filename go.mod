@@ -3,10 +3,14 @@ module fextra
 go 1.24.5
 
 require (
+	github.com/bodgit/sevenzip v1.6.0
+	github.com/extrame/ole2 v0.0.0-20160812065207-d69429661ad7
 	github.com/extrame/xls v0.0.1
 	github.com/gen2brain/go-unarr v0.2.4
+	github.com/klauspost/compress v1.17.9
 	github.com/ledongthuc/pdf v0.0.0-20250511090121-5959a4027728
 	github.com/nwaples/rardecode v1.1.3
+	github.com/pierrec/lz4/v4 v4.1.21
 	github.com/richardlehane/mscfb v1.0.4
 	github.com/rsc/pdf v0.1.1
 	github.com/saintfish/chardet v0.0.0-20230101081208-5e3ef4b5456d
@@ -14,11 +18,18 @@ require (
 	github.com/yuin/goldmark v1.7.12
 	golang.org/x/net v0.42.0
 	golang.org/x/text v0.27.0
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 require (
-	github.com/extrame/ole2 v0.0.0-20160812065207-d69429661ad7 // indirect
+	github.com/andybalholm/brotli v1.1.1 // indirect
+	github.com/bodgit/plumbing v1.3.0 // indirect
+	github.com/bodgit/windows v1.0.1 // indirect
+	github.com/hashicorp/errwrap v1.0.0 // indirect
+	github.com/hashicorp/go-multierror v1.1.1 // indirect
+	github.com/hashicorp/golang-lru/v2 v2.0.7 // indirect
 	github.com/richardlehane/msoleps v1.0.1 // indirect
+	go4.org v0.0.0-20200411211856-f5505b9728dd // indirect
 )
 
 require (
@@ -31,7 +42,6 @@ require (
 	github.com/rivo/uniseg v0.4.7 // indirect
 	golang.org/x/crypto v0.40.0 // indirect
 	golang.org/x/image v0.27.0 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
 
 replace github.com/richardlehane/mscfb => github.com/richardlehane/mscfb v1.0.4
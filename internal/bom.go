@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"bytes"
+
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// 字节顺序标记，Windows生成的文本、XML文件常以此开头
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// StripBOM 检测开头的UTF-8、UTF-16 BOM：UTF-8时去除BOM，UTF-16时去除BOM并转换为UTF-8，
+// 返回处理后的内容及BOM表示的编码（UTF-8、UTF-16LE、UTF-16BE）；没有BOM时原样返回，编码为空
+func StripBOM(data []byte) ([]byte, string) {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return data[len(bomUTF8):], "UTF-8"
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return decodeUTF16(data, unicode.LittleEndian), "UTF-16LE"
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return decodeUTF16(data, unicode.BigEndian), "UTF-16BE"
+	}
+	return data, ""
+}
+
+// decodeUTF16 按BOM将UTF-16内容转换为UTF-8，不成对的代理及末尾的单个字节按Replacement替换
+func decodeUTF16(data []byte, endianness unicode.Endianness) []byte {
+	decoded, _, err := transform.Bytes(unicode.UTF16(endianness, unicode.ExpectBOM).NewDecoder(), data)
+	if err != nil {
+		return ReplaceInvalidBytes(data)
+	}
+	return ReplaceInvalidBytes(decoded)
+}
@@ -0,0 +1,290 @@
+// Package cfbtest 在内存中构造最小的OLE复合文档（CFB），供doc/xls/ppt等读取器的测试使用，
+// 不依赖二进制样本文件即可校验扇区、FAT、MiniFAT及迷你流的偏移计算
+package cfbtest
+
+import (
+	"encoding/binary"
+	"unicode/utf16"
+)
+
+const (
+	endOfChain = 0xFFFFFFFE // 扇区链结束
+	fatSect    = 0xFFFFFFFD // FAT所在扇区
+	freeSect   = 0xFFFFFFFF // 空闲扇区
+	noStream   = 0xFFFFFFFF // 无兄弟/子目录项
+
+	miniSectorSize   = 64
+	miniStreamCutoff = 4096
+	dirEntrySize     = 128
+)
+
+// Stream 存储下的一个流，Children不为nil时为子存储（Data被忽略）
+type Stream struct {
+	Name     string // 不超过31个字符
+	Data     []byte
+	Children []Stream
+}
+
+// Options 构造选项
+type Options struct {
+	SectorSize int  // 扇区大小，512（v3）或4096（v4），为0时使用512
+	Fragment   bool // 各扇区链（流、迷你流、MiniFAT、目录）交错分配，使每条多扇区链都不连续
+}
+
+// chain 需要分配扇区的一段数据
+type chain struct {
+	data    []byte
+	sectors []uint32
+}
+
+// dirEntry 展开后的目录项，在entries中的下标加1即目录项编号（0号为根存储）
+type dirEntry struct {
+	Stream
+	right uint32
+	child uint32
+}
+
+func (e *dirEntry) storage() bool {
+	return e.Children != nil
+}
+
+// flatten 将同一存储下的streams依次作为右兄弟追加到entries，再递归展开子存储，返回第一个子项的编号
+func flatten(streams []Stream, entries []*dirEntry) ([]*dirEntry, uint32) {
+	if len(streams) == 0 {
+		return entries, noStream
+	}
+	first := len(entries)
+	for i, s := range streams {
+		e := &dirEntry{Stream: s, right: noStream, child: noStream}
+		if i+1 < len(streams) {
+			e.right = uint32(first + i + 2)
+		}
+		entries = append(entries, e)
+	}
+	for i, s := range streams {
+		if s.Children != nil {
+			var child uint32
+			entries, child = flatten(s.Children, entries)
+			entries[first+i].child = child
+		}
+	}
+	return entries, uint32(first + 1)
+}
+
+// Build 构造包含streams的复合文档：小于4096字节的流保存在迷你流中，
+// 其余的流直接占用扇区；FAT扇区位于所有数据扇区之后，只使用文件头中的DIFAT
+func Build(streams []Stream, opts Options) []byte {
+	sectorSize := opts.SectorSize
+	if sectorSize == 0 {
+		sectorSize = 512
+	}
+	entries, rootChild := flatten(streams, nil)
+
+	// 1. 小流分配迷你扇区
+	var miniChains []*chain
+	for _, s := range entries {
+		if !s.storage() && len(s.Data) > 0 && len(s.Data) < miniStreamCutoff {
+			miniChains = append(miniChains, &chain{data: s.Data})
+		}
+	}
+	miniCount := allocate(miniChains, miniSectorSize, opts.Fragment)
+	miniStream := make([]byte, miniCount*miniSectorSize)
+	miniFAT := make([]uint32, miniCount)
+	for _, c := range miniChains {
+		link(miniFAT, c.sectors)
+		for i, sector := range c.sectors {
+			copy(miniStream[int(sector)*miniSectorSize:], c.data[i*miniSectorSize:min(len(c.data), (i+1)*miniSectorSize)])
+		}
+	}
+
+	// 2. 目录：0号为根存储，同一存储下的项依次作为右兄弟
+	entriesPerSector := sectorSize / dirEntrySize
+	dirCount := (len(entries) + 1 + entriesPerSector - 1) / entriesPerSector
+	directory := make([]byte, dirCount*sectorSize)
+	for i := 0; i < dirCount*entriesPerSector; i++ {
+		putUnusedEntry(directory[i*dirEntrySize:])
+	}
+
+	// 3. 分配普通扇区：大流、迷你流、MiniFAT、目录
+	var chains []*chain
+	bigChains := make(map[int]*chain)
+	for i, s := range entries {
+		if !s.storage() && len(s.Data) >= miniStreamCutoff {
+			bigChains[i] = &chain{data: s.Data}
+			chains = append(chains, bigChains[i])
+		}
+	}
+	miniStreamChain := &chain{data: miniStream}
+	miniFATChain := &chain{data: uint32Bytes(miniFAT, sectorSize)}
+	dirChain := &chain{data: directory}
+	chains = append(chains, miniStreamChain, miniFATChain, dirChain)
+	dataCount := allocate(chains, sectorSize, opts.Fragment)
+
+	// FAT扇区数：FAT需同时覆盖数据扇区及FAT扇区本身
+	entriesPerFAT := sectorSize / 4
+	fatCount := 1
+	for fatCount*entriesPerFAT < dataCount+fatCount {
+		fatCount++
+	}
+	fat := make([]uint32, fatCount*entriesPerFAT)
+	for i := range fat {
+		fat[i] = freeSect
+	}
+	for _, c := range chains {
+		link(fat, c.sectors)
+	}
+	for i := 0; i < fatCount; i++ {
+		fat[dataCount+i] = fatSect
+	}
+
+	// 4. 写入目录项
+	rootStart, rootSize := uint32(endOfChain), uint64(0)
+	if len(miniStreamChain.sectors) > 0 {
+		rootStart, rootSize = miniStreamChain.sectors[0], uint64(len(miniStream))
+	}
+	putEntry(directory, "Root Entry", 5, noStream, rootChild, rootStart, rootSize)
+	miniIndex := 0
+	for i, s := range entries {
+		if s.storage() {
+			putEntry(directory[(i+1)*dirEntrySize:], s.Name, 1, s.right, s.child, 0, 0)
+			continue
+		}
+		start := uint32(endOfChain)
+		switch {
+		case bigChains[i] != nil:
+			start = bigChains[i].sectors[0]
+		case len(s.Data) > 0:
+			start = miniChains[miniIndex].sectors[0]
+			miniIndex++
+		}
+		putEntry(directory[(i+1)*dirEntrySize:], s.Name, 2, s.right, noStream, start, uint64(len(s.Data)))
+	}
+
+	// 5. 文件头占用第一个扇区，之后依次为各扇区
+	out := make([]byte, sectorSize*(1+dataCount+fatCount))
+	putHeader(out, sectorSize, dirChain.sectors[0], len(dirChain.sectors), miniFATChain.sectors, miniCount, dataCount, fatCount)
+	for _, c := range chains {
+		for i, sector := range c.sectors {
+			copy(out[(int(sector)+1)*sectorSize:], c.data[i*sectorSize:min(len(c.data), (i+1)*sectorSize)])
+		}
+	}
+	fatBytes := uint32Bytes(fat, sectorSize)
+	copy(out[(dataCount+1)*sectorSize:], fatBytes)
+	return out
+}
+
+// allocate 从0号扇区开始为各链分配扇区，fragment时每轮为每条未分配完的链分配一个扇区，返回分配的扇区总数
+func allocate(chains []*chain, sectorSize int, fragment bool) int {
+	var next uint32
+	if !fragment {
+		for _, c := range chains {
+			for i := 0; i < sectorCount(len(c.data), sectorSize); i++ {
+				c.sectors = append(c.sectors, next)
+				next++
+			}
+		}
+		return int(next)
+	}
+
+	for {
+		allocated := false
+		for _, c := range chains {
+			if len(c.sectors) < sectorCount(len(c.data), sectorSize) {
+				c.sectors = append(c.sectors, next)
+				next++
+				allocated = true
+			}
+		}
+		if !allocated {
+			return int(next)
+		}
+	}
+}
+
+func sectorCount(size, sectorSize int) int {
+	return (size + sectorSize - 1) / sectorSize
+}
+
+// link 在分配表中按顺序连接扇区链
+func link(table []uint32, sectors []uint32) {
+	for i, sector := range sectors {
+		if i+1 < len(sectors) {
+			table[sector] = sectors[i+1]
+		} else {
+			table[sector] = endOfChain
+		}
+	}
+}
+
+// uint32Bytes 将分配表写为小端字节并按扇区大小补齐，空闲部分为freeSect
+func uint32Bytes(values []uint32, sectorSize int) []byte {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make([]byte, sectorCount(len(values)*4, sectorSize)*sectorSize)
+	for i := 0; i < len(out)/4; i++ {
+		v := uint32(freeSect)
+		if i < len(values) {
+			v = values[i]
+		}
+		binary.LittleEndian.PutUint32(out[i*4:], v)
+	}
+	return out
+}
+
+func putHeader(out []byte, sectorSize int, dirStart uint32, dirCount int, miniFATSectors []uint32, miniCount, dataCount, fatCount int) {
+	copy(out, []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1})
+	le := binary.LittleEndian
+	le.PutUint16(out[0x18:], 0x003E)
+	if sectorSize == 4096 {
+		le.PutUint16(out[0x1A:], 4)
+		le.PutUint16(out[0x1E:], 12)
+		le.PutUint32(out[0x28:], uint32(dirCount))
+	} else {
+		le.PutUint16(out[0x1A:], 3)
+		le.PutUint16(out[0x1E:], 9)
+	}
+	le.PutUint16(out[0x1C:], 0xFFFE)
+	le.PutUint16(out[0x20:], 6)
+	le.PutUint32(out[0x2C:], uint32(fatCount))
+	le.PutUint32(out[0x30:], dirStart)
+	le.PutUint32(out[0x38:], miniStreamCutoff)
+	if miniCount > 0 {
+		le.PutUint32(out[0x3C:], miniFATSectors[0])
+		le.PutUint32(out[0x40:], uint32(len(miniFATSectors)))
+	} else {
+		le.PutUint32(out[0x3C:], endOfChain)
+	}
+	le.PutUint32(out[0x44:], endOfChain)
+	for i := 0; i < 109; i++ {
+		v := uint32(freeSect)
+		if i < fatCount {
+			v = uint32(dataCount + i)
+		}
+		le.PutUint32(out[0x4C+i*4:], v)
+	}
+}
+
+func putEntry(b []byte, name string, objectType byte, right, child, start uint32, size uint64) {
+	le := binary.LittleEndian
+	units := utf16.Encode([]rune(name))
+	for i, u := range units {
+		le.PutUint16(b[i*2:], u)
+	}
+	le.PutUint16(b[64:], uint16((len(units)+1)*2))
+	b[66] = objectType
+	b[67] = 1 // 黑色
+	le.PutUint32(b[68:], noStream)
+	le.PutUint32(b[72:], right)
+	le.PutUint32(b[76:], child)
+	le.PutUint32(b[116:], start)
+	le.PutUint64(b[120:], size)
+}
+
+// putUnusedEntry 未使用的目录项全部为0，兄弟及子项为noStream
+func putUnusedEntry(b []byte) {
+	le := binary.LittleEndian
+	le.PutUint32(b[68:], noStream)
+	le.PutUint32(b[72:], noStream)
+	le.PutUint32(b[76:], noStream)
+}
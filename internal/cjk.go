@@ -0,0 +1,36 @@
+package internal
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// IsCJK 是否为词语之间不以空格分隔的中文、日文字符：汉字、假名、CJK标点及全角字符。
+// 韩文以空格分隔词语，谚文不属于此类
+func IsCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) ||
+		(r >= 0x3000 && r <= 0x303F) || (r >= 0xFF00 && r <= 0xFFEF)
+}
+
+// CJKAdjacent left的最后一个字符与right的第一个字符是否均为CJK字符，此时两者之间不应插入空格
+func CJKAdjacent(left, right string) bool {
+	l, _ := utf8.DecodeLastRuneInString(left)
+	r, _ := utf8.DecodeRuneInString(right)
+	return IsCJK(l) && IsCJK(r)
+}
+
+// JoinCJK 与strings.Join相同，cjk为true时连接处两侧均为CJK字符的相邻片段直接拼接，不插入sep
+func JoinCJK(segments []string, sep string, cjk bool) string {
+	if !cjk {
+		return strings.Join(segments, sep)
+	}
+	var sb strings.Builder
+	for i, s := range segments {
+		if i > 0 && !CJKAdjacent(segments[i-1], s) {
+			sb.WriteString(sep)
+		}
+		sb.WriteString(s)
+	}
+	return sb.String()
+}
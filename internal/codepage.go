@@ -0,0 +1,42 @@
+package internal
+
+import (
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+// codePages Windows代码页编号对应的编码，用于解码旧版Office格式（BIFF5、ANSI字符串属性等）中的单/双字节文本
+var codePages = map[uint16]encoding.Encoding{
+	437:   charmap.CodePage437,
+	850:   charmap.CodePage850,
+	866:   charmap.CodePage866,
+	874:   charmap.Windows874,
+	932:   japanese.ShiftJIS,
+	936:   simplifiedchinese.GBK,
+	949:   korean.EUCKR,
+	950:   traditionalchinese.Big5,
+	1250:  charmap.Windows1250,
+	1251:  charmap.Windows1251,
+	1252:  charmap.Windows1252,
+	1253:  charmap.Windows1253,
+	1254:  charmap.Windows1254,
+	1255:  charmap.Windows1255,
+	1256:  charmap.Windows1256,
+	1257:  charmap.Windows1257,
+	1258:  charmap.Windows1258,
+	10000: charmap.Macintosh,
+	20936: simplifiedchinese.GBK,
+	54936: simplifiedchinese.GB18030,
+	// BIFF中32768表示Mac Roman，32769表示Windows-1252
+	32768: charmap.Macintosh,
+	32769: charmap.Windows1252,
+}
+
+// CodePageEncoding 返回Windows代码页编号对应的编码，不支持的代码页（含UTF-16的1200、UTF-8的65001）返回nil
+func CodePageEncoding(codePage uint16) encoding.Encoding {
+	return codePages[codePage]
+}
@@ -0,0 +1,126 @@
+package internal
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// zipMagic ZIP本地文件头标识，OOXML、ODF、EPUB、JAR等均为ZIP结构
+var zipMagic = []byte("PK\x03\x04")
+
+// Description 文件路由信息，用于排查文件类型识别及解析器选择问题
+type Description struct {
+	FileType   FileType // 识别的文件类型，启用宏的文档、WPS文档及.xml按内容确认
+	Registered bool     // 该类型是否注册了解析器，未注册时使用UnknownFileParser
+	Parser     string   // 选用的解析器，如 *docx.OfficeDocxParser
+	Entries    []string // ZIP（含OOXML/ODF等）及tar类压缩包的顶层条目，其他格式为空
+}
+
+// Describe 识别文件类型及对应的解析器，列出压缩包/OOXML的顶层条目，不做文本提取
+func Describe(filePath string) (Description, error) {
+	fileType := SniffFileType(filePath)
+	_, registered := parsers[fileType]
+	parser, err := GetParser(fileType)
+	if err != nil {
+		return Description{}, err
+	}
+
+	desc := Description{
+		FileType:   FileType(fileType),
+		Registered: registered,
+		Parser:     fmt.Sprintf("%T", parser),
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return desc, fmt.Errorf("无法打开文件: %v", err)
+	}
+	defer file.Close()
+
+	magic := make([]byte, len(zipMagic))
+	if _, err := io.ReadFull(file, magic); err == nil && bytes.Equal(magic, zipMagic) {
+		desc.Entries, err = zipTopEntries(filePath)
+		return desc, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return desc, err
+	}
+
+	var reader io.Reader
+	switch fileType {
+	case FileTypeTAR:
+		reader = file
+	case FileTypeTARGZ:
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return desc, fmt.Errorf("创建gzip reader失败: %v", err)
+		}
+		defer gz.Close()
+		reader = gz
+	case FileTypeTARBZ2:
+		reader = bzip2.NewReader(file)
+	default:
+		return desc, nil
+	}
+	desc.Entries, err = tarTopEntries(reader)
+	return desc, err
+}
+
+// zipTopEntries 返回ZIP中的顶层条目，目录以"/"结尾
+func zipTopEntries(filePath string) ([]string, error) {
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开ZIP文件: %v", err)
+	}
+	defer reader.Close()
+
+	var names []string
+	for _, f := range reader.File {
+		names = append(names, f.Name)
+	}
+	return topEntries(names), nil
+}
+
+// tarTopEntries 返回tar中的顶层条目，目录以"/"结尾
+func tarTopEntries(r io.Reader) ([]string, error) {
+	tr := tar.NewReader(r)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return topEntries(names), fmt.Errorf("读取tar条目失败: %v", err)
+		}
+		names = append(names, header.Name)
+	}
+	return topEntries(names), nil
+}
+
+// topEntries 按出现顺序去重，保留每个路径的第一级
+func topEntries(names []string) []string {
+	var entries []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.TrimPrefix(strings.TrimPrefix(name, "./"), "/")
+		if name == "" {
+			continue
+		}
+		if i := strings.IndexByte(name, '/'); i >= 0 {
+			name = name[:i+1]
+		}
+		if !seen[name] {
+			seen[name] = true
+			entries = append(entries, name)
+		}
+	}
+	return entries
+}
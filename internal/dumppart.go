@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/richardlehane/mscfb"
+)
+
+// DumpPart 读取容器文件中指定部件的原始内容，用于调试或自行处理库不支持的部件：
+// OLE复合文档（doc、xls、ppt、msg等）返回名为partName的流，存储中的流以"/"连接路径，如"ObjectPool/_1234/CONTENTS"；
+// ZIP（docx、xlsx、odt、jar等）返回名为partName的条目，如"xl/calcChain.xml"。
+// 部件不存在时返回ErrStreamNotFound，文件不是OLE或ZIP时返回ErrUnsupportedFormat
+func DumpPart(filePath string, partName string) ([]byte, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开文件: %w", err)
+	}
+	defer file.Close()
+
+	partName = strings.TrimPrefix(partName, "/")
+	head := make([]byte, len(oleMagic))
+	n, _ := io.ReadFull(file, head)
+	switch {
+	case bytes.Equal(head[:n], oleMagic):
+		return dumpOleStream(file, partName)
+	case bytes.HasPrefix(head[:n], zipMagic), bytes.HasPrefix(head[:n], []byte("PK\x05\x06")):
+		info, err := file.Stat()
+		if err != nil {
+			return nil, err
+		}
+		return dumpZipEntry(file, info.Size(), partName)
+	default:
+		return nil, fmt.Errorf("文件不是OLE复合文档或ZIP: %w", ErrUnsupportedFormat)
+	}
+}
+
+// dumpOleStream 读取OLE复合文档中路径为name的流
+func dumpOleStream(r io.ReaderAt, name string) ([]byte, error) {
+	doc, err := mscfb.New(r)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开OLE复合文档: %w", err)
+	}
+	for _, entry := range doc.File {
+		if path.Join(strings.Join(entry.Path, "/"), entry.Name) != name {
+			continue
+		}
+		if entry.FileInfo().IsDir() {
+			return nil, fmt.Errorf("%s是存储而不是流: %w", name, ErrStreamNotFound)
+		}
+		content := make([]byte, entry.Size)
+		if _, err := io.ReadFull(entry, content); err != nil {
+			return nil, fmt.Errorf("读取流%s失败: %w", name, err)
+		}
+		return content, nil
+	}
+	return nil, fmt.Errorf("流%s: %w", name, ErrStreamNotFound)
+}
+
+// dumpZipEntry 读取ZIP中名为name的条目
+func dumpZipEntry(r io.ReaderAt, size int64, name string) ([]byte, error) {
+	reader, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, ZipOpenError("ZIP", err)
+	}
+	for _, f := range reader.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("打开条目%s失败: %w", name, err)
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return nil, fmt.Errorf("条目%s: %w", name, ErrStreamNotFound)
+}
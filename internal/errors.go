@@ -0,0 +1,31 @@
+package internal
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+)
+
+// 解析器返回的常见失败类型，具体错误通过%w包装这些哨兵错误，调用方使用errors.Is判断，
+// 以区分"文件不是该格式"与"格式正确但解析失败"
+var (
+	ErrInvalidSignature  = errors.New("文件签名无效")     // 文件头/魔数不匹配，文件不是该格式
+	ErrStreamNotFound    = errors.New("未找到必需的流或条目") // 容器中缺少必需的流、目录项或压缩包条目
+	ErrUnsupportedFormat = errors.New("不支持的格式版本")   // 格式可识别，但版本或变体不受支持
+	ErrEncrypted         = errors.New("文件已加密")      // 文件已加密或混淆，无法提取文本
+	ErrTruncated         = errors.New("文件数据不完整")    // 数据被截断或结构长度超出实际数据
+	ErrPartialArchive    = errors.New("压缩包不完整")     // 压缩包被截断或损坏，返回的内容仅包含成功读取的条目
+	ErrNoParser          = errors.New("未注册解析器")     // 文件类型未注册解析器，仅由GetParserStrict及ExtractOptions.Strict返回
+
+	// ErrNoContent 文件中没有任何承载文本的结构（如没有工作表、幻灯片，PDF、DjVu没有文本层），
+	// 与结构完整但文本为空的文档（返回空内容及nil）相区分；压缩包中的此类条目按空内容输出
+	ErrNoContent = errors.New("未找到文本内容")
+)
+
+// ZipOpenError 包装打开OOXML/ODF等ZIP容器时的错误，不是ZIP格式时同时包装ErrInvalidSignature
+func ZipOpenError(kind string, err error) error {
+	if errors.Is(err, zip.ErrFormat) {
+		return fmt.Errorf("无法打开%s文件: %w: %w", kind, ErrInvalidSignature, err)
+	}
+	return fmt.Errorf("无法打开%s文件: %w", kind, err)
+}
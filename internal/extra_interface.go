@@ -2,7 +2,9 @@ package internal
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"sort"
 )
 
 // FileParser 定义文件解析器接口
@@ -10,6 +12,11 @@ type FileParser interface {
 	Parse(filePath string) ([]byte, error)
 }
 
+// ReaderParser 可选接口，支持直接从io.Reader解析，无需先落地为文件
+type ReaderParser interface {
+	ParseReader(r io.Reader) ([]byte, error)
+}
+
 var parsers = make(map[int]FileParser)
 
 type UnknownFileParser struct{}
@@ -22,16 +29,20 @@ func (p *UnknownFileParser) Parse(filePath string) ([]byte, error) {
 	return data, nil
 }
 
+func (p *UnknownFileParser) ParseReader(r io.Reader) ([]byte, error) {
+	return io.ReadAll(r)
+}
+
 // RegisterParser 注册文件类型解析器
 func RegisterParser(fileType int, parser FileParser) {
 	if _, exists := parsers[fileType]; exists {
-		fmt.Printf("警告: 文件类型 %d 已被注册，将忽略重复注册\n", fileType)
+		fmt.Printf("警告: 文件类型 %s 已被注册，将忽略重复注册\n", FileType(fileType))
 		return
 	}
 	parsers[fileType] = parser
 }
 
-// GetParser 获取指定文件类型的解析器
+// GetParser 获取指定文件类型的解析器，未注册时返回按原始字节输出的UnknownFileParser
 func GetParser(fileType int) (FileParser, error) {
 	parser, exists := parsers[fileType]
 	if !exists {
@@ -41,6 +52,61 @@ func GetParser(fileType int) (FileParser, error) {
 	return parser, nil
 }
 
+// GetParserStrict 与GetParser相同，未注册解析器或类型无法识别（UNKNOWN）时返回ErrNoParser，
+// 不回退到UnknownFileParser
+func GetParserStrict(fileType int) (FileParser, error) {
+	parser, exists := parsers[fileType]
+	if !exists || fileType == 114 {
+		return nil, fmt.Errorf("文件类型 %s: %w", FileType(fileType), ErrNoParser)
+	}
+	return parser, nil
+}
+
+// ParseReader 使用指定文件类型的解析器解析io.Reader中的内容
+// 解析器未实现ReaderParser时，先写入临时文件再调用Parse
+func ParseReader(r io.Reader, fileType int) ([]byte, error) {
+	parser, err := GetParser(fileType)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	if rp, ok := parser.(ReaderParser); ok {
+		return rp.ParseReader(r)
+	}
+
+	// 部分解析器依赖文件路径（如pdfcpu、7z），且会根据后缀判断内容，临时文件保留对应后缀
+	tmpFile, err := CreateTemp("fextra_*." + typeSuffix(fileType))
+	if err != nil {
+		return []byte{}, fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := io.Copy(tmpFile, r); err != nil {
+		tmpFile.Close()
+		return []byte{}, fmt.Errorf("写入临时文件失败: %v", err)
+	}
+	tmpFile.Close()
+
+	return parser.Parse(tmpFile.Name())
+}
+
+// typeSuffix 返回文件类型对应的后缀，多个后缀时取字典序最小的一个，保证结果稳定
+func typeSuffix(fileType int) string {
+	var suffixes []string
+	suffixMu.RLock()
+	for ext, t := range suffixMap {
+		if t == fileType {
+			suffixes = append(suffixes, ext)
+		}
+	}
+	suffixMu.RUnlock()
+	if len(suffixes) == 0 {
+		return "bin"
+	}
+	sort.Strings(suffixes)
+	return suffixes[0]
+}
+
 func init() {
 	RegisterParser(114, &UnknownFileParser{})
 }
@@ -1,118 +1,407 @@
 package internal
 
 import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 // 文件类型常量定义
 const (
-	FileTypeHTML  = 1
-	FileTypeTXT   = 2
-	FileTypeXML   = 3
-	FileTypeJSON  = 4
-	FileTypeCSV   = 5
-	FileTypeMD    = 6
-	FileTypeDOC   = 7
-	FileTypeDOCX  = 8
-	FileTypeXLS   = 9
-	FileTypeXLSX  = 10
-	FileTypePPT   = 11
-	FileTypePPTX  = 12
-	FileTypePDF   = 13
-	FileTypeXLSB  = 14
-	FileTypeODT   = 15
-	FileTypeRTF   = 16
-	FileTypeTAR   = 18
-	FileTypeGZ    = 19
-	FileTypeTARGZ = 20
-	FileTypeZIP   = 21
-	FileType7Z    = 22
-	FileTypeRAR   = 23
-	FileTypeBZ2   = 24
-	FileTypeJAR   = 25
-	FileTypeWAR   = 26
-	FileTypeARJ   = 27
-	FileTypeLZH   = 28
-	FileTypeXZ    = 29
-	FileTypeJPEG  = 31
-	FileTypePNG   = 32
-	FileTypeTIF   = 33
-	FileTypeWebP  = 34
-	FileTypeWBMP  = 35
-	FileTypeVSDX  = 201
-	FileTypeVSD   = 202
-	FileTypeFPX   = 401
-	FileTypePBM   = 402
-	FileTypePGM   = 403
-	FileTypeBMP   = 404
+	FileTypeHTML     = 1
+	FileTypeTXT      = 2
+	FileTypeXML      = 3
+	FileTypeJSON     = 4
+	FileTypeCSV      = 5
+	FileTypeMD       = 6
+	FileTypeDOC      = 7
+	FileTypeDOCX     = 8
+	FileTypeXLS      = 9
+	FileTypeXLSX     = 10
+	FileTypePPT      = 11
+	FileTypePPTX     = 12
+	FileTypePDF      = 13
+	FileTypeXLSB     = 14
+	FileTypeODT      = 15
+	FileTypeRTF      = 16
+	FileTypeDOCOTHER = 17 // 其他文档类，如WordPerfect（.wpd）
+	FileTypeTAR      = 18
+	FileTypeGZ       = 19
+	FileTypeTARGZ    = 20
+	FileTypeZIP      = 21
+	FileType7Z       = 22
+	FileTypeRAR      = 23
+	FileTypeBZ2      = 24
+	FileTypeJAR      = 25
+	FileTypeWAR      = 26
+	FileTypeARJ      = 27
+	FileTypeLZH      = 28
+	FileTypeXZ       = 29
+	FileTypeJPEG     = 31
+	FileTypePNG      = 32
+	FileTypeTIF      = 33
+	FileTypeWebP     = 34
+	FileTypeWBMP     = 35
+	FileTypeVSDX     = 201
+	FileTypeVSD      = 202
+	FileTypeEPUB     = 203
+	FileTypeODP      = 204
+	FileTypeODS      = 205
+	FileTypePAGES    = 206
+	FileTypeKEY      = 207
+	FileTypeNUMBERS  = 208
+	FileTypeFLATOPC  = 209
+	FileTypeMSG      = 210
+	FileTypeDJVU     = 211
+	FileTypeTARBZ2   = 301
+	FileTypeTARXZ    = 302
+	FileTypeZST      = 303
+	FileTypeLZ4      = 304
+	FileTypeSNAPPY   = 305
+	FileTypeFPX      = 401
+	FileTypePBM      = 402
+	FileTypePGM      = 403
+	FileTypeBMP      = 404
+	FileTypeSVG      = 405
+	FileTypeTSV      = 501 // 其他文本类从501开始编号，避开114（UNKNOWN）
+	FileTypeMHT      = 502
+	FileTypeEML      = 503
 )
 
+// FileType 文件类型，可输出类型名称，便于日志展示
+type FileType int
+
+// fileTypeNames 文件类型名称，包含按大类归并的其他类型
+var fileTypeNames = map[int]string{
+	FileTypeHTML:    "HTML",
+	FileTypeTXT:     "TXT",
+	FileTypeXML:     "XML",
+	FileTypeJSON:    "JSON",
+	FileTypeCSV:     "CSV",
+	FileTypeMD:      "MD",
+	FileTypeDOC:     "DOC",
+	FileTypeDOCX:    "DOCX",
+	FileTypeXLS:     "XLS",
+	FileTypeXLSX:    "XLSX",
+	FileTypePPT:     "PPT",
+	FileTypePPTX:    "PPTX",
+	FileTypePDF:     "PDF",
+	FileTypeXLSB:    "XLSB",
+	FileTypeODT:     "ODT",
+	FileTypeRTF:     "RTF",
+	FileTypeTAR:     "TAR",
+	FileTypeGZ:      "GZ",
+	FileTypeTARGZ:   "TARGZ",
+	FileTypeZIP:     "ZIP",
+	FileType7Z:      "7Z",
+	FileTypeRAR:     "RAR",
+	FileTypeBZ2:     "BZ2",
+	FileTypeJAR:     "JAR",
+	FileTypeWAR:     "WAR",
+	FileTypeARJ:     "ARJ",
+	FileTypeLZH:     "LZH",
+	FileTypeXZ:      "XZ",
+	FileTypeJPEG:    "JPEG",
+	FileTypePNG:     "PNG",
+	FileTypeTIF:     "TIF",
+	FileTypeWebP:    "WEBP",
+	FileTypeWBMP:    "WBMP",
+	FileTypeVSDX:    "VSDX",
+	FileTypeVSD:     "VSD",
+	FileTypeEPUB:    "EPUB",
+	FileTypeODP:     "ODP",
+	FileTypeODS:     "ODS",
+	FileTypePAGES:   "PAGES",
+	FileTypeKEY:     "KEY",
+	FileTypeNUMBERS: "NUMBERS",
+	FileTypeFLATOPC: "FLATOPC",
+	FileTypeMSG:     "MSG",
+	FileTypeDJVU:    "DJVU",
+	FileTypeTARBZ2:  "TARBZ2",
+	FileTypeTARXZ:   "TARXZ",
+	FileTypeZST:     "ZST",
+	FileTypeLZ4:     "LZ4",
+	FileTypeSNAPPY:  "SNAPPY",
+	FileTypeFPX:     "FPX",
+	FileTypePBM:     "PBM",
+	FileTypePGM:     "PGM",
+	FileTypeBMP:     "BMP",
+	FileTypeSVG:     "SVG",
+	FileTypeTSV:     "TSV",
+	FileTypeMHT:     "MHT",
+	FileTypeEML:     "EML",
+	17:              "DOC_OTHER",
+	30:              "COMPRESS_OTHER",
+	36:              "IMAGE_OTHER",
+	114:             "UNKNOWN",
+}
+
+// String 返回文件类型名称，如 "DOCX"，未知类型返回数字
+func (t FileType) String() string {
+	if name, ok := fileTypeNames[int(t)]; ok {
+		return name
+	}
+	return strconv.Itoa(int(t))
+}
+
+// ParseFileType 根据类型名称（如 "docx"、"TARGZ"）或文件后缀（如 "jpg"、"tar.gz"）查找文件类型，不区分大小写
+func ParseFileType(s string) (int, bool) {
+	name := strings.ToUpper(strings.TrimSpace(s))
+	for t, n := range fileTypeNames {
+		if n == name {
+			return t, true
+		}
+	}
+	return GetSuffixType(s)
+}
+
 // 定义后缀映射表
 var suffixMap = map[string]int{
-	"html":   FileTypeHTML,
-	"txt":    FileTypeTXT,
-	"xml":    FileTypeXML,
-	"json":   FileTypeJSON,
-	"csv":    FileTypeCSV,
-	"doc":    FileTypeDOC,
-	"docx":   FileTypeDOCX,
-	"xls":    FileTypeXLS,
-	"xlsx":   FileTypeXLSX,
-	"ppt":    FileTypePPT,
-	"pptx":   FileTypePPTX,
-	"pdf":    FileTypePDF,
-	"xlsb":   FileTypeXLSB,
-	"odt":    FileTypeODT,
-	"rtf":    FileTypeRTF,
-	"vsdx":   FileTypeVSDX,
-	"vsd":    FileTypeVSD,
-	"tar":    FileTypeTAR,
-	"gz":     FileTypeGZ,
-	"tar.gz": FileTypeTARGZ,
-	"zip":    FileTypeZIP,
-	"7z":     FileType7Z,
-	"rar":    FileTypeRAR,
-	"bz2":    FileTypeBZ2,
-	"jar":    FileTypeJAR,
-	"war":    FileTypeWAR,
-	"arj":    FileTypeARJ,
-	"lzh":    FileTypeLZH,
-	"xz":     FileTypeXZ,
-	"jpeg":   FileTypeJPEG,
-	"jpg":    FileTypeJPEG,
-	"png":    FileTypePNG,
-	"tif":    FileTypeTIF,
-	"tiff":   FileTypeTIF,
-	"webp":   FileTypeWebP,
-	"wbmp":   FileTypeWBMP,
-	"fpx":    FileTypeFPX,
-	"pbm":    FileTypePBM,
-	"pgm":    FileTypePGM,
-	"bmp":    FileTypeBMP,
+	"html":    FileTypeHTML,
+	"txt":     FileTypeTXT,
+	"xml":     FileTypeXML,
+	"json":    FileTypeJSON,
+	"csv":     FileTypeCSV,
+	"tsv":     FileTypeTSV,
+	"mht":     FileTypeMHT,
+	"mhtml":   FileTypeMHT,
+	"eml":     FileTypeEML,
+	"doc":     FileTypeDOC,
+	"docx":    FileTypeDOCX,
+	"xls":     FileTypeXLS,
+	"xlsx":    FileTypeXLSX,
+	"ppt":     FileTypePPT,
+	"pptx":    FileTypePPTX,
+	"pdf":     FileTypePDF,
+	"xlsb":    FileTypeXLSB,
+	"odt":     FileTypeODT,
+	"rtf":     FileTypeRTF,
+	"vsdx":    FileTypeVSDX,
+	"vsd":     FileTypeVSD,
+	"epub":    FileTypeEPUB,
+	"odp":     FileTypeODP,
+	"ods":     FileTypeODS,
+	"pages":   FileTypePAGES,
+	"key":     FileTypeKEY,
+	"numbers": FileTypeNUMBERS,
+	"msg":     FileTypeMSG,
+	"djvu":    FileTypeDJVU,
+	"djv":     FileTypeDJVU,
+	"tar":     FileTypeTAR,
+	"gz":      FileTypeGZ,
+	"tar.gz":  FileTypeTARGZ,
+	"zip":     FileTypeZIP,
+	"7z":      FileType7Z,
+	"rar":     FileTypeRAR,
+	"bz2":     FileTypeBZ2,
+	"jar":     FileTypeJAR,
+	"war":     FileTypeWAR,
+	"arj":     FileTypeARJ,
+	"lzh":     FileTypeLZH,
+	"xz":      FileTypeXZ,
+	"tar.bz2": FileTypeTARBZ2,
+	"tbz2":    FileTypeTARBZ2,
+	"tbz":     FileTypeTARBZ2,
+	"tar.xz":  FileTypeTARXZ,
+	"txz":     FileTypeTARXZ,
+	"zst":     FileTypeZST,
+	"tar.zst": FileTypeZST,
+	"tzst":    FileTypeZST,
+	"lz4":     FileTypeLZ4,
+	"tar.lz4": FileTypeLZ4,
+	"sz":      FileTypeSNAPPY,
+	"tar.sz":  FileTypeSNAPPY,
+	"jpeg":    FileTypeJPEG,
+	"jpg":     FileTypeJPEG,
+	"png":     FileTypePNG,
+	"tif":     FileTypeTIF,
+	"tiff":    FileTypeTIF,
+	"webp":    FileTypeWebP,
+	"wbmp":    FileTypeWBMP,
+	"fpx":     FileTypeFPX,
+	"pbm":     FileTypePBM,
+	"pgm":     FileTypePGM,
+	"bmp":     FileTypeBMP,
+	"svg":     FileTypeSVG,
 }
 
 // 判断属于哪个大类的其他类型，扩展的其他文件类型
 var (
 	textOtherSuffixes     = []string{"md", "css", "js", "log", "ini", "py", "go", "java", "c", "cpp", "h", "sh", "bat", "php", "rb"}
-	docOtherSuffixes      = []string{"odp", "ods", "pages", "key", "numbers", "wpd"}
-	compressOtherSuffixes = []string{"zipx", "tar.bz2", "tar.xz", "rar5", "z"}
-	imageOtherSuffixes    = []string{"gif", "ico", "svg", "jpe"}
+	docOtherSuffixes      = []string{"wpd"}
+	compressOtherSuffixes = []string{"zipx", "rar5", "z"}
+	imageOtherSuffixes    = []string{"gif", "ico", "jpe"}
 )
 
-func GetDynamicFileType(filename string) int {
+// volumeSuffix 分卷压缩包的后缀，part1.rar等以.rar结尾的分卷按后缀即可识别
+var volumeSuffix = regexp.MustCompile(`\.(?:(zip|7z|rar)\.\d{3}|(z|r)\d{2})$`)
+
+// 启用宏的Office文档（docm/xlsm/pptm）与对应的OOXML文档结构相同
+var macroSuffixes = map[string]struct {
+	fileType int
+	root     string
+}{
+	"docm": {FileTypeDOCX, "word/"},
+	"xlsm": {FileTypeXLSX, "xl/"},
+	"pptm": {FileTypePPTX, "ppt/"},
+}
+
+// sniffMacroDocument 检查ZIP中是否包含预期的根目录，不包含或不是ZIP时按普通ZIP处理
+func sniffMacroDocument(r io.ReaderAt, size int64, root string, fileType int) int {
+	reader, err := zip.NewReader(r, size)
+	if err != nil {
+		return FileTypeZIP
+	}
+
+	for _, file := range reader.File {
+		if strings.HasPrefix(file.Name, root) {
+			return fileType
+		}
+	}
+	return FileTypeZIP
+}
+
+// WPS Office文档：旧版为OLE复合文档，结构与doc/xls/ppt相同；新版为OOXML，结构与docx/xlsx/pptx相同
+var wpsSuffixes = map[string]struct {
+	ole   int
+	ooxml int
+}{
+	"wps": {FileTypeDOC, FileTypeDOCX},
+	"et":  {FileTypeXLS, FileTypeXLSX},
+	"dps": {FileTypePPT, FileTypePPTX},
+}
+
+// oleMagic OLE复合文档的文件头标识
+var oleMagic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// sniffWPSDocument 按文件头区分WPS文档为OLE还是OOXML格式，文件头均不匹配时按未知类型处理
+// 只按文件名判断时按更常见的OLE格式处理
+func sniffWPSDocument(r io.ReaderAt, ole int, ooxml int) int {
+	head := make([]byte, len(oleMagic))
+	n, _ := r.ReadAt(head, 0)
+	switch {
+	case bytes.Equal(head[:n], oleMagic):
+		return ole
+	case bytes.HasPrefix(head[:n], zipMagic):
+		return ooxml
+	default:
+		return 114
+	}
+}
+
+// FlatOPCNamespace Flat OPC根元素pkg:package的命名空间，flatopc解析器使用同一常量
+const FlatOPCNamespace = "http://schemas.microsoft.com/office/2006/xmlPackage"
+
+// flatOPCSniffSize 识别Flat OPC时读取的文件头长度，根元素位于XML声明及处理指令之后
+const flatOPCSniffSize = 4096
+
+// sniffFlatOPC 检查XML文件开头是否为Flat OPC的pkg:package根元素
+func sniffFlatOPC(r io.ReaderAt) bool {
+	head := make([]byte, flatOPCSniffSize)
+	n, _ := r.ReadAt(head, 0)
+	head = head[:n]
+	return bytes.Contains(head, []byte(":package")) && bytes.Contains(head, []byte(FlatOPCNamespace))
+}
+
+// suffixMu 保护suffixMap及registeredSuffixes，RegisterSuffix可以与文件类型判断并发调用
+var suffixMu sync.RWMutex
+
+// registeredSuffixes 通过RegisterSuffix注册的后缀，优先于启用宏的Office文档、WPS文档及.xml的内置判断
+var registeredSuffixes = map[string]bool{}
+
+// RegisterSuffix 注册自定义后缀到文件类型的映射，已存在的后缀（包括内置后缀）将被覆盖；
+// 注册后的后缀只按注册的类型处理，docm、wps、xml等后缀也不再按内容判断
+// 后缀不区分大小写，可带或不带前导点，如 "markdown"、".logx"、"tar.zst"
+func RegisterSuffix(ext string, fileType int) {
+	ext = normalizeSuffix(ext)
+	if ext == "" {
+		return
+	}
+	suffixMu.Lock()
+	defer suffixMu.Unlock()
+	suffixMap[ext] = fileType
+	registeredSuffixes[ext] = true
+}
+
+// isRegisteredSuffix 后缀是否通过RegisterSuffix注册
+func isRegisteredSuffix(ext string) bool {
+	suffixMu.RLock()
+	defer suffixMu.RUnlock()
+	return registeredSuffixes[ext]
+}
+
+// GetSuffixType 获取后缀对应的文件类型，未注册时返回false
+func GetSuffixType(ext string) (int, bool) {
+	return lookupSuffix(normalizeSuffix(ext))
+}
+
+// lookupSuffix 查找已规范化的后缀对应的文件类型
+func lookupSuffix(ext string) (int, bool) {
+	suffixMu.RLock()
+	defer suffixMu.RUnlock()
+	t, ok := suffixMap[ext]
+	return t, ok
+}
+
+// normalizeSuffix 统一后缀格式：小写且去除前导点
+func normalizeSuffix(ext string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(ext)), ".")
+}
+
+// fileSuffix 返回文件名的后缀（小写、不含前导点），已注册的复合后缀（如tar.gz）优先，多个匹配时取最长的一个
+func fileSuffix(filename string) string {
 	lowerFilename := strings.ToLower(filename)
 	ext := ""
-
-	// 检查复合后缀
-	if strings.HasSuffix(lowerFilename, "tar.gz") {
-		ext = "tar.gz"
-	} else {
+	suffixMu.RLock()
+	for s := range suffixMap {
+		if strings.Contains(s, ".") && strings.HasSuffix(lowerFilename, "."+s) && len(s) > len(ext) {
+			ext = s
+		}
+	}
+	suffixMu.RUnlock()
+	if ext == "" {
 		ext = strings.TrimPrefix(filepath.Ext(lowerFilename), ".")
 	}
+	return ext
+}
+
+// GetDynamicFileType 只按文件名（后缀）判断文件类型，不读取文件内容，可用于压缩包成员名等不存在于磁盘上的名称。
+// 启用宏的Office文档按对应的OOXML类型处理，WPS文档按OLE格式处理，.xml按普通XML处理；
+// 需要按内容区分时使用SniffFileType
+func GetDynamicFileType(filename string) int {
+	lowerFilename := strings.ToLower(filename)
+	ext := fileSuffix(filename)
+
+	// 分卷压缩包（name.zip.001、name.z01、name.part2.rar、name.r00）按分卷前的压缩格式处理
+	if m := volumeSuffix.FindStringSubmatch(lowerFilename); m != nil {
+		switch {
+		case m[1] != "":
+			t, _ := lookupSuffix(m[1])
+			return t
+		case m[2] == "z":
+			return FileTypeZIP
+		default:
+			return FileTypeRAR
+		}
+	}
+
+	if !isRegisteredSuffix(ext) {
+		if m, ok := macroSuffixes[ext]; ok {
+			return m.fileType
+		}
+		if w, ok := wpsSuffixes[ext]; ok {
+			return w.ole
+		}
+	}
 
 	// 查找后缀对应的FileType
-	if t, ok := suffixMap[ext]; ok {
+	if t, ok := lookupSuffix(ext); ok {
 		return t
 	} else {
 		// 检查是否属于其他文本类
@@ -147,3 +436,62 @@ func GetDynamicFileType(filename string) int {
 		return 114
 	}
 }
+
+// SniffFileType 与GetDynamicFileType相同按文件名判断类型，对需要按内容区分的后缀读取文件内容确认：
+// 启用宏的Office文档需确认ZIP内的目录结构，WPS文档按文件头确定对应的Office格式，
+// .xml检查是否为Word/PowerPoint另存的Flat OPC文档。文件无法打开时返回按文件名判断的类型
+func SniffFileType(path string) int {
+	fileType := GetDynamicFileType(path)
+	if !NeedsSniff(path) {
+		return fileType
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fileType
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return fileType
+	}
+	return sniffContent(fileSuffix(path), file, stat.Size(), fileType)
+}
+
+// SniffFileTypeData 与SniffFileType相同，按内存中的内容（如压缩包成员）确认name的类型
+func SniffFileTypeData(name string, data []byte) int {
+	fileType := GetDynamicFileType(name)
+	if !NeedsSniff(name) {
+		return fileType
+	}
+	return sniffContent(fileSuffix(name), bytes.NewReader(data), int64(len(data)), fileType)
+}
+
+// NeedsSniff 文件名的类型是否需要按内容确认，分卷压缩包及通过RegisterSuffix注册的后缀只按文件名判断
+func NeedsSniff(name string) bool {
+	if volumeSuffix.MatchString(strings.ToLower(name)) {
+		return false
+	}
+	ext := fileSuffix(name)
+	if isRegisteredSuffix(ext) {
+		return false
+	}
+	_, macro := macroSuffixes[ext]
+	_, wps := wpsSuffixes[ext]
+	return macro || wps || ext == "xml"
+}
+
+// sniffContent 按内容确认后缀为ext的文件类型，fileType为按文件名判断的类型
+func sniffContent(ext string, r io.ReaderAt, size int64, fileType int) int {
+	if m, ok := macroSuffixes[ext]; ok {
+		return sniffMacroDocument(r, size, m.root, m.fileType)
+	}
+	if w, ok := wpsSuffixes[ext]; ok {
+		return sniffWPSDocument(r, w.ole, w.ooxml)
+	}
+	// Word/PowerPoint另存的Flat OPC文档同样以.xml为后缀
+	if ext == "xml" && sniffFlatOPC(r) {
+		return FileTypeFLATOPC
+	}
+	return fileType
+}
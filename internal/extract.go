@@ -0,0 +1,165 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+)
+
+// ExtractOptions 文本提取选项，零值表示按后缀识别类型并原样返回解析结果
+type ExtractOptions struct {
+	FileType  int               // 文件类型，为0时根据文件名动态识别
+	Normalize *NormalizeOptions // 不为nil时对输出进行规范化
+
+	// MaxOutputBytes 单个文件的最大输出字节数，超出部分截断并追加TruncatedMarker，为0时不限制
+	// 解析器实现LimitParser时会在达到上限后提前结束解析
+	MaxOutputBytes int
+
+	// Limit 仅提取前Limit页/幻灯片/行/段落，用于生成预览，为0时不限制
+	// 仅对实现PreviewParser的解析器生效，同时设置MaxOutputBytes时结果仍按字节数截断
+	Limit int
+
+	// Strict 为true时文件类型未注册解析器（含无法识别的类型）返回ErrNoParser（见GetParserStrict），
+	// 默认按原始字节输出；只影响顶层文件，压缩包内的条目仍按GetParser处理
+	Strict bool
+}
+
+// parser 按Strict选择GetParser或GetParserStrict
+func (opts ExtractOptions) parser(fileType int) (FileParser, error) {
+	if opts.Strict {
+		return GetParserStrict(fileType)
+	}
+	return GetParser(fileType)
+}
+
+// Extract 识别文件类型、选择解析器并提取文本，按选项对结果做后处理
+func Extract(filePath string, opts ExtractOptions) ([]byte, error) {
+	text, _, err := extract(filePath, opts, false)
+	return text, err
+}
+
+// ExtractWithInfo 与Extract相同，同时返回使用的解析器、源文本编码及推测的语言
+// 解析器实现InfoParser时使用其检测到的编码，未给出语言时按提取结果推测；
+// 设置Limit或MaxOutputBytes并由解析器提前结束时不返回编码
+func ExtractWithInfo(filePath string, opts ExtractOptions) ([]byte, ExtractInfo, error) {
+	return extract(filePath, opts, true)
+}
+
+// ExtractFS 与Extract相同，从fsys（如embed.FS、zip.Reader）中读取name对应的文件
+// 解析器实现ReaderParser时直接解析文件内容，否则先写入临时文件再按路径解析；
+// 文件类型按name的后缀识别
+func ExtractFS(fsys fs.FS, name string, opts ExtractOptions) ([]byte, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return []byte{}, fmt.Errorf("打开文件 %s 失败: %w", name, err)
+	}
+	defer file.Close()
+
+	if opts.FileType == 0 {
+		opts.FileType = GetDynamicFileType(name)
+	}
+	parser, err := opts.parser(opts.FileType)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	// PreviewParser、LimitParser基于文件路径，需要提前结束解析时同样写入临时文件
+	_, isPreview := parser.(PreviewParser)
+	_, isLimit := parser.(LimitParser)
+	rp, ok := parser.(ReaderParser)
+	if !ok || (isPreview && opts.Limit > 0) || (isLimit && opts.MaxOutputBytes > 0) {
+		return extractTemp(file, path.Base(name), opts)
+	}
+
+	text, err := rp.ParseReader(file)
+	if err != nil {
+		return text, err
+	}
+	text = TruncateOutput(text, opts.MaxOutputBytes)
+	if opts.Normalize != nil {
+		text = NormalizeOutput(text, *opts.Normalize)
+	}
+	return text, nil
+}
+
+// extractTemp 将r的内容写入临时文件后调用Extract，临时文件保留原文件名的后缀
+func extractTemp(r io.Reader, name string, opts ExtractOptions) ([]byte, error) {
+	tmpFile, err := CreateTemp("fextra_*_" + name)
+	if err != nil {
+		return []byte{}, fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := io.Copy(tmpFile, r); err != nil {
+		tmpFile.Close()
+		return []byte{}, fmt.Errorf("写入临时文件失败: %v", err)
+	}
+	tmpFile.Close()
+
+	return Extract(tmpFile.Name(), opts)
+}
+
+// extract 提取文本，withInfo为true时收集ExtractInfo
+func extract(filePath string, opts ExtractOptions, withInfo bool) ([]byte, ExtractInfo, error) {
+	fileType := opts.FileType
+	if fileType == 0 {
+		fileType = SniffFileType(filePath)
+	}
+
+	var info ExtractInfo
+	parser, err := opts.parser(fileType)
+	if err != nil {
+		return []byte{}, info, err
+	}
+
+	var text []byte
+	var limited bool // 由LimitParser按上限截断
+	pp, isPreview := parser.(PreviewParser)
+	lp, isLimit := parser.(LimitParser)
+	ip, isInfo := parser.(InfoParser)
+	wp, isWriter := parser.(WriterParser)
+	switch {
+	case isPreview && opts.Limit > 0:
+		text, err = pp.ParsePreview(filePath, opts.Limit)
+	case isLimit && opts.MaxOutputBytes > 0:
+		text, err = lp.ParseLimit(filePath, opts.MaxOutputBytes)
+		limited = true
+	case isInfo && withInfo:
+		text, info, err = ip.ParseWithInfo(filePath)
+	case isWriter:
+		text, err = parsePooled(wp, filePath)
+	default:
+		text, err = parser.Parse(filePath)
+	}
+	if withInfo {
+		info.Parser = fmt.Sprintf("%T", parser)
+		info.FileType = FileType(fileType)
+		if info.Language == "" {
+			info.Language = GuessLanguage(text)
+		}
+	}
+	if err != nil {
+		return text, info, err
+	}
+
+	// LimitParser已按上限截断并追加标记，无需再次截断
+	if !limited {
+		text = TruncateOutput(text, opts.MaxOutputBytes)
+	}
+
+	if opts.Normalize != nil {
+		text = NormalizeOutput(text, *opts.Normalize)
+	}
+	return text, info, nil
+}
+
+// parsePooled 由WriterParser写入池中的缓冲区后复制结果：批量提取时缓冲区已增长到较大的容量，
+// 不需要每个文件从头扩容，只为结果分配一次
+func parsePooled(wp WriterParser, filePath string) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	err := wp.ParseTo(filePath, buf)
+	return append([]byte{}, buf.Bytes()...), err
+}
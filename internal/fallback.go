@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"fextra/pkg/logger"
+)
+
+// FallbackParser 组合解析器，按顺序尝试Parsers，返回第一个没有错误且内容非空（去除空白后）的结果，
+// 用于为容易解析失败的格式注册多个方案，如先尝试纯Go解析，再回退到LibreOffice转换：
+//
+//	internal.RegisterParser(internal.FileTypeDOC, internal.NewFallbackParser(&doc.OfficeDocParser{}, &soffice.TextParser{}))
+//
+// 所有解析器均失败时返回合并后的错误（errors.Is可判断其中任一错误）；
+// 有解析器成功但内容为空时视为空文档，返回其结果
+type FallbackParser struct {
+	Parsers []FileParser
+}
+
+// NewFallbackParser 按给定顺序创建组合解析器
+func NewFallbackParser(parsers ...FileParser) *FallbackParser {
+	return &FallbackParser{Parsers: parsers}
+}
+
+func (p *FallbackParser) Parse(filePath string) ([]byte, error) {
+	return p.try(func(parser FileParser) ([]byte, error) {
+		return parser.Parse(filePath)
+	})
+}
+
+// ParsePreview 依次尝试，实现PreviewParser的解析器仅提取前limit个单元，其余完整解析
+func (p *FallbackParser) ParsePreview(filePath string, limit int) ([]byte, error) {
+	return p.try(func(parser FileParser) ([]byte, error) {
+		if pp, ok := parser.(PreviewParser); ok {
+			return pp.ParsePreview(filePath, limit)
+		}
+		return parser.Parse(filePath)
+	})
+}
+
+// ParseLimit 依次尝试，实现LimitParser的解析器提前结束解析，其余完整解析后截断
+func (p *FallbackParser) ParseLimit(filePath string, maxBytes int) ([]byte, error) {
+	return p.try(func(parser FileParser) ([]byte, error) {
+		if lp, ok := parser.(LimitParser); ok {
+			return lp.ParseLimit(filePath, maxBytes)
+		}
+		text, err := parser.Parse(filePath)
+		return TruncateOutput(text, maxBytes), err
+	})
+}
+
+// try 按顺序调用parse，返回第一个非空结果
+func (p *FallbackParser) try(parse func(FileParser) ([]byte, error)) ([]byte, error) {
+	if len(p.Parsers) == 0 {
+		return []byte{}, fmt.Errorf("组合解析器为空: %w", ErrNoParser)
+	}
+
+	var (
+		empty []byte
+		found bool
+		errs  []error
+	)
+	for _, parser := range p.Parsers {
+		text, err := parse(parser)
+		if err != nil {
+			logger.Logger.Printf("解析器%T失败，尝试下一个: %v", parser, err)
+			errs = append(errs, fmt.Errorf("%T: %w", parser, err))
+			continue
+		}
+		if len(bytes.TrimSpace(text)) > 0 {
+			return text, nil
+		}
+		logger.Logger.Printf("解析器%T未提取到内容，尝试下一个", parser)
+		if !found {
+			empty, found = text, true
+		}
+	}
+	if found {
+		return empty, nil
+	}
+	return []byte{}, errors.Join(errs...)
+}
@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// languageSampleSize 推测语言时最多检查的字节数
+const languageSampleSize = 64 * 1024
+
+// ExtractInfo 提取结果的附加信息，便于下游按编码、语言分流处理
+type ExtractInfo struct {
+	Parser   string   // 实际使用的解析器，如 *plaintxt.TextPlainParser
+	FileType FileType // 文件类型
+	Charset  string   // 检测到的源文本编码（如 UTF-8、GB18030），未做编码检测的格式为空
+	Language string   // 推测的语言，ISO 639-1代码（如 zh、ja、en），无法推测时为空
+
+	// Metadata 文档自带的元数据（如Markdown front-matter中的title、tags、date），列表以逗号分隔，没有元数据时为nil
+	Metadata map[string]string
+}
+
+// InfoParser 可选接口，解析器在提取文本的同时返回检测到的编码及语言
+type InfoParser interface {
+	ParseWithInfo(filePath string) ([]byte, ExtractInfo, error)
+}
+
+// scriptLanguages 文字系统到语言的对应关系，拉丁字母文本统一推测为en
+var scriptLanguages = []struct {
+	table    *unicode.RangeTable
+	language string
+}{
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Hangul, "ko"},
+	{unicode.Han, "zh"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Greek, "el"},
+	{unicode.Hebrew, "he"},
+	{unicode.Thai, "th"},
+	{unicode.Latin, "en"},
+}
+
+// GuessLanguage 按文字系统粗略推测UTF-8文本的语言：
+// 出现假名即判断为日文，否则取字符数最多的文字系统
+func GuessLanguage(text []byte) string {
+	if len(text) > languageSampleSize {
+		text = text[:languageSampleSize]
+	}
+
+	counts := make(map[string]int)
+	for len(text) > 0 {
+		r, size := utf8.DecodeRune(text)
+		text = text[size:]
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		for _, s := range scriptLanguages {
+			if unicode.Is(s.table, r) {
+				counts[s.language]++
+				break
+			}
+		}
+	}
+
+	if counts["ja"] > 0 {
+		return "ja"
+	}
+	language, best := "", 0
+	for _, s := range scriptLanguages {
+		if n := counts[s.language]; n > best {
+			language, best = s.language, n
+		}
+	}
+	return language
+}
@@ -0,0 +1,42 @@
+package internal
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// TruncatedMarker 输出超过上限被截断时追加的标记
+const TruncatedMarker = "\n[...truncated...]"
+
+// LimitParser 可选接口，解析器在输出达到上限后提前结束解析（如停止遍历XLSX行、PDF页），
+// 而不是完整提取后再截断
+type LimitParser interface {
+	ParseLimit(filePath string, maxBytes int) ([]byte, error)
+}
+
+// PreviewParser 可选接口，仅提取前limit个单元用于生成预览：PDF、DjVu为页，PPT/PPTX为幻灯片，
+// XLS/XLSX/XLSB为行，DOC/DOCX为段落。达到数量后直接结束遍历
+type PreviewParser interface {
+	ParsePreview(filePath string, limit int) ([]byte, error)
+}
+
+// TruncateOutput 超过maxBytes时截断文本（不拆分UTF-8字符）并追加截断标记
+// maxBytes<=0表示不限制；text已按maxBytes截断（以截断标记结尾）时原样返回
+func TruncateOutput(text []byte, maxBytes int) []byte {
+	if maxBytes <= 0 || len(text) <= maxBytes {
+		return text
+	}
+	if bytes.HasSuffix(text, []byte(TruncatedMarker)) && len(text)-len(TruncatedMarker) <= maxBytes {
+		return text
+	}
+
+	cut := maxBytes
+	// 回退到完整字符的边界
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+
+	truncated := make([]byte, 0, cut+len(TruncatedMarker))
+	truncated = append(truncated, text[:cut]...)
+	return append(truncated, TruncatedMarker...)
+}
@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"bytes"
+	"unicode"
+)
+
+// NormalizeOptions 输出规范化选项
+type NormalizeOptions struct {
+	CollapseBlankLines bool // 将连续多个空行合并为一个空行
+}
+
+// NormalizeOutput 统一各解析器的输出格式：
+// \r\n、\r、\f（pptx/xlsx用于分隔页/表）、\v 统一为 \n，去除每行行尾空白，
+// 去除开头和结尾的空行，可选合并连续空行
+func NormalizeOutput(text []byte, opts NormalizeOptions) []byte {
+	text = bytes.ReplaceAll(text, []byte("\r\n"), []byte("\n"))
+
+	var out bytes.Buffer
+	out.Grow(len(text))
+	blankLines := 0
+	start := 0
+	for i := 0; i <= len(text); i++ {
+		if i < len(text) && !isLineBreak(text[i]) {
+			continue
+		}
+		line := bytes.TrimRightFunc(text[start:i], unicode.IsSpace)
+		start = i + 1
+
+		if len(line) == 0 {
+			blankLines++
+			continue
+		}
+
+		// 写入此前累积的空行，开头的空行直接丢弃
+		if out.Len() > 0 {
+			if opts.CollapseBlankLines && blankLines > 1 {
+				blankLines = 1
+			}
+			out.WriteByte('\n')
+			for j := 0; j < blankLines; j++ {
+				out.WriteByte('\n')
+			}
+		}
+		blankLines = 0
+		out.Write(line)
+	}
+
+	if out.Len() > 0 {
+		out.WriteByte('\n')
+	}
+	return out.Bytes()
+}
+
+// isLineBreak 判断是否为换行类控制字符
+func isLineBreak(c byte) bool {
+	return c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
@@ -0,0 +1,29 @@
+package internal
+
+import "sync/atomic"
+
+// OCREngine 文字识别引擎，img为完整的图片文件内容（JPEG、PNG、TIFF等），返回识别出的文本。
+// 本包不内置任何OCR实现，由调用方通过SetOCREngine注册（如封装tesseract或在线识别服务）；
+// 引擎可能被多个协程同时调用
+type OCREngine interface {
+	Image(img []byte) (string, error)
+}
+
+var ocrEngine atomic.Pointer[OCREngine] // 为nil时不做OCR
+
+// SetOCREngine 设置OCR引擎，设置后图片文件及没有文本层的PDF（扫描件）逐张图片识别文字；为nil时关闭OCR
+func SetOCREngine(e OCREngine) {
+	if e == nil {
+		ocrEngine.Store(nil)
+		return
+	}
+	ocrEngine.Store(&e)
+}
+
+// OCR 返回SetOCREngine设置的OCR引擎，未设置时返回nil
+func OCR() OCREngine {
+	if e := ocrEngine.Load(); e != nil {
+		return *e
+	}
+	return nil
+}
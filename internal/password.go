@@ -0,0 +1,18 @@
+package internal
+
+import "sync/atomic"
+
+var password atomic.Pointer[string] // 为nil时未设置密码
+
+// SetPassword 设置打开加密文档（设置了打开密码的docx、xlsx、pptx、xlsb）使用的密码，对所有格式生效
+func SetPassword(s string) {
+	password.Store(&s)
+}
+
+// Password 返回SetPassword设置的密码，未设置时返回false
+func Password() (string, bool) {
+	if s := password.Load(); s != nil {
+		return *s, true
+	}
+	return "", false
+}
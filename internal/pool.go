@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBufferSize 超过该容量的缓冲区不再放回池中，避免个别大文件长期占用内存
+const maxPooledBufferSize = 4 << 20
+
+// Resetter 可复用的解析状态，Reset清空上一个文件的数据但保留已分配的内存
+type Resetter interface {
+	Reset()
+}
+
+// Pool 基于sync.Pool的解析状态对象池，批量处理文件时避免每个文件重新分配
+type Pool[T Resetter] struct {
+	pool sync.Pool
+}
+
+// NewPool 创建对象池，newFn用于池为空时创建新对象
+func NewPool[T Resetter](newFn func() T) *Pool[T] {
+	return &Pool[T]{pool: sync.Pool{New: func() any { return newFn() }}}
+}
+
+// Get 从池中取出对象
+func (p *Pool[T]) Get() T {
+	return p.pool.Get().(T)
+}
+
+// Put 重置对象后放回池中
+func (p *Pool[T]) Put(v T) {
+	v.Reset()
+	p.pool.Put(v)
+}
+
+var bufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// getBuffer 从池中取出一个空的bytes.Buffer，用完后调用putBuffer归还
+// 归还后缓冲区内容会被复用，需要保留的结果应先通过bytes.Clone复制
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer 归还getBuffer取出的缓冲区
+func putBuffer(b *bytes.Buffer) {
+	if b.Cap() > maxPooledBufferSize {
+		return
+	}
+	b.Reset()
+	bufferPool.Put(b)
+}
@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// benchFileType 基准测试注册解析器使用的文件类型，不与内置类型冲突
+const (
+	benchFileTypeParse  = 9001
+	benchFileTypeWriter = 9002
+)
+
+// benchChunk 模拟逐页、逐行输出的解析器，每个文件共输出256KB
+var benchChunk = bytes.Repeat([]byte("0123456789abcdef"), 256)
+
+const benchChunks = 64
+
+// bufferParser 只实现Parse，每次在新的缓冲区中拼接输出
+type bufferParser struct{}
+
+func (bufferParser) Parse(string) ([]byte, error) {
+	var buf bytes.Buffer
+	for i := 0; i < benchChunks; i++ {
+		buf.Write(benchChunk)
+	}
+	return buf.Bytes(), nil
+}
+
+// writerParser 实现WriterParser，由Extract使用池中的缓冲区接收输出
+type writerParser struct{ bufferParser }
+
+func (writerParser) ParseTo(_ string, w io.Writer) error {
+	for i := 0; i < benchChunks; i++ {
+		if _, err := w.Write(benchChunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	RegisterParser(benchFileTypeParse, bufferParser{})
+	RegisterParser(benchFileTypeWriter, writerParser{})
+}
+
+// BenchmarkExtractAllocs 对比解析器自行拼接输出与Extract使用池中缓冲区时每个文件的分配
+func BenchmarkExtractAllocs(b *testing.B) {
+	for _, tc := range []struct {
+		name     string
+		fileType int
+	}{
+		{"Parse", benchFileTypeParse},
+		{"ParseTo", benchFileTypeWriter},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				text, err := Extract("bench.bin", ExtractOptions{FileType: tc.fileType})
+				if err != nil || len(text) != benchChunks*len(benchChunk) {
+					b.Fatalf("got %d bytes, %v", len(text), err)
+				}
+			}
+		})
+	}
+}
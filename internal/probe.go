@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// probeSize Probe读取的文件头长度
+const probeSize = 1024
+
+// ProbeResult 提取前的文件概况，用于在解析前按大小、类型拒绝文件
+type ProbeResult struct {
+	FileType  FileType // 按文件名识别的文件类型，与Extract选择解析器时一致
+	SizeBytes int64    // 文件大小
+	Magic     string   // 按文件头识别的格式，如zip、ole、pdf、gzip，无法识别时为空
+	MIME      string   // 按文件头推测的MIME类型，见net/http.DetectContentType
+	IsArchive bool     // 文件类型为压缩包（zip、tar、gz、7z等，不含docx等基于ZIP的文档）
+}
+
+// fileMagics 文件头标识，按顺序匹配
+var fileMagics = []struct {
+	name   string
+	offset int
+	magic  []byte
+}{
+	{"zip", 0, zipMagic},
+	{"zip", 0, []byte("PK\x05\x06")}, // 空ZIP
+	{"ole", 0, oleMagic},
+	{"pdf", 0, []byte("%PDF-")},
+	{"gzip", 0, []byte{0x1F, 0x8B}},
+	{"bzip2", 0, []byte("BZh")},
+	{"xz", 0, []byte{0xFD, '7', 'z', 'X', 'Z', 0x00}},
+	{"zstd", 0, []byte{0x28, 0xB5, 0x2F, 0xFD}},
+	{"lz4", 0, []byte{0x04, 0x22, 0x4D, 0x18}},
+	{"snappy", 0, []byte("\xFF\x06\x00\x00sNaPpY")},
+	{"7z", 0, []byte{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C}},
+	{"rar", 0, []byte("Rar!\x1A\x07")},
+	{"tar", 257, []byte("ustar")},
+	{"rtf", 0, []byte(`{\rtf`)},
+	{"djvu", 0, []byte("AT&TFORM")},
+	{"png", 0, []byte("\x89PNG\r\n\x1A\n")},
+	{"jpeg", 0, []byte{0xFF, 0xD8, 0xFF}},
+	{"tiff", 0, []byte("II*\x00")},
+	{"tiff", 0, []byte("MM\x00*")},
+	{"bmp", 0, []byte("BM")},
+}
+
+// archiveTypes 压缩包类文件类型
+var archiveTypes = map[int]bool{
+	FileTypeTAR:    true,
+	FileTypeGZ:     true,
+	FileTypeTARGZ:  true,
+	FileTypeZIP:    true,
+	FileType7Z:     true,
+	FileTypeRAR:    true,
+	FileTypeBZ2:    true,
+	FileTypeJAR:    true,
+	FileTypeWAR:    true,
+	FileTypeARJ:    true,
+	FileTypeLZH:    true,
+	FileTypeXZ:     true,
+	FileTypeTARBZ2: true,
+	FileTypeTARXZ:  true,
+	FileTypeZST:    true,
+	FileTypeLZ4:    true,
+	FileTypeSNAPPY: true,
+	30:             true, // 其他压缩文件类
+}
+
+// Probe 只读取文件信息及前1KB，返回文件类型、大小及文件头识别结果，不解析文件内容，
+// 供调用方在Extract之前按大小或类型拒绝文件
+// 启用宏的Office文档（如.docm）识别类型时会读取ZIP目录，与Extract一致（见SniffFileType）
+func Probe(filePath string) (ProbeResult, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return ProbeResult{}, fmt.Errorf("无法打开文件: %w", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return ProbeResult{}, fmt.Errorf("读取文件信息失败: %w", err)
+	}
+	if stat.IsDir() {
+		return ProbeResult{}, fmt.Errorf("%s 是目录", filePath)
+	}
+
+	head := make([]byte, probeSize)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return ProbeResult{}, fmt.Errorf("读取文件头失败: %w", err)
+	}
+	head = head[:n]
+
+	fileType := SniffFileType(filePath)
+	result := ProbeResult{
+		FileType:  FileType(fileType),
+		SizeBytes: stat.Size(),
+		Magic:     detectMagic(head),
+		MIME:      http.DetectContentType(head),
+		IsArchive: IsArchiveType(fileType),
+	}
+	return result, nil
+}
+
+// IsArchiveType 文件类型是否为压缩包（zip、tar、gz、7z等，不含docx等基于ZIP的文档）
+func IsArchiveType(fileType int) bool {
+	return archiveTypes[fileType]
+}
+
+// detectMagic 按文件头识别格式，无法识别时返回空字符串
+func detectMagic(head []byte) string {
+	for _, m := range fileMagics {
+		if len(head) >= m.offset+len(m.magic) && bytes.Equal(head[m.offset:m.offset+len(m.magic)], m.magic) {
+			return m.name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"bytes"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// DefaultReplacement 无法解码的字符默认替换为U+FFFD
+const DefaultReplacement = "\uFFFD"
+
+var replacement atomic.Pointer[string] // 为nil时使用DefaultReplacement
+
+// SetReplacement 设置无法解码的字符（无效的GBK、UTF-16、UTF-8序列）统一替换的文本，对所有格式生效：
+// DefaultReplacement保留U+FFFD，空字符串直接删除，其他字符串原样替换（如"?"）
+func SetReplacement(s string) {
+	replacement.Store(&s)
+}
+
+// Replacement 返回SetReplacement设置的替换文本，未设置时返回DefaultReplacement
+func Replacement() string {
+	if s := replacement.Load(); s != nil {
+		return *s
+	}
+	return DefaultReplacement
+}
+
+// ReplaceInvalid 将解码结果中的U+FFFD及无效的UTF-8序列替换为Replacement，
+// 用于解码器已输出U+FFFD或解码失败时按原始字节返回的文本；连续的无效字节只替换一次
+func ReplaceInvalid(s string) string {
+	repl := Replacement()
+	if repl == DefaultReplacement {
+		if utf8.ValidString(s) {
+			return s
+		}
+		return strings.ToValidUTF8(s, repl)
+	}
+	return strings.ReplaceAll(strings.ToValidUTF8(s, DefaultReplacement), DefaultReplacement, repl)
+}
+
+// ReplaceInvalidBytes 与ReplaceInvalid相同，作用于[]byte
+func ReplaceInvalidBytes(b []byte) []byte {
+	repl := Replacement()
+	if repl == DefaultReplacement {
+		if utf8.Valid(b) {
+			return b
+		}
+		return bytes.ToValidUTF8(b, []byte(repl))
+	}
+	return bytes.ReplaceAll(bytes.ToValidUTF8(b, []byte(DefaultReplacement)), []byte(DefaultReplacement), []byte(repl))
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// 分节输出的节类型
+const (
+	SectionSheet = "工作表"
+	SectionSlide = "幻灯片"
+)
+
+// SectionFormatter 分节输出（电子表格的工作表、演示文稿的幻灯片）的节标题及节分隔，
+// 所有格式统一按此输出，下游可据此切分各节而无需区分来源格式
+type SectionFormatter struct {
+	// Header 节标题模板（含换行），{kind}、{index}、{name}替换为节类型、从1开始的编号、节名称；
+	// 幻灯片没有名称时名称为编号。为空时不输出节标题
+	Header string
+
+	// Separator 相邻两节之间输出的分隔，最后一节之后不输出
+	Separator string
+
+	// PageSeparator 分页格式（PDF、DjVu）相邻两页之间输出的分隔，最后一页之后不输出
+	PageSeparator string
+}
+
+// DefaultSectionFormatter 默认的分节格式，节之间与PDF等格式的分页一致使用换页符
+var DefaultSectionFormatter = SectionFormatter{
+	Header:        "=== {kind}: {name} ===\n",
+	Separator:     "\n\f\n",
+	PageSeparator: "\f",
+}
+
+var sectionFormatter atomic.Pointer[SectionFormatter] // 为nil时使用DefaultSectionFormatter
+
+// SetSectionFormatter 设置所有格式分节输出使用的格式
+func SetSectionFormatter(f SectionFormatter) {
+	sectionFormatter.Store(&f)
+}
+
+// Sections 返回SetSectionFormatter设置的分节格式，未设置时返回DefaultSectionFormatter
+func Sections() SectionFormatter {
+	if f := sectionFormatter.Load(); f != nil {
+		return *f
+	}
+	return DefaultSectionFormatter
+}
+
+// FormatHeader 返回第index个节的标题，name为空时使用编号
+func (f SectionFormatter) FormatHeader(kind string, index int, name string) string {
+	if f.Header == "" {
+		return ""
+	}
+	if name == "" {
+		name = strconv.Itoa(index)
+	}
+	return strings.NewReplacer("{kind}", kind, "{index}", strconv.Itoa(index), "{name}", name).Replace(f.Header)
+}
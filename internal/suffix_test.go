@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestRegisterSuffixConcurrent 注册后缀可以与按文件名判断类型并发进行（配合-race运行）
+func TestRegisterSuffixConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				RegisterSuffix(fmt.Sprintf("test%d.%d", i, j), FileTypeTXT)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				GetDynamicFileType("report.tar.gz")
+				GetSuffixType("docx")
+				typeSuffix(FileTypeTXT)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := GetDynamicFileType("a.test0.99"); got != FileTypeTXT {
+		t.Errorf("registered compound suffix: got %v", FileType(got))
+	}
+}
+
+// TestRegisterSuffixOverridesBuiltin 注册的后缀优先于启用宏的Office文档、WPS文档及.xml的内置判断
+func TestRegisterSuffixOverridesBuiltin(t *testing.T) {
+	for _, ext := range []string{"wps", "docm", "xml"} {
+		suffixMu.RLock()
+		old, existed := suffixMap[ext]
+		suffixMu.RUnlock()
+		t.Cleanup(func() {
+			suffixMu.Lock()
+			defer suffixMu.Unlock()
+			delete(registeredSuffixes, ext)
+			if existed {
+				suffixMap[ext] = old
+			} else {
+				delete(suffixMap, ext)
+			}
+		})
+		RegisterSuffix(ext, FileTypeTXT)
+	}
+
+	flatOPC := []byte(`<pkg:package xmlns:pkg="` + FlatOPCNamespace + `"/>`)
+	for _, tt := range []struct {
+		name string
+		data []byte
+	}{
+		{"a.wps", oleMagic},
+		{"a.docm", zipMagic},
+		{"a.xml", flatOPC},
+	} {
+		if got := GetDynamicFileType(tt.name); got != FileTypeTXT {
+			t.Errorf("GetDynamicFileType(%s): got %v", tt.name, FileType(got))
+		}
+		if got := SniffFileTypeData(tt.name, tt.data); got != FileTypeTXT {
+			t.Errorf("SniffFileTypeData(%s): got %v", tt.name, FileType(got))
+		}
+	}
+	// 未注册的内置特殊后缀不受影响
+	if got := GetDynamicFileType("a.et"); got != FileTypeXLS {
+		t.Errorf("GetDynamicFileType(a.et): got %v", FileType(got))
+	}
+}
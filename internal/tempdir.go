@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"os"
+	"sync/atomic"
+)
+
+var (
+	tempDir       atomic.Value // string，为空时使用系统临时目录
+	inMemoryLimit atomic.Int64
+)
+
+// SetTempDir 设置解压压缩包、写入临时文件使用的目录，为空时使用系统临时目录（$TMPDIR）
+// 适用于系统临时目录只读或空间受限的容器环境
+func SetTempDir(dir string) {
+	tempDir.Store(dir)
+}
+
+// TempDir 返回SetTempDir设置的临时目录，未设置时返回空字符串
+func TempDir() string {
+	dir, _ := tempDir.Load().(string)
+	return dir
+}
+
+// MkdirTemp 在临时目录下创建新目录，用法同os.MkdirTemp
+func MkdirTemp(pattern string) (string, error) {
+	return os.MkdirTemp(TempDir(), pattern)
+}
+
+// CreateTemp 在临时目录下创建新文件，用法同os.CreateTemp
+func CreateTemp(pattern string) (*os.File, error) {
+	return os.CreateTemp(TempDir(), pattern)
+}
+
+// SetInMemoryLimit 设置压缩包在内存中解压的大小上限（字节）：tar/gz/bz2/xz/zst/lz4/sz解压后的总大小不超过上限时
+// 直接在内存中解析各条目，不写入磁盘；超过上限时仍解压到临时目录。为0时关闭内存解压
+// 仅依赖文件路径的解析器（如PDF、7z）解析条目时仍需写入临时文件
+func SetInMemoryLimit(limit int64) {
+	inMemoryLimit.Store(max(limit, 0))
+}
+
+// InMemoryLimit 返回内存解压的大小上限，为0表示关闭
+func InMemoryLimit() int64 {
+	return inMemoryLimit.Load()
+}
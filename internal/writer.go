@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+)
+
+// WriterParser 可选接口，边解析边将文本写入w（如逐页、逐行、逐个压缩包条目），
+// 不在内存中保留完整的提取结果，适用于超大文档及压缩包；写入的内容与Parse的返回值一致
+type WriterParser interface {
+	ParseTo(filePath string, w io.Writer) error
+}
+
+// ExtractTo 与Extract相同，结果写入w
+// 解析器实现WriterParser且未设置Limit、MaxOutputBytes、Normalize时流式写入，否则先完整提取再写入
+func ExtractTo(filePath string, w io.Writer, opts ExtractOptions) error {
+	fileType := opts.FileType
+	if fileType == 0 {
+		fileType = SniffFileType(filePath)
+	}
+	parser, err := opts.parser(fileType)
+	if err != nil {
+		return err
+	}
+
+	if wp, ok := parser.(WriterParser); ok && opts.Limit <= 0 && opts.MaxOutputBytes <= 0 && opts.Normalize == nil {
+		return wp.ParseTo(filePath, w)
+	}
+
+	opts.FileType = fileType
+	text, err := Extract(filePath, opts)
+	if len(text) > 0 {
+		if _, werr := w.Write(text); werr != nil {
+			return fmt.Errorf("写入输出失败: %w", werr)
+		}
+	}
+	return err
+}
@@ -0,0 +1,31 @@
+// Package ziptest 在内存中构造ZIP包，供docx/pptx/xlsx/odt等基于ZIP的格式的测试使用，
+// 不依赖二进制样本文件
+package ziptest
+
+import (
+	"archive/zip"
+	"bytes"
+)
+
+// File ZIP中的一个条目
+type File struct {
+	Name string
+	Data string
+}
+
+// Build 按顺序写入files并返回ZIP的内容，条目使用deflate压缩
+func Build(files []File) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			panic(err)
+		}
+		w.Write([]byte(f.Data))
+	}
+	if err := zw.Close(); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
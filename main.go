@@ -1,31 +1,98 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"fextra/internal"
-	_ "fextra/pkg/compressfile"
+	"fextra/pkg/compressfile"
+	_ "fextra/pkg/imagefile"
 	"fextra/pkg/logger"
 	_ "fextra/pkg/office"
+	"fextra/pkg/office/soffice"
 	_ "fextra/pkg/plaintext"
 )
 
 var (
 	InputFile     string
+	OutputFile    string
 	FileType      int
+	FileTypeName  string
 	Verbose       bool
 	DetailVerbose bool
+	Normalize     bool
+	CollapseBlank bool
+	MaxOutput     int
+	Limit         int
+	ShowInfo      bool
+	Detect        bool
+	TempDir       string
+	InMemory      int64
+	JSONOutput    bool
+	Dedup         bool
+	Soffice       string
+	SofficeTime   time.Duration
+	Replacement   string
+	Strict        bool
+	ProbeOnly     bool
+	IncludeTypes  string
+	SectionHeader string
+	SectionSep    string
+	PageSep       string
+	DumpPart      string
+	Volumes       string
+	MemberSep     string
 )
 
+// jsonResult -json输出的结果
+type jsonResult struct {
+	File     string            `json:"file"`
+	Type     string            `json:"type"`
+	Parser   string            `json:"parser"`
+	Charset  string            `json:"charset,omitempty"`
+	Language string            `json:"language,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Size     int               `json:"size"`
+	Text     string            `json:"text"`
+	Error    string            `json:"error,omitempty"`
+}
+
 func main() {
 	flag.StringVar(&InputFile, "i", "", "input file")
-	flag.IntVar(&FileType, "t", 0, "file type")
+	flag.StringVar(&OutputFile, "o", "", "write the extracted text to this file, streaming where the parser supports it")
+	flag.StringVar(&FileTypeName, "t", "", "file type, number or name (e.g. 8 or docx)")
 	flag.BoolVar(&Verbose, "v", false, "verbose")
 	flag.BoolVar(&DetailVerbose, "vv", false, "detail verbose")
+	flag.BoolVar(&Normalize, "normalize", false, "normalize line endings and trailing whitespace")
+	flag.BoolVar(&CollapseBlank, "collapse", false, "collapse blank-line runs (implies -normalize)")
+	flag.IntVar(&MaxOutput, "max", 0, "max output bytes per file, 0 for unlimited")
+	flag.BoolVar(&ShowInfo, "info", false, "print parser, detected charset and guessed language")
+	flag.IntVar(&Limit, "limit", 0, "extract only the first N pages/slides/rows/paragraphs, 0 for unlimited")
+	flag.BoolVar(&Detect, "detect", false, "print detected file type, parser and top-level entries without extracting")
+	flag.BoolVar(&ProbeOnly, "probe", false, "print file type, size and detected magic/MIME without parsing")
+	flag.StringVar(&DumpPart, "dump", "", "write the raw bytes of the named OLE stream or ZIP entry (e.g. xl/calcChain.xml) to stdout or -o")
+	flag.StringVar(&Volumes, "volumes", "", "comma-separated paths of all volumes of a split archive in order, when they are not in the same directory or not named like .z01/.001/part2.rar")
+	flag.StringVar(&TempDir, "tmpdir", "", "directory for temporary files when extracting archives, default $TMPDIR")
+	flag.BoolVar(&JSONOutput, "json", false, "print the result as a JSON object")
+	flag.BoolVar(&Strict, "strict", false, "fail on file types without a registered parser instead of printing the raw bytes")
+	flag.BoolVar(&Dedup, "dedup", false, "skip archive members whose content duplicates an earlier member")
+	flag.StringVar(&IncludeTypes, "include", "", "comma-separated file types (e.g. docx,pdf) to parse inside archives, other members are only listed")
+	flag.StringVar(&Soffice, "soffice", "", "LibreOffice binary (e.g. soffice) used to convert formats such as VSD, empty to disable")
+	flag.DurationVar(&SofficeTime, "soffice-timeout", soffice.DefaultTimeout, "timeout for a single LibreOffice conversion")
+	flag.StringVar(&Replacement, "replacement", internal.DefaultReplacement, "text substituted for undecodable characters, empty to drop them")
+	flag.StringVar(&SectionHeader, "section-header", `=== {kind}: {name} ===\n`, "header of each sheet/slide section, {kind}, {index} and {name} are substituted, Go escapes allowed")
+	flag.StringVar(&SectionSep, "section-sep", `\n\f\n`, "separator written between sheet/slide sections, Go escapes allowed")
+	flag.StringVar(&PageSep, "page-sep", `\f`, "separator written between PDF/DjVu pages, Go escapes allowed")
+	flag.StringVar(&MemberSep, "member-sep", `\n\n`, "separator written after the text of each archive member, Go escapes allowed")
+	flag.Int64Var(&InMemory, "inmem", 0, "extract tar/gz/bz2/xz/zst/lz4/sz in memory when the decompressed size is at most N bytes, 0 to disable")
 
 	flag.Parse()
 	if InputFile == "" {
@@ -33,31 +100,103 @@ func main() {
 		return
 	}
 
+	// 输出JSON时日志写入标准错误，保证标准输出只有JSON对象
+	var logOut io.Writer = os.Stdout
+	if JSONOutput {
+		logOut = os.Stderr
+	}
 	if DetailVerbose {
 		// 启用常规日志输出到控制台
-		logger.SetLogger(log.New(os.Stdout, "[Fextra Logger] ", log.LstdFlags))
+		logger.SetLogger(log.New(logOut, "[Fextra Logger] ", log.LstdFlags))
 		// 启用调试日志
-		logger.SetDebugLogger(log.New(os.Stdout, "[Fextra Logger Debug] ", log.LstdFlags))
+		logger.SetDebugLogger(log.New(logOut, "[Fextra Logger Debug] ", log.LstdFlags))
 	} else if Verbose {
 		// 启用常规日志输出到控制台
-		logger.SetLogger(log.New(os.Stdout, "[Fextra Logger] ", log.LstdFlags))
+		logger.SetLogger(log.New(logOut, "[Fextra Logger] ", log.LstdFlags))
 		// 启用调试日志
 		logger.DebugLogger = log.New(io.Discard, "", 0)
 	}
 
+	internal.SetTempDir(TempDir)
+	internal.SetInMemoryLimit(InMemory)
+	internal.SetReplacement(Replacement)
+	compressfile.SetDedup(Dedup)
+	if IncludeTypes != "" {
+		types, err := parseFileTypes(IncludeTypes)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		compressfile.SetIncludeTypes(types)
+	}
+	memberSep, err := strconv.Unquote(`"` + MemberSep + `"`)
+	if err != nil {
+		fmt.Printf("无效的-member-sep %q: %v\n", MemberSep, err)
+		return
+	}
+	compressfile.SetMemberSeparator(memberSep)
+	if Volumes != "" {
+		compressfile.SetVolumes(strings.Split(Volumes, ","))
+	}
+	soffice.SetBinary(Soffice)
+	soffice.SetTimeout(SofficeTime)
+	sections, err := parseSectionFormatter(SectionHeader, SectionSep, PageSep)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	internal.SetSectionFormatter(sections)
+
+	if Detect {
+		describe(InputFile)
+		return
+	}
+	if ProbeOnly {
+		probe(InputFile)
+		return
+	}
+	if DumpPart != "" {
+		dumpPart(InputFile, DumpPart, OutputFile)
+		return
+	}
+
+	if FileTypeName != "" {
+		t, err := parseFileType(FileTypeName)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		FileType = t
+	}
+
 	if FileType == 0 {
 		// 动态获取文件类型
-		FileType = internal.GetDynamicFileType(InputFile)
+		FileType = internal.SniffFileType(InputFile)
 	}
 
-	parser, err := internal.GetParser(FileType)
-	if err != nil {
-		fmt.Println(err)
+	logger.Logger.Printf("文件类型: %s", internal.FileType(FileType))
+	opts := internal.ExtractOptions{FileType: FileType, MaxOutputBytes: MaxOutput, Limit: Limit, Strict: Strict}
+	if Normalize || CollapseBlank {
+		opts.Normalize = &internal.NormalizeOptions{CollapseBlankLines: CollapseBlank}
+	}
+
+	if OutputFile != "" && !JSONOutput {
+		extractToFile(InputFile, OutputFile, opts)
 		return
 	}
 
-	text, err := parser.Parse(InputFile)
-	if err != nil {
+	text, info, err := internal.ExtractWithInfo(InputFile, opts)
+	if JSONOutput {
+		printJSON(InputFile, text, info, err)
+		return
+	}
+	if ShowInfo {
+		fmt.Printf("parser[%s], type[%s], charset[%s], language[%s]\n", info.Parser, info.FileType, info.Charset, info.Language)
+	}
+	if errors.Is(err, internal.ErrPartialArchive) {
+		// 压缩包不完整时仍输出已读取条目的内容
+		fmt.Printf("警告:%v\n", err)
+	} else if err != nil {
 		logger.Logger.Printf("content[%d]:\n%s\n", len(text), string(text))
 		fmt.Printf("文本解析失败:%v\n", err)
 		return
@@ -66,3 +205,139 @@ func main() {
 	logger.Logger.Printf("content:\n%s\n", string(text))
 	fmt.Printf("file[%s], size[%d]\n", InputFile, len(text))
 }
+
+// extractToFile 将提取结果写入outPath，解析器支持时边解析边写入，不在内存中保留完整文本
+func extractToFile(filePath string, outPath string, opts internal.ExtractOptions) {
+	out, err := os.Create(outPath)
+	if err != nil {
+		fmt.Printf("创建输出文件失败:%v\n", err)
+		return
+	}
+	err = internal.ExtractTo(filePath, out, opts)
+	if cerr := out.Close(); err == nil && cerr != nil {
+		err = cerr
+	}
+	if errors.Is(err, internal.ErrPartialArchive) {
+		fmt.Printf("警告:%v\n", err)
+	} else if err != nil {
+		fmt.Printf("文本解析失败:%v\n", err)
+		return
+	}
+
+	var size int64
+	if fi, err := os.Stat(outPath); err == nil {
+		size = fi.Size()
+	}
+	fmt.Printf("file[%s], output[%s], size[%d]\n", filePath, outPath, size)
+}
+
+// printJSON 以JSON对象输出提取结果，解析失败时在error字段给出原因
+func printJSON(filePath string, text []byte, info internal.ExtractInfo, err error) {
+	result := jsonResult{
+		File:     filePath,
+		Type:     info.FileType.String(),
+		Parser:   info.Parser,
+		Charset:  info.Charset,
+		Language: info.Language,
+		Metadata: info.Metadata,
+		Size:     len(text),
+		Text:     string(text),
+	}
+	if err != nil {
+		result.Error = strings.TrimSpace(err.Error())
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(result); err != nil {
+		fmt.Printf("输出JSON失败:%v\n", err)
+	}
+}
+
+// probe 输出文件类型、大小及文件头识别结果
+func probe(filePath string) {
+	result, err := internal.Probe(filePath)
+	if err != nil {
+		fmt.Printf("识别失败:%v\n", err)
+		return
+	}
+	fmt.Printf("file[%s], type[%s(%d)], size[%d], magic[%s], mime[%s], archive[%t]\n", filePath,
+		result.FileType, int(result.FileType), result.SizeBytes, result.Magic, result.MIME, result.IsArchive)
+}
+
+// dumpPart 将OLE流或ZIP条目的原始内容写入outPath，outPath为空时写入标准输出
+func dumpPart(filePath string, partName string, outPath string) {
+	data, err := internal.DumpPart(filePath, partName)
+	if err != nil {
+		fmt.Printf("读取部件失败:%v\n", err)
+		return
+	}
+	if outPath == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(outPath, data, 0644); err != nil {
+		fmt.Printf("写入输出文件失败:%v\n", err)
+		return
+	}
+	fmt.Printf("file[%s], part[%s], output[%s], size[%d]\n", filePath, partName, outPath, len(data))
+}
+
+// describe 输出文件的识别类型、解析器及顶层条目
+func describe(filePath string) {
+	desc, err := internal.Describe(filePath)
+	fmt.Printf("file[%s], type[%s(%d)], parser[%s]", filePath, desc.FileType, int(desc.FileType), desc.Parser)
+	if !desc.Registered {
+		fmt.Printf(" (类型未注册解析器)")
+	}
+	fmt.Println()
+	for _, entry := range desc.Entries {
+		fmt.Printf("  %s\n", entry)
+	}
+	if err != nil {
+		fmt.Printf("识别失败:%v\n", err)
+	}
+}
+
+// parseFileType 解析文件类型编号或名称（如8、docx）
+func parseFileType(s string) (int, error) {
+	if t, err := strconv.Atoi(s); err == nil {
+		return t, nil
+	}
+	if t, ok := internal.ParseFileType(s); ok {
+		return t, nil
+	}
+	return 0, fmt.Errorf("未知的文件类型: %s", s)
+}
+
+// parseSectionFormatter 解析-section-header、-section-sep、-page-sep，均可使用Go字符串转义（如\n、\f）
+func parseSectionFormatter(header, separator, pageSeparator string) (internal.SectionFormatter, error) {
+	var f internal.SectionFormatter
+	var err error
+	if f.Header, err = strconv.Unquote(`"` + header + `"`); err != nil {
+		return f, fmt.Errorf("无效的-section-header %q: %w", header, err)
+	}
+	if f.Separator, err = strconv.Unquote(`"` + separator + `"`); err != nil {
+		return f, fmt.Errorf("无效的-section-sep %q: %w", separator, err)
+	}
+	if f.PageSeparator, err = strconv.Unquote(`"` + pageSeparator + `"`); err != nil {
+		return f, fmt.Errorf("无效的-page-sep %q: %w", pageSeparator, err)
+	}
+	return f, nil
+}
+
+// parseFileTypes 解析以逗号分隔的文件类型列表
+func parseFileTypes(s string) ([]int, error) {
+	var types []int
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		t, err := parseFileType(name)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
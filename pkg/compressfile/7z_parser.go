@@ -1,49 +1,228 @@
 package compressfile
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 
 	"fextra/internal"
 	"fextra/pkg/logger"
 
+	"github.com/bodgit/sevenzip"
 	"github.com/gen2brain/go-unarr"
 )
 
-type SevenZFileParser struct{}
+// SevenZFileParser 7z文件解析器
+// 默认使用go-unarr解压，失败时（如固实压缩、加密文件）回退到sevenzip原生实现
+type SevenZFileParser struct {
+	Password string // 加密7z文件的密码，设置后直接使用sevenzip解压
+}
 
 func (p *SevenZFileParser) Parse(filePath string) ([]byte, error) {
+	return bufferOutput(func(w io.Writer) error { return p.ParseTo(filePath, w) })
+}
+
+// ParseTo 解压到临时目录后逐个文件解析写入w
+func (p *SevenZFileParser) ParseTo(filePath string, w io.Writer) error {
+	// 创建临时目录
+	tmpDir, err := internal.MkdirTemp("7z_extract_")
+	if err != nil {
+		return fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(tmpDir) // 确保程序退出时清理临时目录
+	logger.Logger.Printf("临时目录: %s", tmpDir)
+
+	_, extractErr := extractSevenZ(filePath, tmpDir, p.Password)
+	// 遍历临时目录并提取所有文件内容
+	cnt, err := walkExtractedTo(tmpDir, w, extractErr)
+	if err != nil {
+		return err
+	}
+
+	logger.Logger.Printf("7z文件解析完成，共提取 %d 个文件(一级目录)", cnt)
+	return nil
+}
+
+// extract7z 使用go-unarr将7z/rar文件解压到destDir，返回解压出的文件路径
+// go-unarr的Extract（ReadAll）忽略解压失败，遇到不支持的编码方法时写出全0的内容，
+// 因此逐个条目一次读取全部内容并检查结果，失败时返回错误以便回退到sevenzip
+func extract7z(filePath string, destDir string) ([]string, error) {
 	// 打开7z文件
 	archive, err := unarr.NewArchive(filePath)
 	if err != nil {
-		return []byte{}, fmt.Errorf("无法打开7z文件: %v", err)
+		return nil, fmt.Errorf("无法打开7z文件: %v", err)
 	}
 	defer archive.Close()
 
 	logger.Logger.Printf("提取7z文件: %s", filePath)
+	var extracted []string
+	for {
+		if err := archive.Entry(); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return extracted, fmt.Errorf("提取7z文件失败: %v", err)
+		}
 
-	// 创建临时目录
-	tmpDir, err := os.MkdirTemp("", "7z_extract_")
+		name := archive.Name()
+		data := make([]byte, archive.Size())
+		if len(data) > 0 {
+			if _, err := archive.Read(data); err != nil {
+				return extracted, fmt.Errorf("解压 %s 失败: %w", name, err)
+			}
+		}
+
+		// 防止路径遍历攻击
+		safePath := filepath.Join(destDir, sanitizePath(name))
+		if err := os.MkdirAll(filepath.Dir(safePath), 0755); err != nil {
+			return extracted, fmt.Errorf("创建目录失败 %s: %v", safePath, err)
+		}
+		if err := os.WriteFile(safePath, data, 0644); err != nil {
+			return extracted, fmt.Errorf("写入文件 %s 失败: %v", safePath, err)
+		}
+		extracted = append(extracted, safePath)
+	}
+	logger.Logger.Printf("7z文件提取完成，共提取 %d 个文件", len(extracted))
+	return extracted, nil
+}
+
+// extractSevenZ 优先使用go-unarr解压，7z文件失败时回退到sevenzip原生实现；
+// RAR分卷使用rardecode依次读取各分卷，按字节切分的分卷先拼接为完整的压缩包
+func extractSevenZ(filePath string, destDir string, password string) ([]string, error) {
+	if volumes := findVolumes(filePath); volumes != nil {
+		if isRarVolumes(volumes) {
+			return extractRarVolumes(volumes, destDir, password)
+		}
+		joined, err := joinVolumes(volumes)
+		if err != nil {
+			return nil, fmt.Errorf("拼接分卷失败: %w", err)
+		}
+		defer os.Remove(joined)
+		filePath = joined
+	}
+
+	// RAR等其他格式同样由go-unarr解压，sevenzip只能处理7z
+	sevenZ := is7z(filePath)
+	if password == "" || !sevenZ {
+		before, err := dirEntries(destDir)
+		if err != nil {
+			return nil, err
+		}
+		extracted, err := extract7z(filePath, destDir)
+		if err == nil || !sevenZ {
+			return extracted, err
+		}
+		logger.Logger.Printf("go-unarr解压失败: %v，尝试sevenzip解压", err)
+		// go-unarr失败前可能已写出部分文件，删除后再由sevenzip重新解压，避免残留不完整的条目
+		if err := removeNewEntries(destDir, before); err != nil {
+			return nil, err
+		}
+	}
+
+	extracted, err := extract7zNative(filePath, destDir, password)
 	if err != nil {
-		return []byte{}, fmt.Errorf("创建临时目录失败: %v", err)
+		return extracted, fmt.Errorf("sevenzip解压失败: %v", err)
 	}
-	defer os.RemoveAll(tmpDir) // 确保程序退出时清理临时目录
-	logger.Logger.Printf("临时目录: %s", tmpDir)
+	return extracted, nil
+}
 
-	files, err := archive.Extract(tmpDir)
+// sevenZSignature 7z文件头的签名
+var sevenZSignature = []byte{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C}
+
+// is7z 按文件头签名判断是否为7z文件
+func is7z(filePath string) bool {
+	f, err := os.Open(filePath)
 	if err != nil {
-		return []byte{}, fmt.Errorf("提取7z文件失败: %v", err)
+		return false
 	}
-	logger.Logger.Printf("7z文件提取完成，共提取 %d 个文件", len(files))
+	defer f.Close()
 
-	// 遍历临时目录并提取所有文件内容
-	content, cnt, err := WalkDir(tmpDir)
+	header := make([]byte, len(sevenZSignature))
+	if _, err := io.ReadFull(f, header); err != nil {
+		return false
+	}
+	return bytes.Equal(header, sevenZSignature)
+}
+
+// dirEntries 返回dir中已有条目的名称
+func dirEntries(dir string) (map[string]bool, error) {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return content, err
+		return nil, fmt.Errorf("读取目录 %s 失败: %v", dir, err)
+	}
+	names := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		names[entry.Name()] = true
 	}
+	return names, nil
+}
 
-	logger.Logger.Printf("7z文件解析完成，共提取 %d 个文件(一级目录)", cnt)
-	return content, nil
+// removeNewEntries 删除dir中不在before中的条目，destDir可能是调用方已有内容的目录，只删除本次解压写出的条目
+func removeNewEntries(dir string, before map[string]bool) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("读取目录 %s 失败: %v", dir, err)
+	}
+	for _, entry := range entries {
+		if before[entry.Name()] {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return fmt.Errorf("删除 %s 失败: %v", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// extract7zNative 使用sevenzip将7z文件解压到destDir，支持固实压缩和密码
+func extract7zNative(filePath string, destDir string, password string) ([]string, error) {
+	r, err := sevenzip.OpenReaderWithPassword(filePath, password)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开7z文件: %v", err)
+	}
+	defer r.Close()
+
+	var extracted []string
+	for _, f := range r.File {
+		// 防止路径遍历攻击
+		safePath := filepath.Join(destDir, sanitizePath(f.Name))
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(safePath, 0755); err != nil {
+				return extracted, fmt.Errorf("创建目录失败 %s: %v", safePath, err)
+			}
+			continue
+		}
+
+		if !included(f.Name, internal.GetDynamicFileType(safePath)) {
+			if err := writePlaceholder(safePath); err != nil {
+				return extracted, fmt.Errorf("写入文件 %s 失败: %v", safePath, err)
+			}
+			extracted = append(extracted, safePath)
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(safePath), 0755); err != nil {
+			return extracted, fmt.Errorf("创建目录失败 %s: %v", safePath, err)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return extracted, fmt.Errorf("打开7z内文件 %s 失败: %v", f.Name, err)
+		}
+		if err := WriteDstFile(rc, safePath, 0755); err != nil {
+			rc.Close()
+			// 条目内容不完整，删除写入了一部分的文件，只保留完整的条目
+			os.Remove(safePath)
+			return extracted, err
+		}
+		rc.Close()
+		extracted = append(extracted, safePath)
+	}
+
+	logger.Logger.Printf("sevenzip提取完成，共提取 %d 个文件", len(extracted))
+	return extracted, nil
 }
 
 func init() {
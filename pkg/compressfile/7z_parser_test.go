@@ -0,0 +1,177 @@
+package compressfile
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// coder7z folder中唯一的coder：方法ID及编码函数
+type coder7z struct {
+	id     []byte
+	encode func([]byte) []byte
+}
+
+var (
+	copyCoder = coder7z{id: []byte{0x00}, encode: func(b []byte) []byte { return b }}
+	// zstdCoder 7-Zip ZS分支的Zstandard方法，go-unarr不支持，sevenzip支持
+	zstdCoder = coder7z{id: []byte{0x04, 0xF7, 0x11, 0x01}, encode: func(b []byte) []byte {
+		enc, _ := zstd.NewWriter(nil)
+		defer enc.Close()
+		return enc.EncodeAll(b, nil)
+	}}
+)
+
+// buildSolid7z 构造固实7z文件：所有文件保存在同一个folder中，按SubStreamsInfo切分。
+// 文件内容及名称总长、压缩后的大小需小于128字节，各数值均按单字节NUMBER编码，与7-Zip相同为每个文件写入CRC
+func buildSolid7z(files []struct{ name, data string }, coder coder7z) []byte {
+	var unpacked []byte
+	for _, f := range files {
+		unpacked = append(unpacked, f.data...)
+	}
+	packed := coder.encode(unpacked)
+
+	header := []byte{
+		0x01,       // kHeader
+		0x04,       // kMainStreamsInfo
+		0x06, 0, 1, // kPackInfo: packPos, numPackStreams
+		0x09, byte(len(packed)), 0x00,
+		0x07, 0x0B, 1, 0, // kUnPackInfo, kFolder: numFolders, external
+		1, byte(len(coder.id)), // numCoders, 简单coder及其ID长度
+	}
+	header = append(header, coder.id...)
+	header = append(header,
+		0x0C, byte(len(unpacked)), 0x00, // kCodersUnPackSize
+		0x08, 0x0D, byte(len(files)), // kSubStreamsInfo, kNumUnPackStream
+		0x09,
+	)
+	for _, f := range files[:len(files)-1] {
+		header = append(header, byte(len(f.data)))
+	}
+	header = append(header, 0x0A, 1) // kCRC，全部定义
+	for _, f := range files {
+		header = binary.LittleEndian.AppendUint32(header, crc32.ChecksumIEEE([]byte(f.data)))
+	}
+	header = append(header, 0x00, 0x00) // kSubStreamsInfo、kMainStreamsInfo结束
+
+	var names []byte
+	for _, f := range files {
+		for _, u := range utf16.Encode([]rune(f.name + "\x00")) {
+			names = binary.LittleEndian.AppendUint16(names, u)
+		}
+	}
+	header = append(header, 0x05, byte(len(files)), 0x11, byte(len(names)+1), 0)
+	header = append(header, names...)
+	header = append(header, 0x00, 0x00) // kFilesInfo、kHeader结束
+
+	start := make([]byte, 20)
+	binary.LittleEndian.PutUint64(start[0:], uint64(len(packed)))
+	binary.LittleEndian.PutUint64(start[8:], uint64(len(header)))
+	binary.LittleEndian.PutUint32(start[16:], crc32.ChecksumIEEE(header))
+
+	out := append([]byte{}, sevenZSignature...)
+	out = append(out, 0, 4)
+	out = binary.LittleEndian.AppendUint32(out, crc32.ChecksumIEEE(start))
+	out = append(out, start...)
+	out = append(out, packed...)
+	return append(out, header...)
+}
+
+func TestExtractSolid7z(t *testing.T) {
+	files := []struct{ name, data string }{
+		{"a.txt", "first file in the block\n"},
+		{"b.txt", "second file in the block\n"},
+	}
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "solid.7z")
+	if err := os.WriteFile(archive, buildSolid7z(files, copyCoder), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	destDir := filepath.Join(dir, "out")
+	if err := os.Mkdir(destDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	extracted, err := extractSevenZ(archive, destDir, "")
+	if err != nil {
+		t.Fatalf("extractSevenZ: %v", err)
+	}
+	if len(extracted) != len(files) {
+		t.Errorf("extracted %d files, want %d", len(extracted), len(files))
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Join(destDir, f.name))
+		if err != nil {
+			t.Errorf("%s: %v", f.name, err)
+			continue
+		}
+		if string(data) != f.data {
+			t.Errorf("%s: got %q, want %q", f.name, data, f.data)
+		}
+	}
+
+	// 使用sevenzip解压时结果相同
+	nativeDir := filepath.Join(dir, "native")
+	if _, err := extract7zNative(archive, nativeDir, ""); err != nil {
+		t.Fatalf("extract7zNative: %v", err)
+	}
+	for _, f := range files {
+		if data, _ := os.ReadFile(filepath.Join(nativeDir, f.name)); string(data) != f.data {
+			t.Errorf("native %s: got %q, want %q", f.name, data, f.data)
+		}
+	}
+}
+
+// TestExtractSevenZFallback go-unarr无法解压的7z（Zstandard编码的固实folder）回退到sevenzip解压
+func TestExtractSevenZFallback(t *testing.T) {
+	files := []struct{ name, data string }{
+		{"a.txt", "zstd solid block, first file\n"},
+		{"b.txt", "zstd solid block, second file\n"},
+	}
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "zstd.7z")
+	if err := os.WriteFile(archive, buildSolid7z(files, zstdCoder), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 默认路径go-unarr无法解压该文件（不能写出全0的内容而不报错）
+	unarrDir := filepath.Join(dir, "unarr")
+	if err := os.Mkdir(unarrDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := extract7z(archive, unarrDir); err == nil {
+		t.Fatal("go-unarr extracted a Zstandard folder; the fixture no longer exercises the fallback")
+	}
+
+	text, err := (&SevenZFileParser{}).Parse(archive)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	for _, f := range files {
+		if !strings.Contains(string(text), f.data) {
+			t.Errorf("missing %q in %q", f.data, text)
+		}
+	}
+}
+
+// TestExtractSevenZNoFallbackForRar 非7z文件（如RAR）解压失败时不回退到sevenzip
+func TestExtractSevenZNoFallbackForRar(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "broken.rar")
+	if err := os.WriteFile(archive, []byte("Rar!\x1a\x07\x01\x00broken"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := extractSevenZ(archive, dir, "")
+	if err == nil {
+		t.Fatal("extractSevenZ succeeded on a broken RAR")
+	}
+	if strings.Contains(err.Error(), "sevenzip") {
+		t.Errorf("got %v, want the go-unarr error", err)
+	}
+}
@@ -13,6 +13,9 @@ import (
 	"compress/bzip2"
 )
 
+// bz2ShortExts bz2的简写后缀及解压后对应的后缀
+var bz2ShortExts = map[string]string{".tbz2": ".tar", ".tbz": ".tar"}
+
 type Bz2FileParser struct{}
 
 func (p *Bz2FileParser) Parse(filePath string) ([]byte, error) {
@@ -24,12 +27,27 @@ func (p *Bz2FileParser) Parse(filePath string) ([]byte, error) {
 	}
 	defer file.Close()
 
-	return parseBz2FromReader(file, filePath)
+	if content, ok, err := parseInMemory(file, filePath, readBz2Members); ok {
+		return content, err
+	}
+	return bufferOutput(func(w io.Writer) error { return parseBz2To(file, filePath, w) })
+}
+
+// ParseTo 解压bz2并逐个文件解析写入w，不使用内存解压
+func (p *Bz2FileParser) ParseTo(filePath string, w io.Writer) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("无法打开文件: %v", err)
+	}
+	defer file.Close()
+
+	return parseBz2To(file, filePath, w)
 }
 
 func init() {
-	// BZ2相关类型: 24(bz2)
+	// BZ2相关类型: 24(bz2), 301(tar.bz2)
 	internal.RegisterParser(internal.FileTypeBZ2, &Bz2FileParser{})
+	internal.RegisterParser(internal.FileTypeTARBZ2, &Bz2FileParser{})
 }
 
 func WriteBz2File(rc io.Reader, safePath string, mode fs.FileMode) error {
@@ -47,29 +65,36 @@ func WriteBz2File(rc io.Reader, safePath string, mode fs.FileMode) error {
 	return nil
 }
 
-func parseBz2FromReader(reader io.Reader, filename string) ([]byte, error) {
-	bz2Reader := bzip2.NewReader(reader)
-
+// parseBz2To 从io.Reader解压bz2内容，逐个文件解析并写入w
+func parseBz2To(reader io.Reader, filename string, w io.Writer) error {
 	// 创建临时目录
-	tmpDir, err := os.MkdirTemp("", "bz2_extract_")
+	tmpDir, err := internal.MkdirTemp("bz2_extract_")
 	if err != nil {
-		return []byte{}, fmt.Errorf("创建临时目录失败: %v", err)
+		return fmt.Errorf("创建临时目录失败: %v", err)
 	}
 	defer os.RemoveAll(tmpDir) // 确保程序退出时清理临时目录
 	logger.Logger.Printf("临时目录: %s", tmpDir)
 
-	original := filepath.Base(filename[:len(filename)-len(".bz2")])
-	safePath := filepath.Join(tmpDir, sanitizePath(original))
-
-	if err = WriteBz2File(bz2Reader, safePath, os.ModePerm); err != nil {
-		return []byte{}, err
-	}
-
-	content, cnt, err := WalkDir(tmpDir)
+	_, extractErr := extractBz2FromReader(reader, filename, tmpDir)
+	cnt, err := walkExtractedTo(tmpDir, w, extractErr)
 	if err != nil {
-		return content, err
+		return err
 	}
 
 	logger.Logger.Printf("bz2文件解析完成，共提取 %d 个文件(一级目录)", cnt)
-	return content, nil
+	return nil
+}
+
+// extractBz2FromReader 将bz2内容解压到destDir，返回解压出的文件路径
+func extractBz2FromReader(reader io.Reader, filename string, destDir string) ([]string, error) {
+	bz2Reader := bzip2.NewReader(reader)
+
+	original := decompressedName(filename, ".bz2", bz2ShortExts)
+	safePath := filepath.Join(destDir, sanitizePath(original))
+	if err := WriteBz2File(bz2Reader, safePath, os.ModePerm); err != nil {
+		// 保留已解压的部分内容
+		return []string{renameIfTar(safePath)}, err
+	}
+
+	return []string{renameIfTar(safePath)}, nil
 }
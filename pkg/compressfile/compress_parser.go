@@ -2,12 +2,16 @@ package compressfile
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"fextra/internal"
@@ -21,6 +25,65 @@ import (
 // CompressFileParser 压缩文件解析器
 type CompressFileParser struct{}
 
+// dedupEnabled 是否跳过内容重复的压缩包条目
+var dedupEnabled atomic.Bool
+
+// SetDedup 设置是否按SHA-256跳过压缩包内内容重复的文件：重复的文件不再解析，
+// 仅输出文件名及"(duplicate of 首次出现的路径)"标记。适用于包含大量相同文件的压缩包（如node_modules）
+// 去重范围为单个压缩包，嵌套的压缩包各自去重
+func SetDedup(enabled bool) {
+	dedupEnabled.Store(enabled)
+}
+
+// dedupSet 记录已解析条目的SHA-256及路径，未启用去重时为nil
+type dedupSet map[[sha256.Size]byte]string
+
+func newDedupSet() dedupSet {
+	if !dedupEnabled.Load() {
+		return nil
+	}
+	return make(dedupSet)
+}
+
+// seen 返回与sum内容相同的已解析条目路径，首次出现时记录path并返回false
+func (s dedupSet) seen(sum [sha256.Size]byte, path string) (string, bool) {
+	if first, ok := s[sum]; ok {
+		return first, true
+	}
+	s[sum] = path
+	return "", false
+}
+
+// writeDuplicate 写入重复条目的文件名及标记，格式与正常条目一致
+func writeDuplicate(w io.Writer, path string, size int64, fileType int, first string) {
+	logger.Logger.Printf("跳过重复文件: %s (与 %s 相同)", path, first)
+	writeHeader(w, path, size, fileType)
+	fmt.Fprintf(w, "(duplicate of %s)", first)
+	writeTrailer(w)
+}
+
+// fileSum 计算文件内容的SHA-256
+func fileSum(path string) ([sha256.Size]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	defer file.Close()
+
+	return readerSum(file)
+}
+
+// readerSum 计算r中剩余内容的SHA-256
+func readerSum(r io.Reader) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return sum, err
+	}
+	copy(sum[:], hash.Sum(nil))
+	return sum, nil
+}
+
 // GetFullTmpDir 获取完整的临时目录路径
 func GetFullTmpDir(tmpdir string) string {
 	logger.DebugLogger.Printf("生成临时目录路径，基础路径: %s", tmpdir)
@@ -63,10 +126,23 @@ func WriteDstFile(rc io.ReadCloser, safePath string, mode fs.FileMode) error {
 	return nil
 }
 
+// WalkDir 解析目录下的所有文件并拼接文本
+// 文件按完整路径排序后依次解析，与解压顺序无关，同一压缩文件多次提取的结果完全一致
+// 嵌套的压缩包不完整时保留其已读取的内容并继续解析其余文件，最后返回包装了ErrPartialArchive的错误
 func WalkDir(tmpDir string) ([]byte, int, error) {
 	var buffer bytes.Buffer
+	fileCnt, err := walkDirTo(tmpDir, &buffer)
+	return buffer.Bytes(), fileCnt, err
+}
+
+// walkDirTo 与WalkDir相同，每解析完一个文件即写入w，解析失败的文件不输出标题及部分文本
+func walkDirTo(tmpDir string, w io.Writer) (int, error) {
 	var fileCnt int
+	var partial error
+	out := newStickyWriter(w)
 
+	var paths []string
+	sizes := make(map[string]int64)
 	err := filepath.Walk(tmpDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -75,28 +151,257 @@ func WalkDir(tmpDir string) ([]byte, int, error) {
 			// filepath.Walk内部实现子目录的递归调用
 			return nil
 		}
+		paths = append(paths, path)
+		sizes[path] = info.Size()
+		return nil
+	})
+	if err != nil {
+		return fileCnt, err
+	}
+	sortEntryNames(paths)
 
+	dedup := newDedupSet()
+	for _, path := range paths {
+		name := strings.TrimPrefix(path, tmpDir)
+		// 分卷压缩包在解析第一个分卷时已包含其余分卷的内容
+		if isSecondaryVolume(path) {
+			logger.DebugLogger.Printf("跳过分卷: %s", path)
+			continue
+		}
 		// 读取文件内容，这里再去校验文件类型，按照对应类型去解析
-		fileType := internal.GetDynamicFileType(path)
+		fileType := internal.SniffFileType(path)
+		if !included(name, fileType) {
+			writeSkipped(out, name, sizes[path], fileType)
+			continue
+		}
+		if dedup != nil {
+			if sum, err := fileSum(path); err == nil {
+				if first, ok := dedup.seen(sum, name); ok {
+					writeDuplicate(out, name, sizes[path], fileType, first)
+					continue
+				}
+			}
+		}
+
 		parser, err := internal.GetParser(fileType)
 		if err != nil {
-			return fmt.Errorf("获取解析器失败: %v", err)
+			return fileCnt, fmt.Errorf("获取解析器失败: %v", err)
 		}
 
 		logger.Logger.Printf("walkDir 解析文件: %s", path)
-		content, err := parser.Parse(path)
-		if err != nil {
-			return fmt.Errorf("读取文件 %s 失败: %v", path, err)
+		if err := parseFileTo(out, parser, path, name, sizes[path], fileType); err != nil {
+			if !errors.Is(err, internal.ErrPartialArchive) {
+				return fileCnt, fmt.Errorf("读取文件 %s 失败: %v", path, err)
+			}
+			partial = nestedPartialError(name, err)
+		}
+		if out.err != nil {
+			return fileCnt, fmt.Errorf("写入输出失败: %w", out.err)
 		}
-
-		// 在文件解析成功后，添加文件名称等信息
-		buffer.WriteString(fmt.Sprintf("=== 文件名: %s ===\n\n", strings.TrimPrefix(path, tmpDir)))
 		fileCnt++
+	}
+
+	return fileCnt, partial
+}
+
+// parseFileTo 解析文件并写入文件名标题及文本，解析成功（或压缩包不完整）后才写入；
+// 解析器实现WriterParser时先写入缓冲区，解析失败时标题及已解析的部分文本均不输出，与Parse一致
+func parseFileTo(w io.Writer, parser internal.FileParser, path string, name string, size int64, fileType int) error {
+	var content []byte
+	var err error
+	if wp, ok := parser.(internal.WriterParser); ok {
+		content, err = bufferOutput(func(w io.Writer) error { return wp.ParseTo(path, w) })
+	} else {
+		content, err = parser.Parse(path)
+	}
+	err = memberError(name, err)
+	if err != nil && !errors.Is(err, internal.ErrPartialArchive) {
+		return err
+	}
+	// 在文件解析成功后，添加文件名称等信息
+	writeMember(w, name, size, fileType, content)
+	return err
+}
+
+// writeMember 写入条目的文件名标题及文本
+func writeMember(w io.Writer, name string, size int64, fileType int, content []byte) {
+	writeHeader(w, name, size, fileType)
+	w.Write(content)
+	writeTrailer(w)
+}
+
+// stickyWriter 记录第一次写入错误，之后的写入直接返回该错误，调用方只需在每个条目结束后检查err
+type stickyWriter struct {
+	w   io.Writer
+	err error
+
+	// 直接写入manifestWriter时记录各条目的位置，member为正在写入的条目
+	manifest *manifestWriter
+	member   *MemberResult
+}
+
+func newStickyWriter(w io.Writer) *stickyWriter {
+	manifest, _ := w.(*manifestWriter)
+	return &stickyWriter{w: w, manifest: manifest}
+}
+
+func (s *stickyWriter) Write(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	n, err := s.w.Write(p)
+	if err != nil {
+		s.err = err
+	}
+	return n, err
+}
 
-		buffer.Write(content)
-		buffer.WriteString("\n\n")
+// bufferOutput 将写入w的解析函数转换为返回[]byte的形式
+func bufferOutput(parse func(w io.Writer) error) ([]byte, error) {
+	var buffer bytes.Buffer
+	err := parse(&buffer)
+	return buffer.Bytes(), err
+}
+
+// memberError 条目中没有文本内容（ErrNoContent）时按空内容输出，不视为解析失败
+func memberError(name string, err error) error {
+	if errors.Is(err, internal.ErrNoContent) {
+		logger.Logger.Printf("文件 %s 中没有文本内容: %v", name, err)
 		return nil
+	}
+	return err
+}
+
+// nestedPartialError 记录不完整的嵌套压缩包，保留ErrPartialArchive以便调用方判断
+func nestedPartialError(name string, err error) error {
+	logger.Logger.Printf("压缩包 %s 不完整，保留已读取的内容: %v", name, err)
+	return fmt.Errorf("文件 %s: %w", name, err)
+}
+
+// partialError 压缩包读取中途出错时，包装为ErrPartialArchive，此时返回的内容仅包含已成功读取的条目
+func partialError(err error) error {
+	return fmt.Errorf("%w: %v", internal.ErrPartialArchive, err)
+}
+
+// walkExtractedTo 解析tmpDir中已解压的文件并逐个写入w，extractErr为解压过程中的错误（如压缩包被截断）：
+// 已解压出文件时仍写入其内容，错误包装为ErrPartialArchive；未解压出任何文件时返回extractErr
+func walkExtractedTo(tmpDir string, w io.Writer, extractErr error) (int, error) {
+	cnt, err := walkDirTo(tmpDir, w)
+	if extractErr == nil {
+		return cnt, err
+	}
+	if cnt == 0 {
+		return 0, extractErr
+	}
+	logger.Logger.Printf("压缩包读取中断，返回已解压的 %d 个文件的内容: %v", cnt, extractErr)
+	return cnt, partialError(extractErr)
+}
+
+// decompressedName 根据压缩文件名推导解压后的文件名：去除ext后缀，
+// 或将简写后缀（如.tbz2、.txz）替换为对应的完整后缀
+func decompressedName(filename string, ext string, shortExts map[string]string) string {
+	base := filepath.Base(filename)
+	lower := strings.ToLower(base)
+	for short, full := range shortExts {
+		if strings.HasSuffix(lower, short) {
+			return base[:len(base)-len(short)] + full
+		}
+	}
+	if strings.HasSuffix(lower, ext) {
+		return base[:len(base)-len(ext)]
+	}
+	return base
+}
+
+// writeDecompressedFile 将单文件压缩格式（zst、lz4、sz）解压出的内容写入path，
+// 出错时已写入的部分内容保留在文件中
+func writeDecompressedFile(r io.Reader, path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("创建文件 %s 失败: %v", path, err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, r); err != nil {
+		return fmt.Errorf("解压 %s 失败: %w", path, err)
+	}
+	return nil
+}
+
+// renameIfTar 解压出的文件为tar包但文件名不以.tar结尾时（如 backup.bz2 实为tar），
+// 追加.tar后缀，使WalkDir能按tar继续解包，返回最终的文件路径
+func renameIfTar(path string) string {
+	if strings.HasSuffix(strings.ToLower(path), ".tar") || !isTarFile(path) {
+		return path
+	}
+
+	tarPath := uniquePath(path + ".tar")
+	if err := os.Rename(path, tarPath); err != nil {
+		logger.Logger.Printf("重命名tar文件 %s 失败: %v", path, err)
+		return path
+	}
+	logger.Logger.Printf("解压内容为tar包: %s", tarPath)
+	return tarPath
+}
+
+// isTarFile 检查文件偏移257处是否为tar的ustar标识
+func isTarFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	magic := make([]byte, 5)
+	if _, err := file.ReadAt(magic, 257); err != nil {
+		return false
+	}
+	return string(magic) == "ustar"
+}
+
+// sortEntryNames 按统一为"/"分隔的路径对压缩包条目排序，保证各格式输出顺序一致
+func sortEntryNames(names []string) {
+	sort.Slice(names, func(i, j int) bool {
+		return filepath.ToSlash(names[i]) < filepath.ToSlash(names[j])
 	})
+}
 
-	return buffer.Bytes(), fileCnt, err
+// ExtractArchive 将压缩文件解压到destDir，返回解压出的文件路径列表，不做文本提取
+func ExtractArchive(filePath, destDir string) ([]string, error) {
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建目录 %s 失败: %v", destDir, err)
+	}
+
+	fileType := internal.SniffFileType(filePath)
+	switch fileType {
+	case internal.FileTypeZIP, internal.FileTypeJAR, internal.FileTypeWAR:
+		return extractZip(filePath, destDir)
+	case internal.FileType7Z, internal.FileTypeRAR:
+		return extractSevenZ(filePath, destDir, "")
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开文件: %v", err)
+	}
+	defer file.Close()
+
+	switch fileType {
+	case internal.FileTypeTAR:
+		return extractTarFromReader(file, destDir)
+	case internal.FileTypeGZ, internal.FileTypeTARGZ:
+		return extractGzFromReader(file, filePath, destDir)
+	case internal.FileTypeBZ2, internal.FileTypeTARBZ2:
+		return extractBz2FromReader(file, filePath, destDir)
+	case internal.FileTypeXZ, internal.FileTypeTARXZ:
+		return extractXzFromReader(file, filePath, destDir)
+	case internal.FileTypeZST:
+		return extractZstFromReader(file, filePath, destDir)
+	case internal.FileTypeLZ4:
+		return extractLz4FromReader(file, filePath, destDir)
+	case internal.FileTypeSNAPPY:
+		return extractSnappyFromReader(file, filePath, destDir)
+	}
+
+	return nil, fmt.Errorf("不支持的压缩文件类型: %s", internal.FileType(fileType))
 }
@@ -0,0 +1,169 @@
+package compressfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fextra/internal"
+)
+
+// failingWriterParser 写入部分文本后返回错误
+type failingWriterParser struct{}
+
+func (failingWriterParser) Parse(filePath string) ([]byte, error) {
+	return bufferOutput(func(w io.Writer) error { return failingWriterParser{}.ParseTo(filePath, w) })
+}
+
+func (failingWriterParser) ParseTo(filePath string, w io.Writer) error {
+	io.WriteString(w, "partial text")
+	return errors.New("corrupt member")
+}
+
+// TestParseFileToDropsFailedMember WriterParser解析失败时不输出条目的标题及已写入的部分文本
+func TestParseFileToDropsFailedMember(t *testing.T) {
+	var out bytes.Buffer
+	if err := parseFileTo(&out, failingWriterParser{}, "a.bin", "a.bin", 10, 0); err == nil {
+		t.Fatal("expected the member error")
+	}
+	if out.Len() != 0 {
+		t.Errorf("got %q, want no output for the failed member", out.String())
+	}
+}
+
+// forgeCRC 返回与target长度及CRC32相同、以prefix开头的内容，prefix至少比target短4字节：CRC32对定长输入是仿射变换，
+// 按末尾4字节各位对CRC的影响解GF(2)上的线性方程组
+func forgeCRC(prefix, target []byte) []byte {
+	data := append(append([]byte{}, prefix...), make([]byte, len(target)-len(prefix))...)
+	tail := data[len(data)-4:]
+	base := crc32.ChecksumIEEE(data)
+	var columns [32]uint32 // 第i位对CRC的影响
+	for i := range columns {
+		tail[i/8] ^= 1 << (i % 8)
+		columns[i] = crc32.ChecksumIEEE(data) ^ base
+		tail[i/8] ^= 1 << (i % 8)
+	}
+
+	// 高斯消元：rows[k]记录消元后的影响及对应的位组合
+	want := crc32.ChecksumIEEE(target) ^ base
+	var bits uint32
+	type row struct{ effect, combo uint32 }
+	var rows []row
+	for i, c := range columns {
+		r := row{c, 1 << i}
+		for _, pivot := range rows {
+			if r.effect&(pivot.effect&-pivot.effect) != 0 {
+				r.effect ^= pivot.effect
+				r.combo ^= pivot.combo
+			}
+		}
+		if r.effect == 0 {
+			continue
+		}
+		for k := range rows {
+			if rows[k].effect&(r.effect&-r.effect) != 0 {
+				rows[k].effect ^= r.effect
+				rows[k].combo ^= r.combo
+			}
+		}
+		rows = append(rows, r)
+	}
+	for _, r := range rows {
+		if want&(r.effect&-r.effect) != 0 {
+			want ^= r.effect
+			bits ^= r.combo
+		}
+	}
+	binary.LittleEndian.PutUint32(tail, bits)
+	return data
+}
+
+// TestZipDedup 内容相同的条目只解析第一个，CRC32及大小相同但内容不同的条目不视为重复
+func TestZipDedup(t *testing.T) {
+	SetDedup(true)
+	defer SetDedup(false)
+
+	same := []byte("same content....")
+	forged := forgeCRC([]byte("forged"), same)
+	if crc32.ChecksumIEEE(forged) != crc32.ChecksumIEEE(same) || bytes.Equal(forged, same) {
+		t.Fatalf("forgeCRC: %q does not collide with %q", forged, same)
+	}
+
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for _, m := range []struct {
+		name    string
+		content []byte
+	}{
+		{"a.txt", same},
+		{"b.txt", same},
+		{"c.txt", forged},
+		{"d.txt", []byte("other content")},
+	} {
+		fw, _ := zw.Create(m.name)
+		fw.Write(m.content)
+	}
+	zw.Close()
+	path := filepath.Join(t.TempDir(), "dup.zip")
+	if err := os.WriteFile(path, archive.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := (&ZipFileParser{}).Parse(path)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	got := string(text)
+	if !strings.Contains(got, "(duplicate of /a.txt)") || strings.Count(got, string(same)) != 1 ||
+		strings.Count(got, "duplicate of") != 1 || !strings.Contains(got, string(forged)) || !strings.Contains(got, "other content") {
+		t.Errorf("got %q", got)
+	}
+}
+
+// typeParser 按注册的类型输出固定文本，用于确认条目交给了哪个解析器
+type typeParser string
+
+func (p typeParser) Parse(filePath string) ([]byte, error) {
+	return []byte(p), nil
+}
+
+func (p typeParser) ParseReader(r io.Reader) ([]byte, error) {
+	return []byte(p), nil
+}
+
+// TestZipSniffsMembers ZIP条目与WalkDir相同按内容确认类型：OOXML格式的.wps按docx解析
+func TestZipSniffsMembers(t *testing.T) {
+	internal.RegisterParser(internal.FileTypeDOC, typeParser("doc parser"))
+	internal.RegisterParser(internal.FileTypeDOCX, typeParser("docx parser"))
+
+	var inner bytes.Buffer
+	zw := zip.NewWriter(&inner)
+	fw, _ := zw.Create("word/document.xml")
+	io.WriteString(fw, "<w:document/>")
+	zw.Close()
+
+	var archive bytes.Buffer
+	zw = zip.NewWriter(&archive)
+	fw, _ = zw.Create("report.wps")
+	fw.Write(inner.Bytes())
+	zw.Close()
+	path := filepath.Join(t.TempDir(), "wps.zip")
+	if err := os.WriteFile(path, archive.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := (&ZipFileParser{}).Parse(path)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got := string(text); !strings.Contains(got, "docx parser") {
+		t.Errorf("got %q, want the member parsed as docx", got)
+	}
+}
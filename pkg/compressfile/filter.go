@@ -0,0 +1,72 @@
+package compressfile
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"fextra/internal"
+	"fextra/pkg/logger"
+)
+
+/*
+	条目过滤：设置过滤条件后，不满足条件的条目不解压也不解析，仅输出文件名及"(skipped)"标记，
+	用于只关心压缩包中部分类型（如文档）而忽略大量图片等资源文件的场景。
+	tar、7z（sevenzip）解压到临时目录时为跳过的条目创建空文件占位，保持输出中的条目列表完整
+*/
+
+// MemberFilter 判断压缩包条目是否需要解析，name为条目在压缩包内的相对路径，fileType为按文件名识别的类型
+type MemberFilter func(name string, fileType int) bool
+
+var memberFilter atomic.Pointer[MemberFilter]
+
+// SetMemberFilter 设置压缩包条目过滤条件，对所有压缩格式及嵌套的压缩包生效，为nil时解析全部条目
+func SetMemberFilter(filter MemberFilter) {
+	if filter == nil {
+		memberFilter.Store(nil)
+		return
+	}
+	memberFilter.Store(&filter)
+}
+
+// SetIncludeTypes 只解析指定类型的条目，嵌套的压缩包始终解析以便过滤其中的条目；types为空时解析全部条目
+func SetIncludeTypes(types []int) {
+	if len(types) == 0 {
+		SetMemberFilter(nil)
+		return
+	}
+	include := make(map[int]bool, len(types))
+	for _, t := range types {
+		include[t] = true
+	}
+	SetMemberFilter(func(_ string, fileType int) bool {
+		return include[fileType] || internal.IsArchiveType(fileType)
+	})
+}
+
+// included 条目是否需要解析，name可以"/"开头
+func included(name string, fileType int) bool {
+	filter := memberFilter.Load()
+	if filter == nil {
+		return true
+	}
+	return (*filter)(strings.TrimLeft(filepath.ToSlash(name), "/"), fileType)
+}
+
+// writeSkipped 写入被过滤条目的文件名及标记，格式与正常条目一致
+func writeSkipped(w io.Writer, path string, size int64, fileType int) {
+	logger.DebugLogger.Printf("跳过被过滤的文件: %s", path)
+	writeHeader(w, path, size, fileType)
+	io.WriteString(w, "(skipped)")
+	writeTrailer(w)
+}
+
+// writePlaceholder 为跳过的条目创建空文件，解压到临时目录时用于保留条目列表
+func writePlaceholder(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, nil, 0644)
+}
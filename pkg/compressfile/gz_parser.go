@@ -1,6 +1,7 @@
 package compressfile
 
 import (
+	"bufio"
 	"compress/gzip"
 	"fextra/internal"
 	"fextra/pkg/logger"
@@ -20,7 +21,21 @@ func (p *GzFileParser) Parse(filePath string) ([]byte, error) {
 	}
 	defer file.Close()
 
-	return parseGzFromReader(file, filePath)
+	if content, ok, err := parseInMemory(file, filePath, readGzMembers); ok {
+		return content, err
+	}
+	return bufferOutput(func(w io.Writer) error { return parseGzTo(file, filePath, w) })
+}
+
+// ParseTo 解压gz并逐个文件解析写入w，不使用内存解压
+func (p *GzFileParser) ParseTo(filePath string, w io.Writer) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("无法打开文件: %v", err)
+	}
+	defer file.Close()
+
+	return parseGzTo(file, filePath, w)
 }
 
 func init() {
@@ -50,42 +65,88 @@ func writeGzFile(gz *gzip.Reader, path string) error {
 	return nil
 }
 
-// parseGzFromReader 从io.Reader解析gz内容并返回格式化字符串
-func parseGzFromReader(reader io.Reader, filename string) ([]byte, error) {
-	gzReader, err := gzip.NewReader(reader)
-	if err != nil {
-		return []byte{}, fmt.Errorf("创建gzip reader失败: %v", err)
-	}
-	defer gzReader.Close()
-
+// parseGzTo 从io.Reader解压gz内容，逐个文件解析并写入w
+func parseGzTo(reader io.Reader, filename string, w io.Writer) error {
 	// 创建临时目录
-	tmpDir, err := os.MkdirTemp("", "gz_extract_")
+	tmpDir, err := internal.MkdirTemp("gz_extract_")
 	if err != nil {
-		return []byte{}, fmt.Errorf("创建临时目录失败: %v", err)
+		return fmt.Errorf("创建临时目录失败: %v", err)
 	}
 	defer os.RemoveAll(tmpDir) // 确保程序退出时清理临时目录
 	logger.Logger.Printf("临时目录: %s", tmpDir)
 
-	original := gzReader.Header.Name
-	if original == "" {
-		if strings.HasSuffix(filename, ".tar.gz") {
-			original = "default_gz_file_name.tar"
-		} else {
-			original = "default_gz_file_name.txt"
+	_, extractErr := extractGzFromReader(reader, filename, tmpDir)
+	files, err := walkExtractedTo(tmpDir, w, extractErr)
+	if err != nil {
+		return err
+	}
+
+	logger.Logger.Printf("gz文件解析完成，共提取 %d 个文件(一级目录)", files)
+	return nil
+}
+
+// extractGzFromReader 将gz内容解压到destDir，返回解压出的文件路径
+// gz文件可能由多个member拼接而成（如cat a.gz b.gz），逐个member解压为独立文件
+func extractGzFromReader(reader io.Reader, filename string, destDir string) ([]string, error) {
+	// Reset依赖io.ByteReader，避免缓冲读取越过当前member
+	br := bufio.NewReader(reader)
+	gzReader, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, fmt.Errorf("创建gzip reader失败: %v", err)
+	}
+	defer gzReader.Close()
+
+	var extracted []string
+	for {
+		gzReader.Multistream(false)
+
+		original := gzMemberName(gzReader.Header.Name, filename)
+		logger.Logger.Printf("原始文件名[%d]: %s", len(extracted), original)
+
+		safePath := uniquePath(filepath.Join(destDir, sanitizePath(original)))
+		if err = writeGzFile(gzReader, safePath); err != nil {
+			// gz只有一个数据流，保留已解压的部分内容
+			return append(extracted, renameIfTar(safePath)), err
+		}
+		extracted = append(extracted, renameIfTar(safePath))
+
+		// 继续读取下一个member
+		if err = gzReader.Reset(br); err == io.EOF {
+			break
+		} else if err != nil {
+			return extracted, fmt.Errorf("读取gzip member失败: %v", err)
 		}
 	}
-	logger.Logger.Printf("原始文件名: %s", original)
 
-	safePath := filepath.Join(tmpDir, sanitizePath(original))
+	if len(extracted) > 1 {
+		logger.Logger.Printf("gz文件包含 %d 个member", len(extracted))
+	}
+	return extracted, nil
+}
 
-	if err = writeGzFile(gzReader, safePath); err != nil {
-		return []byte{}, err
+// gzMemberName 返回member解压后的文件名，头部未记录原始文件名时使用默认名称
+func gzMemberName(original string, filename string) string {
+	if original != "" {
+		return original
+	}
+	if strings.HasSuffix(filename, ".tar.gz") {
+		return "default_gz_file_name.tar"
 	}
+	return "default_gz_file_name.txt"
+}
 
-	content, files, err := WalkDir(tmpDir)
-	if err != nil {
-		return content, err
+// uniquePath 目标文件已存在时，在文件名后追加序号
+func uniquePath(path string) string {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return path
+	}
+
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s_%d%s", base, i, ext)
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
 	}
-	logger.Logger.Printf("gz文件解析完成，共提取 %d 个文件(一级目录)", files)
-	return content, nil
 }
@@ -0,0 +1,78 @@
+package compressfile
+
+import (
+	"bytes"
+	"fextra/internal"
+	"fextra/pkg/logger"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pierrec/lz4/v4"
+)
+
+type Lz4FileParser struct{}
+
+func (p *Lz4FileParser) Parse(filePath string) ([]byte, error) {
+	var content bytes.Buffer
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return content.Bytes(), fmt.Errorf("无法打开文件: %v", err)
+	}
+	defer file.Close()
+
+	if content, ok, err := parseInMemory(file, filePath, readLz4Members); ok {
+		return content, err
+	}
+	return bufferOutput(func(w io.Writer) error { return parseLz4To(file, filePath, w) })
+}
+
+// ParseTo 解压lz4并逐个文件解析写入w，不使用内存解压
+func (p *Lz4FileParser) ParseTo(filePath string, w io.Writer) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("无法打开文件: %v", err)
+	}
+	defer file.Close()
+
+	return parseLz4To(file, filePath, w)
+}
+
+func init() {
+	// lz4类型: 304(lz4、tar.lz4)
+	internal.RegisterParser(internal.FileTypeLZ4, &Lz4FileParser{})
+}
+
+// parseLz4To 从io.Reader解压lz4内容，逐个文件解析并写入w
+func parseLz4To(reader io.Reader, filename string, w io.Writer) error {
+	tmpDir, err := internal.MkdirTemp("lz4_extract_")
+	if err != nil {
+		return fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	logger.Logger.Printf("临时目录: %s", tmpDir)
+
+	_, extractErr := extractLz4FromReader(reader, filename, tmpDir)
+	cnt, err := walkExtractedTo(tmpDir, w, extractErr)
+	if err != nil {
+		return err
+	}
+
+	logger.Logger.Printf("lz4文件解析完成，共提取 %d 个文件(一级目录)", cnt)
+	return nil
+}
+
+// extractLz4FromReader 将lz4帧格式（lz4命令行工具的默认格式，也支持旧版的legacy格式）的内容解压到destDir，
+// 返回解压出的文件路径；多个连续的帧解压为一个文件
+func extractLz4FromReader(reader io.Reader, filename string, destDir string) ([]string, error) {
+	original := decompressedName(filename, ".lz4", nil)
+	safePath := filepath.Join(destDir, sanitizePath(original))
+	if err := writeDecompressedFile(lz4.NewReader(reader), safePath); err != nil {
+		// 保留已解压的部分内容
+		return []string{renameIfTar(safePath)}, err
+	}
+
+	return []string{renameIfTar(safePath)}, nil
+}
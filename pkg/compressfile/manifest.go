@@ -0,0 +1,96 @@
+package compressfile
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"fextra/internal"
+)
+
+/*
+	条目清单：压缩包的输出为各条目的"=== 文件名: path ===\n\n"标题、文本及分隔符依次拼接，便于阅读但不便于程序处理。
+	ParseWithManifest、WalkDirWithManifest在返回拼接文本的同时返回各条目的路径、大小、类型及文本在输出中的位置，
+	调用方按TextOffset、TextLength即可截取每个条目的文本。清单只记录最外层的条目，
+	嵌套压缩包作为一个条目，其文本包含内层条目的标题
+*/
+
+// MemberResult 压缩包条目在拼接输出中的位置
+type MemberResult struct {
+	Path       string // 条目在压缩包内的路径，以"/"开头，与标题中的路径相同
+	Size       int64  // 条目解压后的大小（字节）
+	Type       int    // 按文件名识别的文件类型
+	TextOffset int    // 条目文本在输出中的起始偏移（字节），不含标题
+	TextLength int    // 条目文本的长度（字节），不含其后的分隔符；跳过、重复的条目为对应的标记
+}
+
+// DefaultMemberSeparator 默认的条目分隔符
+const DefaultMemberSeparator = "\n\n"
+
+var memberSeparator atomic.Pointer[string]
+
+// SetMemberSeparator 设置压缩包各条目文本之后的分隔符，默认为两个换行符
+func SetMemberSeparator(sep string) {
+	memberSeparator.Store(&sep)
+}
+
+// separator 返回SetMemberSeparator设置的分隔符，未设置时返回DefaultMemberSeparator
+func separator() string {
+	if sep := memberSeparator.Load(); sep != nil {
+		return *sep
+	}
+	return DefaultMemberSeparator
+}
+
+// manifestWriter 保存拼接输出并收集最外层条目的清单，由直接写入它的stickyWriter记录条目位置
+type manifestWriter struct {
+	bytes.Buffer
+	members []MemberResult
+}
+
+// ParseWithManifest 解析压缩文件，返回拼接的文本及各条目在文本中的位置，输出格式与Parse相同。
+// 文件不是压缩包时返回ErrUnsupportedFormat
+func ParseWithManifest(filePath string) ([]byte, []MemberResult, error) {
+	fileType := internal.SniffFileType(filePath)
+	if !internal.IsArchiveType(fileType) {
+		return []byte{}, nil, fmt.Errorf("%s不是压缩包: %w", internal.FileType(fileType), internal.ErrUnsupportedFormat)
+	}
+	parser, err := internal.GetParser(fileType)
+	if err != nil {
+		return []byte{}, nil, fmt.Errorf("获取解析器失败: %v", err)
+	}
+	wp, ok := parser.(internal.WriterParser)
+	if !ok {
+		return []byte{}, nil, fmt.Errorf("%s的解析器不支持逐个条目输出: %w", internal.FileType(fileType), internal.ErrUnsupportedFormat)
+	}
+
+	var out manifestWriter
+	err = wp.ParseTo(filePath, &out)
+	return out.Bytes(), out.members, err
+}
+
+// WalkDirWithManifest 与WalkDir相同，同时返回各文件在拼接文本中的位置
+func WalkDirWithManifest(tmpDir string) ([]byte, []MemberResult, error) {
+	var out manifestWriter
+	_, err := walkDirTo(tmpDir, &out)
+	return out.Bytes(), out.members, err
+}
+
+// writeHeader 写入条目的文件名标题，输出记录清单时开始记录该条目
+func writeHeader(w io.Writer, path string, size int64, fileType int) {
+	fmt.Fprintf(w, "=== 文件名: %s ===\n\n", path)
+	if s, ok := w.(*stickyWriter); ok && s.manifest != nil {
+		s.member = &MemberResult{Path: path, Size: size, Type: fileType, TextOffset: s.manifest.Len()}
+	}
+}
+
+// writeTrailer 写入条目文本之后的分隔符，输出记录清单时将该条目加入清单
+func writeTrailer(w io.Writer) {
+	if s, ok := w.(*stickyWriter); ok && s.member != nil {
+		s.member.TextLength = s.manifest.Len() - s.member.TextOffset
+		s.manifest.members = append(s.manifest.members, *s.member)
+		s.member = nil
+	}
+	io.WriteString(w, separator())
+}
@@ -0,0 +1,292 @@
+package compressfile
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz"
+
+	"fextra/internal"
+	"fextra/pkg/logger"
+)
+
+/*
+	内存解压：internal.SetInMemoryLimit设置上限后，tar/gz/bz2/xz/zst/lz4/sz先尝试在内存中解压，
+	解压后的总大小不超过上限时各条目直接经internal.ParseReader解析，不创建临时目录；
+	超过上限时丢弃已解压的内容，回到文件开头改为解压到临时目录。
+	依赖文件路径的条目解析器（如PDF、7z）及需要读取文件内容判断类型的条目（如.docm、Flat OPC）
+	仍按原有方式处理或写入临时文件
+*/
+
+// memoryMember 在内存中解压出的条目
+type memoryMember struct {
+	name string // 经sanitizePath处理的相对路径
+	data []byte
+}
+
+// memoryMembers 内存中的条目集合，总大小不超过limit
+type memoryMembers struct {
+	list  []memoryMember
+	names map[string]bool
+	size  int64
+	limit int64
+}
+
+func newMemoryMembers(limit int64) *memoryMembers {
+	return &memoryMembers{names: make(map[string]bool), limit: limit}
+}
+
+// remaining 返回剩余可用的大小
+func (m *memoryMembers) remaining() int64 {
+	return m.limit - m.size
+}
+
+// read 从r读取一个条目的内容，超过剩余大小时返回false；读取出错时同时返回已读取的部分内容
+func (m *memoryMembers) read(r io.Reader) ([]byte, bool, error) {
+	remaining := m.remaining()
+	data, err := io.ReadAll(io.LimitReader(r, remaining+1))
+	if err != nil {
+		return data, false, err
+	}
+	return data, int64(len(data)) <= remaining, nil
+}
+
+// add 添加条目，同名条目与uniquePath一致在文件名后追加序号，内容为tar包时与renameIfTar一致追加.tar后缀
+func (m *memoryMembers) add(name string, data []byte) {
+	if !strings.HasSuffix(strings.ToLower(name), ".tar") && isTarData(data) {
+		name += ".tar"
+	}
+	if m.names[name] {
+		ext := filepath.Ext(name)
+		base := strings.TrimSuffix(name, ext)
+		for i := 1; m.names[name]; i++ {
+			name = fmt.Sprintf("%s_%d%s", base, i, ext)
+		}
+	}
+	m.names[name] = true
+	m.list = append(m.list, memoryMember{name: name, data: data})
+	m.size += int64(len(data))
+}
+
+// isTarData 检查偏移257处是否为tar的ustar标识
+func isTarData(data []byte) bool {
+	return len(data) >= 262 && string(data[257:262]) == "ustar"
+}
+
+// memberReader 将压缩内容解压到members，总大小超过上限时返回false
+type memberReader func(reader io.Reader, filename string, members *memoryMembers) (bool, error)
+
+// parseInMemory 启用内存解压时尝试在内存中解压并解析file，返回是否已处理；
+// 超过上限时将file重置到开头并返回false，由调用方解压到临时目录
+func parseInMemory(file *os.File, filename string, read memberReader) ([]byte, bool, error) {
+	limit := internal.InMemoryLimit()
+	if limit <= 0 {
+		return nil, false, nil
+	}
+
+	members := newMemoryMembers(limit)
+	ok, err := read(file, filename, members)
+	if err != nil {
+		// 压缩包被截断或损坏，仍解析已读取的条目
+		if len(members.list) == 0 {
+			return []byte{}, true, err
+		}
+		content, cnt, _ := parseMembers(members.list)
+		logger.Logger.Printf("压缩包读取中断，返回已读取的 %d 个文件的内容: %v", cnt, err)
+		return content, true, partialError(err)
+	}
+	if !ok {
+		logger.Logger.Printf("解压后大小超过内存解压上限 %d 字节，改为解压到临时目录", limit)
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return []byte{}, true, fmt.Errorf("重置文件读取位置失败: %v", err)
+		}
+		return nil, false, nil
+	}
+
+	content, cnt, err := parseMembers(members.list)
+	if err != nil {
+		return content, true, err
+	}
+	logger.Logger.Printf("内存解压完成，共解析 %d 个文件", cnt)
+	return content, true, nil
+}
+
+// parseMembers 按路径排序后依次解析内存中的条目，输出格式与WalkDir一致
+func parseMembers(members []memoryMember) ([]byte, int, error) {
+	sort.Slice(members, func(i, j int) bool {
+		return filepath.ToSlash(members[i].name) < filepath.ToSlash(members[j].name)
+	})
+
+	var buffer bytes.Buffer
+	var fileCnt int
+	var partial error
+	dedup := newDedupSet()
+	for _, member := range members {
+		path := string(filepath.Separator) + member.name
+		fileType := internal.SniffFileTypeData(member.name, member.data)
+		if !included(member.name, fileType) {
+			writeSkipped(&buffer, path, int64(len(member.data)), fileType)
+			continue
+		}
+		if dedup != nil {
+			if first, ok := dedup.seen(sha256.Sum256(member.data), path); ok {
+				writeDuplicate(&buffer, path, int64(len(member.data)), fileType, first)
+				continue
+			}
+		}
+		logger.Logger.Printf("内存解析文件: %s", path)
+		content, err := internal.ParseReader(bytes.NewReader(member.data), fileType)
+		if err = memberError(path, err); err != nil {
+			if !errors.Is(err, internal.ErrPartialArchive) {
+				return buffer.Bytes(), fileCnt, fmt.Errorf("读取文件 %s 失败: %v", path, err)
+			}
+			partial = nestedPartialError(path, err)
+		}
+
+		fileCnt++
+		writeMember(&buffer, path, int64(len(member.data)), fileType, content)
+	}
+	return buffer.Bytes(), fileCnt, partial
+}
+
+// readTarMembers 将tar中的普通文件读入内存
+func readTarMembers(reader io.Reader, _ string, members *memoryMembers) (bool, error) {
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("tar解析错误: %v", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if name := sanitizePath(header.Name); !included(name, internal.GetDynamicFileType(name)) {
+			members.add(name, nil)
+			continue
+		}
+		// 头部已声明大小，超过上限时无需读取内容
+		if header.Size > members.remaining() {
+			return false, nil
+		}
+
+		data, ok, err := members.read(tarReader)
+		if err != nil {
+			return false, fmt.Errorf("读取文件 %s 失败: %w", header.Name, err)
+		}
+		if !ok {
+			return false, nil
+		}
+		members.add(sanitizePath(header.Name), data)
+	}
+}
+
+// readGzMembers 将gz的各个member读入内存，文件名规则与extractGzFromReader一致
+func readGzMembers(reader io.Reader, filename string, members *memoryMembers) (bool, error) {
+	br := bufio.NewReader(reader)
+	gzReader, err := gzip.NewReader(br)
+	if err != nil {
+		return false, fmt.Errorf("创建gzip reader失败: %v", err)
+	}
+	defer gzReader.Close()
+
+	for {
+		gzReader.Multistream(false)
+
+		data, ok, err := members.read(gzReader)
+		if err != nil {
+			// 与解压到临时目录一致，保留已解压的部分内容
+			if len(data) > 0 {
+				members.add(sanitizePath(gzMemberName(gzReader.Header.Name, filename)), data)
+			}
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+		members.add(sanitizePath(gzMemberName(gzReader.Header.Name, filename)), data)
+
+		if err = gzReader.Reset(br); err == io.EOF {
+			return true, nil
+		} else if err != nil {
+			return false, fmt.Errorf("读取gzip member失败: %v", err)
+		}
+	}
+}
+
+// readBz2Members 将bz2内容读入内存
+func readBz2Members(reader io.Reader, filename string, members *memoryMembers) (bool, error) {
+	data, ok, err := members.read(bzip2.NewReader(reader))
+	if !ok && err == nil {
+		return false, nil
+	}
+	// 读取出错时保留已解压的部分内容
+	if err == nil || len(data) > 0 {
+		members.add(sanitizePath(decompressedName(filename, ".bz2", bz2ShortExts)), data)
+	}
+	return err == nil, err
+}
+
+// readXzMembers 将xz内容读入内存
+func readXzMembers(reader io.Reader, filename string, members *memoryMembers) (bool, error) {
+	xzReader, err := xz.NewReader(reader)
+	if err != nil {
+		return false, err
+	}
+	data, ok, err := members.read(xzReader)
+	if !ok && err == nil {
+		return false, nil
+	}
+	// 读取出错时保留已解压的部分内容
+	if err == nil || len(data) > 0 {
+		members.add(sanitizePath(decompressedName(filename, ".xz", xzShortExts)), data)
+	}
+	return err == nil, err
+}
+
+// readZstMembers 将zstd内容读入内存
+func readZstMembers(reader io.Reader, filename string, members *memoryMembers) (bool, error) {
+	zstReader, err := newZstReader(reader)
+	if err != nil {
+		return false, err
+	}
+	defer zstReader.Close()
+	return readSingleMember(zstReader, decompressedName(filename, ".zst", zstShortExts), members)
+}
+
+// readLz4Members 将lz4内容读入内存
+func readLz4Members(reader io.Reader, filename string, members *memoryMembers) (bool, error) {
+	return readSingleMember(lz4.NewReader(reader), decompressedName(filename, ".lz4", nil), members)
+}
+
+// readSnappyMembers 将snappy内容读入内存
+func readSnappyMembers(reader io.Reader, filename string, members *memoryMembers) (bool, error) {
+	return readSingleMember(newSnappyReader(reader), decompressedName(filename, ".sz", nil), members)
+}
+
+// readSingleMember 将单文件压缩格式解压出的内容作为名为name的条目读入内存
+func readSingleMember(r io.Reader, name string, members *memoryMembers) (bool, error) {
+	data, ok, err := members.read(r)
+	if !ok && err == nil {
+		return false, nil
+	}
+	// 读取出错时保留已解压的部分内容
+	if err == nil || len(data) > 0 {
+		members.add(sanitizePath(name), data)
+	}
+	return err == nil, err
+}
@@ -0,0 +1,83 @@
+package compressfile
+
+import (
+	"bytes"
+	"fextra/internal"
+	"fextra/pkg/logger"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/s2"
+)
+
+// SnappyFileParser 解析snappy帧格式（.sz）的压缩文件，不支持没有帧头的原始snappy块
+type SnappyFileParser struct{}
+
+func (p *SnappyFileParser) Parse(filePath string) ([]byte, error) {
+	var content bytes.Buffer
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return content.Bytes(), fmt.Errorf("无法打开文件: %v", err)
+	}
+	defer file.Close()
+
+	if content, ok, err := parseInMemory(file, filePath, readSnappyMembers); ok {
+		return content, err
+	}
+	return bufferOutput(func(w io.Writer) error { return parseSnappyTo(file, filePath, w) })
+}
+
+// ParseTo 解压snappy并逐个文件解析写入w，不使用内存解压
+func (p *SnappyFileParser) ParseTo(filePath string, w io.Writer) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("无法打开文件: %v", err)
+	}
+	defer file.Close()
+
+	return parseSnappyTo(file, filePath, w)
+}
+
+func init() {
+	// snappy类型: 305(sz、tar.sz)
+	internal.RegisterParser(internal.FileTypeSNAPPY, &SnappyFileParser{})
+}
+
+// newSnappyReader 创建snappy帧格式的解压器，s2兼容snappy的帧格式
+func newSnappyReader(reader io.Reader) io.Reader {
+	return s2.NewReader(reader)
+}
+
+// parseSnappyTo 从io.Reader解压snappy内容，逐个文件解析并写入w
+func parseSnappyTo(reader io.Reader, filename string, w io.Writer) error {
+	tmpDir, err := internal.MkdirTemp("sz_extract_")
+	if err != nil {
+		return fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	logger.Logger.Printf("临时目录: %s", tmpDir)
+
+	_, extractErr := extractSnappyFromReader(reader, filename, tmpDir)
+	cnt, err := walkExtractedTo(tmpDir, w, extractErr)
+	if err != nil {
+		return err
+	}
+
+	logger.Logger.Printf("snappy文件解析完成，共提取 %d 个文件(一级目录)", cnt)
+	return nil
+}
+
+// extractSnappyFromReader 将snappy内容解压到destDir，返回解压出的文件路径
+func extractSnappyFromReader(reader io.Reader, filename string, destDir string) ([]string, error) {
+	original := decompressedName(filename, ".sz", nil)
+	safePath := filepath.Join(destDir, sanitizePath(original))
+	if err := writeDecompressedFile(newSnappyReader(reader), safePath); err != nil {
+		// 保留已解压的部分内容
+		return []string{renameIfTar(safePath)}, err
+	}
+
+	return []string{renameIfTar(safePath)}, nil
+}
@@ -45,54 +45,115 @@ func (p *TarFileParser) Parse(filePath string) ([]byte, error) {
 	}
 	defer file.Close()
 
-	return parseTarFromReader(file)
+	if content, ok, err := parseInMemory(file, filePath, readTarMembers); ok {
+		return content, err
+	}
+	return bufferOutput(func(w io.Writer) error { return parseTarTo(file, w) })
+}
+
+// ParseTo 解压tar并逐个文件解析写入w，不使用内存解压
+func (p *TarFileParser) ParseTo(filePath string, w io.Writer) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("无法打开文件: %v", err)
+	}
+	defer file.Close()
+
+	return parseTarTo(file, w)
+}
+
+// ParseReader 从io.Reader解析tar内容，使压缩包中嵌套的tar无需写入临时文件；
+// 启用内存解压且内容不超过上限时直接在内存中解析
+func (p *TarFileParser) ParseReader(r io.Reader) ([]byte, error) {
+	limit := internal.InMemoryLimit()
+	if limit <= 0 {
+		return bufferOutput(func(w io.Writer) error { return parseTarTo(r, w) })
+	}
+
+	// tar条目总大小不超过tar本身，内容不超过上限时条目必然可全部读入内存
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return []byte{}, fmt.Errorf("读取tar内容失败: %v", err)
+	}
+	if int64(len(data)) > limit {
+		logger.Logger.Printf("tar大小超过内存解压上限 %d 字节，改为解压到临时目录", limit)
+		return bufferOutput(func(w io.Writer) error { return parseTarTo(io.MultiReader(bytes.NewReader(data), r), w) })
+	}
+
+	members := newMemoryMembers(limit)
+	_, readErr := readTarMembers(bytes.NewReader(data), "", members)
+	if readErr != nil && len(members.list) == 0 {
+		return []byte{}, readErr
+	}
+	content, _, err := parseMembers(members.list)
+	if readErr != nil {
+		// tar被截断或损坏，返回已读取条目的内容
+		return content, partialError(readErr)
+	}
+	return content, err
 }
 
 func init() {
 	internal.RegisterParser(internal.FileTypeTAR, &TarFileParser{})
 }
 
-// parseTarFromReader 从io.Reader解析tar内容并返回格式化字符串
-func parseTarFromReader(reader io.Reader) ([]byte, error) {
-	tarReader := tar.NewReader(reader)
-	var tarContent bytes.Buffer
-
+// parseTarTo 从io.Reader解压tar内容，逐个文件解析并写入w
+func parseTarTo(reader io.Reader, w io.Writer) error {
 	// 创建临时目录
-	tmpDir, err := os.MkdirTemp("", "tar_extract_")
+	tmpDir, err := internal.MkdirTemp("tar_extract_")
 	if err != nil {
-		return tarContent.Bytes(), fmt.Errorf("创建临时目录失败: %v", err)
+		return fmt.Errorf("创建临时目录失败: %v", err)
 	}
 	defer os.RemoveAll(tmpDir) // 确保程序退出时清理临时目录
 	logger.Logger.Printf("临时目录: %s", tmpDir)
 
+	_, extractErr := extractTarFromReader(reader, tmpDir)
+	files, err := walkExtractedTo(tmpDir, w, extractErr)
+	if err != nil {
+		return err
+	}
+
+	logger.Logger.Printf("Tar文件解析完成，共提取 %d 个文件(一级目录)", files)
+	return nil
+}
+
+// extractTarFromReader 将tar内容解压到destDir，返回解压出的文件路径
+func extractTarFromReader(reader io.Reader, destDir string) ([]string, error) {
+	tarReader := tar.NewReader(reader)
+
+	var extracted []string
 	for {
 		header, err := tarReader.Next()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return tarContent.Bytes(), fmt.Errorf("tar解析错误: %v", err)
+			return extracted, fmt.Errorf("tar解析错误: %v", err)
 		}
 
-		targetPath := filepath.Join(tmpDir, sanitizePath(header.Name))
-
+		targetPath := filepath.Join(destDir, sanitizePath(header.Name))
 		switch header.Typeflag {
 		case tar.TypeDir: // 处理目录
 			if err := os.MkdirAll(targetPath, os.FileMode(header.Mode)); err != nil {
-				return tarContent.Bytes(), fmt.Errorf("创建目录 %s 失败: %w", targetPath, err)
+				return extracted, fmt.Errorf("创建目录 %s 失败: %w", targetPath, err)
 			}
 		case tar.TypeReg: // 处理普通文件
+			if !included(header.Name, internal.GetDynamicFileType(targetPath)) {
+				if err := writePlaceholder(targetPath); err != nil {
+					return extracted, fmt.Errorf("写入文件 %s 失败: %w", targetPath, err)
+				}
+				extracted = append(extracted, targetPath)
+				break
+			}
 			if err := writeTarFile(tarReader, targetPath, header); err != nil {
-				return tarContent.Bytes(), fmt.Errorf("写入文件 %s 失败: %w", targetPath, err)
+				// 条目内容不完整，删除写入了一部分的文件，只保留完整的条目
+				os.Remove(targetPath)
+				return extracted, fmt.Errorf("写入文件 %s 失败: %w", targetPath, err)
 			}
+			extracted = append(extracted, targetPath)
 		}
-		logger.Logger.Printf("提取文件: %s", strings.TrimPrefix(targetPath, tmpDir))
+		logger.Logger.Printf("提取文件: %s", strings.TrimPrefix(targetPath, destDir))
 	}
 
-	content, files, err := WalkDir(tmpDir)
-	if err != nil {
-		return content, err
-	}
-	logger.Logger.Printf("Tar文件解析完成，共提取 %d 个文件(一级目录)", files)
-	return content, nil
+	return extracted, nil
 }
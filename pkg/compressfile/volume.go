@@ -0,0 +1,495 @@
+package compressfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"fextra/internal"
+	"fextra/pkg/logger"
+
+	"github.com/nwaples/rardecode"
+)
+
+/*
+	分卷压缩包：较大的压缩包（如备份）常按以下方式分卷保存，单独的一个分卷无法解压
+		name.zip.001、name.7z.001、name.rar.001  7-Zip、HJSplit按字节切分，依次拼接即为完整的压缩包
+		name.z01 … name.zNN、name.zip           zip -s、WinZip分卷，中央目录中的偏移量相对于各自的分卷，拼接后需修正
+		name.part1.rar … name.partN.rar         RAR 3.0以后的分卷，每个分卷都是独立的RAR卷
+		name.rar、name.r00 … name.rNN           RAR 2.x的分卷命名
+	解析任一分卷时在同一目录查找其余分卷，重组为完整的压缩包后解压；分卷不在同一目录或文件名不符合上述规则时，
+	可通过SetVolumes指定全部分卷
+*/
+
+var (
+	splitVolumeName  = regexp.MustCompile(`^(.+\.(?i:zip|7z|rar))\.(\d{3})$`)
+	zipVolumeName    = regexp.MustCompile(`^(.+)\.(?:([zZ])(\d{2})|((?i:zip)))$`)
+	rarPartName      = regexp.MustCompile(`^(.+\.(?i:part))(\d+)(\.(?i:rar))$`)
+	rarOldVolumeName = regexp.MustCompile(`^(.+)\.(?:([rR])(\d{2})|((?i:rar)))$`)
+)
+
+// rarSignature RAR 1.5至RAR 5.0文件头的公共部分
+var rarSignature = []byte("Rar!\x1a\x07")
+
+var suppliedVolumes atomic.Pointer[[]string]
+
+// SetVolumes 指定分卷压缩包的全部分卷路径（按分卷顺序），解析其中任一分卷时按此列表重组，
+// 用于分卷不在同一目录或文件名不符合分卷命名规则的情况；为空时按文件名在同一目录查找其余分卷
+func SetVolumes(volumes []string) {
+	if len(volumes) == 0 {
+		suppliedVolumes.Store(nil)
+		return
+	}
+	suppliedVolumes.Store(&volumes)
+}
+
+// findVolumes 返回filePath所属分卷压缩包的全部分卷路径（按分卷顺序），filePath不是分卷或只有一个分卷时返回nil
+func findVolumes(filePath string) []string {
+	if supplied := suppliedVolumes.Load(); supplied != nil {
+		for _, v := range *supplied {
+			if sameFile(v, filePath) {
+				return *supplied
+			}
+		}
+	}
+	return discoverVolumes(filePath)
+}
+
+// discoverVolumes 按分卷命名规则在filePath所在目录查找全部分卷，不是分卷或只有一个分卷时返回nil
+func discoverVolumes(filePath string) []string {
+	return namedVolumes(filePath, fileExists)
+}
+
+// namedVolumes 按分卷命名规则查找filePath所属分卷压缩包的全部分卷，exists判断分卷是否存在，
+// 用于在磁盘或压缩包的条目中查找；不是分卷或只有一个分卷时返回nil
+func namedVolumes(filePath string, exists func(path string) bool) []string {
+	dir, name := filepath.Split(filePath)
+	var volumes []string
+	if m := splitVolumeName.FindStringSubmatch(name); m != nil {
+		volumes = numberedVolumes(dir, func(n int) string { return fmt.Sprintf("%s.%03d", m[1], n) }, 1, exists)
+	} else if m := rarPartName.FindStringSubmatch(name); m != nil {
+		width := len(m[2])
+		volumes = numberedVolumes(dir, func(n int) string { return fmt.Sprintf("%s%0*d%s", m[1], width, n, m[3]) }, 1, exists)
+	} else if m := zipVolumeName.FindStringSubmatch(name); m != nil {
+		volumes = suffixedVolumes(dir, m[1], "z", "zip", m[2] == "Z" || m[4] == "ZIP", 1, exists)
+	} else if m := rarOldVolumeName.FindStringSubmatch(name); m != nil {
+		// RAR 2.x的第一个分卷为.rar，其后依次为.r00、.r01……
+		last := filepath.Join(dir, m[1]+"."+caseOf("rar", m[2] == "R" || m[4] == "RAR"))
+		if !exists(last) {
+			return nil
+		}
+		rest := suffixedVolumes(dir, m[1], "r", "", m[2] == "R" || m[4] == "RAR", 0, exists)
+		if len(rest) == 0 {
+			return nil
+		}
+		volumes = append([]string{last}, rest...)
+	}
+	if len(volumes) < 2 {
+		return nil
+	}
+	return volumes
+}
+
+// numberedVolumes 从first开始依次查找name(n)对应的文件，直到某个编号不存在
+func numberedVolumes(dir string, name func(n int) string, first int, exists func(path string) bool) []string {
+	var volumes []string
+	for n := first; ; n++ {
+		path := filepath.Join(dir, name(n))
+		if !exists(path) {
+			return volumes
+		}
+		volumes = append(volumes, path)
+	}
+}
+
+// suffixedVolumes 依次查找base.<letter>NN分卷，编号从first开始；final不为空时最后一个分卷为base.final，不存在时返回nil
+func suffixedVolumes(dir, base, letter, final string, upper bool, first int, exists func(path string) bool) []string {
+	letter = caseOf(letter, upper)
+	volumes := numberedVolumes(dir, func(n int) string { return fmt.Sprintf("%s.%s%02d", base, letter, n) }, first, exists)
+	if final == "" || len(volumes) == 0 {
+		return volumes
+	}
+	last := filepath.Join(dir, base+"."+caseOf(final, upper))
+	if !exists(last) {
+		return nil
+	}
+	return append(volumes, last)
+}
+
+// caseOf Windows上创建的分卷后缀可能为大写，其余分卷的后缀与之保持一致
+func caseOf(s string, upper bool) string {
+	if upper {
+		return strings.ToUpper(s)
+	}
+	return s
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+func sameFile(a, b string) bool {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bi, err := os.Stat(b)
+	return err == nil && os.SameFile(ai, bi)
+}
+
+// isSecondaryVolume filePath是否为分卷压缩包中第一个分卷以外的分卷，解压出的目录中只解析第一个分卷，避免重复输出
+func isSecondaryVolume(filePath string) bool {
+	volumes := findVolumes(filePath)
+	return volumes != nil && !sameFile(volumes[0], filePath)
+}
+
+// isRarVolumes 各分卷是否为独立的RAR卷，按字节切分的分卷除第一个外不以RAR文件头开始
+func isRarVolumes(volumes []string) bool {
+	file, err := os.Open(volumes[1])
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	head := make([]byte, len(rarSignature))
+	if _, err := io.ReadFull(file, head); err != nil {
+		return false
+	}
+	return bytes.Equal(head, rarSignature)
+}
+
+// joinVolumes 按顺序拼接全部分卷，写入临时文件并返回其路径，临时文件的后缀与拼接前的压缩包相同；
+// 拼接后为ZIP分卷时修正中央目录中相对于各分卷的偏移量
+func joinVolumes(volumes []string) (string, error) {
+	name := filepath.Base(volumes[0])
+	if m := splitVolumeName.FindStringSubmatch(name); m != nil {
+		name = m[1]
+	}
+	dst, err := internal.CreateTemp("volumes_*" + filepath.Ext(name))
+	if err != nil {
+		return "", fmt.Errorf("创建临时文件失败: %v", err)
+	}
+
+	starts, err := concatVolumes(dst, volumes)
+	if err == nil {
+		err = fixSpannedZip(dst, starts)
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst.Name())
+		return "", err
+	}
+	logger.Logger.Printf("已拼接 %d 个分卷: %s", len(volumes), dst.Name())
+	return dst.Name(), nil
+}
+
+// concatVolumes 将各分卷依次写入dst，返回每个分卷在dst中的起始偏移
+func concatVolumes(dst io.Writer, volumes []string) ([]int64, error) {
+	starts := make([]int64, 0, len(volumes))
+	var offset int64
+	for _, path := range volumes {
+		starts = append(starts, offset)
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("无法打开分卷: %v", err)
+		}
+		n, err := io.Copy(dst, file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取分卷 %s 失败: %v", path, err)
+		}
+		offset += n
+	}
+	return starts, nil
+}
+
+// 分卷ZIP中需要修正的记录，中央目录条目的签名及ZIP64扩展字段ID见zip_recover.go
+const (
+	zipDirEndSig      = 0x06054b50
+	zipDir64EndSig    = 0x06064b50
+	zipDir64LocSig    = 0x07064b50
+	zipDirEndLen      = 22
+	zipDir64LocLen    = 20
+	zipDir64EndLen    = 56
+	zipDirHeaderLen   = 46
+	zipMaxCommentLen  = 0xffff
+	zipUint16Max      = 0xffff
+	zipUint32Max      = 0xffffffff
+	zipMaxEndSearched = zipDirEndLen + zipMaxCommentLen
+)
+
+// fixSpannedZip 拼接后的ZIP分卷中，中央目录记录的各条目偏移量、中央目录及ZIP64记录的位置均相对于所在的分卷，
+// 按starts换算为拼接后的绝对偏移并将分卷号置0，使archive/zip能够读取。不是ZIP或未分卷（分卷号均为0）时不做修改
+func fixSpannedZip(f *os.File, starts []int64) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+
+	endOffset, end := findZipDirEnd(f, size)
+	if end == nil {
+		return nil
+	}
+	disk := uint32(binary.LittleEndian.Uint16(end[4:]))
+	dirDisk := uint32(binary.LittleEndian.Uint16(end[6:]))
+	dirSize := uint64(binary.LittleEndian.Uint32(end[12:]))
+	dirOffset := uint64(binary.LittleEndian.Uint32(end[16:]))
+
+	// ZIP64：目录结束记录前为ZIP64定位记录，指向ZIP64目录结束记录
+	var loc, end64 []byte
+	var end64Offset int64
+	if endOffset >= zipDir64LocLen {
+		loc = make([]byte, zipDir64LocLen)
+		if _, err := f.ReadAt(loc, endOffset-zipDir64LocLen); err != nil || binary.LittleEndian.Uint32(loc) != zipDir64LocSig {
+			loc = nil
+		}
+	}
+	if loc != nil {
+		locDisk := binary.LittleEndian.Uint32(loc[4:])
+		if int(locDisk) >= len(starts) {
+			return fmt.Errorf("ZIP64定位记录的分卷号%d超出分卷数%d", locDisk, len(starts))
+		}
+		end64Offset = starts[locDisk] + int64(binary.LittleEndian.Uint64(loc[8:]))
+		end64 = make([]byte, zipDir64EndLen)
+		if _, err := f.ReadAt(end64, end64Offset); err != nil || binary.LittleEndian.Uint32(end64) != zipDir64EndSig {
+			return fmt.Errorf("找不到ZIP64目录结束记录: %w", internal.ErrTruncated)
+		}
+		disk = binary.LittleEndian.Uint32(end64[16:])
+		dirDisk = binary.LittleEndian.Uint32(end64[20:])
+		dirSize = binary.LittleEndian.Uint64(end64[40:])
+		dirOffset = binary.LittleEndian.Uint64(end64[48:])
+	}
+	if disk == 0 && dirDisk == 0 {
+		return nil
+	}
+	if int(dirDisk) >= len(starts) {
+		return fmt.Errorf("中央目录所在的分卷号%d超出分卷数%d，可能缺少分卷", dirDisk, len(starts))
+	}
+
+	dirStart := starts[dirDisk] + int64(dirOffset)
+	if dirStart < 0 || dirStart+int64(dirSize) > size {
+		return fmt.Errorf("中央目录超出文件范围: %w", internal.ErrTruncated)
+	}
+	dir := make([]byte, dirSize)
+	if _, err := f.ReadAt(dir, dirStart); err != nil {
+		return fmt.Errorf("读取中央目录失败: %v", err)
+	}
+	records, err := fixZipDirHeaders(dir, starts)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteAt(dir, dirStart); err != nil {
+		return err
+	}
+
+	if end64 != nil {
+		binary.LittleEndian.PutUint32(end64[16:], 0)
+		binary.LittleEndian.PutUint32(end64[20:], 0)
+		binary.LittleEndian.PutUint64(end64[24:], uint64(records))
+		binary.LittleEndian.PutUint64(end64[32:], uint64(records))
+		binary.LittleEndian.PutUint64(end64[48:], uint64(dirStart))
+		if _, err := f.WriteAt(end64, end64Offset); err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint32(loc[4:], 0)
+		binary.LittleEndian.PutUint64(loc[8:], uint64(end64Offset))
+		binary.LittleEndian.PutUint32(loc[16:], 1)
+		if _, err := f.WriteAt(loc, endOffset-zipDir64LocLen); err != nil {
+			return err
+		}
+	}
+
+	binary.LittleEndian.PutUint16(end[4:], 0)
+	binary.LittleEndian.PutUint16(end[6:], 0)
+	if binary.LittleEndian.Uint16(end[10:]) != zipUint16Max {
+		binary.LittleEndian.PutUint16(end[8:], binary.LittleEndian.Uint16(end[10:]))
+	}
+	if binary.LittleEndian.Uint32(end[16:]) != zipUint32Max {
+		if dirStart >= zipUint32Max {
+			return fmt.Errorf("拼接后中央目录偏移超出ZIP32范围且没有ZIP64记录")
+		}
+		binary.LittleEndian.PutUint32(end[16:], uint32(dirStart))
+	}
+	_, err = f.WriteAt(end[:zipDirEndLen], endOffset)
+	return err
+}
+
+// findZipDirEnd 在文件末尾查找目录结束记录，返回其偏移及内容，找不到时返回nil
+func findZipDirEnd(f io.ReaderAt, size int64) (int64, []byte) {
+	searchLen := min(size, zipMaxEndSearched)
+	buf := make([]byte, searchLen)
+	if _, err := f.ReadAt(buf, size-searchLen); err != nil {
+		return 0, nil
+	}
+	for i := len(buf) - zipDirEndLen; i >= 0; i-- {
+		if binary.LittleEndian.Uint32(buf[i:]) == zipDirEndSig {
+			return size - searchLen + int64(i), buf[i:]
+		}
+	}
+	return 0, nil
+}
+
+// fixZipDirHeaders 将中央目录中各条目本地文件头的偏移换算为绝对偏移并将起始分卷号置0，返回条目数
+func fixZipDirHeaders(dir []byte, starts []int64) (int, error) {
+	var records int
+	for p := 0; p+zipDirHeaderLen <= len(dir); records++ {
+		h := dir[p:]
+		if binary.LittleEndian.Uint32(h) != zipCentralHeaderSig {
+			return records, fmt.Errorf("中央目录第%d条记录签名错误: %w", records, internal.ErrInvalidSignature)
+		}
+		nameLen := int(binary.LittleEndian.Uint16(h[28:]))
+		extraLen := int(binary.LittleEndian.Uint16(h[30:]))
+		commentLen := int(binary.LittleEndian.Uint16(h[32:]))
+		next := p + zipDirHeaderLen + nameLen + extraLen + commentLen
+		if next > len(dir) {
+			return records, fmt.Errorf("中央目录第%d条记录: %w", records, internal.ErrTruncated)
+		}
+
+		disk := uint32(binary.LittleEndian.Uint16(h[34:]))
+		offset := uint64(binary.LittleEndian.Uint32(h[42:]))
+		// ZIP64扩展字段依次为原始大小、压缩大小、偏移、分卷号，只包含对应字段为最大值的项
+		var offset64, disk64 []byte
+		extra := h[zipDirHeaderLen+nameLen : zipDirHeaderLen+nameLen+extraLen]
+		for len(extra) >= 4 {
+			id := binary.LittleEndian.Uint16(extra)
+			n := int(binary.LittleEndian.Uint16(extra[2:]))
+			if 4+n > len(extra) {
+				break
+			}
+			if id == zip64ExtraID {
+				field := extra[4 : 4+n]
+				if binary.LittleEndian.Uint32(h[24:]) == zipUint32Max && len(field) >= 8 {
+					field = field[8:]
+				}
+				if binary.LittleEndian.Uint32(h[20:]) == zipUint32Max && len(field) >= 8 {
+					field = field[8:]
+				}
+				if offset == zipUint32Max && len(field) >= 8 {
+					offset64, field = field[:8], field[8:]
+					offset = binary.LittleEndian.Uint64(offset64)
+				}
+				if disk == zipUint16Max && len(field) >= 4 {
+					disk64 = field[:4]
+					disk = binary.LittleEndian.Uint32(disk64)
+				}
+			}
+			extra = extra[4+n:]
+		}
+
+		if int(disk) >= len(starts) {
+			return records, fmt.Errorf("条目所在的分卷号%d超出分卷数%d，可能缺少分卷", disk, len(starts))
+		}
+		abs := uint64(starts[disk]) + offset
+		switch {
+		case offset64 != nil:
+			binary.LittleEndian.PutUint64(offset64, abs)
+		case abs < zipUint32Max:
+			binary.LittleEndian.PutUint32(h[42:], uint32(abs))
+		default:
+			return records, fmt.Errorf("拼接后条目偏移超出ZIP32范围且没有ZIP64扩展字段")
+		}
+		if disk64 != nil {
+			binary.LittleEndian.PutUint32(disk64, 0)
+		} else {
+			binary.LittleEndian.PutUint16(h[34:], 0)
+		}
+		p = next
+	}
+	return records, nil
+}
+
+// extractRarVolumes 使用rardecode将RAR分卷解压到destDir，返回解压出的文件路径。
+// rardecode按文件名依次打开后续分卷，分卷由SetVolumes指定且不符合命名规则时先在临时目录中按规则链接
+func extractRarVolumes(volumes []string, destDir string, password string) ([]string, error) {
+	first := volumes[0]
+	if discovered := discoverVolumes(first); len(discovered) != len(volumes) || !sameFile(discovered[0], first) {
+		linkDir, err := internal.MkdirTemp("rar_volumes_")
+		if err != nil {
+			return nil, fmt.Errorf("创建临时目录失败: %v", err)
+		}
+		defer os.RemoveAll(linkDir)
+		if first, err = linkRarVolumes(volumes, linkDir); err != nil {
+			return nil, err
+		}
+	}
+
+	r, err := rardecode.OpenReader(first, password)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开RAR分卷: %v", err)
+	}
+	defer r.Close()
+
+	logger.Logger.Printf("提取RAR分卷: %s，共 %d 个分卷", volumes[0], len(volumes))
+	var extracted []string
+	for {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return extracted, fmt.Errorf("读取RAR分卷失败: %v", err)
+		}
+
+		// 防止路径遍历攻击
+		safePath := filepath.Join(destDir, sanitizePath(hdr.Name))
+		if hdr.IsDir {
+			if err := os.MkdirAll(safePath, 0755); err != nil {
+				return extracted, fmt.Errorf("创建目录失败 %s: %v", safePath, err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(safePath), 0755); err != nil {
+			return extracted, fmt.Errorf("创建目录失败 %s: %v", safePath, err)
+		}
+
+		if !included(hdr.Name, internal.GetDynamicFileType(safePath)) {
+			if err := writePlaceholder(safePath); err != nil {
+				return extracted, fmt.Errorf("写入文件 %s 失败: %v", safePath, err)
+			}
+			extracted = append(extracted, safePath)
+			continue
+		}
+		if err := WriteDstFile(io.NopCloser(r), safePath, 0755); err != nil {
+			// 条目内容不完整，删除写入了一部分的文件，只保留完整的条目
+			os.Remove(safePath)
+			return extracted, err
+		}
+		extracted = append(extracted, safePath)
+	}
+
+	logger.Logger.Printf("RAR分卷提取完成，共提取 %d 个文件", len(extracted))
+	return extracted, nil
+}
+
+// linkRarVolumes 在dir中为各分卷创建符合命名规则的符号链接，返回第一个分卷的链接路径。
+// RAR 3.0以后的分卷按volume.partN.rar查找，RAR 2.x格式的分卷按volume.part1.rNN查找，两种名称均创建
+func linkRarVolumes(volumes []string, dir string) (string, error) {
+	width := len(strconv.Itoa(len(volumes)))
+	for i, v := range volumes {
+		abs, err := filepath.Abs(v)
+		if err != nil {
+			return "", err
+		}
+		names := []string{fmt.Sprintf("volume.part%0*d.rar", width, i+1)}
+		if i > 0 {
+			names = append(names, fmt.Sprintf("volume.part%0*d.r%02d", width, 1, i-1))
+		}
+		for _, name := range names {
+			if err := os.Symlink(abs, filepath.Join(dir, name)); err != nil {
+				return "", fmt.Errorf("链接分卷 %s 失败: %v", v, err)
+			}
+		}
+	}
+	return filepath.Join(dir, fmt.Sprintf("volume.part%0*d.rar", width, 1)), nil
+}
@@ -12,6 +12,9 @@ import (
 	"github.com/ulikunitz/xz"
 )
 
+// xzShortExts xz的简写后缀及解压后对应的后缀
+var xzShortExts = map[string]string{".txz": ".tar"}
+
 type XzFileParser struct{}
 
 func (p *XzFileParser) Parse(filePath string) ([]byte, error) {
@@ -23,12 +26,27 @@ func (p *XzFileParser) Parse(filePath string) ([]byte, error) {
 	}
 	defer file.Close()
 
-	return parseXzFromReader(file, filePath)
+	if content, ok, err := parseInMemory(file, filePath, readXzMembers); ok {
+		return content, err
+	}
+	return bufferOutput(func(w io.Writer) error { return parseXzTo(file, filePath, w) })
+}
+
+// ParseTo 解压xz并逐个文件解析写入w，不使用内存解压
+func (p *XzFileParser) ParseTo(filePath string, w io.Writer) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("无法打开文件: %v", err)
+	}
+	defer file.Close()
+
+	return parseXzTo(file, filePath, w)
 }
 
 func init() {
-	// XZ相关类型:29(xz)
+	// XZ相关类型:29(xz), 302(tar.xz)
 	internal.RegisterParser(internal.FileTypeXZ, &XzFileParser{})
+	internal.RegisterParser(internal.FileTypeTARXZ, &XzFileParser{})
 }
 
 func WriteXzFile(reader *xz.Reader, path string, mode os.FileMode) error {
@@ -42,32 +60,39 @@ func WriteXzFile(reader *xz.Reader, path string, mode os.FileMode) error {
 	return err
 }
 
-func parseXzFromReader(reader io.Reader, filename string) ([]byte, error) {
-	xzReader, err := xz.NewReader(reader)
-	if err != nil {
-		return []byte{}, err
-	}
-
+// parseXzTo 从io.Reader解压xz内容，逐个文件解析并写入w
+func parseXzTo(reader io.Reader, filename string, w io.Writer) error {
 	// 创建临时目录
-	tmpDir, err := os.MkdirTemp("", "xz_extract_")
+	tmpDir, err := internal.MkdirTemp("xz_extract_")
 	if err != nil {
-		return []byte{}, fmt.Errorf("创建临时目录失败: %v", err)
+		return fmt.Errorf("创建临时目录失败: %v", err)
 	}
 	defer os.RemoveAll(tmpDir) // 确保程序退出时清理临时目录
 	logger.Logger.Printf("临时目录: %s", tmpDir)
 
-	original := filepath.Base(filename[:len(filename)-len(".xz")])
-	safePath := filepath.Join(tmpDir, sanitizePath(original))
-
-	if err = WriteXzFile(xzReader, safePath, os.ModePerm); err != nil {
-		return []byte{}, err
+	_, extractErr := extractXzFromReader(reader, filename, tmpDir)
+	cnt, err := walkExtractedTo(tmpDir, w, extractErr)
+	if err != nil {
+		return err
 	}
 
-	content, cnt, err := WalkDir(tmpDir)
+	logger.Logger.Printf("xz文件解析完成，共提取 %d 个文件(一级目录)", cnt)
+	return nil
+}
+
+// extractXzFromReader 将xz内容解压到destDir，返回解压出的文件路径
+func extractXzFromReader(reader io.Reader, filename string, destDir string) ([]string, error) {
+	xzReader, err := xz.NewReader(reader)
 	if err != nil {
-		return content, err
+		return nil, err
 	}
 
-	logger.Logger.Printf("xz文件解析完成，共提取 %d 个文件(一级目录)", cnt)
-	return content, nil
+	original := decompressedName(filename, ".xz", xzShortExts)
+	safePath := filepath.Join(destDir, sanitizePath(original))
+	if err = WriteXzFile(xzReader, safePath, os.ModePerm); err != nil {
+		// 保留已解压的部分内容
+		return []string{renameIfTar(safePath)}, err
+	}
+
+	return []string{renameIfTar(safePath)}, nil
 }
@@ -2,10 +2,14 @@ package compressfile
 
 import (
 	"archive/zip"
+	"crypto/sha256"
+	"errors"
 	"fextra/internal"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"fextra/pkg/logger"
 )
@@ -13,36 +17,292 @@ import (
 type ZipFileParser struct{}
 
 // 提取zip压缩文件中所有文件的内容
+// 支持io.Reader的解析器直接读取ZIP条目，其余解析器（如pdfcpu、7z）依赖文件路径，写入临时目录后再解析
 func (p *ZipFileParser) Parse(filePath string) ([]byte, error) {
+	return bufferOutput(func(w io.Writer) error { return p.ParseTo(filePath, w) })
+}
+
+// ParseTo 逐个条目解析zip并写入w
+func (p *ZipFileParser) ParseTo(filePath string, w io.Writer) error {
+	// 分卷ZIP先拼接为完整的压缩包
+	if volumes := findVolumes(filePath); volumes != nil {
+		joined, err := joinVolumes(volumes)
+		if err != nil {
+			return fmt.Errorf("拼接分卷失败: %w", err)
+		}
+		defer os.Remove(joined)
+		filePath = joined
+	}
+
 	r, err := zip.OpenReader(filePath)
 	if err != nil {
-		return []byte{}, fmt.Errorf("无法打开文件: %v", err)
+		return parseRecoveredZip(filePath, w, fmt.Errorf("无法打开文件: %v", err))
 	}
 	defer r.Close()
+
 	logger.Logger.Printf("提取文件: %s", filePath)
 
-	// 创建临时目录
-	tmpDir, err := os.MkdirTemp("", "zip_extract_")
+	// 临时目录按需创建
+	var tmpDir string
+	defer func() {
+		if tmpDir != "" {
+			os.RemoveAll(tmpDir)
+		}
+	}()
+
+	// 按条目路径排序，不依赖中央目录中的顺序，与WalkDir的输出顺序一致
+	type zipEntry struct {
+		name string
+		file *zip.File
+	}
+	var entries []zipEntry
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		// 防止路径遍历攻击
+		entries = append(entries, zipEntry{sanitizePath(f.Name), f})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+	members := make(map[string]*zip.File, len(entries))
+	for _, entry := range entries {
+		members[entry.name] = entry.file
+	}
+	memberExists := func(name string) bool { return members[name] != nil }
+
+	out := newStickyWriter(w)
+	var fileCnt int
+	var partial error
+	dedup := newDedupSet()
+	// 只有CRC32及大小都与其他条目相同的条目才可能重复，只为这些条目解压计算SHA-256
+	var sameCRC map[zipContentKey]int
+	if dedup != nil {
+		sameCRC = make(map[zipContentKey]int)
+		for _, entry := range entries {
+			sameCRC[contentKey(entry.file)]++
+		}
+	}
+	for _, entry := range entries {
+		f, name := entry.file, entry.name
+		// 压缩包内的分卷在第一个分卷处与其余分卷一起解析
+		volumes := namedVolumes(name, memberExists)
+		if volumes != nil && volumes[0] != name {
+			logger.DebugLogger.Printf("跳过分卷: %s", name)
+			continue
+		}
+		fileType := zipMemberType(f, name)
+		if !included(name, fileType) {
+			writeSkipped(out, "/"+name, int64(f.UncompressedSize64), fileType)
+			continue
+		}
+		if dedup != nil && sameCRC[contentKey(f)] > 1 {
+			if sum, err := zipSum(f); err == nil {
+				if first, ok := dedup.seen(sum, "/"+name); ok {
+					writeDuplicate(out, "/"+name, int64(f.UncompressedSize64), fileType, first)
+					continue
+				}
+			}
+		}
+		logger.DebugLogger.Printf("处理ZIP条目: %s, 类型: %s", f.Name, internal.FileType(fileType))
+
+		var content []byte
+		if volumes != nil {
+			content, err = parseZipVolumes(members, volumes, fileType, &tmpDir)
+		} else {
+			content, err = parseZipMember(f, name, fileType, &tmpDir)
+		}
+		if err = memberError("/"+name, err); err != nil {
+			if !errors.Is(err, internal.ErrPartialArchive) {
+				return fmt.Errorf("读取文件 %s 失败: %v", f.Name, err)
+			}
+			partial = nestedPartialError("/"+name, err)
+		}
+
+		// 在文件解析成功后，添加文件名称等信息
+		writeMember(out, "/"+name, int64(f.UncompressedSize64), fileType, content)
+		if out.err != nil {
+			return fmt.Errorf("写入输出失败: %w", out.err)
+		}
+		fileCnt++
+	}
+
+	logger.Logger.Printf("ZIP文件解析完成，共提取 %d 个文件", fileCnt)
+	return partial
+}
+
+// parseRecoveredZip 无法读取中央目录（如文件被截断）时，按本地文件头扫描恢复条目并解析，
+// 恢复出条目时将其内容写入w并返回包装了ErrPartialArchive的openErr，否则返回openErr
+func parseRecoveredZip(filePath string, w io.Writer, openErr error) error {
+	tmpDir, err := internal.MkdirTemp("zip_recover_")
+	if err != nil {
+		return openErr
+	}
+	defer os.RemoveAll(tmpDir)
+
+	extracted, scanErr := recoverZip(filePath, tmpDir)
+	if len(extracted) == 0 {
+		return openErr
+	}
+	if scanErr != nil {
+		logger.Logger.Printf("ZIP本地文件头扫描中断: %v", scanErr)
+	}
+
+	logger.Logger.Printf("ZIP中央目录不可用，按本地文件头恢复 %d 个文件", len(extracted))
+	_, err = walkExtractedTo(tmpDir, w, openErr)
+	return err
+}
+
+// zipContentKey 中央目录中记录的条目CRC32及解压后大小，不同时内容一定不同
+type zipContentKey struct {
+	crc  uint32
+	size uint64
+}
+
+func contentKey(f *zip.File) zipContentKey {
+	return zipContentKey{crc: f.CRC32, size: f.UncompressedSize64}
+}
+
+// zipMemberType 判断ZIP条目的类型：启用宏的Office文档、WPS文档及.xml等需要按内容区分的条目
+// 读取条目内容后确认，与WalkDir及内存解压一致；条目无法读取时按文件名判断
+func zipMemberType(f *zip.File, name string) int {
+	if !internal.NeedsSniff(name) {
+		return internal.GetDynamicFileType(name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return internal.GetDynamicFileType(name)
+	}
+	defer rc.Close()
+
+	// 启用宏的Office文档需读取ZIP的中央目录，因此读取整个条目
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return internal.GetDynamicFileType(name)
+	}
+	return internal.SniffFileTypeData(name, data)
+}
+
+// zipSum 计算ZIP条目解压后内容的SHA-256
+func zipSum(f *zip.File) ([sha256.Size]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	defer rc.Close()
+
+	return readerSum(rc)
+}
+
+// parseZipMember 解析单个ZIP条目
+func parseZipMember(f *zip.File, name string, fileType int, tmpDir *string) ([]byte, error) {
+	parser, err := internal.GetParser(fileType)
 	if err != nil {
-		return []byte{}, fmt.Errorf("创建临时目录失败: %v", err)
+		return []byte{}, fmt.Errorf("获取解析器失败: %v", err)
 	}
-	defer os.RemoveAll(tmpDir) // 确保程序退出时清理临时目录
-	logger.Logger.Printf("临时目录: %s", tmpDir)
 
+	rc, err := f.Open()
+	if err != nil {
+		return []byte{}, fmt.Errorf("打开ZIP内文件 %s 失败: %v", f.Name, err)
+	}
+	defer rc.Close()
+
+	if rp, ok := parser.(internal.ReaderParser); ok {
+		return rp.ParseReader(rc)
+	}
+
+	// 解析器依赖文件路径，写入临时目录后再解析
+	if *tmpDir == "" {
+		dir, err := internal.MkdirTemp("zip_extract_")
+		if err != nil {
+			return []byte{}, fmt.Errorf("创建临时目录失败: %v", err)
+		}
+		*tmpDir = dir
+		logger.Logger.Printf("临时目录: %s", dir)
+	}
+
+	safePath := filepath.Join(*tmpDir, name)
+	if err := os.MkdirAll(filepath.Dir(safePath), 0755); err != nil {
+		return []byte{}, fmt.Errorf("创建目录失败 %s: %v", safePath, err)
+	}
+	if err := WriteDstFile(rc, safePath, 0755); err != nil {
+		return []byte{}, fmt.Errorf("写入文件 %s 失败: %v", safePath, err)
+	}
+
+	return parser.Parse(safePath)
+}
+
+// parseZipVolumes 将ZIP中分卷压缩包的全部分卷写入临时目录，再按第一个分卷解析
+func parseZipVolumes(members map[string]*zip.File, volumes []string, fileType int, tmpDir *string) ([]byte, error) {
+	parser, err := internal.GetParser(fileType)
+	if err != nil {
+		return []byte{}, fmt.Errorf("获取解析器失败: %v", err)
+	}
+	if *tmpDir == "" {
+		dir, err := internal.MkdirTemp("zip_extract_")
+		if err != nil {
+			return []byte{}, fmt.Errorf("创建临时目录失败: %v", err)
+		}
+		*tmpDir = dir
+		logger.Logger.Printf("临时目录: %s", dir)
+	}
+
+	for _, name := range volumes {
+		f := members[name]
+		rc, err := f.Open()
+		if err != nil {
+			return []byte{}, fmt.Errorf("打开ZIP内文件 %s 失败: %v", f.Name, err)
+		}
+		safePath := filepath.Join(*tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(safePath), 0755); err != nil {
+			rc.Close()
+			return []byte{}, fmt.Errorf("创建目录失败 %s: %v", safePath, err)
+		}
+		err = WriteDstFile(rc, safePath, 0755)
+		rc.Close()
+		if err != nil {
+			return []byte{}, fmt.Errorf("写入文件 %s 失败: %v", safePath, err)
+		}
+	}
+
+	return parser.Parse(filepath.Join(*tmpDir, volumes[0]))
+}
+
+// extractZip 将zip文件解压到destDir，返回解压出的文件路径
+func extractZip(filePath string, destDir string) ([]string, error) {
+	if volumes := findVolumes(filePath); volumes != nil {
+		joined, err := joinVolumes(volumes)
+		if err != nil {
+			return nil, fmt.Errorf("拼接分卷失败: %w", err)
+		}
+		defer os.Remove(joined)
+		filePath = joined
+	}
+
+	r, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开文件: %v", err)
+	}
+	defer r.Close()
+
+	logger.Logger.Printf("提取文件: %s", filePath)
+
+	var extracted []string
 	for _, f := range r.File {
 		// 防止路径遍历攻击
-		safePath := filepath.Join(tmpDir, sanitizePath(f.Name))
+		safePath := filepath.Join(destDir, sanitizePath(f.Name))
 
 		// 创建目录结构
 		if err := os.MkdirAll(filepath.Dir(safePath), 0755); err != nil {
-			return []byte{}, fmt.Errorf("创建目录失败 %s: %v", safePath, err)
+			return extracted, fmt.Errorf("创建目录失败 %s: %v", safePath, err)
 		}
 
 		logger.DebugLogger.Printf("处理ZIP条目: %s -> %s", f.Name, safePath)
 		// 处理目录文件
 		if f.FileInfo().IsDir() {
 			if err := os.MkdirAll(safePath, 0755); err != nil {
-				return []byte{}, fmt.Errorf("创建目录失败 %s: %v", safePath, err)
+				return extracted, fmt.Errorf("创建目录失败 %s: %v", safePath, err)
 			}
 			continue
 		}
@@ -50,23 +310,18 @@ func (p *ZipFileParser) Parse(filePath string) ([]byte, error) {
 		// 打开ZIP内的文件
 		rc, err := f.Open()
 		if err != nil {
-			return []byte{}, fmt.Errorf("打开ZIP内文件 %s 失败: %v", f.Name, err)
+			return extracted, fmt.Errorf("打开ZIP内文件 %s 失败: %v", f.Name, err)
 		}
 
 		if err := WriteDstFile(rc, safePath, 0755); err != nil {
 			rc.Close()
-			return []byte{}, fmt.Errorf("写入文件 %s 失败: %v", safePath, err)
+			return extracted, fmt.Errorf("写入文件 %s 失败: %v", safePath, err)
 		}
-
 		rc.Close()
+		extracted = append(extracted, safePath)
 	}
 
-	content, files, err := WalkDir(tmpDir)
-	if err != nil {
-		return content, err
-	}
-	logger.Logger.Printf("ZIP文件解析完成，共提取 %d 个文件(一级目录)", files)
-	return content, nil
+	return extracted, nil
 }
 
 func init() {
@@ -0,0 +1,212 @@
+package compressfile
+
+import (
+	"archive/zip"
+	"bufio"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fextra/pkg/logger"
+)
+
+/*
+	ZIP的中央目录位于文件末尾，文件被截断（如上传中断）时zip.OpenReader无法打开。
+	每个条目的数据前都有本地文件头（PK\x03\x04），按顺序扫描本地文件头仍可解压出完整的条目：
+	本地文件头30字节，之后为文件名、扩展字段及压缩数据；标志位3表示大小及CRC记录在数据之后的数据描述符中，
+	此时deflate数据按压缩流自身的结束标记确定长度，stored数据无法确定长度，扫描到此为止
+*/
+
+const (
+	zipLocalHeaderSig   = 0x04034b50
+	zipCentralHeaderSig = 0x02014b50
+	zipDescriptorSig    = 0x08074b50
+	zipLocalHeaderLen   = 30
+	zipFlagEncrypted    = 0x1
+	zipFlagDescriptor   = 0x8
+	zip64ExtraID        = 0x0001
+)
+
+// zipLocalEntry 本地文件头中的条目信息
+type zipLocalEntry struct {
+	name   string
+	flags  uint16
+	method uint16
+	crc    uint32
+	size   uint64 // 压缩后大小
+	zip64  bool   // 有ZIP64扩展字段，数据描述符中的大小为各8字节
+}
+
+// recoverZip 按本地文件头顺序扫描ZIP，将可完整读取的条目解压到destDir，返回解压出的文件路径；
+// 扫描到中央目录时返回nil，数据被截断或无法继续定位下一个条目时返回停止的原因
+func recoverZip(filePath string, destDir string) ([]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开文件: %v", err)
+	}
+	defer file.Close()
+
+	// flate从io.ByteReader读取时不会越过压缩流的结尾，下一个本地文件头可以接着读取
+	br := bufio.NewReader(file)
+	var extracted []string
+	for {
+		entry, err := readZipLocalHeader(br)
+		if err != nil || entry == nil {
+			return extracted, err
+		}
+
+		if strings.HasSuffix(entry.name, "/") {
+			if _, err := br.Discard(int(entry.size)); err != nil {
+				return extracted, fmt.Errorf("条目 %s 数据不完整: %w", entry.name, io.ErrUnexpectedEOF)
+			}
+			continue
+		}
+
+		safePath := uniquePath(filepath.Join(destDir, sanitizePath(entry.name)))
+		ok, err := writeZipLocalEntry(br, entry, safePath)
+		if err != nil {
+			// 条目内容不完整，删除写入了一部分的文件，只保留完整的条目
+			os.Remove(safePath)
+			return extracted, fmt.Errorf("条目 %s: %w", entry.name, err)
+		}
+		if ok {
+			logger.Logger.Printf("恢复ZIP条目: %s", entry.name)
+			extracted = append(extracted, safePath)
+		} else {
+			os.Remove(safePath)
+		}
+	}
+}
+
+// readZipLocalHeader 读取本地文件头，遇到中央目录（本地条目已全部读取）时返回nil
+func readZipLocalHeader(br *bufio.Reader) (*zipLocalEntry, error) {
+	var header [zipLocalHeaderLen]byte
+	if _, err := io.ReadFull(br, header[:4]); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	switch binary.LittleEndian.Uint32(header[:]) {
+	case zipLocalHeaderSig:
+	case zipCentralHeaderSig:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("无法定位下一个本地文件头: %w", zip.ErrFormat)
+	}
+	if _, err := io.ReadFull(br, header[4:]); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	entry := &zipLocalEntry{
+		flags:  binary.LittleEndian.Uint16(header[6:]),
+		method: binary.LittleEndian.Uint16(header[8:]),
+		crc:    binary.LittleEndian.Uint32(header[14:]),
+		size:   uint64(binary.LittleEndian.Uint32(header[18:])),
+	}
+	extra := make([]byte, int(binary.LittleEndian.Uint16(header[26:]))+int(binary.LittleEndian.Uint16(header[28:])))
+	if _, err := io.ReadFull(br, extra); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	nameLen := int(binary.LittleEndian.Uint16(header[26:]))
+	entry.name = string(extra[:nameLen])
+
+	// ZIP64：大小为0xFFFFFFFF时，实际大小在扩展字段中（原始大小在前，压缩后大小在后）；
+	// 有ZIP64扩展字段时数据描述符中的大小也为8字节（APPNOTE 4.3.9.2），即使本地文件头中的大小为0
+	for fields := extra[nameLen:]; len(fields) >= 4; {
+		id, size := binary.LittleEndian.Uint16(fields), int(binary.LittleEndian.Uint16(fields[2:]))
+		if len(fields) < 4+size {
+			break
+		}
+		if id == zip64ExtraID {
+			entry.zip64 = true
+			if entry.size == 0xFFFFFFFF && size >= 16 {
+				entry.size = binary.LittleEndian.Uint64(fields[12:])
+			}
+			break
+		}
+		fields = fields[4+size:]
+	}
+	return entry, nil
+}
+
+// writeZipLocalEntry 将条目数据解压到path并校验CRC，条目加密、压缩方法不支持或CRC不一致时跳过并返回false
+func writeZipLocalEntry(br *bufio.Reader, entry *zipLocalEntry, path string) (bool, error) {
+	descriptor := entry.flags&zipFlagDescriptor != 0
+	if descriptor && entry.method == zip.Store && entry.size == 0 {
+		return false, errors.New("数据长度记录在数据描述符中，无法确定条目结尾")
+	}
+
+	if entry.flags&zipFlagEncrypted != 0 || (entry.method != zip.Store && entry.method != zip.Deflate) {
+		logger.Logger.Printf("跳过无法解压的ZIP条目: %s (标志 0x%X, 压缩方法 %d)", entry.name, entry.flags, entry.method)
+		if _, err := br.Discard(int(entry.size)); err != nil {
+			return false, io.ErrUnexpectedEOF
+		}
+		return false, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, fmt.Errorf("创建目录失败 %s: %v", path, err)
+	}
+	dst, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return false, fmt.Errorf("创建文件 %s 失败: %v", path, err)
+	}
+	defer dst.Close()
+
+	hash := crc32.NewIEEE()
+	w := io.MultiWriter(dst, hash)
+	if entry.method == zip.Store {
+		_, err = io.CopyN(w, br, int64(entry.size))
+	} else {
+		var src io.Reader = br
+		if !descriptor {
+			src = io.LimitReader(br, int64(entry.size))
+		}
+		fr := flate.NewReader(src)
+		_, err = io.Copy(w, fr)
+		fr.Close()
+	}
+	if err != nil {
+		return false, io.ErrUnexpectedEOF
+	}
+
+	crc := entry.crc
+	if descriptor {
+		if crc, err = readZipDescriptor(br, entry.zip64); err != nil {
+			return false, err
+		}
+	}
+	if hash.Sum32() != crc {
+		logger.Logger.Printf("ZIP条目 %s CRC校验失败，跳过", entry.name)
+		return false, nil
+	}
+	return true, nil
+}
+
+// readZipDescriptor 读取数据描述符并返回其中的CRC，签名可省略；
+// zip64为true时压缩前后的大小各8字节，否则各4字节
+func readZipDescriptor(br *bufio.Reader, zip64 bool) (uint32, error) {
+	var buf [20]byte
+	if _, err := io.ReadFull(br, buf[:4]); err != nil {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if binary.LittleEndian.Uint32(buf[:]) == zipDescriptorSig {
+		if _, err := io.ReadFull(br, buf[:4]); err != nil {
+			return 0, io.ErrUnexpectedEOF
+		}
+	}
+	crc := binary.LittleEndian.Uint32(buf[:])
+	// 跳过压缩前后的大小
+	sizes := buf[4:12]
+	if zip64 {
+		sizes = buf[4:20]
+	}
+	if _, err := io.ReadFull(br, sizes); err != nil {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return crc, nil
+}
@@ -0,0 +1,75 @@
+package compressfile
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// appendLocalEntry 追加一个本地文件头及数据；streamed为true时按流式写入：
+// 本地文件头中的CRC及大小为0，带ZIP64扩展字段，数据之后为24字节的ZIP64数据描述符
+func appendLocalEntry(out []byte, name string, content []byte, streamed bool) []byte {
+	le := binary.LittleEndian
+	data, method, flags := content, uint16(0), uint16(0)
+	var extra []byte
+	if streamed {
+		var compressed bytes.Buffer
+		fw, _ := flate.NewWriter(&compressed, flate.DefaultCompression)
+		fw.Write(content)
+		fw.Close()
+		data, method, flags = compressed.Bytes(), 8, zipFlagDescriptor
+		extra = le.AppendUint16(extra, zip64ExtraID)
+		extra = le.AppendUint16(extra, 16)
+		extra = append(extra, make([]byte, 16)...)
+	}
+
+	crc := crc32.ChecksumIEEE(content)
+	out = le.AppendUint32(out, zipLocalHeaderSig)
+	out = le.AppendUint16(out, 45)
+	out = le.AppendUint16(out, flags)
+	out = le.AppendUint16(out, method)
+	out = le.AppendUint32(out, 0) // 修改时间及日期
+	if streamed {
+		out = append(out, make([]byte, 12)...)
+	} else {
+		out = le.AppendUint32(out, crc)
+		out = le.AppendUint32(out, uint32(len(data)))
+		out = le.AppendUint32(out, uint32(len(content)))
+	}
+	out = le.AppendUint16(out, uint16(len(name)))
+	out = le.AppendUint16(out, uint16(len(extra)))
+	out = append(out, name...)
+	out = append(out, extra...)
+	out = append(out, data...)
+	if streamed {
+		out = le.AppendUint32(out, zipDescriptorSig)
+		out = le.AppendUint32(out, crc)
+		out = le.AppendUint64(out, uint64(len(data)))
+		out = le.AppendUint64(out, uint64(len(content)))
+	}
+	return out
+}
+
+// TestRecoverZip64Descriptor 带ZIP64扩展字段的流式条目之后为24字节的数据描述符，其后的条目仍可恢复
+func TestRecoverZip64Descriptor(t *testing.T) {
+	dir := t.TempDir()
+	archive := appendLocalEntry(nil, "a.txt", bytes.Repeat([]byte("streamed "), 100), true)
+	archive = appendLocalEntry(archive, "b.txt", []byte("stored"), false)
+	path := filepath.Join(dir, "truncated.zip")
+	if err := os.WriteFile(path, archive, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	destDir := filepath.Join(dir, "out")
+	files, _ := recoverZip(path, destDir)
+	if len(files) != 2 {
+		t.Fatalf("recovered %v, want a.txt and b.txt", files)
+	}
+	if data, _ := os.ReadFile(files[1]); string(data) != "stored" {
+		t.Errorf("b.txt = %q", data)
+	}
+}
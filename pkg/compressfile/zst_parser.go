@@ -0,0 +1,95 @@
+package compressfile
+
+import (
+	"bytes"
+	"fextra/internal"
+	"fextra/pkg/logger"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// zstShortExts zstd的简写后缀及解压后对应的后缀
+var zstShortExts = map[string]string{".tzst": ".tar"}
+
+// zstMaxWindow 解压时允许的最大窗口，zstd --long=31压缩的文件窗口为2GB，默认的上限无法解压
+const zstMaxWindow = 1 << 31
+
+type ZstFileParser struct{}
+
+func (p *ZstFileParser) Parse(filePath string) ([]byte, error) {
+	var content bytes.Buffer
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return content.Bytes(), fmt.Errorf("无法打开文件: %v", err)
+	}
+	defer file.Close()
+
+	if content, ok, err := parseInMemory(file, filePath, readZstMembers); ok {
+		return content, err
+	}
+	return bufferOutput(func(w io.Writer) error { return parseZstTo(file, filePath, w) })
+}
+
+// ParseTo 解压zstd并逐个文件解析写入w，不使用内存解压
+func (p *ZstFileParser) ParseTo(filePath string, w io.Writer) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("无法打开文件: %v", err)
+	}
+	defer file.Close()
+
+	return parseZstTo(file, filePath, w)
+}
+
+func init() {
+	// zstd类型: 303(zst、tar.zst)
+	internal.RegisterParser(internal.FileTypeZST, &ZstFileParser{})
+}
+
+// newZstReader 创建zstd解压器，单线程解压，使用完毕后需调用Close
+func newZstReader(reader io.Reader) (*zstd.Decoder, error) {
+	return zstd.NewReader(reader, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxWindow(zstMaxWindow))
+}
+
+// parseZstTo 从io.Reader解压zstd内容，逐个文件解析并写入w
+func parseZstTo(reader io.Reader, filename string, w io.Writer) error {
+	tmpDir, err := internal.MkdirTemp("zst_extract_")
+	if err != nil {
+		return fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	logger.Logger.Printf("临时目录: %s", tmpDir)
+
+	_, extractErr := extractZstFromReader(reader, filename, tmpDir)
+	cnt, err := walkExtractedTo(tmpDir, w, extractErr)
+	if err != nil {
+		return err
+	}
+
+	logger.Logger.Printf("zstd文件解析完成，共提取 %d 个文件(一级目录)", cnt)
+	return nil
+}
+
+// extractZstFromReader 将zstd内容解压到destDir，返回解压出的文件路径。
+// 多个连续的帧解压为一个文件，可跳过帧（如pzstd写入的索引）忽略
+func extractZstFromReader(reader io.Reader, filename string, destDir string) ([]string, error) {
+	zstReader, err := newZstReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("创建zstd reader失败: %v", err)
+	}
+	defer zstReader.Close()
+
+	original := decompressedName(filename, ".zst", zstShortExts)
+	safePath := filepath.Join(destDir, sanitizePath(original))
+	if err = writeDecompressedFile(zstReader, safePath); err != nil {
+		// 保留已解压的部分内容
+		return []string{renameIfTar(safePath)}, err
+	}
+
+	return []string{renameIfTar(safePath)}, nil
+}
@@ -0,0 +1,136 @@
+package imagefile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+
+	"fextra/pkg/logger"
+	"fextra/pkg/office/officeenc"
+)
+
+var (
+	exifHeader = []byte("Exif\x00\x00")
+	xmpHeader  = []byte("http://ns.adobe.com/xap/1.0/\x00")
+)
+
+// 需要提取的TIFF/EXIF标签
+const (
+	tagImageDescription = 0x010E
+	tagXMP              = 0x02BC
+	tagExifIFD          = 0x8769
+	tagUserComment      = 0x9286
+	tagXPTitle          = 0x9C9B
+	tagXPComment        = 0x9C9C
+	tagXPSubject        = 0x9C9F
+)
+
+// typeSizes TIFF字段类型对应的单个值字节数
+var typeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8}
+
+// ifdEntry IFD目录项
+type ifdEntry struct {
+	typ   uint16
+	value []byte
+}
+
+// parseTiff 解析TIFF结构（EXIF数据与TIFF图片相同），提取IFD0及Exif子IFD中的文本标签
+func parseTiff(data []byte) []metaField {
+	if len(data) < 8 {
+		return nil
+	}
+
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil
+	}
+
+	ifd0 := readIfd(data, order, order.Uint32(data[4:]))
+	var fields []metaField
+	if e, ok := ifd0[tagImageDescription]; ok {
+		fields = append(fields, metaField{"ImageDescription", trimNul(string(e.value))})
+	}
+	for _, tag := range []struct {
+		id   uint16
+		name string
+	}{{tagXPTitle, "XPTitle"}, {tagXPSubject, "XPSubject"}, {tagXPComment, "XPComment"}} {
+		if e, ok := ifd0[tag.id]; ok {
+			fields = append(fields, metaField{tag.name, trimNul(officeenc.DecodeUTF16(e.value, binary.LittleEndian))})
+		}
+	}
+	if e, ok := ifd0[tagExifIFD]; ok && len(e.value) >= 4 {
+		exifIfd := readIfd(data, order, order.Uint32(e.value))
+		if c, ok := exifIfd[tagUserComment]; ok {
+			fields = append(fields, metaField{"UserComment", decodeUserComment(c.value, order)})
+		}
+	}
+	// TIFF图片可在IFD0中直接嵌入XMP
+	if e, ok := ifd0[tagXMP]; ok {
+		fields = append(fields, parseXmp(e.value)...)
+	}
+	return fields
+}
+
+// readIfd 读取指定偏移处的IFD，返回标签到目录项的映射
+func readIfd(data []byte, order binary.ByteOrder, offset uint32) map[uint16]ifdEntry {
+	entries := make(map[uint16]ifdEntry)
+	if uint64(offset)+2 > uint64(len(data)) {
+		return entries
+	}
+
+	count := int(order.Uint16(data[offset:]))
+	pos := int(offset) + 2
+	for i := 0; i < count && pos+12 <= len(data); i, pos = i+1, pos+12 {
+		tag := order.Uint16(data[pos:])
+		typ := order.Uint16(data[pos+2:])
+		n := uint64(order.Uint32(data[pos+4:]))
+		size, ok := typeSizes[typ]
+		if !ok {
+			continue
+		}
+
+		total := n * uint64(size)
+		var value []byte
+		if total <= 4 {
+			value = data[pos+8 : pos+8+int(total)]
+		} else {
+			valueOffset := uint64(order.Uint32(data[pos+8:]))
+			if valueOffset+total > uint64(len(data)) {
+				logger.DebugLogger.Printf("IFD标签 0x%04X 数据越界", tag)
+				continue
+			}
+			value = data[valueOffset : valueOffset+total]
+		}
+		entries[tag] = ifdEntry{typ: typ, value: value}
+	}
+	return entries
+}
+
+// decodeUserComment 解析UserComment，前8字节为字符集标识
+func decodeUserComment(value []byte, order binary.ByteOrder) string {
+	if len(value) < 8 {
+		return trimNul(string(value))
+	}
+	charset, text := string(value[:8]), value[8:]
+	switch {
+	case strings.HasPrefix(charset, "UNICODE"):
+		// 大多数写入方使用与TIFF头相同的字节序
+		return trimNul(officeenc.DecodeUTF16(text, order))
+	default:
+		// ASCII及未定义字符集按原始字节处理
+		return trimNul(string(text))
+	}
+}
+
+// trimNul 去除字符串末尾的NUL及空白
+func trimNul(s string) string {
+	if i := strings.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(string(bytes.ToValidUTF8([]byte(s), nil)))
+}
@@ -0,0 +1,262 @@
+package imagefile
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"fextra/internal"
+	"fextra/pkg/logger"
+)
+
+/*
+	提取图片中嵌入的文本元数据：
+	EXIF ImageDescription/UserComment、XMP dc:title/dc:description、PNG tEXt/zTXt/iTXt文本块、JPEG注释；
+	通过internal.SetOCREngine注册了OCR引擎时，在元数据之后追加识别出的文字
+*/
+
+// ImageMetaParser 图片元数据文本解析器
+type ImageMetaParser struct{}
+
+// metaField 提取到的一条元数据
+type metaField struct {
+	Name  string
+	Value string
+}
+
+func init() {
+	internal.RegisterParser(internal.FileTypeJPEG, &ImageMetaParser{})
+	internal.RegisterParser(internal.FileTypePNG, &ImageMetaParser{})
+	internal.RegisterParser(internal.FileTypeTIF, &ImageMetaParser{})
+	internal.RegisterParser(internal.FileTypeWebP, &ImageMetaParser{})
+}
+
+// Parse 提取图片文件中的文本元数据
+func (p *ImageMetaParser) Parse(filePath string) ([]byte, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return []byte{}, fmt.Errorf("无法读取图片文件: %v", err)
+	}
+	return p.parseImage(data)
+}
+
+// ParseReader 从io.Reader中读取图片并提取文本元数据
+func (p *ImageMetaParser) ParseReader(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return []byte{}, fmt.Errorf("无法读取图片内容: %v", err)
+	}
+	return p.parseImage(data)
+}
+
+// parseImage 根据文件头识别图片格式并提取元数据，每条输出为 "名称: 内容"；注册了OCR引擎时追加识别出的文字
+func (p *ImageMetaParser) parseImage(data []byte) ([]byte, error) {
+	var fields []metaField
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		fields = parsePng(data)
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		fields = parseJpeg(data)
+	case bytes.HasPrefix(data, []byte("II*\x00")), bytes.HasPrefix(data, []byte("MM\x00*")):
+		fields = parseTiff(data)
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		fields = parseWebp(data)
+	default:
+		logger.Logger.Printf("未识别的图片格式，无元数据可提取")
+	}
+
+	var textBuffer bytes.Buffer
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		value := strings.TrimSpace(f.Value)
+		key := f.Name + "\x00" + value
+		if value == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		textBuffer.WriteString(fmt.Sprintf("%s: %s\n", f.Name, value))
+	}
+
+	if text := recognize(data); text != "" {
+		if textBuffer.Len() > 0 {
+			textBuffer.WriteString("\n")
+		}
+		textBuffer.WriteString(text + "\n")
+	}
+	return textBuffer.Bytes(), nil
+}
+
+// recognize 使用注册的OCR引擎识别图片中的文字，未注册引擎或识别失败时返回空字符串，不影响元数据
+func recognize(data []byte) string {
+	engine := internal.OCR()
+	if engine == nil {
+		return ""
+	}
+	text, err := engine.Image(data)
+	if err != nil {
+		logger.Logger.Printf("OCR识别图片失败: %v", err)
+		return ""
+	}
+	return strings.TrimSpace(text)
+}
+
+// parsePng 遍历PNG数据块，提取文本块、eXIf及XMP
+func parsePng(data []byte) []metaField {
+	var fields []metaField
+	pos := 8
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		chunkType := string(data[pos+4 : pos+8])
+		start := pos + 8
+		end := start + length
+		if end > len(data) {
+			logger.Logger.Printf("PNG数据块 %s 长度越界", chunkType)
+			break
+		}
+		chunk := data[start:end]
+		pos = end + 4 // 跳过CRC
+
+		switch chunkType {
+		case "tEXt":
+			if keyword, text, ok := bytes.Cut(chunk, []byte{0}); ok {
+				fields = append(fields, metaField{string(keyword), latin1(text)})
+			}
+		case "zTXt":
+			keyword, rest, ok := bytes.Cut(chunk, []byte{0})
+			if !ok || len(rest) < 1 {
+				continue
+			}
+			if text, err := inflate(rest[1:]); err == nil {
+				fields = append(fields, metaField{string(keyword), latin1(text)})
+			}
+		case "iTXt":
+			fields = append(fields, parsePngIText(chunk)...)
+		case "eXIf":
+			fields = append(fields, parseTiff(chunk)...)
+		case "IEND":
+			return fields
+		}
+	}
+	return fields
+}
+
+// parsePngIText 解析iTXt块：keyword\0 压缩标志 压缩方法 语言\0 翻译关键字\0 文本
+func parsePngIText(chunk []byte) []metaField {
+	keyword, rest, ok := bytes.Cut(chunk, []byte{0})
+	if !ok || len(rest) < 2 {
+		return nil
+	}
+	compressed := rest[0] == 1
+	_, rest, ok = bytes.Cut(rest[2:], []byte{0}) // 语言标签
+	if !ok {
+		return nil
+	}
+	_, text, ok := bytes.Cut(rest, []byte{0}) // 翻译后的关键字
+	if !ok {
+		return nil
+	}
+	if compressed {
+		var err error
+		if text, err = inflate(text); err != nil {
+			return nil
+		}
+	}
+
+	if string(keyword) == "XML:com.adobe.xmp" {
+		return parseXmp(text)
+	}
+	return []metaField{{string(keyword), string(text)}}
+}
+
+// parseJpeg 遍历JPEG标记段，提取APP1中的EXIF/XMP及COM注释
+func parseJpeg(data []byte) []metaField {
+	var fields []metaField
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			break
+		}
+		marker := data[pos+1]
+		// 独立标记，无长度字段
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) || marker == 0xFF {
+			pos++
+			continue
+		}
+		// SOS之后为压缩图像数据，元数据段均位于其之前
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		start := pos + 4
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			logger.Logger.Printf("JPEG标记段 0x%X 长度越界", marker)
+			break
+		}
+		segment := data[start:end]
+		pos = end
+
+		switch marker {
+		case 0xE1: // APP1
+			if bytes.HasPrefix(segment, exifHeader) {
+				fields = append(fields, parseTiff(segment[len(exifHeader):])...)
+			} else if bytes.HasPrefix(segment, xmpHeader) {
+				fields = append(fields, parseXmp(segment[len(xmpHeader):])...)
+			}
+		case 0xFE: // COM
+			fields = append(fields, metaField{"Comment", string(segment)})
+		}
+	}
+	return fields
+}
+
+// parseWebp 遍历WebP的RIFF数据块，提取EXIF及XMP
+func parseWebp(data []byte) []metaField {
+	var fields []metaField
+	pos := 12
+	for pos+8 <= len(data) {
+		fourcc := string(data[pos : pos+4])
+		length := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		start := pos + 8
+		end := start + length
+		if end > len(data) {
+			logger.Logger.Printf("WebP数据块 %s 长度越界", fourcc)
+			break
+		}
+		chunk := data[start:end]
+		pos = end + length%2 // 数据块按偶数字节对齐
+
+		switch fourcc {
+		case "EXIF":
+			// 部分编码器会保留JPEG的Exif头
+			fields = append(fields, parseTiff(bytes.TrimPrefix(chunk, exifHeader))...)
+		case "XMP ":
+			fields = append(fields, parseXmp(chunk)...)
+		}
+	}
+	return fields
+}
+
+// inflate 解压zlib数据
+func inflate(data []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// latin1 将ISO-8859-1编码的字节转换为UTF-8字符串
+func latin1(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
@@ -0,0 +1,59 @@
+package imagefile
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"fextra/pkg/logger"
+)
+
+const (
+	dcNamespace  = "http://purl.org/dc/elements/1.1/"
+	rdfNamespace = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+)
+
+// parseXmp 解析XMP数据包，提取dc:title与dc:description
+// 两者均为rdf:Alt多语言列表，每个rdf:li作为一条结果
+func parseXmp(data []byte) []metaField {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+
+	var fields []metaField
+	var current string // 当前所在的dc属性名
+	var inItem bool
+	var text strings.Builder
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err != io.EOF {
+				logger.Logger.Printf("XMP解析错误: %v", err)
+			}
+			break
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space == dcNamespace && (t.Name.Local == "title" || t.Name.Local == "description") {
+				current = t.Name.Local
+			} else if current != "" && t.Name.Space == rdfNamespace && t.Name.Local == "li" {
+				inItem = true
+				text.Reset()
+			}
+		case xml.EndElement:
+			if t.Name.Space == dcNamespace && t.Name.Local == current {
+				current = ""
+			} else if inItem && t.Name.Space == rdfNamespace && t.Name.Local == "li" {
+				inItem = false
+				fields = append(fields, metaField{"dc:" + current, text.String()})
+			}
+		case xml.CharData:
+			if inItem {
+				text.Write(t)
+			}
+		}
+	}
+	return fields
+}
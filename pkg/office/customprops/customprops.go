@@ -0,0 +1,198 @@
+// Package customprops 读取OOXML文档（docx、pptx）中的自定义属性，
+// 密级标签等企业元数据通常只保存在这些部件中，不出现在正文里
+package customprops
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"fextra/pkg/logger"
+)
+
+/*
+	自定义属性保存在两类部件中：
+	- docProps/custom.xml：文件-属性-自定义中的属性，每个<property name="...">包含一个vt:*类型的值，
+	  如敏感度标签的MSIP_Label_<GUID>_Name
+	- customXml/item*.xml：SharePoint等写入的自定义XML数据，如<documentManagement>下的分类字段，
+	  按叶子元素的本地名及文本读取；itemProps*.xml只描述数据的架构，不读取
+*/
+
+// CustomPart 文件-属性-自定义中的属性所在部件
+const CustomPart = "docProps/custom.xml"
+
+// customXmlItem customXml中的数据部件
+var customXmlItem = regexp.MustCompile(`^customXml/item\d+\.xml$`)
+
+// Property 自定义属性，Value为属性值的文本，多值（vt:vector）以", "连接
+type Property struct {
+	Name  string
+	Value string
+}
+
+// Read 依次读取docProps/custom.xml中的属性及customXml/item*.xml中的数据，部件不存在或解析失败时跳过
+func Read(files []*zip.File) []Property {
+	var custom *zip.File
+	var items []*zip.File
+	for _, f := range files {
+		switch {
+		case f.Name == CustomPart:
+			custom = f
+		case customXmlItem.MatchString(f.Name):
+			items = append(items, f)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+
+	var props []Property
+	if custom != nil {
+		props = append(props, readPart(custom, parseCustomXml)...)
+	}
+	for _, item := range items {
+		props = append(props, readPart(item, parseCustomXmlItem)...)
+	}
+	return props
+}
+
+// readPart 读取并解析一个部件，失败时记录日志并返回已解析的属性
+func readPart(f *zip.File, parse func(content []byte) ([]Property, error)) []Property {
+	rc, err := f.Open()
+	if err != nil {
+		logger.Logger.Printf("打开%s失败: %v", f.Name, err)
+		return nil
+	}
+	defer rc.Close()
+
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		logger.Logger.Printf("读取%s失败: %v", f.Name, err)
+		return nil
+	}
+	props, err := parse(content)
+	if err != nil {
+		logger.Logger.Printf("解析%s失败: %v", f.Name, err)
+	}
+	return props
+}
+
+// parseCustomXml 解析docProps/custom.xml，每个property元素下所有文本为其值
+func parseCustomXml(content []byte) ([]Property, error) {
+	var props []Property
+	var current *Property
+	var values []string
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return props, nil
+		}
+		if err != nil {
+			return props, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "property" {
+				current = &Property{Name: attr(t, "name")}
+				values = values[:0]
+			}
+		case xml.CharData:
+			if current != nil {
+				if s := strings.TrimSpace(string(t)); s != "" {
+					values = append(values, s)
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "property" && current != nil {
+				if current.Name != "" {
+					current.Value = strings.Join(values, ", ")
+					props = append(props, *current)
+				}
+				current = nil
+			}
+		}
+	}
+}
+
+// parseCustomXmlItem 解析customXml/item*.xml，输出有文本的叶子元素，同一元素名的多个值以", "连接
+func parseCustomXmlItem(content []byte) ([]Property, error) {
+	var props []Property
+	index := make(map[string]int)
+	var text strings.Builder
+	leaf := false
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return props, nil
+		}
+		if err != nil {
+			return props, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			// 遇到子元素时父元素不再是叶子元素
+			leaf = true
+			text.Reset()
+		case xml.CharData:
+			if leaf {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			if !leaf {
+				continue
+			}
+			leaf = false
+			value := strings.TrimSpace(text.String())
+			if value == "" {
+				continue
+			}
+			if i, ok := index[t.Name.Local]; ok {
+				props[i].Value += ", " + value
+				continue
+			}
+			index[t.Name.Local] = len(props)
+			props = append(props, Property{Name: t.Name.Local, Value: value})
+		}
+	}
+}
+
+func attr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// Metadata 将属性转换为ExtractInfo.Metadata，同名属性只保留第一个（docProps/custom.xml优先），没有属性时返回nil
+func Metadata(props []Property) map[string]string {
+	if len(props) == 0 {
+		return nil
+	}
+	meta := make(map[string]string, len(props))
+	for _, p := range props {
+		if _, ok := meta[p.Name]; !ok {
+			meta[p.Name] = p.Value
+		}
+	}
+	return meta
+}
+
+// WriteSection 以"=== 自定义属性 ==="为标题写入属性，每个属性一行，格式为"名称: 值"；没有属性时不写入
+func WriteSection(buf *bytes.Buffer, props []Property) {
+	if len(props) == 0 {
+		return
+	}
+	buf.WriteString("\n=== 自定义属性 ===\n")
+	for _, p := range props {
+		buf.WriteString(p.Name + ": " + p.Value + "\n")
+	}
+}
@@ -0,0 +1,380 @@
+package djvu
+
+import (
+	"errors"
+	"fmt"
+
+	"fextra/internal"
+)
+
+/*
+	BZZ压缩（DjVuLibre的BSByteStream）：数据分块进行Burrows-Wheeler变换，变换结果经按频率调整的MTF编码，
+	再由ZP自适应二进制算术编码器编码。每块以24位块大小开始，块大小为0表示结束；
+	块内包含一个标记字符（MTF编号256），用于逆变换时定位原始字符串的起点
+*/
+
+// maxBlockSize 单个BZZ块的最大大小（4096KB）
+const maxBlockSize = 4096 * 1024
+
+// errCorrupt BZZ数据损坏
+var errCorrupt = errors.New("BZZ数据损坏")
+
+// zpEntry ZP编码器的状态表项：p为LPS区间大小，m为MPS自适应阈值，up、dn为MPS、LPS后的下一状态
+type zpEntry struct {
+	p, m   uint16
+	up, dn uint8
+}
+
+// zpTable DjVu标准的ZP编码器状态表（DjVuLibre ZPCodec.cpp中的default_ztable）
+var zpTable = [256]zpEntry{
+	{0x8000, 0x0000, 84, 145}, {0x8000, 0x0000, 3, 4}, {0x8000, 0x0000, 4, 3}, {0x6bbd, 0x10a5, 5, 1},
+	{0x6bbd, 0x10a5, 6, 2}, {0x5d45, 0x1f28, 7, 3}, {0x5d45, 0x1f28, 8, 4}, {0x51b9, 0x2bd3, 9, 5},
+	{0x51b9, 0x2bd3, 10, 6}, {0x4813, 0x36e3, 11, 7}, {0x4813, 0x36e3, 12, 8}, {0x3fd5, 0x408c, 13, 9},
+	{0x3fd5, 0x408c, 14, 10}, {0x38b1, 0x48fd, 15, 11}, {0x38b1, 0x48fd, 16, 12}, {0x3275, 0x505d, 17, 13},
+	{0x3275, 0x505d, 18, 14}, {0x2cfd, 0x56d0, 19, 15}, {0x2cfd, 0x56d0, 20, 16}, {0x2825, 0x5c71, 21, 17},
+	{0x2825, 0x5c71, 22, 18}, {0x23ab, 0x615b, 23, 19}, {0x23ab, 0x615b, 24, 20}, {0x1f87, 0x65a5, 25, 21},
+	{0x1f87, 0x65a5, 26, 22}, {0x1bbb, 0x6962, 27, 23}, {0x1bbb, 0x6962, 28, 24}, {0x1845, 0x6ca2, 29, 25},
+	{0x1845, 0x6ca2, 30, 26}, {0x1523, 0x6f74, 31, 27}, {0x1523, 0x6f74, 32, 28}, {0x1253, 0x71dc, 33, 29},
+	{0x1253, 0x71dc, 34, 30}, {0x0fcf, 0x73e6, 35, 31}, {0x0fcf, 0x73e6, 36, 32}, {0x0d90, 0x759e, 37, 33},
+	{0x0d90, 0x759e, 38, 34}, {0x0b92, 0x770c, 39, 35}, {0x0b92, 0x770c, 40, 36}, {0x09d0, 0x7836, 41, 37},
+	{0x09d0, 0x7836, 42, 38}, {0x0845, 0x7932, 43, 39}, {0x0845, 0x7932, 44, 40}, {0x06ec, 0x7a02, 45, 41},
+	{0x06ec, 0x7a02, 46, 42}, {0x05c0, 0x7aae, 47, 43}, {0x05c0, 0x7aae, 48, 44}, {0x04bd, 0x7b3a, 49, 45},
+	{0x04bd, 0x7b3a, 50, 46}, {0x03e2, 0x7bac, 51, 47}, {0x03e2, 0x7bac, 52, 48}, {0x0327, 0x7c08, 53, 49},
+	{0x0327, 0x7c08, 54, 50}, {0x0288, 0x7c52, 55, 51}, {0x0288, 0x7c52, 56, 52}, {0x0201, 0x7c8e, 57, 53},
+	{0x0201, 0x7c8e, 58, 54}, {0x0190, 0x7cc0, 59, 55}, {0x0190, 0x7cc0, 60, 56}, {0x0131, 0x7ce6, 61, 57},
+	{0x0131, 0x7ce6, 62, 58}, {0x00e3, 0x7d06, 63, 59}, {0x00e3, 0x7d06, 64, 60}, {0x00a3, 0x7d20, 65, 61},
+	{0x00a3, 0x7d20, 66, 62}, {0x0072, 0x7d34, 67, 63}, {0x0072, 0x7d34, 68, 64}, {0x004e, 0x7d44, 69, 65},
+	{0x004e, 0x7d44, 70, 66}, {0x0034, 0x7d50, 71, 67}, {0x0034, 0x7d50, 72, 68}, {0x0021, 0x7d58, 73, 69},
+	{0x0021, 0x7d58, 74, 70}, {0x0014, 0x7d5e, 75, 71}, {0x0014, 0x7d5e, 76, 72}, {0x000c, 0x7d62, 77, 73},
+	{0x000c, 0x7d62, 78, 74}, {0x0007, 0x7d64, 79, 75}, {0x0007, 0x7d64, 80, 76}, {0x0004, 0x7d66, 81, 77},
+	{0x0004, 0x7d66, 82, 78}, {0x0002, 0x7d67, 81, 79}, {0x0002, 0x7d67, 82, 80}, {0x5695, 0x0000, 9, 85},
+	{0x24ee, 0x0000, 86, 226}, {0x8000, 0x0000, 5, 6}, {0x0d30, 0x0000, 88, 176}, {0x481a, 0x0000, 89, 143},
+	{0x0481, 0x0000, 90, 138}, {0x3579, 0x0000, 91, 141}, {0x017a, 0x0000, 92, 112}, {0x24ef, 0x0000, 93, 135},
+	{0x007b, 0x0000, 94, 104}, {0x1978, 0x0000, 95, 133}, {0x0028, 0x0000, 96, 100}, {0x10ca, 0x0000, 97, 129},
+	{0x000d, 0x0000, 82, 98}, {0x0b5d, 0x0000, 99, 127}, {0x0034, 0x0000, 76, 72}, {0x078a, 0x0000, 101, 125},
+	{0x00a0, 0x0000, 70, 102}, {0x050f, 0x0000, 103, 123}, {0x0117, 0x0000, 66, 60}, {0x0358, 0x0000, 105, 121},
+	{0x01ea, 0x0000, 106, 110}, {0x0234, 0x0000, 107, 119}, {0x0144, 0x0000, 66, 108}, {0x0173, 0x0000, 109, 117},
+	{0x0234, 0x0000, 60, 54}, {0x00f5, 0x0000, 111, 115}, {0x0353, 0x0000, 56, 48}, {0x00a1, 0x0000, 69, 113},
+	{0x05c5, 0x0000, 114, 134}, {0x011a, 0x0000, 65, 59}, {0x03cf, 0x0000, 116, 132}, {0x01aa, 0x0000, 61, 55},
+	{0x0285, 0x0000, 118, 130}, {0x0286, 0x0000, 57, 51}, {0x01ab, 0x0000, 120, 128}, {0x03d3, 0x0000, 53, 47},
+	{0x011a, 0x0000, 122, 126}, {0x05c5, 0x0000, 49, 41}, {0x00ba, 0x0000, 124, 62}, {0x08ad, 0x0000, 43, 37},
+	{0x007a, 0x0000, 72, 66}, {0x0ccc, 0x0000, 39, 31}, {0x01eb, 0x0000, 60, 54}, {0x1302, 0x0000, 33, 25},
+	{0x02e6, 0x0000, 56, 50}, {0x1b81, 0x0000, 29, 131}, {0x045e, 0x0000, 52, 46}, {0x24ef, 0x0000, 23, 17},
+	{0x0690, 0x0000, 48, 40}, {0x2865, 0x0000, 23, 15}, {0x09de, 0x0000, 42, 136}, {0x3987, 0x0000, 137, 7},
+	{0x0dc8, 0x0000, 38, 32}, {0x2c99, 0x0000, 21, 139}, {0x10ca, 0x0000, 140, 172}, {0x3b5f, 0x0000, 15, 9},
+	{0x0b5d, 0x0000, 142, 170}, {0x5695, 0x0000, 9, 85}, {0x078a, 0x0000, 144, 168}, {0x8000, 0x0000, 141, 248},
+	{0x050f, 0x0000, 146, 166}, {0x24ee, 0x0000, 147, 247}, {0x0358, 0x0000, 148, 164}, {0x0d30, 0x0000, 149, 197},
+	{0x0234, 0x0000, 150, 162}, {0x0481, 0x0000, 151, 95}, {0x0173, 0x0000, 152, 160}, {0x017a, 0x0000, 153, 173},
+	{0x00f5, 0x0000, 154, 158}, {0x007b, 0x0000, 155, 165}, {0x00a1, 0x0000, 70, 156}, {0x0028, 0x0000, 157, 161},
+	{0x011a, 0x0000, 66, 60}, {0x000d, 0x0000, 81, 159}, {0x01aa, 0x0000, 62, 56}, {0x0034, 0x0000, 75, 71},
+	{0x0286, 0x0000, 58, 52}, {0x00a0, 0x0000, 69, 163}, {0x03d3, 0x0000, 54, 48}, {0x0117, 0x0000, 65, 59},
+	{0x05c5, 0x0000, 50, 42}, {0x01ea, 0x0000, 167, 171}, {0x08ad, 0x0000, 44, 38}, {0x0144, 0x0000, 65, 169},
+	{0x0ccc, 0x0000, 40, 32}, {0x0234, 0x0000, 59, 53}, {0x1302, 0x0000, 34, 26}, {0x0353, 0x0000, 55, 47},
+	{0x1b81, 0x0000, 30, 174}, {0x05c5, 0x0000, 175, 193}, {0x24ef, 0x0000, 24, 18}, {0x03cf, 0x0000, 177, 191},
+	{0x2b74, 0x0000, 178, 222}, {0x0285, 0x0000, 179, 189}, {0x201d, 0x0000, 180, 218}, {0x01ab, 0x0000, 181, 187},
+	{0x1715, 0x0000, 182, 216}, {0x011a, 0x0000, 183, 185}, {0x0fb7, 0x0000, 184, 214}, {0x00ba, 0x0000, 69, 61},
+	{0x0a67, 0x0000, 186, 212}, {0x01eb, 0x0000, 59, 53}, {0x06e7, 0x0000, 188, 210}, {0x02e6, 0x0000, 55, 49},
+	{0x0496, 0x0000, 190, 208}, {0x045e, 0x0000, 51, 45}, {0x030d, 0x0000, 192, 206}, {0x0690, 0x0000, 47, 39},
+	{0x0206, 0x0000, 194, 204}, {0x09de, 0x0000, 41, 195}, {0x0155, 0x0000, 196, 202}, {0x0dc8, 0x0000, 37, 31},
+	{0x00e1, 0x0000, 198, 200}, {0x2b74, 0x0000, 199, 243}, {0x0094, 0x0000, 72, 64}, {0x201d, 0x0000, 201, 239},
+	{0x0188, 0x0000, 62, 56}, {0x1715, 0x0000, 203, 237}, {0x0252, 0x0000, 58, 52}, {0x0fb7, 0x0000, 205, 235},
+	{0x0383, 0x0000, 54, 48}, {0x0a67, 0x0000, 207, 233}, {0x0547, 0x0000, 50, 44}, {0x06e7, 0x0000, 209, 231},
+	{0x07e2, 0x0000, 46, 38}, {0x0496, 0x0000, 211, 229}, {0x0bc0, 0x0000, 40, 34}, {0x030d, 0x0000, 213, 227},
+	{0x1178, 0x0000, 36, 28}, {0x0206, 0x0000, 215, 225}, {0x19da, 0x0000, 30, 22}, {0x0155, 0x0000, 217, 223},
+	{0x24ef, 0x0000, 26, 16}, {0x00e1, 0x0000, 219, 221}, {0x320e, 0x0000, 20, 220}, {0x0094, 0x0000, 71, 63},
+	{0x432a, 0x0000, 14, 8}, {0x0188, 0x0000, 61, 55}, {0x447d, 0x0000, 14, 224}, {0x0252, 0x0000, 57, 51},
+	{0x5ece, 0x0000, 8, 2}, {0x0383, 0x0000, 53, 47}, {0x8000, 0x0000, 228, 87}, {0x0547, 0x0000, 49, 43},
+	{0x481a, 0x0000, 230, 246}, {0x07e2, 0x0000, 45, 37}, {0x3579, 0x0000, 232, 244}, {0x0bc0, 0x0000, 39, 33},
+	{0x24ef, 0x0000, 234, 238}, {0x1178, 0x0000, 35, 27}, {0x1978, 0x0000, 138, 236}, {0x19da, 0x0000, 29, 21},
+	{0x2865, 0x0000, 24, 16}, {0x24ef, 0x0000, 25, 15}, {0x3987, 0x0000, 240, 8}, {0x320e, 0x0000, 19, 241},
+	{0x2c99, 0x0000, 22, 242}, {0x432a, 0x0000, 13, 7}, {0x3b5f, 0x0000, 16, 10}, {0x447d, 0x0000, 13, 245},
+	{0x5695, 0x0000, 10, 2}, {0x5ece, 0x0000, 7, 1}, {0x8000, 0x0000, 244, 83}, {0x8000, 0x0000, 249, 250},
+	{0x5695, 0x0000, 10, 2}, {0x481a, 0x0000, 89, 143}, {0x481a, 0x0000, 230, 246},
+}
+
+// zpDecoder ZP自适应二进制算术解码器，上下文为zpTable中的状态编号
+type zpDecoder struct {
+	data   []byte
+	pos    int
+	a      uint32
+	code   uint32
+	fence  uint32
+	buffer uint32
+	scount int
+	delay  int
+	err    error
+}
+
+func newZPDecoder(data []byte) *zpDecoder {
+	z := &zpDecoder{data: data}
+	z.code = uint32(z.readByte()) << 8
+	z.code |= uint32(z.readByte())
+	z.delay = 25
+	z.preload()
+	z.setFence()
+	return z
+}
+
+// readByte 读取下一个字节，数据结束后返回0xFF
+func (z *zpDecoder) readByte() byte {
+	if z.pos >= len(z.data) {
+		return 0xFF
+	}
+	b := z.data[z.pos]
+	z.pos++
+	return b
+}
+
+// preload 向缓冲区补充字节，数据结束后最多补充delay个0xFF
+func (z *zpDecoder) preload() {
+	for z.scount <= 24 {
+		if z.pos >= len(z.data) {
+			z.delay--
+			if z.delay < 1 && z.err == nil {
+				z.err = fmt.Errorf("%w: %w", errCorrupt, internal.ErrTruncated)
+			}
+		}
+		z.buffer = z.buffer<<8 | uint32(z.readByte())
+		z.scount += 8
+	}
+}
+
+func (z *zpDecoder) setFence() {
+	z.fence = z.code
+	if z.code >= 0x8000 {
+		z.fence = 0x7FFF
+	}
+}
+
+// decode 按上下文解码一位并更新上下文状态
+func (z *zpDecoder) decode(ctx *uint8) int {
+	entry := &zpTable[*ctx]
+	v := z.a + uint32(entry.p)
+	if v <= z.fence {
+		z.a = v
+		return int(*ctx & 1)
+	}
+
+	bit := int(*ctx & 1)
+	// 避免区间反转
+	if d := 0x6000 + (v+z.a)>>2; v > d {
+		v = d
+	}
+	if v > z.code {
+		*ctx = entry.dn
+		z.lps(v)
+		return bit ^ 1
+	}
+	if z.a >= uint32(entry.m) {
+		*ctx = entry.up
+	}
+	z.mps(v)
+	return bit
+}
+
+// decodeRaw 不使用上下文解码一位（概率固定为1/2）
+func (z *zpDecoder) decodeRaw() int {
+	v := 0x8000 + z.a>>1
+	if v > z.code {
+		z.lps(v)
+		return 1
+	}
+	z.mps(v)
+	return 0
+}
+
+func (z *zpDecoder) lps(v uint32) {
+	v = 0x10000 - v
+	z.a += v
+	z.code += v
+	shift := leadingOnes(z.a)
+	z.scount -= shift
+	z.a = (z.a << shift) & 0xFFFF
+	z.code = (z.code<<shift)&0xFFFF | (z.buffer>>z.scount)&(1<<shift-1)
+	if z.scount < 16 {
+		z.preload()
+	}
+	z.setFence()
+}
+
+func (z *zpDecoder) mps(v uint32) {
+	z.scount--
+	z.a = (v << 1) & 0xFFFF
+	z.code = (z.code<<1)&0xFFFF | (z.buffer>>z.scount)&1
+	if z.scount < 16 {
+		z.preload()
+	}
+	z.setFence()
+}
+
+// leadingOnes 16位数值高位连续1的个数
+func leadingOnes(x uint32) int {
+	n := 0
+	for bit := uint32(0x8000); bit != 0 && x&bit != 0; bit >>= 1 {
+		n++
+	}
+	return n
+}
+
+// decodeRawBits 不使用上下文解码bits位无符号整数，高位在前
+func (z *zpDecoder) decodeRawBits(bits int) int {
+	n := 1
+	for m := 1 << bits; n < m; {
+		n = n<<1 | z.decodeRaw()
+	}
+	return n - 1<<bits
+}
+
+// decodeBinary 使用ctx中的2^bits-1个上下文解码bits位无符号整数
+func (z *zpDecoder) decodeBinary(ctx []uint8, bits int) int {
+	n := 1
+	for m := 1 << bits; n < m; {
+		n = n<<1 | z.decode(&ctx[n-1])
+	}
+	return n - 1<<bits
+}
+
+// freqMax MTF中按频率排序的位置数
+const freqMax = 4
+
+// decodeBZZ 解压BZZ数据
+func decodeBZZ(data []byte) ([]byte, error) {
+	z := newZPDecoder(data)
+	var ctx [300]uint8
+	var out []byte
+	for {
+		block, err := z.decodeBlock(&ctx)
+		if err != nil {
+			return out, err
+		}
+		if block == nil {
+			return out, nil
+		}
+		out = append(out, block...)
+	}
+}
+
+// decodeBlock 解码一个块，返回逆变换后的内容，遇到结束块时返回nil
+func (z *zpDecoder) decodeBlock(ctx *[300]uint8) ([]byte, error) {
+	size := z.decodeRawBits(24)
+	if z.err != nil {
+		return nil, z.err
+	}
+	if size == 0 {
+		return nil, nil
+	}
+	if size > maxBlockSize {
+		return nil, fmt.Errorf("%w: 块大小 %d 超过上限", errCorrupt, size)
+	}
+
+	// 频率的衰减速度
+	fshift := 0
+	if z.decodeRaw() != 0 {
+		fshift++
+		if z.decodeRaw() != 0 {
+			fshift++
+		}
+	}
+
+	var mtf [256]byte
+	for i := range mtf {
+		mtf[i] = byte(i)
+	}
+	var freq [freqMax]uint32
+	fadd := uint32(4)
+	mtfno := 3
+	markerPos := -1
+	block := make([]byte, size)
+	for i := 0; i < size; i++ {
+		ctxID := min(2, mtfno)
+		switch {
+		case z.decode(&ctx[ctxID]) != 0:
+			mtfno = 0
+		case z.decode(&ctx[3+ctxID]) != 0:
+			mtfno = 1
+		default:
+			mtfno = 256
+			offset := 6
+			for bits := 1; bits <= 7; bits++ {
+				if z.decode(&ctx[offset]) != 0 {
+					mtfno = 1<<bits + z.decodeBinary(ctx[offset+1:], bits)
+					break
+				}
+				offset += 1 << bits
+			}
+		}
+		if z.err != nil {
+			return nil, z.err
+		}
+
+		if mtfno == 256 {
+			block[i] = 0
+			markerPos = i
+			continue
+		}
+
+		// 按经验频率调整MTF中字符的位置
+		c := mtf[mtfno]
+		block[i] = c
+		fadd += fadd >> fshift
+		if fadd > 0x10000000 {
+			fadd >>= 24
+			for k := range freq {
+				freq[k] >>= 24
+			}
+		}
+		fc := fadd
+		if mtfno < freqMax {
+			fc += freq[mtfno]
+		}
+		k := mtfno
+		for ; k >= freqMax; k-- {
+			mtf[k] = mtf[k-1]
+		}
+		for ; k > 0 && fc >= freq[k-1]; k-- {
+			mtf[k] = mtf[k-1]
+			freq[k] = freq[k-1]
+		}
+		mtf[k] = c
+		freq[k] = fc
+	}
+	if markerPos < 1 || markerPos >= size {
+		return nil, fmt.Errorf("%w: 标记位置 %d 无效", errCorrupt, markerPos)
+	}
+	return inverseBWT(block, markerPos)
+}
+
+// inverseBWT Burrows-Wheeler逆变换，block为变换结果（最后一列），markerPos为标记字符的位置，标记字符排在最前
+func inverseBWT(block []byte, markerPos int) ([]byte, error) {
+	size := len(block)
+	posn := make([]uint32, size)
+	var count [256]int
+	for i, c := range block {
+		if i == markerPos {
+			continue
+		}
+		posn[i] = uint32(c)<<24 | uint32(count[c])&0xFFFFFF
+		count[c]++
+	}
+	last := 1
+	for i := range count {
+		count[i], last = last, last+count[i]
+	}
+
+	out := make([]byte, size-1)
+	i := 0
+	for last = size - 1; last > 0; {
+		n := posn[i]
+		c := byte(n >> 24)
+		last--
+		out[last] = c
+		i = count[c] + int(n&0xFFFFFF)
+		if i >= size {
+			return nil, errCorrupt
+		}
+	}
+	if i != markerPos {
+		return nil, errCorrupt
+	}
+	return out, nil
+}
@@ -0,0 +1,165 @@
+package djvu
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"fextra/internal"
+	"fextra/pkg/logger"
+)
+
+/*
+	DjVu为IFF85容器：文件以"AT&T"开始，之后为一个FORM块。块头为4字节ID及4字节大端长度，块内容按偶数偏移对齐；
+	FORM块内容以4字节的类型开始，之后为子块。单页文档为FORM:DJVU，多页文档为FORM:DJVM，
+	其中依次包含各页的FORM:DJVU（间接文档的页面保存在独立文件中，DJVM内只有目录DIRM）。
+	页面的文本层保存在TXTa（未压缩）或TXTz（BZZ压缩）块中：3字节大端文本长度，之后为UTF-8文本及文本区域信息
+*/
+
+// iffMagic DjVu文件头
+var iffMagic = []byte("AT&T")
+
+// textSeparators 文本层中的行、段落、栏分隔符
+var textSeparators = strings.NewReplacer("\x0B", "\n", "\x1D", "\n", "\x1F", "\n")
+
+// OfficeDjvuParser DjVu文档解析器，提取各页文本层
+type OfficeDjvuParser struct{}
+
+// chunk IFF块
+type chunk struct {
+	id   string
+	data []byte
+}
+
+// Parse 提取DjVu文档各页的文本，页之间以分页分隔（见internal.Sections）隔开
+func (p *OfficeDjvuParser) Parse(filePath string) ([]byte, error) {
+	return p.ParsePreview(filePath, 0)
+}
+
+// ParsePreview 只提取前limit页的文本，limit<=0时提取全部
+func (p *OfficeDjvuParser) ParsePreview(filePath string, limit int) ([]byte, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return []byte{}, fmt.Errorf("无法读取DjVu文件: %w", err)
+	}
+	return extractText(data, limit)
+}
+
+// ParseReader 从io.Reader中读取DjVu文档并提取文本
+func (p *OfficeDjvuParser) ParseReader(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return []byte{}, fmt.Errorf("无法读取DjVu文件: %w", err)
+	}
+	return extractText(data, 0)
+}
+
+func extractText(data []byte, limit int) ([]byte, error) {
+	if !bytes.HasPrefix(data, iffMagic) {
+		return []byte{}, fmt.Errorf("不是DjVu文件: %w", internal.ErrInvalidSignature)
+	}
+	chunks, err := readChunks(data[len(iffMagic):])
+	if err != nil {
+		return []byte{}, err
+	}
+	if len(chunks) == 0 || chunks[0].id != "FORM" || len(chunks[0].data) < 4 {
+		return []byte{}, fmt.Errorf("未找到FORM块: %w", internal.ErrInvalidSignature)
+	}
+
+	form := chunks[0].data
+	var pages [][]byte
+	switch formType := string(form[:4]); formType {
+	case "DJVU":
+		pages = [][]byte{form[4:]}
+	case "DJVM":
+		children, err := readChunks(form[4:])
+		if err != nil {
+			logger.Logger.Printf("DJVM块不完整: %v", err)
+		}
+		for _, c := range children {
+			if c.id == "FORM" && len(c.data) >= 4 && string(c.data[:4]) == "DJVU" {
+				pages = append(pages, c.data[4:])
+			}
+		}
+		if len(pages) == 0 {
+			return []byte{}, fmt.Errorf("间接DjVu文档的页面保存在独立文件中: %w", internal.ErrUnsupportedFormat)
+		}
+	default:
+		return []byte{}, fmt.Errorf("不支持的FORM类型 %q: %w", formType, internal.ErrUnsupportedFormat)
+	}
+
+	var textBuffer bytes.Buffer
+	var layers int // 有文本层的页数
+	separator := internal.Sections().PageSeparator
+	for i, page := range pages {
+		if limit > 0 && i >= limit {
+			break
+		}
+		if i > 0 {
+			textBuffer.WriteString(separator)
+		}
+		text, found, err := pageText(page)
+		if err != nil {
+			logger.Logger.Printf("提取第%d页文本失败: %v", i+1, err)
+		}
+		if found {
+			layers++
+		}
+		textBuffer.WriteString(text)
+	}
+	if layers == 0 {
+		// 只有图像的扫描文档
+		return textBuffer.Bytes(), fmt.Errorf("DjVu页面均没有文本层: %w", internal.ErrNoContent)
+	}
+	return textBuffer.Bytes(), nil
+}
+
+// readChunks 读取连续的IFF块，数据不完整时返回已读取的块
+func readChunks(data []byte) ([]chunk, error) {
+	var chunks []chunk
+	for pos := 0; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.BigEndian.Uint32(data[pos+4:]))
+		pos += 8
+		if size > len(data)-pos {
+			return chunks, fmt.Errorf("%s块: %w", id, internal.ErrTruncated)
+		}
+		chunks = append(chunks, chunk{id: id, data: data[pos : pos+size]})
+		pos += size + size&1
+	}
+	return chunks, nil
+}
+
+// pageText 提取FORM:DJVU页面的文本层，found表示页面是否有文本层
+func pageText(page []byte) (text string, found bool, err error) {
+	chunks, err := readChunks(page)
+	for _, c := range chunks {
+		switch c.id {
+		case "TXTa":
+			return parseTextLayer(c.data), true, err
+		case "TXTz":
+			data, err := decodeBZZ(c.data)
+			if err != nil && len(data) < 3 {
+				return "", true, fmt.Errorf("解压TXTz块失败: %w", err)
+			}
+			return parseTextLayer(data), true, err
+		}
+	}
+	return "", false, err
+}
+
+// parseTextLayer 读取文本层中的文本，不含其后的文本区域信息
+func parseTextLayer(data []byte) string {
+	if len(data) < 3 {
+		return ""
+	}
+	size := int(data[0])<<16 | int(data[1])<<8 | int(data[2])
+	text := data[3:]
+	if size < len(text) {
+		text = text[:size]
+	}
+	return textSeparators.Replace(string(internal.ReplaceInvalidBytes(text)))
+}
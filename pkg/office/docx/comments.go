@@ -0,0 +1,48 @@
+package docx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+)
+
+// commentsXml 用于解析comments.xml的结构
+type commentsXml struct {
+	Comments []comment `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main comment"`
+}
+
+type comment struct {
+	Author string `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main author,attr"`
+	Paras  []para `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main p"`
+}
+
+// parseCommentsXml 解析comments.xml，每条批注输出一行"作者: 文本"，批注中的多个段落以换行分隔，
+// 没有作者时只输出文本，空批注跳过
+func parseCommentsXml(xmlContent []byte) ([]byte, error) {
+	var doc commentsXml
+	if err := xml.Unmarshal(xmlContent, &doc); err != nil {
+		return []byte{}, err
+	}
+
+	var textBuffer bytes.Buffer
+	for _, c := range doc.Comments {
+		var paras []string
+		for _, para := range c.Paras {
+			var paraText strings.Builder
+			for _, run := range para.Runs {
+				paraText.WriteString(run.runText(false))
+			}
+			paras = append(paras, paraText.String())
+		}
+		text := strings.TrimSpace(strings.Join(paras, "\n"))
+		if text == "" {
+			continue
+		}
+		if author := strings.TrimSpace(c.Author); author != "" {
+			textBuffer.WriteString(author + ": ")
+		}
+		textBuffer.WriteString(text)
+		textBuffer.WriteString("\n")
+	}
+	return textBuffer.Bytes(), nil
+}
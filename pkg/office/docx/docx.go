@@ -4,30 +4,96 @@ import (
 	"archive/zip"
 	"bytes"
 	"encoding/xml"
-	"errors"
+	"fextra/internal"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 
 	"fextra/pkg/logger"
+	"fextra/pkg/office/customprops"
+	"fextra/pkg/office/ooxmlcrypt"
 )
 
-type OfficeDocxParser struct{}
+type OfficeDocxParser struct {
+	RenderLists bool // 按numbering.xml为列表段落添加缩进及编号/项目符号
+	Limit       int  // 最多提取的段落数，用于生成预览，为0时不限制
+
+	// IncludeComments 提取comments.xml中的批注，以"=== 批注 ==="为标题追加到正文之后，
+	// 每条批注一行，带有作者时输出为"作者: 文本"；预览时不提取
+	IncludeComments bool
+
+	// IncludeEmbedded 提取嵌入对象（embeddings/下的工作簿、文档及oleObject*.bin）的文本及图表中的标签，
+	// 以"=== 嵌入对象: 部件名 ==="、"=== 图表: 部件名 ==="为标题追加到正文之后；预览时不提取
+	IncludeEmbedded bool
+
+	// IncludeAltText 在图片所在位置输出其替代文字（wp:docPr的descr属性，没有时为title属性），格式为"[image: 描述]"
+	IncludeAltText bool
+
+	// IncludeCustomProperties 提取docProps/custom.xml及customXml/item*.xml中的自定义属性（如密级标签），
+	// 以"=== 自定义属性 ==="为标题追加到正文之后，每个属性一行，格式为"名称: 值"；预览时不提取。
+	// 无论是否设置，ParseWithInfo都在Metadata中返回这些属性
+	IncludeCustomProperties bool
+}
+
+// ParsePreview 仅提取DOCX前limit个段落的文本
+func (p *OfficeDocxParser) ParsePreview(filename string, limit int) ([]byte, error) {
+	preview := *p
+	preview.Limit = limit
+	return preview.Parse(filename)
+}
 
 // Parse 提取DOCX文件中的文本内容
 func (p *OfficeDocxParser) Parse(filename string) ([]byte, error) {
-	// 打开DOCX文件（ZIP格式）
-	zipReader, err := zip.OpenReader(filename)
+	// 打开DOCX文件（ZIP格式，加密时解密）
+	zipReader, err := ooxmlcrypt.OpenReader(filename, "DOCX")
 	if err != nil {
-		return nil, fmt.Errorf("无法打开DOCX文件: %w", err)
+		return nil, err
 	}
 	defer zipReader.Close()
 
-	// 查找word/document.xml文件
-	docFile, err := findDocumentXml(zipReader.File)
+	return p.parseZip(zipReader.Reader)
+}
+
+// ParseWithInfo 提取文本，并在Metadata中返回自定义属性
+func (p *OfficeDocxParser) ParseWithInfo(filename string) ([]byte, internal.ExtractInfo, error) {
+	zipReader, err := ooxmlcrypt.OpenReader(filename, "DOCX")
 	if err != nil {
-		return nil, fmt.Errorf("找不到document.xml: %w", err)
+		return nil, internal.ExtractInfo{}, err
 	}
+	defer zipReader.Close()
+
+	info := internal.ExtractInfo{Metadata: customprops.Metadata(customprops.Read(zipReader.File))}
+	text, err := p.parseZip(zipReader.Reader)
+	return text, info, err
+}
+
+// ParseReader 从io.Reader中读取DOCX内容并提取文本
+func (p *OfficeDocxParser) ParseReader(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取DOCX内容: %w", err)
+	}
+
+	zipReader, err := ooxmlcrypt.NewReader(data, "DOCX")
+	if err != nil {
+		return nil, err
+	}
+
+	return p.parseZip(zipReader)
+}
+
+// parseZip 从已打开的ZIP中提取文本
+func (p *OfficeDocxParser) parseZip(zipReader *zip.Reader) ([]byte, error) {
+	parts := newPackageParts(zipReader.File)
+
+	// 通过包关系定位主文档部件，未声明时使用word/document.xml
+	documentPart := parts.resolve("", relTypeOfficeDocument, defaultDocumentPart)
+	docFile := parts.get(documentPart)
+	if docFile == nil {
+		return nil, fmt.Errorf("找不到document.xml: %s not found in DOCX file: %w", documentPart, internal.ErrStreamNotFound)
+	}
+	logger.Logger.Printf("docx 主文档: %s", docFile.Name)
 
 	// 读取XML内容
 	xmlContent, err := readZipFile(docFile)
@@ -35,24 +101,72 @@ func (p *OfficeDocxParser) Parse(filename string) ([]byte, error) {
 		return nil, fmt.Errorf("无法读取XML内容: %w", err)
 	}
 
+	// 样式名称用于识别本地化的标题样式，styles.xml缺失或解析失败时仅按样式ID识别
+	styleNames := make(map[string]string)
+	if file := parts.get(parts.resolve(documentPart, relTypeStyles, defaultStylesPart)); file != nil {
+		if stylesContent, err := readZipFile(file); err != nil {
+			logger.Logger.Printf("读取styles.xml失败: %v", err)
+		} else if styleNames, err = parseStylesXml(stylesContent); err != nil {
+			logger.Logger.Printf("解析styles.xml失败: %v", err)
+		}
+	}
+
+	var lists *numbering
+	if p.RenderLists {
+		if file := parts.get(parts.resolve(documentPart, relTypeNumbering, defaultNumberingPart)); file != nil {
+			if numberingContent, err := readZipFile(file); err != nil {
+				logger.Logger.Printf("读取numbering.xml失败: %v", err)
+			} else if lists, err = parseNumberingXml(numberingContent); err != nil {
+				logger.Logger.Printf("解析numbering.xml失败: %v", err)
+			}
+		}
+	}
+
 	// 解析XML提取文本
-	extractedText, err := parseDocumentXml(xmlContent)
+	extractedText, err := parseDocumentXml(xmlContent, styleNames, lists, p.Limit, p.IncludeAltText)
 	if err != nil {
 		return nil, fmt.Errorf("解析XML失败: %w", err)
 	}
 
+	if p.IncludeComments && p.Limit <= 0 {
+		if comments := parseComments(parts, documentPart); len(comments) > 0 {
+			extractedText = append(extractedText, "\n=== 批注 ===\n"...)
+			extractedText = append(extractedText, comments...)
+		}
+	}
+
+	if p.IncludeEmbedded && p.Limit <= 0 {
+		buf := bytes.NewBuffer(extractedText)
+		writeEmbedded(buf, zipReader.File, documentPart)
+		extractedText = buf.Bytes()
+	}
+
+	if p.IncludeCustomProperties && p.Limit <= 0 {
+		buf := bytes.NewBuffer(extractedText)
+		customprops.WriteSection(buf, customprops.Read(zipReader.File))
+		extractedText = buf.Bytes()
+	}
+
 	return extractedText, nil
 }
 
-// findDocumentXml 在ZIP文件中查找word/document.xml
-func findDocumentXml(files []*zip.File) (*zip.File, error) {
-	for _, file := range files {
-		logger.Logger.Printf("docx 文件: %s", file.Name)
-		if file.Name == "word/document.xml" {
-			return file, nil
-		}
+// parseComments 读取主文档关联的批注部件，不存在或解析失败时返回nil，不影响正文
+func parseComments(parts packageParts, documentPart string) []byte {
+	file := parts.get(parts.resolve(documentPart, relTypeComments, defaultCommentsPart))
+	if file == nil {
+		return nil
 	}
-	return nil, errors.New("word/document.xml not found in DOCX file")
+	content, err := readZipFile(file)
+	if err != nil {
+		logger.Logger.Printf("读取comments.xml失败: %v", err)
+		return nil
+	}
+	comments, err := parseCommentsXml(content)
+	if err != nil {
+		logger.Logger.Printf("解析comments.xml失败: %v", err)
+		return nil
+	}
+	return comments
 }
 
 // readZipFile 读取ZIP文件内容
@@ -82,44 +196,155 @@ const wNamespace = "http://schemas.openxmlformats.org/wordprocessingml/2006/main
 
 type para struct {
 	XMLName xml.Name `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main p"`
-	PStyle  pStyle   `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main pPr>http://schemas.openxmlformats.org/wordprocessingml/2006/main pStyle"` // 段落样式
-	Runs    []run    `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main r"`                                                                       // 文本 run
+	PPr     pPr      `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main pPr"` // 段落属性
+	Runs    []run    `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main r"`   // 文本 run
+}
+
+// pPr 段落属性
+type pPr struct {
+	PStyle pStyle `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main pStyle"` // 段落样式
+	NumPr  numPr  `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main numPr"`  // 列表编号
 }
 
 type pStyle struct {
-	Val string `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main val,attr"` // 样式值，如 Heading1, Heading2
+	Val string `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main val,attr"` // 样式ID，如 Heading1，本地化文档中可能为 berschrift1
+}
+
+// stylesXml 用于解析styles.xml的结构
+type stylesXml struct {
+	Styles []style `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main style"`
+}
+
+type style struct {
+	StyleID string  `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main styleId,attr"`
+	Name    valAttr `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main name"` // 内置样式的名称固定为英文，如 heading 1
+}
+
+// valAttr 仅包含w:val属性的元素
+type valAttr struct {
+	Val string `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main val,attr"`
+}
+
+// parseStylesXml 解析styles.xml，返回样式ID到样式名称的映射
+func parseStylesXml(xmlContent []byte) (map[string]string, error) {
+	var styles stylesXml
+	if err := xml.Unmarshal(xmlContent, &styles); err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(styles.Styles))
+	for _, s := range styles.Styles {
+		names[s.StyleID] = s.Name.Val
+	}
+	return names, nil
+}
+
+// headingLevel 返回段落样式对应的标题级别，优先按styles.xml中的样式名称识别，
+// 其次按样式ID识别，"heading"之后必须为1-9的数字
+func headingLevel(styleID string, styleNames map[string]string) (int, bool) {
+	for _, name := range []string{styleNames[styleID], styleID} {
+		name = strings.ToLower(strings.ReplaceAll(name, " ", ""))
+		digits, ok := strings.CutPrefix(name, "heading")
+		if !ok {
+			continue
+		}
+		if level, err := strconv.Atoi(digits); err == nil && level >= 1 && level <= 9 {
+			return level, true
+		}
+	}
+	return 0, false
 }
 
 type run struct {
-	XMLName xml.Name `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main r"`
-	Texts   []text   `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main t"` // 文本内容
+	XMLName xml.Name  `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main r"`
+	Items   []runItem `xml:",any"` // 按文档顺序保留 t/tab/br/cr 等子元素
+}
+
+// runItem run中的子元素，文本取自w:t，w:tab/w:br/w:cr转换为对应空白字符
+type runItem struct {
+	XMLName xml.Name
+	Space   string `xml:"http://www.w3.org/XML/1998/namespace space,attr"` // xml:space="preserve" 时保留首尾空白
+	Value   string `xml:",chardata"`
+
+	// w:drawing中嵌入（wp:inline）或浮动（wp:anchor）图片的非可视属性
+	Inline *docPr `xml:"http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing inline>docPr"`
+	Anchor *docPr `xml:"http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing anchor>docPr"`
+}
+
+// docPr 绘图对象的非可视属性，descr、title为替代文字
+type docPr struct {
+	Descr string `xml:"descr,attr"`
+	Title string `xml:"title,attr"`
+}
+
+// altText 返回图片的替代文字"[image: 描述]"，没有替代文字时返回空
+func (d *docPr) altText() string {
+	if d == nil {
+		return ""
+	}
+	text := strings.TrimSpace(d.Descr)
+	if text == "" {
+		text = strings.TrimSpace(d.Title)
+	}
+	if text == "" {
+		return ""
+	}
+	return "[image: " + text + "]"
 }
 
-type text struct {
-	XMLName xml.Name `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main t"`
-	Value   string   `xml:",chardata"`
+// runText 返回run的文本内容，altText为true时输出其中图片的替代文字
+func (r *run) runText(altText bool) string {
+	var sb strings.Builder
+	for _, item := range r.Items {
+		if item.XMLName.Space != wNamespace {
+			continue
+		}
+		switch item.XMLName.Local {
+		case "t":
+			if item.Space == "preserve" {
+				sb.WriteString(item.Value)
+			} else {
+				sb.WriteString(strings.TrimSpace(item.Value))
+			}
+		case "tab":
+			sb.WriteString("\t")
+		case "br", "cr":
+			sb.WriteString("\n")
+		case "drawing":
+			if altText {
+				sb.WriteString(item.Inline.altText())
+				sb.WriteString(item.Anchor.altText())
+			}
+		}
+	}
+	return sb.String()
 }
 
-// parseDocumentXml 解析XML内容并提取文本
-func parseDocumentXml(xmlContent []byte) ([]byte, error) {
+// parseDocumentXml 解析XML内容并提取文本，styleNames为样式ID到样式名称的映射，
+// lists不为nil时为列表段落添加编号，maxParagraphs>0时只提取前maxParagraphs个段落，altText为true时输出图片的替代文字
+func parseDocumentXml(xmlContent []byte, styleNames map[string]string, lists *numbering, maxParagraphs int, altText bool) ([]byte, error) {
 	var doc documentXml
 	if err := xml.Unmarshal(xmlContent, &doc); err != nil {
 		return []byte{}, err
 	}
 
 	var textBuffer bytes.Buffer
-	for _, para := range doc.Body.Paras {
-		style := para.PStyle.Val
+	for i, para := range doc.Body.Paras {
+		if maxParagraphs > 0 && i >= maxParagraphs {
+			break
+		}
+		style := para.PPr.PStyle.Val
 		var paraText bytes.Buffer
 		// 提取段落文本内容
 		for _, run := range para.Runs {
-			for _, t := range run.Texts {
-				paraText.WriteString(t.Value)
-			}
+			paraText.WriteString(run.runText(altText))
 		}
 		// 根据样式添加标识
-		if strings.HasPrefix(style, "Heading") {
-			textBuffer.WriteString(fmt.Sprintf("【标题%s】 ", style[7:]))
+		if level, ok := headingLevel(style, styleNames); ok {
+			textBuffer.WriteString(fmt.Sprintf("【标题%d】 ", level))
+		}
+		if lists != nil {
+			textBuffer.WriteString(lists.nextMarker(para.PPr.NumPr))
 		}
 		textBuffer.WriteString(paraText.String())
 		textBuffer.WriteString("\n") // 段落间添加换行
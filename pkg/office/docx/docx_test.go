@@ -0,0 +1,43 @@
+package docx
+
+import (
+	"bytes"
+	"testing"
+
+	"fextra/internal/ziptest"
+)
+
+const wordNamespace = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"`
+
+func TestParseReader(t *testing.T) {
+	document := `<w:document ` + wordNamespace + `><w:body>` +
+		`<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Title</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:t xml:space="preserve">Hello </w:t></w:r><w:r><w:t>world</w:t><w:tab/><w:t>tab</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:t>中文段落</w:t></w:r></w:p>` +
+		`</w:body></w:document>`
+	data := ziptest.Build([]ziptest.File{{Name: "word/document.xml", Data: document}})
+
+	for _, tt := range []struct {
+		name   string
+		parser OfficeDocxParser
+		want   string
+	}{
+		{"full", OfficeDocxParser{}, "【标题1】 Title\nHello world\ttab\n中文段落\n"},
+		{"limit", OfficeDocxParser{Limit: 1}, "【标题1】 Title\n"},
+	} {
+		got, err := tt.parser.ParseReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: ParseReader: %v", tt.name, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseReaderMissingDocument(t *testing.T) {
+	data := ziptest.Build([]ziptest.File{{Name: "word/styles.xml", Data: "<styles/>"}})
+	if _, err := (&OfficeDocxParser{}).ParseReader(bytes.NewReader(data)); err == nil {
+		t.Error("expected an error for a package without document.xml")
+	}
+}
@@ -0,0 +1,161 @@
+package docx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"fextra/internal"
+	"fextra/pkg/logger"
+	"fextra/pkg/office/oleobj"
+)
+
+/*
+	嵌入对象保存在主文档目录的embeddings/下：嵌入的Office文档直接以原格式保存（如Microsoft_Excel_Worksheet.xlsx），
+	其他OLE对象保存为oleObjectN.bin（OLE复合文档）。图表保存在charts/chartN.xml，
+	其数据来自embeddings/下的工作簿，chartN.xml中缓存了标题、系列名称及分类标签
+*/
+
+// drawingML图表命名空间
+const chartNamespace = "http://schemas.openxmlformats.org/drawingml/2006/chart"
+
+// writeEmbedded 依次解析主文档目录下的嵌入对象及图表，按部件名排序；
+// 无法识别或解析失败的对象只记录日志
+func writeEmbedded(buf *bytes.Buffer, files []*zip.File, documentPart string) {
+	dir := path.Dir(documentPart)
+	embeddings := strings.ToLower(path.Join(dir, "embeddings")) + "/"
+	charts := strings.ToLower(path.Join(dir, "charts")) + "/"
+
+	var objects, chartParts []*zip.File
+	for _, file := range files {
+		name := strings.ToLower(file.Name)
+		switch {
+		case strings.HasPrefix(name, embeddings) && !strings.HasSuffix(name, "/"):
+			objects = append(objects, file)
+		case strings.HasPrefix(name, charts) && path.Dir(name)+"/" == charts &&
+			strings.HasPrefix(path.Base(name), "chart") && path.Ext(name) == ".xml":
+			chartParts = append(chartParts, file)
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Name < objects[j].Name })
+	sort.Slice(chartParts, func(i, j int) bool { return chartParts[i].Name < chartParts[j].Name })
+
+	for _, file := range objects {
+		content, err := parseEmbeddedObject(file)
+		if err != nil {
+			logger.Logger.Printf("解析嵌入对象%s失败: %v", file.Name, err)
+			continue
+		}
+		if text := bytes.TrimSpace(content); len(text) > 0 {
+			buf.WriteString(fmt.Sprintf("\n=== 嵌入对象: %s ===\n", path.Base(file.Name)))
+			buf.Write(text)
+			buf.WriteString("\n")
+		}
+	}
+
+	for _, file := range chartParts {
+		content, err := readZipFile(file)
+		if err != nil {
+			logger.Logger.Printf("读取图表%s失败: %v", file.Name, err)
+			continue
+		}
+		labels, err := parseChartXml(content)
+		if err != nil {
+			logger.Logger.Printf("解析图表%s失败: %v", file.Name, err)
+			continue
+		}
+		if len(labels) > 0 {
+			buf.WriteString(fmt.Sprintf("\n=== 图表: %s ===\n", path.Base(file.Name)))
+			buf.Write(labels)
+		}
+	}
+}
+
+// parseEmbeddedObject 解析一个嵌入对象：oleObject*.bin按内容识别类型，
+// 其他部件按后缀选择解析器，直接从ZIP条目流式读取
+func parseEmbeddedObject(file *zip.File) ([]byte, error) {
+	if strings.EqualFold(path.Ext(file.Name), ".bin") {
+		data, err := readZipFile(file)
+		if err != nil {
+			return nil, err
+		}
+		return oleobj.Parse(data)
+	}
+
+	fileType := internal.GetDynamicFileType(path.Base(file.Name))
+	if fileType == 114 {
+		return nil, oleobj.ErrUnknownObject
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return internal.ParseReader(rc, fileType)
+}
+
+// parseChartXml 提取图表中的标题（含坐标轴标题）、系列名称及分类标签，每项一行，重复的标签只输出一次；
+// 标题为富文本a:t，系列名称及分类标签为c:tx、c:cat下缓存的c:v，数值不输出
+func parseChartXml(content []byte) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+
+	var buf bytes.Buffer
+	seen := make(map[string]bool)
+	var stack []xml.Name
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return buf.Bytes(), err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name)
+			text.Reset()
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if isChartLabel(stack) {
+				if label := strings.TrimSpace(text.String()); label != "" && !seen[label] {
+					seen[label] = true
+					buf.WriteString(label)
+					buf.WriteString("\n")
+				}
+			}
+			text.Reset()
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// isChartLabel 判断当前元素是否为标签文本：a:t，或c:tx、c:cat下的c:v
+func isChartLabel(stack []xml.Name) bool {
+	if len(stack) == 0 {
+		return false
+	}
+	current := stack[len(stack)-1]
+	if current.Local == "t" && current.Space != chartNamespace {
+		return true
+	}
+	if current.Local != "v" || current.Space != chartNamespace {
+		return false
+	}
+	for i := len(stack) - 2; i >= 0; i-- {
+		if stack[i].Space == chartNamespace && (stack[i].Local == "tx" || stack[i].Local == "cat") {
+			return true
+		}
+	}
+	return false
+}
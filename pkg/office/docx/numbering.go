@@ -0,0 +1,197 @@
+package docx
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+/*
+	列表编号定义在word/numbering.xml中：
+	段落通过 w:pPr/w:numPr 的 w:numId、w:ilvl 引用 w:num，w:num 再引用 w:abstractNum，
+	abstractNum 中每一级 w:lvl 给出起始值(w:start)、编号格式(w:numFmt)及编号文本模板(w:lvlText，如 "%1.%2.")
+*/
+
+const maxListLevel = 9 // WordprocessingML 列表最多9级
+
+// numPr 段落的列表编号引用
+type numPr struct {
+	NumID valAttr `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main numId"`
+	Ilvl  valAttr `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main ilvl"`
+}
+
+// numberingXml 用于解析numbering.xml的结构
+type numberingXml struct {
+	AbstractNums []abstractNum `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main abstractNum"`
+	Nums         []num         `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main num"`
+}
+
+type abstractNum struct {
+	AbstractNumID string     `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main abstractNumId,attr"`
+	Lvls          []numLevel `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main lvl"`
+}
+
+type numLevel struct {
+	Ilvl    int     `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main ilvl,attr"`
+	Start   valAttr `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main start"`
+	NumFmt  valAttr `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main numFmt"`
+	LvlText valAttr `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main lvlText"`
+}
+
+type num struct {
+	NumID         string        `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main numId,attr"`
+	AbstractNumID valAttr       `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main abstractNumId"`
+	LvlOverrides  []lvlOverride `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main lvlOverride"`
+}
+
+type lvlOverride struct {
+	Ilvl          int     `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main ilvl,attr"`
+	StartOverride valAttr `xml:"http://schemas.openxmlformats.org/wordprocessingml/2006/main startOverride"`
+}
+
+// listLevel 解析后的单级编号定义
+type listLevel struct {
+	start   int
+	numFmt  string
+	lvlText string
+}
+
+// numbering 列表编号定义及解析过程中各列表的当前计数
+type numbering struct {
+	levels   map[string]*[maxListLevel]listLevel // numId -> 各级编号定义
+	counters map[string]*[maxListLevel]int       // numId -> 各级当前编号
+}
+
+// parseNumberingXml 解析numbering.xml，将w:num与其引用的w:abstractNum合并
+func parseNumberingXml(xmlContent []byte) (*numbering, error) {
+	var doc numberingXml
+	if err := xml.Unmarshal(xmlContent, &doc); err != nil {
+		return nil, err
+	}
+
+	abstracts := make(map[string][maxListLevel]listLevel, len(doc.AbstractNums))
+	for _, an := range doc.AbstractNums {
+		var levels [maxListLevel]listLevel
+		for _, lvl := range an.Lvls {
+			if lvl.Ilvl < 0 || lvl.Ilvl >= maxListLevel {
+				continue
+			}
+			start, err := strconv.Atoi(lvl.Start.Val)
+			if err != nil {
+				start = 1
+			}
+			levels[lvl.Ilvl] = listLevel{start: start, numFmt: lvl.NumFmt.Val, lvlText: lvl.LvlText.Val}
+		}
+		abstracts[an.AbstractNumID] = levels
+	}
+
+	n := &numbering{
+		levels:   make(map[string]*[maxListLevel]listLevel, len(doc.Nums)),
+		counters: make(map[string]*[maxListLevel]int),
+	}
+	for _, nm := range doc.Nums {
+		levels, ok := abstracts[nm.AbstractNumID.Val]
+		if !ok {
+			continue
+		}
+		for _, o := range nm.LvlOverrides {
+			if o.Ilvl < 0 || o.Ilvl >= maxListLevel {
+				continue
+			}
+			if start, err := strconv.Atoi(o.StartOverride.Val); err == nil {
+				levels[o.Ilvl].start = start
+			}
+		}
+		n.levels[nm.NumID] = &levels
+	}
+	return n, nil
+}
+
+// nextMarker 推进列表计数并返回段落的缩进及编号前缀，段落不属于列表时返回空字符串
+func (n *numbering) nextMarker(p numPr) string {
+	numID := p.NumID.Val
+	levels, ok := n.levels[numID]
+	if !ok {
+		return "" // numId为0或未定义表示取消编号
+	}
+	ilvl, err := strconv.Atoi(p.Ilvl.Val)
+	if err != nil || ilvl < 0 || ilvl >= maxListLevel {
+		ilvl = 0
+	}
+
+	counters, ok := n.counters[numID]
+	if !ok {
+		counters = &[maxListLevel]int{}
+		for i := range counters {
+			counters[i] = levels[i].start - 1
+		}
+		n.counters[numID] = counters
+	}
+	counters[ilvl]++
+	// 上级编号推进后下级重新计数
+	for i := ilvl + 1; i < maxListLevel; i++ {
+		counters[i] = levels[i].start - 1
+	}
+
+	lvl := levels[ilvl]
+	var marker string
+	switch lvl.numFmt {
+	case "bullet":
+		marker = "•"
+	case "none":
+		marker = ""
+	default:
+		// 将模板中的 %1..%9 替换为对应级别的编号
+		marker = lvl.lvlText
+		for i := 0; i <= ilvl; i++ {
+			marker = strings.ReplaceAll(marker, "%"+strconv.Itoa(i+1), formatListNumber(counters[i], levels[i].numFmt))
+		}
+	}
+
+	indent := strings.Repeat("  ", ilvl)
+	if marker == "" {
+		return indent
+	}
+	return indent + marker + " "
+}
+
+// formatListNumber 按编号格式格式化序号，不支持的格式按十进制输出
+func formatListNumber(n int, numFmt string) string {
+	switch numFmt {
+	case "lowerLetter":
+		return letterNumber(n, 'a')
+	case "upperLetter":
+		return letterNumber(n, 'A')
+	case "lowerRoman":
+		return strings.ToLower(romanNumber(n))
+	case "upperRoman":
+		return romanNumber(n)
+	default:
+		return strconv.Itoa(n)
+	}
+}
+
+// letterNumber 字母编号，超过26时重复字母：a..z, aa..zz
+func letterNumber(n int, base rune) string {
+	if n <= 0 {
+		return strconv.Itoa(n)
+	}
+	return strings.Repeat(string(base+rune((n-1)%26)), (n-1)/26+1)
+}
+
+// romanNumber 罗马数字编号，超出范围时按十进制输出
+func romanNumber(n int) string {
+	if n <= 0 || n >= 4000 {
+		return strconv.Itoa(n)
+	}
+	values := []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	symbols := []string{"M", "CM", "D", "CD", "C", "XC", "L", "XL", "X", "IX", "V", "IV", "I"}
+	var sb strings.Builder
+	for i, v := range values {
+		for n >= v {
+			sb.WriteString(symbols[i])
+			n -= v
+		}
+	}
+	return sb.String()
+}
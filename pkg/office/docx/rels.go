@@ -0,0 +1,99 @@
+package docx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"path"
+	"strings"
+
+	"fextra/pkg/logger"
+)
+
+/*
+	OPC包中各部件的位置由关系文件声明：
+	_rels/.rels 中 officeDocument 类型的关系指向主文档部件（通常为word/document.xml，但可以任意命名），
+	主文档的关系文件（如 word/_rels/document.xml.rels）再给出styles、numbering等部件的位置。
+	部件名不区分大小写，关系文件缺失或无对应关系时使用默认路径
+*/
+
+// 关系类型以这些名称结尾，兼容Transitional与Strict两种命名空间
+const (
+	relTypeOfficeDocument = "/officeDocument"
+	relTypeStyles         = "/styles"
+	relTypeNumbering      = "/numbering"
+	relTypeComments       = "/comments"
+)
+
+// 默认部件路径
+const (
+	defaultDocumentPart  = "word/document.xml"
+	defaultStylesPart    = "word/styles.xml"
+	defaultNumberingPart = "word/numbering.xml"
+	defaultCommentsPart  = "word/comments.xml"
+)
+
+type relationships struct {
+	Rels []relationship `xml:"Relationship"`
+}
+
+type relationship struct {
+	Type       string `xml:"Type,attr"`
+	Target     string `xml:"Target,attr"`
+	TargetMode string `xml:"TargetMode,attr"`
+}
+
+// packageParts 按小写部件名索引ZIP条目
+type packageParts map[string]*zip.File
+
+func newPackageParts(files []*zip.File) packageParts {
+	parts := make(packageParts, len(files))
+	for _, file := range files {
+		logger.Logger.Printf("docx 文件: %s", file.Name)
+		parts[strings.ToLower(file.Name)] = file
+	}
+	return parts
+}
+
+// get 按部件名查找ZIP条目，不区分大小写
+func (parts packageParts) get(name string) *zip.File {
+	return parts[strings.ToLower(name)]
+}
+
+// resolve 在source部件的关系中查找relType类型的目标部件，未找到时返回fallback
+// source为空表示包级关系(_rels/.rels)
+func (parts packageParts) resolve(source string, relType string, fallback string) string {
+	dir, name := path.Split(source)
+	rels := parts.get(dir + "_rels/" + name + ".rels")
+	if rels == nil {
+		return fallback
+	}
+
+	content, err := readZipFile(rels)
+	if err != nil {
+		logger.Logger.Printf("读取关系文件%s失败: %v", rels.Name, err)
+		return fallback
+	}
+	var doc relationships
+	if err := xml.Unmarshal(content, &doc); err != nil {
+		logger.Logger.Printf("解析关系文件%s失败: %v", rels.Name, err)
+		return fallback
+	}
+
+	for _, rel := range doc.Rels {
+		if !strings.HasSuffix(rel.Type, relType) || strings.EqualFold(rel.TargetMode, "External") {
+			continue
+		}
+		// 以"/"开头的目标为包内绝对路径，否则相对于source所在目录
+		target := rel.Target
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join(dir, target)
+		}
+		if parts.get(target) != nil {
+			return target
+		}
+		logger.Logger.Printf("关系目标%s不存在，使用默认路径%s", target, fallback)
+	}
+	return fallback
+}
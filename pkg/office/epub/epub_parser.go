@@ -0,0 +1,184 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fextra/internal"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+
+	"fextra/pkg/logger"
+	"fextra/pkg/plaintext/plainhtml"
+)
+
+/*
+	EPUB为ZIP格式，META-INF/container.xml指向OPF包文件，
+	OPF中manifest列出所有资源，spine给出阅读顺序，正文为XHTML文档
+*/
+
+// OfficeEpubParser EPUB电子书解析器
+type OfficeEpubParser struct{}
+
+// Parse 按spine阅读顺序提取EPUB中各章节的文本内容
+func (p *OfficeEpubParser) Parse(filePath string) ([]byte, error) {
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return []byte{}, internal.ZipOpenError("EPUB", err)
+	}
+	defer reader.Close()
+
+	files := make(map[string]*zip.File, len(reader.File))
+	for _, file := range reader.File {
+		files[file.Name] = file
+	}
+
+	// 定位OPF包文件
+	opfPath, err := findOpfPath(files)
+	if err != nil {
+		return []byte{}, err
+	}
+	logger.Logger.Printf("OPF文件: %s", opfPath)
+
+	opfContent, err := readZipFile(files[opfPath])
+	if err != nil {
+		return []byte{}, fmt.Errorf("无法读取OPF文件 %s: %v", opfPath, err)
+	}
+
+	var pkg opfPackage
+	if err := xml.Unmarshal(opfContent, &pkg); err != nil {
+		return []byte{}, fmt.Errorf("解析OPF文件失败: %v", err)
+	}
+
+	// manifest: id -> item
+	items := make(map[string]manifestItem, len(pkg.Manifest.Items))
+	for _, item := range pkg.Manifest.Items {
+		items[item.ID] = item
+	}
+
+	var textBuffer bytes.Buffer
+	htmlParser := &plainhtml.TextHTMLParser{}
+	baseDir := path.Dir(opfPath)
+
+	for _, ref := range pkg.Spine.ItemRefs {
+		item, ok := items[ref.IDRef]
+		if !ok {
+			logger.Logger.Printf("spine引用的资源不存在: %s", ref.IDRef)
+			continue
+		}
+		if !isContentDocument(item.MediaType) {
+			logger.DebugLogger.Printf("跳过非正文资源: %s(%s)", item.Href, item.MediaType)
+			continue
+		}
+
+		// href为相对于OPF文件所在目录的URL，空格、中文等字符经过百分号编码，ZIP中的文件名未编码
+		href := item.Href
+		if unescaped, err := url.PathUnescape(href); err == nil {
+			href = unescaped
+		}
+		name := path.Join(baseDir, href)
+		file, ok := files[name]
+		if !ok {
+			logger.Logger.Printf("章节文件不存在: %s", name)
+			continue
+		}
+
+		logger.Logger.Printf("处理章节文件: %s", name)
+		content, err := readZipFile(file)
+		if err != nil {
+			logger.Logger.Printf("无法读取章节文件 %s: %v", name, err)
+			continue
+		}
+
+		chapterText, err := htmlParser.ParseHtml(content)
+		if err != nil {
+			logger.Logger.Printf("无法解析章节文件 %s: %v", name, err)
+			continue
+		}
+
+		textBuffer.WriteString(fmt.Sprintf("=== 章节: %s ===\n", href))
+		textBuffer.Write(chapterText)
+		textBuffer.WriteString("\n\n")
+	}
+
+	return textBuffer.Bytes(), nil
+}
+
+// findOpfPath 通过container.xml查找OPF文件路径，失败时回退到第一个.opf文件
+func findOpfPath(files map[string]*zip.File) (string, error) {
+	if file, ok := files["META-INF/container.xml"]; ok {
+		content, err := readZipFile(file)
+		if err == nil {
+			var c container
+			if err := xml.Unmarshal(content, &c); err == nil {
+				for _, rootFile := range c.RootFiles {
+					if _, ok := files[rootFile.FullPath]; ok {
+						return rootFile.FullPath, nil
+					}
+				}
+			} else {
+				logger.Logger.Printf("解析container.xml失败: %v", err)
+			}
+		}
+	}
+
+	for name := range files {
+		if strings.HasSuffix(strings.ToLower(name), ".opf") {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("EPUB文件中未找到OPF包文件: %w", internal.ErrStreamNotFound)
+}
+
+// isContentDocument 判断资源是否为XHTML/HTML正文
+func isContentDocument(mediaType string) bool {
+	return mediaType == "application/xhtml+xml" || mediaType == "text/html"
+}
+
+// readZipFile 读取ZIP文件中的指定文件内容
+func readZipFile(zf *zip.File) ([]byte, error) {
+	rc, err := zf.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+// container META-INF/container.xml结构
+type container struct {
+	RootFiles []rootFile `xml:"rootfiles>rootfile"`
+}
+
+type rootFile struct {
+	FullPath  string `xml:"full-path,attr"`
+	MediaType string `xml:"media-type,attr"`
+}
+
+// opfPackage OPF包文件结构
+type opfPackage struct {
+	Manifest manifest `xml:"manifest"`
+	Spine    spine    `xml:"spine"`
+}
+
+type manifest struct {
+	Items []manifestItem `xml:"item"`
+}
+
+type manifestItem struct {
+	ID        string `xml:"id,attr"`
+	Href      string `xml:"href,attr"`
+	MediaType string `xml:"media-type,attr"`
+}
+
+type spine struct {
+	ItemRefs []itemRef `xml:"itemref"`
+}
+
+type itemRef struct {
+	IDRef string `xml:"idref,attr"`
+}
@@ -0,0 +1,49 @@
+package epub
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fextra/internal/ziptest"
+)
+
+func chapter(text string) string {
+	return `<html xmlns="http://www.w3.org/1999/xhtml"><body><p>` + text + `</p></body></html>`
+}
+
+// TestParseEscapedHref manifest中百分号编码的href（空格、中文文件名）按解码后的名称在ZIP中查找
+func TestParseEscapedHref(t *testing.T) {
+	opf := `<package xmlns="http://www.idpf.org/2007/opf"><manifest>` +
+		`<item id="c1" href="Text/chapter%201.xhtml" media-type="application/xhtml+xml"/>` +
+		`<item id="c2" href="Text/%E7%AC%AC%E4%BA%8C%E7%AB%A0.xhtml" media-type="application/xhtml+xml"/>` +
+		`<item id="css" href="style.css" media-type="text/css"/>` +
+		`</manifest><spine><itemref idref="c2"/><itemref idref="c1"/><itemref idref="css"/></spine></package>`
+	data := ziptest.Build([]ziptest.File{
+		{Name: "mimetype", Data: "application/epub+zip"},
+		{Name: "META-INF/container.xml", Data: `<container><rootfiles>` +
+			`<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`},
+		{Name: "OEBPS/content.opf", Data: opf},
+		{Name: "OEBPS/Text/chapter 1.xhtml", Data: chapter("First chapter")},
+		{Name: "OEBPS/Text/第二章.xhtml", Data: chapter("第二章内容")},
+		{Name: "OEBPS/style.css", Data: "p { margin: 0 }"},
+	})
+	path := filepath.Join(t.TempDir(), "book.epub")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := (&OfficeEpubParser{}).Parse(path)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	got := string(text)
+	second, first := strings.Index(got, "第二章内容"), strings.Index(got, "First chapter")
+	if second < 0 || first < 0 || second > first {
+		t.Errorf("chapters missing or out of spine order: %q", got)
+	}
+	if !strings.Contains(got, "=== 章节: Text/chapter 1.xhtml ===") || strings.Contains(got, "margin") {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,172 @@
+package flatopc
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fextra/internal"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"fextra/pkg/logger"
+	"fextra/pkg/office/docx"
+	"fextra/pkg/office/pptx"
+	"fextra/pkg/office/xlsx"
+)
+
+/*
+	Flat OPC 将OPC包的所有部件保存在单个XML文件中：
+	<pkg:package> 下每个 <pkg:part pkg:name="/word/document.xml"> 对应一个部件，
+	XML部件内容内联在 <pkg:xmlData> 中，二进制部件以base64保存在 <pkg:binaryData> 中。
+	此处将部件重新组装为ZIP，按主文档的内容类型交由docx/xlsx/pptx解析器处理
+*/
+
+// 主文档类型
+const (
+	wordprocessingML = "wordprocessingml"
+	spreadsheetML    = "spreadsheetml"
+	presentationML   = "presentationml"
+)
+
+// mainContentTypes 主文档部件内容类型中的标识，启用宏的变体以vnd.ms-word等开头
+var mainContentTypes = []struct {
+	marker string
+	kind   string
+}{
+	{wordprocessingML, wordprocessingML},
+	{"ms-word", wordprocessingML},
+	{spreadsheetML, spreadsheetML},
+	{"ms-excel", spreadsheetML},
+	{presentationML, presentationML},
+	{"ms-powerpoint", presentationML},
+}
+
+type part struct {
+	Name        string `xml:"name,attr"`
+	ContentType string `xml:"contentType,attr"`
+	XMLData     struct {
+		Inner []byte `xml:",innerxml"`
+	} `xml:"xmlData"`
+	BinaryData string `xml:"binaryData"`
+}
+
+// OfficeFlatOpcParser Flat OPC（单文件XML格式的Word/Excel/PowerPoint文档）解析器
+type OfficeFlatOpcParser struct{}
+
+func (p *OfficeFlatOpcParser) Parse(filePath string) ([]byte, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return []byte{}, fmt.Errorf("无法打开Flat OPC文件: %v", err)
+	}
+	defer file.Close()
+
+	return p.ParseReader(file)
+}
+
+func (p *OfficeFlatOpcParser) ParseReader(r io.Reader) ([]byte, error) {
+	pkg, kind, err := ToZip(r)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	logger.Logger.Printf("Flat OPC文档类型: %s", kind)
+	reader := bytes.NewReader(pkg)
+	switch kind {
+	case wordprocessingML:
+		return (&docx.OfficeDocxParser{}).ParseReader(reader)
+	case spreadsheetML:
+		return (&xlsx.OfficeXlsxParser{}).ParseReader(reader)
+	case presentationML:
+		return (&pptx.OfficePptxParser{}).ParseReader(reader)
+	default:
+		return []byte{}, fmt.Errorf("Flat OPC中未找到Word/Excel/PowerPoint主文档: %w", internal.ErrUnsupportedFormat)
+	}
+}
+
+// ToZip 将Flat OPC文档重新组装为OPC（ZIP）包，同时返回主文档类型
+// （wordprocessingml、spreadsheetml或presentationml，无法识别时为空）
+func ToZip(r io.Reader) ([]byte, string, error) {
+	decoder := xml.NewDecoder(r)
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	kind := ""
+	isPackage := false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("解析Flat OPC失败: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Space != internal.FlatOPCNamespace {
+			continue
+		}
+		switch start.Name.Local {
+		case "package":
+			isPackage = true
+		case "part":
+			var pt part
+			if err := decoder.DecodeElement(&pt, &start); err != nil {
+				return nil, "", fmt.Errorf("解析Flat OPC部件失败: %w", err)
+			}
+			if err := writePart(writer, pt); err != nil {
+				return nil, "", err
+			}
+			if kind == "" && strings.HasSuffix(pt.ContentType, ".main+xml") {
+				kind = documentKind(pt.ContentType)
+			}
+		}
+	}
+
+	if !isPackage {
+		return nil, "", fmt.Errorf("不是Flat OPC文档: %w", internal.ErrInvalidSignature)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("生成OPC包失败: %w", err)
+	}
+	return buf.Bytes(), kind, nil
+}
+
+// writePart 将部件写入ZIP，部件名去除前导"/"
+func writePart(writer *zip.Writer, pt part) error {
+	name := strings.TrimPrefix(pt.Name, "/")
+	if name == "" {
+		return nil
+	}
+
+	var data []byte
+	if pt.BinaryData != "" {
+		decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(pt.BinaryData), ""))
+		if err != nil {
+			logger.Logger.Printf("部件%s的base64数据解码失败: %v", pt.Name, err)
+			return nil
+		}
+		data = decoded
+	} else {
+		data = pt.XMLData.Inner
+	}
+
+	w, err := writer.Create(name)
+	if err != nil {
+		return fmt.Errorf("写入部件%s失败: %w", name, err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// documentKind 根据主文档部件的内容类型返回文档类型
+func documentKind(contentType string) string {
+	for _, t := range mainContentTypes {
+		if strings.Contains(contentType, t.marker) {
+			return t.kind
+		}
+	}
+	return ""
+}
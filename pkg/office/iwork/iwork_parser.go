@@ -0,0 +1,83 @@
+package iwork
+
+import (
+	"archive/zip"
+	"fextra/internal"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"fextra/pkg/logger"
+	"fextra/pkg/office/pdf"
+)
+
+/*
+	iWork 2013及以后版本（Pages/Keynote/Numbers）为ZIP格式，正文保存在Snappy压缩的protobuf(.iwa)中，
+	暂不解析IWA，但保存时通常会在包内生成预览PDF（preview.pdf 或 QuickLook/Preview.pdf），
+	此处提取该PDF并交由PDF解析器提取文本
+*/
+
+// previewNames 预览PDF在包内的路径，按优先级排列，匹配时不区分大小写
+var previewNames = []string{"preview.pdf", "quicklook/preview.pdf"}
+
+// OfficeIworkParser iWork（pages/key/numbers）解析器
+type OfficeIworkParser struct{}
+
+// Parse 提取iWork包中预览PDF的文本内容
+func (p *OfficeIworkParser) Parse(filePath string) ([]byte, error) {
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return []byte{}, internal.ZipOpenError("iWork", err)
+	}
+	defer reader.Close()
+
+	preview := findPreview(reader.File)
+	if preview == nil {
+		return []byte{}, fmt.Errorf("iWork文件中未找到预览PDF: %w", internal.ErrStreamNotFound)
+	}
+	logger.Logger.Printf("iWork预览PDF: %s", preview.Name)
+
+	// PDF解析器基于文件路径，先将预览PDF写入临时文件
+	tmpPath, err := extractToTemp(preview)
+	if err != nil {
+		return []byte{}, err
+	}
+	defer os.Remove(tmpPath)
+
+	pdfParser := &pdf.OfficePdfParser{}
+	return pdfParser.Parse(tmpPath)
+}
+
+// findPreview 查找包内的预览PDF
+func findPreview(files []*zip.File) *zip.File {
+	for _, name := range previewNames {
+		for _, file := range files {
+			if strings.ToLower(file.Name) == name {
+				return file
+			}
+		}
+	}
+	return nil
+}
+
+// extractToTemp 将ZIP条目写入临时文件，返回临时文件路径
+func extractToTemp(file *zip.File) (string, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("无法打开预览PDF: %v", err)
+	}
+	defer rc.Close()
+
+	tmp, err := internal.CreateTemp("iwork_preview_*.pdf")
+	if err != nil {
+		return "", fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, rc); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("写入临时文件失败: %v", err)
+	}
+	return tmp.Name(), nil
+}
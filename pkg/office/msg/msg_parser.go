@@ -0,0 +1,315 @@
+package msg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+
+	"fextra/internal"
+	"fextra/pkg/logger"
+	"fextra/pkg/office/doc"
+	"fextra/pkg/plaintext/plainhtml"
+	"fextra/pkg/plaintext/plaintxt"
+)
+
+/*
+	Outlook MSG 为OLE复合文档，邮件属性保存在根存储的流中：
+	__substg1.0_XXXXYYYY 为可变长属性，XXXX为属性ID，YYYY为属性类型（001F为UTF-16LE字符串，001E为代码页字符串，0102为二进制）；
+	定长属性（如时间、代码页）保存在 __properties_version1.0 流中，头部之后每项16字节（标签4字节、标志4字节、值8字节）；
+	收件人、附件分别保存在 __recip_version1.0_#XXXXXXXX、__attach_version1.0_#XXXXXXXX 子存储中，结构与根存储相同
+*/
+
+// 属性ID
+const (
+	propSubject         = 0x0037
+	propSubmitTime      = 0x0039
+	propSenderName      = 0x0C1A
+	propSenderEmail     = 0x0C1F
+	propRecipientType   = 0x0C15
+	propDisplayCc       = 0x0E03
+	propDisplayTo       = 0x0E04
+	propDeliveryTime    = 0x0E06
+	propBody            = 0x1000
+	propHTML            = 0x1013
+	propDisplayName     = 0x3001
+	propEmailAddress    = 0x3003
+	propAttachFilename  = 0x3704
+	propAttachLongName  = 0x3707
+	propSMTPAddress     = 0x39FE
+	propInternetCPID    = 0x3FDE
+	propMessageCodePage = 0x3FFD
+	propSenderSMTP      = 0x5D01
+)
+
+// 属性类型
+const (
+	typeString8 = 0x001E
+	typeUnicode = 0x001F
+	typeBinary  = 0x0102
+)
+
+// 收件人类型
+const (
+	recipientTo  = 1
+	recipientCc  = 2
+	recipientBcc = 3
+)
+
+const (
+	propertiesStream = "__properties_version1.0"
+	recipientPrefix  = "__recip_version1.0_"
+	attachmentPrefix = "__attach_version1.0_"
+
+	// __properties_version1.0流的头部长度：邮件为32字节，收件人、附件为8字节
+	messageHeaderSize = 32
+	childHeaderSize   = 8
+)
+
+// filetimeEpoch FILETIME（自1601年1月1日起的100纳秒间隔）与Unix时间的差值
+const filetimeEpoch = 116444736000000000
+
+var utf16le = unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+
+// OfficeMsgParser Outlook MSG邮件解析器，提取主题、发件人、收件人、日期、附件名及正文
+type OfficeMsgParser struct{}
+
+// propertyStorage 一个存储（邮件、收件人或附件）中的属性
+type propertyStorage struct {
+	d        *doc.DocParse
+	streams  map[string]*doc.PDirectoryEntry // 按流名索引的可变长属性
+	storages []*doc.PDirectoryEntry          // 子存储
+	fixed    map[uint16]uint64               // 按属性ID索引的定长属性
+	codePage encoding.Encoding               // 代码页字符串使用的编码，为nil时自动检测
+}
+
+func (p *OfficeMsgParser) Parse(filePath string) ([]byte, error) {
+	d, err := doc.OpenDocParse(filePath)
+	if err != nil {
+		return []byte{}, fmt.Errorf("打开MSG文件失败: %w", err)
+	}
+	defer d.Close()
+
+	message := newPropertyStorage(d, d.DirEntry[0], messageHeaderSize, nil)
+	if len(message.streams) == 0 {
+		return []byte{}, fmt.Errorf("未找到MSG属性流: %w", internal.ErrStreamNotFound)
+	}
+	message.codePage = message.encoding()
+
+	var content bytes.Buffer
+	writeField(&content, "主题", message.str(propSubject))
+	writeField(&content, "发件人", address(message.str(propSenderName), firstNonEmpty(message.str(propSenderSMTP), message.str(propSenderEmail))))
+
+	recipients, attachments := message.children()
+	to, cc, bcc := message.str(propDisplayTo), message.str(propDisplayCc), ""
+	if len(recipients) > 0 {
+		to, cc, bcc = groupRecipients(recipients)
+	}
+	writeField(&content, "收件人", to)
+	writeField(&content, "抄送", cc)
+	writeField(&content, "密送", bcc)
+
+	if date, ok := message.time(propSubmitTime); ok {
+		writeField(&content, "日期", date)
+	} else if date, ok := message.time(propDeliveryTime); ok {
+		writeField(&content, "日期", date)
+	}
+
+	var names []string
+	for _, attachment := range attachments {
+		if name := firstNonEmpty(attachment.str(propAttachLongName), attachment.str(propAttachFilename)); name != "" {
+			names = append(names, name)
+		}
+	}
+	writeField(&content, "附件", strings.Join(names, ", "))
+
+	content.WriteString("\n")
+	content.Write(message.body())
+	return content.Bytes(), nil
+}
+
+// newPropertyStorage 读取存储下的属性流及定长属性，headerSize为__properties_version1.0流的头部长度
+func newPropertyStorage(d *doc.DocParse, storage *doc.PDirectoryEntry, headerSize int, codePage encoding.Encoding) *propertyStorage {
+	s := &propertyStorage{
+		d:        d,
+		streams:  make(map[string]*doc.PDirectoryEntry),
+		fixed:    make(map[uint16]uint64),
+		codePage: codePage,
+	}
+	for _, child := range d.Children(storage) {
+		name := strings.TrimRight(child.Name, "\x00")
+		switch child.Type {
+		case 0x01:
+			s.storages = append(s.storages, child)
+		case 0x02:
+			s.streams[name] = child
+		}
+	}
+
+	entry, ok := s.streams[propertiesStream]
+	if !ok {
+		return s
+	}
+	data, err := d.ReadEntry(entry)
+	if err != nil {
+		logger.Logger.Printf("读取%s失败: %v", propertiesStream, err)
+		return s
+	}
+	for pos := headerSize; pos+16 <= len(data); pos += 16 {
+		tag := binary.LittleEndian.Uint32(data[pos:])
+		s.fixed[uint16(tag>>16)] = binary.LittleEndian.Uint64(data[pos+8:])
+	}
+	return s
+}
+
+// encoding 根据邮件声明的代码页选择代码页字符串的编码
+func (s *propertyStorage) encoding() encoding.Encoding {
+	for _, id := range []uint16{propMessageCodePage, propInternetCPID} {
+		if cp, ok := s.fixed[id]; ok {
+			if enc := internal.CodePageEncoding(uint16(cp)); enc != nil {
+				logger.Logger.Printf("MSG代码页: %d", cp)
+				return enc
+			}
+		}
+	}
+	return nil
+}
+
+// read 读取可变长属性的原始内容及类型
+func (s *propertyStorage) read(id uint16, types ...uint16) ([]byte, uint16, bool) {
+	for _, t := range types {
+		entry, ok := s.streams[fmt.Sprintf("__substg1.0_%04X%04X", id, t)]
+		if !ok {
+			continue
+		}
+		data, err := s.d.ReadEntry(entry)
+		if err != nil {
+			logger.Logger.Printf("读取属性%04X%04X失败: %v", id, t, err)
+			continue
+		}
+		return data, t, true
+	}
+	return nil, 0, false
+}
+
+// str 读取字符串属性并转换为UTF-8，去除结尾的NUL
+func (s *propertyStorage) str(id uint16) string {
+	data, t, ok := s.read(id, typeUnicode, typeString8)
+	if !ok {
+		return ""
+	}
+	var text []byte
+	if t == typeUnicode {
+		text, _ = utf16le.NewDecoder().Bytes(data)
+	} else {
+		text = s.decode(data)
+	}
+	return strings.TrimSpace(strings.TrimRight(internal.ReplaceInvalid(string(text)), "\x00"))
+}
+
+// decode 按代码页解码字符串，未声明代码页时自动检测编码
+func (s *propertyStorage) decode(data []byte) []byte {
+	if s.codePage != nil {
+		if decoded, err := s.codePage.NewDecoder().Bytes(data); err == nil {
+			return decoded
+		}
+	}
+	decoded, _ := plaintxt.DecodeText(data)
+	return decoded
+}
+
+// time 读取PT_SYSTIME定长属性，格式化为UTC时间
+func (s *propertyStorage) time(id uint16) (string, bool) {
+	ft, ok := s.fixed[id]
+	if !ok || ft < filetimeEpoch {
+		return "", false
+	}
+	t := time.Unix(0, int64(ft-filetimeEpoch)*100).UTC()
+	return t.Format("2006-01-02 15:04:05 UTC"), true
+}
+
+// children 读取收件人及附件子存储
+func (s *propertyStorage) children() (recipients []*propertyStorage, attachments []*propertyStorage) {
+	for _, storage := range s.storages {
+		name := strings.TrimRight(storage.Name, "\x00")
+		switch {
+		case strings.HasPrefix(name, recipientPrefix):
+			recipients = append(recipients, newPropertyStorage(s.d, storage, childHeaderSize, s.codePage))
+		case strings.HasPrefix(name, attachmentPrefix):
+			attachments = append(attachments, newPropertyStorage(s.d, storage, childHeaderSize, s.codePage))
+		}
+	}
+	return recipients, attachments
+}
+
+// body 返回纯文本正文，没有纯文本正文时提取HTML正文的文本
+func (s *propertyStorage) body() []byte {
+	if text := s.str(propBody); text != "" {
+		return []byte(strings.ReplaceAll(text, "\r\n", "\n"))
+	}
+
+	data, t, ok := s.read(propHTML, typeBinary, typeUnicode, typeString8)
+	if !ok {
+		return nil
+	}
+	if t == typeUnicode {
+		data, _ = utf16le.NewDecoder().Bytes(data)
+	} else {
+		data = s.decode(data)
+	}
+	data = internal.ReplaceInvalidBytes(data)
+	text, err := (&plainhtml.TextHTMLParser{}).ParseHtml(data)
+	if err != nil {
+		logger.Logger.Printf("解析HTML正文失败: %v", err)
+		return nil
+	}
+	return text
+}
+
+// groupRecipients 按收件人类型拼接收件人、抄送、密送列表
+func groupRecipients(recipients []*propertyStorage) (to string, cc string, bcc string) {
+	var lists [4][]string
+	for _, r := range recipients {
+		kind := int(r.fixed[propRecipientType])
+		if kind < recipientTo || kind > recipientBcc {
+			kind = recipientTo
+		}
+		entry := address(r.str(propDisplayName), firstNonEmpty(r.str(propSMTPAddress), r.str(propEmailAddress)))
+		if entry != "" {
+			lists[kind] = append(lists[kind], entry)
+		}
+	}
+	return strings.Join(lists[recipientTo], "; "), strings.Join(lists[recipientCc], "; "), strings.Join(lists[recipientBcc], "; ")
+}
+
+// address 组合为 "名称 <地址>"，名称与地址相同时只保留其一
+func address(name string, email string) string {
+	switch {
+	case email == "" || name == email:
+		return name
+	case name == "":
+		return email
+	default:
+		return fmt.Sprintf("%s <%s>", name, email)
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// writeField 写入"名称: 值"行，值为空时跳过
+func writeField(buf *bytes.Buffer, name string, value string) {
+	if value != "" {
+		fmt.Fprintf(buf, "%s: %s\n", name, value)
+	}
+}
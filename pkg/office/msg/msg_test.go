@@ -0,0 +1,116 @@
+package msg
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+
+	"fextra/internal/cfbtest"
+)
+
+// prop 可变长属性流
+func prop(id, t uint16, data []byte) cfbtest.Stream {
+	return cfbtest.Stream{Name: fmt.Sprintf("__substg1.0_%04X%04X", id, t), Data: data}
+}
+
+func unicodeProp(id uint16, s string) cfbtest.Stream {
+	data, _ := utf16le.NewEncoder().Bytes([]byte(s))
+	return prop(id, typeUnicode, data)
+}
+
+// fixedProps 构造__properties_version1.0流，values的值均按PT_LONG写入
+func fixedProps(headerSize int, values map[uint16]uint32) cfbtest.Stream {
+	data := make([]byte, headerSize, headerSize+16*len(values))
+	for id, v := range values {
+		entry := make([]byte, 16)
+		binary.LittleEndian.PutUint32(entry, uint32(id)<<16|0x0003)
+		binary.LittleEndian.PutUint32(entry[8:], v)
+		data = append(data, entry...)
+	}
+	return cfbtest.Stream{Name: propertiesStream, Data: data}
+}
+
+func recipient(index int, kind uint32, name, smtp string) cfbtest.Stream {
+	return cfbtest.Stream{
+		Name: fmt.Sprintf("%s#%08X", recipientPrefix, index),
+		Children: []cfbtest.Stream{
+			fixedProps(childHeaderSize, map[uint16]uint32{propRecipientType: kind}),
+			unicodeProp(propDisplayName, name),
+			unicodeProp(propSMTPAddress, smtp),
+		},
+	}
+}
+
+func cp1251(t *testing.T, s string) []byte {
+	data, err := charmap.Windows1251.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+	return data
+}
+
+func parseMsg(t *testing.T, streams []cfbtest.Stream) string {
+	path := filepath.Join(t.TempDir(), "mail.msg")
+	if err := os.WriteFile(path, cfbtest.Build(streams, cfbtest.Options{}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := (&OfficeMsgParser{}).Parse(path)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return string(out)
+}
+
+func TestParseMsg(t *testing.T) {
+	out := parseMsg(t, []cfbtest.Stream{
+		fixedProps(messageHeaderSize, map[uint16]uint32{propMessageCodePage: 1251}),
+		prop(propSubject, typeString8, cp1251(t, "Отчёт за март\x00")),
+		unicodeProp(propSenderName, "Иван"),
+		unicodeProp(propSenderSMTP, "ivan@example.com"),
+		unicodeProp(propDisplayTo, "不应使用的显示名"),
+		unicodeProp(propBody, "第一行\r\n第二行"),
+		recipient(0, recipientTo, "Alice", "alice@example.com"),
+		recipient(1, recipientCc, "Bob", "bob@example.com"),
+		recipient(2, recipientTo, "Carol", "Carol"),
+		recipient(3, recipientBcc, "Dave", "dave@example.com"),
+	})
+
+	for _, want := range []string{
+		"主题: Отчёт за март\n",
+		"发件人: Иван <ivan@example.com>\n",
+		"收件人: Alice <alice@example.com>; Carol\n",
+		"抄送: Bob <bob@example.com>\n",
+		"密送: Dave <dave@example.com>\n",
+		"\n第一行\n第二行",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("输出缺少 %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "不应使用的显示名") {
+		t.Errorf("有收件人存储时不应使用PR_DISPLAY_TO:\n%s", out)
+	}
+}
+
+func TestParseMsgHTMLBody(t *testing.T) {
+	out := parseMsg(t, []cfbtest.Stream{
+		fixedProps(messageHeaderSize, map[uint16]uint32{propInternetCPID: 1251}),
+		unicodeProp(propSubject, "HTML"),
+		unicodeProp(propDisplayTo, "Alice; Bob"),
+		prop(propHTML, typeBinary, cp1251(t, "<html><body><p>Привет, мир</p><script>var x;</script></body></html>")),
+	})
+
+	for _, want := range []string{"收件人: Alice; Bob\n", "Привет, мир"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("输出缺少 %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "<p>") || strings.Contains(out, "var x") {
+		t.Errorf("HTML正文未转换为文本:\n%s", out)
+	}
+}
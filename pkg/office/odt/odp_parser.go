@@ -0,0 +1,140 @@
+package odt
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fextra/internal"
+	"fextra/pkg/logger"
+	"fmt"
+	"io"
+)
+
+const (
+	odfTextNS         = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	odfDrawNS         = "urn:oasis:names:tc:opendocument:xmlns:drawing:1.0"
+	odfPresentationNS = "urn:oasis:names:tc:opendocument:xmlns:presentation:1.0"
+)
+
+// OfficeOdpParser ODP演示文稿解析器
+type OfficeOdpParser struct{}
+
+// Parse 解析ODP文件并提取每页幻灯片的文本内容
+func (p *OfficeOdpParser) Parse(filePath string) ([]byte, error) {
+	zipReader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return []byte{}, internal.ZipOpenError("ODP", err)
+	}
+	defer zipReader.Close()
+
+	return p.parseZip(&zipReader.Reader)
+}
+
+// ParseReader 从io.Reader中读取ODP内容并提取文本
+func (p *OfficeOdpParser) ParseReader(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return []byte{}, fmt.Errorf("无法读取ODP内容: %v", err)
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return []byte{}, internal.ZipOpenError("ODP", err)
+	}
+
+	return p.parseZip(zipReader)
+}
+
+// parseZip 流式解析content.xml，按 draw:page > draw:frame > draw:text-box > text:p 提取文本，没有幻灯片时返回ErrNoContent
+func (p *OfficeOdpParser) parseZip(zipReader *zip.Reader) ([]byte, error) {
+	xmlFile, err := openContentXml(zipReader, "ODP")
+	if err != nil {
+		return []byte{}, err
+	}
+	defer xmlFile.Close()
+
+	sections := internal.Sections()
+	var textBuilder bytes.Buffer
+	var inPage bool
+	var pages int
+	var textDepth int  // 所在text:p/text:h的嵌套层数
+	var notesDepth int // 所在presentation:notes的嵌套层数，备注不输出
+	d := xml.NewDecoder(xmlFile)
+
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			logger.Logger.Printf("XML解析错误: %v", err)
+			break
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Space == odfDrawNS && t.Name.Local == "page":
+				inPage = true
+				pages++
+				logger.DebugLogger.Printf("处理幻灯片: %s", attrValue(t, odfDrawNS, "name"))
+				if pages > 1 {
+					textBuilder.WriteString(sections.Separator)
+				}
+				textBuilder.WriteString(sections.FormatHeader(internal.SectionSlide, pages, ""))
+			case t.Name.Space == odfPresentationNS && t.Name.Local == "notes":
+				notesDepth++
+			case t.Name.Space == odfTextNS && (t.Name.Local == "p" || t.Name.Local == "h"):
+				textDepth++
+			case textDepth > 0 && notesDepth == 0:
+				writeTextControl(&textBuilder, t)
+			}
+		case xml.EndElement:
+			switch {
+			case t.Name.Space == odfDrawNS && t.Name.Local == "page":
+				inPage = false
+			case t.Name.Space == odfPresentationNS && t.Name.Local == "notes":
+				notesDepth--
+			case t.Name.Space == odfTextNS && (t.Name.Local == "p" || t.Name.Local == "h"):
+				textDepth--
+				if inPage && notesDepth == 0 {
+					textBuilder.WriteString("\n")
+				}
+			}
+		case xml.CharData:
+			if inPage && textDepth > 0 && notesDepth == 0 {
+				textBuilder.Write(t)
+			}
+		}
+	}
+
+	if pages == 0 {
+		return []byte{}, fmt.Errorf("ODP中没有幻灯片: %w", internal.ErrNoContent)
+	}
+	return textBuilder.Bytes(), nil
+}
+
+// writeTextControl 处理段落内的空格、制表符与换行控制元素
+func writeTextControl(buf *bytes.Buffer, t xml.StartElement) {
+	if t.Name.Space != odfTextNS {
+		return
+	}
+	switch t.Name.Local {
+	case "s":
+		buf.WriteString(" ")
+	case "tab":
+		buf.WriteString("\t")
+	case "line-break":
+		buf.WriteString("\n")
+	}
+}
+
+// attrValue 获取元素指定命名空间下的属性值
+func attrValue(t xml.StartElement, space, local string) string {
+	for _, attr := range t.Attr {
+		if attr.Name.Space == space && attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,202 @@
+package odt
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fextra/internal"
+	"fextra/pkg/logger"
+	"fextra/pkg/office/spreadsheet"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const odfTableNS = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+
+// odsMaxColumns 每行最多展开的列数（LibreOffice Calc的列数上限），
+// table:number-columns-repeated常用于将行尾空单元格重复到最大列
+const odsMaxColumns = 16384
+
+// OfficeOdsParser ODS电子表格解析器
+type OfficeOdsParser struct{}
+
+// Parse 解析ODS文件并提取每个工作表的单元格内容
+func (p *OfficeOdsParser) Parse(filePath string) ([]byte, error) {
+	book, err := p.OpenSpreadsheet(filePath)
+	if err != nil {
+		return []byte{}, err
+	}
+	return renderWorkbook(book)
+}
+
+// ParseReader 从io.Reader中读取ODS内容并提取文本
+func (p *OfficeOdsParser) ParseReader(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return []byte{}, fmt.Errorf("无法读取ODS内容: %v", err)
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return []byte{}, internal.ZipOpenError("ODS", err)
+	}
+
+	book, err := readWorkbook(zipReader)
+	if err != nil {
+		return []byte{}, err
+	}
+	return renderWorkbook(book)
+}
+
+// OpenSpreadsheet 打开ODS文件，按工作表、行读取单元格文本；
+// content.xml中的工作表依次排列，打开时即读取全部单元格
+func (p *OfficeOdsParser) OpenSpreadsheet(filePath string) (spreadsheet.Workbook, error) {
+	zipReader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, internal.ZipOpenError("ODS", err)
+	}
+	defer zipReader.Close()
+
+	book, err := readWorkbook(&zipReader.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+func renderWorkbook(book spreadsheet.Spreadsheet) ([]byte, error) {
+	var textBuilder bytes.Buffer
+	if _, err := spreadsheet.Render(&textBuilder, book, 0); err != nil {
+		return []byte{}, err
+	}
+	return textBuilder.Bytes(), nil
+}
+
+// odsWorkbook 已读取的ODS工作表
+type odsWorkbook struct {
+	sheets []*odsSheet
+}
+
+func (b *odsWorkbook) Sheets() []spreadsheet.Sheet {
+	sheets := make([]spreadsheet.Sheet, len(b.sheets))
+	for i, sheet := range b.sheets {
+		sheets[i] = sheet
+	}
+	return sheets
+}
+
+func (b *odsWorkbook) Close() error {
+	return nil
+}
+
+// odsSheet ODS工作表，只保存非空行
+type odsSheet struct {
+	name string
+	rows [][]string
+}
+
+func (s *odsSheet) Name() string {
+	return s.name
+}
+
+func (s *odsSheet) Rows() iter.Seq[[]string] {
+	return slices.Values(s.rows)
+}
+
+// readWorkbook 流式解析content.xml，按 table:table > table:table-row > table:table-cell 提取单元格，没有工作表时返回ErrNoContent
+func readWorkbook(zipReader *zip.Reader) (*odsWorkbook, error) {
+	xmlFile, err := openContentXml(zipReader, "ODS")
+	if err != nil {
+		return nil, err
+	}
+	defer xmlFile.Close()
+
+	book := &odsWorkbook{}
+	var sheet *odsSheet
+	var cells []string           // 当前行的单元格
+	var cellBuilder bytes.Buffer // 当前单元格的文本
+	var inCell bool
+	var textDepth int // 所在text:p/text:h的嵌套层数
+	var paragraphs int
+	var repeated int // 当前单元格的列重复次数
+	var blanks int   // 尚未写入cells的连续空单元格数，之后出现非空单元格时才展开
+	d := xml.NewDecoder(xmlFile)
+
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			logger.Logger.Printf("XML解析错误: %v", err)
+			break
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Space == odfTableNS && t.Name.Local == "table":
+				sheet = &odsSheet{name: attrValue(t, odfTableNS, "name")}
+				book.sheets = append(book.sheets, sheet)
+			case t.Name.Space == odfTableNS && t.Name.Local == "table-row":
+				cells = nil
+				blanks = 0
+			case t.Name.Space == odfTableNS && (t.Name.Local == "table-cell" || t.Name.Local == "covered-table-cell"):
+				inCell = true
+				paragraphs = 0
+				repeated = 1
+				if n, err := strconv.Atoi(attrValue(t, odfTableNS, "number-columns-repeated")); err == nil && n > 1 {
+					repeated = min(n, odsMaxColumns)
+				}
+				cellBuilder.Reset()
+			case inCell && t.Name.Space == odfTextNS && (t.Name.Local == "p" || t.Name.Local == "h"):
+				// 单元格内多个段落以空格连接
+				if paragraphs > 0 {
+					cellBuilder.WriteString(" ")
+				}
+				paragraphs++
+				textDepth++
+			case textDepth > 0:
+				writeTextControl(&cellBuilder, t)
+			}
+		case xml.EndElement:
+			switch {
+			case t.Name.Space == odfTableNS && t.Name.Local == "table-row":
+				// 行尾的空单元格未展开，整行为空时跳过
+				if len(cells) > 0 && sheet != nil {
+					sheet.rows = append(sheet.rows, cells)
+				}
+			case t.Name.Space == odfTableNS && (t.Name.Local == "table-cell" || t.Name.Local == "covered-table-cell"):
+				inCell = false
+				value := strings.ReplaceAll(cellBuilder.String(), "\n", " ")
+				// 空单元格只计数，行尾的空单元格不展开
+				if value == "" {
+					blanks = min(blanks+repeated, odsMaxColumns)
+					break
+				}
+				for n := min(blanks, odsMaxColumns-len(cells)); n > 0; n-- {
+					cells = append(cells, "")
+				}
+				blanks = 0
+				for n := min(repeated, odsMaxColumns-len(cells)); n > 0; n-- {
+					cells = append(cells, value)
+				}
+			case inCell && t.Name.Space == odfTextNS && (t.Name.Local == "p" || t.Name.Local == "h"):
+				textDepth--
+			}
+		case xml.CharData:
+			if inCell && textDepth > 0 {
+				cellBuilder.Write(t)
+			}
+		}
+	}
+
+	if len(book.sheets) == 0 {
+		return nil, fmt.Errorf("ODS中没有工作表: %w", internal.ErrNoContent)
+	}
+	return book, nil
+}
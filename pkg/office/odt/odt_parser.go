@@ -4,6 +4,7 @@ import (
 	"archive/zip"
 	"bytes"
 	"encoding/xml"
+	"fextra/internal"
 	"fextra/pkg/logger"
 	"fmt"
 	"io"
@@ -17,25 +18,31 @@ func (p *OfficeOdtParser) Parse(filePath string) ([]byte, error) {
 	// 打开ODT文件（ZIP格式）
 	zipReader, err := zip.OpenReader(filePath)
 	if err != nil {
-		return []byte{}, fmt.Errorf("无法打开ODT文件: %v", err)
+		return []byte{}, internal.ZipOpenError("ODT", err)
 	}
 	defer zipReader.Close()
 
-	// 查找content.xml文件
-	var contentFile *zip.File
-	for _, file := range zipReader.File {
-		if file.Name == "content.xml" {
-			contentFile = file
-			break
-		}
+	return p.parseZip(&zipReader.Reader)
+}
+
+// ParseReader 从io.Reader中读取ODT内容并提取文本
+func (p *OfficeOdtParser) ParseReader(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return []byte{}, fmt.Errorf("无法读取ODT内容: %v", err)
 	}
 
-	if contentFile == nil {
-		return []byte{}, fmt.Errorf("content.xml不存在于ODT文件中")
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return []byte{}, internal.ZipOpenError("ODT", err)
 	}
 
-	// 读取content.xml内容
-	xmlFile, err := contentFile.Open()
+	return p.parseZip(zipReader)
+}
+
+// parseZip 从已打开的ZIP中解析content.xml
+func (p *OfficeOdtParser) parseZip(zipReader *zip.Reader) ([]byte, error) {
+	xmlFile, err := openContentXml(zipReader, "ODT")
 	if err != nil {
 		return []byte{}, err
 	}
@@ -81,3 +88,13 @@ func (p *OfficeOdtParser) Parse(filePath string) ([]byte, error) {
 
 	return textBuilder.Bytes(), nil
 }
+
+// openContentXml 打开ODF文档（ODT/ODP/ODS）中的content.xml
+func openContentXml(zipReader *zip.Reader, kind string) (io.ReadCloser, error) {
+	for _, file := range zipReader.File {
+		if file.Name == "content.xml" {
+			return file.Open()
+		}
+	}
+	return nil, fmt.Errorf("content.xml不存在于%s文件中: %w", kind, internal.ErrStreamNotFound)
+}
@@ -0,0 +1,88 @@
+package odt
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"fextra/internal/ziptest"
+)
+
+const odfNamespaces = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+	`xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" ` +
+	`xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" ` +
+	`xmlns:draw="urn:oasis:names:tc:opendocument:xmlns:drawing:1.0"`
+
+// odfPackage 构造只有mimetype及content.xml的ODF包，body为office:body的内容
+func odfPackage(mimetype, body string) []byte {
+	return ziptest.Build([]ziptest.File{
+		{Name: "mimetype", Data: mimetype},
+		{Name: "content.xml", Data: `<office:document-content ` + odfNamespaces + `><office:body>` + body + `</office:body></office:document-content>`},
+	})
+}
+
+func TestOdtParseReader(t *testing.T) {
+	data := odfPackage("application/vnd.oasis.opendocument.text", `<office:text>`+
+		`<text:h text:outline-level="1">Heading</text:h>`+
+		`<text:p>Hello <text:span>world</text:span></text:p>`+
+		`<text:p>第二段</text:p>`+
+		`</office:text>`)
+
+	got, err := (&OfficeOdtParser{}).ParseReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	for _, want := range []string{"Heading\n", "Hello world\n", "第二段\n"} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("missing %q in %q", want, got)
+		}
+	}
+}
+
+// TestOdsRepeatedCells 重复的单元格按次数展开，行尾重复到最大列数的空单元格不展开
+func TestOdsRepeatedCells(t *testing.T) {
+	data := odfPackage("application/vnd.oasis.opendocument.spreadsheet", `<office:spreadsheet>`+
+		`<table:table table:name="Sheet1">`+
+		`<table:table-row>`+
+		`<table:table-cell><text:p>a</text:p></table:table-cell>`+
+		`<table:table-cell table:number-columns-repeated="2"/>`+
+		`<table:table-cell table:number-columns-repeated="2"><text:p>b</text:p></table:table-cell>`+
+		`<table:table-cell table:number-columns-repeated="16380"/>`+
+		`</table:table-row>`+
+		`<table:table-row table:number-rows-repeated="1048570"><table:table-cell table:number-columns-repeated="16384"/></table:table-row>`+
+		`</table:table></office:spreadsheet>`)
+
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	book, err := readWorkbook(zipReader)
+	if err != nil {
+		t.Fatalf("readWorkbook: %v", err)
+	}
+	if len(book.sheets) != 1 || book.sheets[0].name != "Sheet1" {
+		t.Fatalf("got sheets %+v", book.sheets)
+	}
+	rows := book.sheets[0].rows
+	if len(rows) != 1 || strings.Join(rows[0], "|") != "a|||b|b" {
+		t.Errorf("got rows %q", rows)
+	}
+}
+
+func TestOdpParseReader(t *testing.T) {
+	page := func(text string) string {
+		return `<draw:page><draw:frame><draw:text-box><text:p>` + text + `</text:p></draw:text-box></draw:frame></draw:page>`
+	}
+	data := odfPackage("application/vnd.oasis.opendocument.presentation",
+		`<office:presentation>`+page("first slide")+page("second slide")+`</office:presentation>`)
+
+	got, err := (&OfficeOdpParser{}).ParseReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	text := string(got)
+	if first, second := strings.Index(text, "first slide"), strings.Index(text, "second slide"); first < 0 || second < first {
+		t.Errorf("got %q", text)
+	}
+}
@@ -2,13 +2,19 @@ package office
 
 import (
 	"fextra/internal"
+	"fextra/pkg/office/djvu"
 	"fextra/pkg/office/doc"
 	"fextra/pkg/office/docx"
+	"fextra/pkg/office/epub"
+	"fextra/pkg/office/flatopc"
+	"fextra/pkg/office/iwork"
+	"fextra/pkg/office/msg"
 	"fextra/pkg/office/odt"
 	"fextra/pkg/office/pdf"
 	"fextra/pkg/office/ppt"
 	"fextra/pkg/office/pptx"
 	"fextra/pkg/office/rtf"
+	"fextra/pkg/office/soffice"
 	"fextra/pkg/office/vsd"
 	"fextra/pkg/office/vsdx"
 	"fextra/pkg/office/xls"
@@ -30,4 +36,15 @@ func init() {
 	internal.RegisterParser(internal.FileTypeVSDX, &vsdx.OfficeVsdxParser{})
 	internal.RegisterParser(internal.FileTypeXLSB, &xlsb.OfficeXlsbParser{})
 	internal.RegisterParser(internal.FileTypeVSD, &vsd.OfficeVsdParser{})
+	internal.RegisterParser(internal.FileTypeEPUB, &epub.OfficeEpubParser{})
+	internal.RegisterParser(internal.FileTypeODP, &odt.OfficeOdpParser{})
+	internal.RegisterParser(internal.FileTypeODS, &odt.OfficeOdsParser{})
+	internal.RegisterParser(internal.FileTypePAGES, &iwork.OfficeIworkParser{})
+	internal.RegisterParser(internal.FileTypeKEY, &iwork.OfficeIworkParser{})
+	internal.RegisterParser(internal.FileTypeNUMBERS, &iwork.OfficeIworkParser{})
+	internal.RegisterParser(internal.FileTypeFLATOPC, &flatopc.OfficeFlatOpcParser{})
+	internal.RegisterParser(internal.FileTypeMSG, &msg.OfficeMsgParser{})
+	internal.RegisterParser(internal.FileTypeDJVU, &djvu.OfficeDjvuParser{})
+	// WordPerfect等没有纯Go解析器的文档，配置了LibreOffice时转换为纯文本，未配置时返回soffice.ErrDisabled
+	internal.RegisterParser(internal.FileTypeDOCOTHER, &soffice.TextParser{})
 }
@@ -0,0 +1,63 @@
+// Package officeenc 提供Office二进制格式（DOC、PPT、XLS、EXIF等）共用的文本解码
+package officeenc
+
+import (
+	"encoding/binary"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"fextra/internal"
+	"fextra/pkg/logger"
+)
+
+// DecodeUTF16 将UTF-16字节流解码为字符串：
+// 以BOM开头时按BOM确定字节序并去除BOM，否则按bo解码（bo为nil时按小端序）；
+// 高低代理组成一个字符，不完整或顺序错误的代理输出internal.Replacement()，不吞掉其后的字符；
+// 奇数长度时丢弃末尾的单个字节并记录日志
+func DecodeUTF16(data []byte, bo binary.ByteOrder) string {
+	var bomSize int
+	if len(data) >= 2 {
+		switch {
+		case data[0] == 0xFE && data[1] == 0xFF:
+			bo = binary.BigEndian
+			bomSize = 2
+		case data[0] == 0xFF && data[1] == 0xFE:
+			bo = binary.LittleEndian
+			bomSize = 2
+		}
+	}
+	if bo == nil {
+		bo = binary.LittleEndian
+	}
+
+	// 末尾的单个字节无法组成字符，记录日志便于发现上游长度计算错误
+	if (len(data)-bomSize)%2 != 0 {
+		logger.Logger.Printf("UTF-16数据长度为奇数(%d字节)，丢弃末尾字节0x%02X", len(data), data[len(data)-1])
+	}
+	u16s := make([]uint16, (len(data)-bomSize)/2)
+	for i := range u16s {
+		u16s[i] = bo.Uint16(data[bomSize+2*i:])
+	}
+
+	repl := internal.Replacement()
+	var text strings.Builder
+	text.Grow(len(u16s))
+	for i := 0; i < len(u16s); {
+		if utf16.IsSurrogate(rune(u16s[i])) {
+			if i+1 < len(u16s) {
+				if r := utf16.DecodeRune(rune(u16s[i]), rune(u16s[i+1])); r != utf8.RuneError {
+					text.WriteRune(r)
+					i += 2
+					continue
+				}
+			}
+			text.WriteString(repl)
+			i++
+			continue
+		}
+		text.WriteRune(rune(u16s[i]))
+		i++
+	}
+	return text.String()
+}
@@ -0,0 +1,188 @@
+// Package oleobj 解析Office文档中嵌入的OLE对象（如PPT的ExOleObjStg记录），
+// 按对象内容识别其类型后交给对应文件类型的解析器
+package oleobj
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/richardlehane/mscfb"
+
+	"fextra/internal"
+)
+
+/*
+	嵌入对象本身是一个OLE复合文档，根存储中的流决定对象的类型：
+	- Package：OOXML文档（docx/xlsx/pptx）的ZIP内容
+	- WordDocument、Workbook/Book、PowerPoint Document：Word、Excel、PowerPoint 97-2003文档
+	- \x01Ole10Native：对象包装器（Packager）嵌入的任意文件，带原始文件名
+	- CONTENTS：部分程序（如Acrobat）直接保存的文件内容
+*/
+
+// ErrUnknownObject 无法识别嵌入对象的类型
+var ErrUnknownObject = errors.New("无法识别的嵌入对象")
+
+var (
+	oleMagic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+	zipMagic = []byte("PK\x03\x04")
+	pdfMagic = []byte("%PDF-")
+)
+
+// streamTypes 根存储中按文档流识别的OLE文档类型，整个复合文档交给对应的解析器
+var streamTypes = map[string]int{
+	"WordDocument":        internal.FileTypeDOC,
+	"Workbook":            internal.FileTypeXLS,
+	"Book":                internal.FileTypeXLS,
+	"PowerPoint Document": internal.FileTypePPT,
+}
+
+// ooxmlRoots OOXML文档各类型的根目录
+var ooxmlRoots = []struct {
+	root     string
+	fileType int
+}{
+	{"word/", internal.FileTypeDOCX},
+	{"xl/", internal.FileTypeXLSX},
+	{"ppt/", internal.FileTypePPTX},
+}
+
+// Parse 识别嵌入对象的类型并提取其文本
+func Parse(data []byte) ([]byte, error) {
+	fileType, content, err := Detect(data)
+	if err != nil {
+		return nil, err
+	}
+	return internal.ParseReader(bytes.NewReader(content), fileType)
+}
+
+// Detect 识别嵌入对象的类型，返回文件类型及交给解析器的内容；
+// 对象为OLE复合文档且内容保存在其中某个流（Package、Ole10Native等）时返回该流的内容
+func Detect(data []byte) (int, []byte, error) {
+	switch {
+	case bytes.HasPrefix(data, oleMagic):
+		return detectCompound(data)
+	case bytes.HasPrefix(data, zipMagic):
+		return detectZip(data), data, nil
+	case bytes.HasPrefix(data, pdfMagic):
+		return internal.FileTypePDF, data, nil
+	default:
+		return 0, nil, ErrUnknownObject
+	}
+}
+
+// detectCompound 按根存储中的流识别OLE复合文档
+func detectCompound(data []byte) (int, []byte, error) {
+	cfb, err := mscfb.New(bytes.NewReader(data))
+	if err != nil {
+		return 0, nil, fmt.Errorf("打开嵌入对象失败: %w", err)
+	}
+
+	streams := make(map[string]*mscfb.File)
+	for _, file := range cfb.File {
+		if len(file.Path) > 0 {
+			continue
+		}
+		if fileType, ok := streamTypes[file.Name]; ok {
+			return fileType, data, nil
+		}
+		streams[file.Name] = file
+	}
+
+	if file := streams["Package"]; file != nil {
+		content, err := readStream(file)
+		if err != nil {
+			return 0, nil, err
+		}
+		return Detect(content)
+	}
+	if file := streams["\x01Ole10Native"]; file != nil {
+		content, err := readStream(file)
+		if err != nil {
+			return 0, nil, err
+		}
+		name, payload, err := parseNative(content)
+		if err != nil {
+			return 0, nil, err
+		}
+		if fileType := internal.GetDynamicFileType(name); fileType != 114 {
+			return fileType, payload, nil
+		}
+		return Detect(payload)
+	}
+	if file := streams["CONTENTS"]; file != nil {
+		content, err := readStream(file)
+		if err != nil {
+			return 0, nil, err
+		}
+		return Detect(content)
+	}
+	return 0, nil, ErrUnknownObject
+}
+
+// detectZip 按根目录区分OOXML文档类型，均不匹配时按普通ZIP处理
+func detectZip(data []byte) int {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return internal.FileTypeZIP
+	}
+	for _, file := range reader.File {
+		for _, r := range ooxmlRoots {
+			if strings.HasPrefix(file.Name, r.root) {
+				return r.fileType
+			}
+		}
+	}
+	return internal.FileTypeZIP
+}
+
+func readStream(file *mscfb.File) ([]byte, error) {
+	content := make([]byte, file.Size)
+	if _, err := io.ReadFull(file, content); err != nil {
+		return nil, fmt.Errorf("读取嵌入对象流 %s 失败: %w", file.Name, err)
+	}
+	return content, nil
+}
+
+// parseNative 解析\x01Ole10Native流，返回原始文件名及文件内容：
+// 总长度(4) 保留(2) 标签(以0结尾) 原始路径(以0结尾) 保留(4) 临时路径长度(4) 临时路径 内容长度(4) 内容
+func parseNative(data []byte) (string, []byte, error) {
+	pos := 6
+	label, pos, ok := cString(data, pos)
+	if !ok {
+		return "", nil, fmt.Errorf("Ole10Native标签: %w", internal.ErrTruncated)
+	}
+	if _, pos, ok = cString(data, pos); !ok {
+		return "", nil, fmt.Errorf("Ole10Native原始路径: %w", internal.ErrTruncated)
+	}
+	pos += 4
+	if pos+4 > len(data) {
+		return "", nil, fmt.Errorf("Ole10Native临时路径: %w", internal.ErrTruncated)
+	}
+	pos += 4 + int(binary.LittleEndian.Uint32(data[pos:]))
+	if pos < 0 || pos+4 > len(data) {
+		return "", nil, fmt.Errorf("Ole10Native内容长度: %w", internal.ErrTruncated)
+	}
+	size := int(binary.LittleEndian.Uint32(data[pos:]))
+	pos += 4
+	if size < 0 || size > len(data)-pos {
+		return "", nil, fmt.Errorf("Ole10Native内容: %w", internal.ErrTruncated)
+	}
+	return label, data[pos : pos+size], nil
+}
+
+// cString 读取以0结尾的字符串，返回字符串及其后的偏移
+func cString(data []byte, pos int) (string, int, bool) {
+	if pos > len(data) {
+		return "", pos, false
+	}
+	end := bytes.IndexByte(data[pos:], 0)
+	if end < 0 {
+		return "", pos, false
+	}
+	return string(data[pos : pos+end]), pos + end + 1, true
+}
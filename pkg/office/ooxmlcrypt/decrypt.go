@@ -0,0 +1,382 @@
+package ooxmlcrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"unicode/utf16"
+
+	"fextra/internal"
+)
+
+// errWrongPassword 密码校验失败
+var errWrongPassword = fmt.Errorf("密码错误: %w", internal.ErrEncrypted)
+
+// errUnsupportedEncryption 不支持的加密方式（如RC4、证书加密、Extensible加密）
+var errUnsupportedEncryption = fmt.Errorf("不支持的加密方式: %w", internal.ErrEncrypted)
+
+const (
+	// agileSegmentSize Agile加密的EncryptedPackage按4096字节分段加密，每段使用独立的IV
+	agileSegmentSize = 4096
+
+	// maxSpinCount 规范规定的最大迭代次数，防止构造的文件耗尽CPU
+	maxSpinCount = 10000000
+
+	// passwordKeyEncryptor 密码方式的keyEncryptor
+	passwordKeyEncryptor = "http://schemas.microsoft.com/office/2006/keyEncryptor/password"
+)
+
+// Agile加密各密钥派生时使用的块标识
+var (
+	blockVerifierHashInput = []byte{0xfe, 0xa7, 0xd2, 0x76, 0x3b, 0x4b, 0x9e, 0x79}
+	blockVerifierHashValue = []byte{0xd7, 0xaa, 0x0f, 0x6d, 0x30, 0x61, 0x34, 0x4e}
+	blockEncryptedKeyValue = []byte{0x14, 0x6e, 0x0b, 0xe7, 0xab, 0xac, 0xd0, 0xd6}
+)
+
+// Decrypt 按EncryptionInfo流中的加密参数解密EncryptedPackage流，返回解密后的ZIP包；
+// 密码错误或加密方式不支持时返回的错误均可用errors.Is判断为internal.ErrEncrypted
+func Decrypt(encryptionInfo []byte, encryptedPackage []byte, password string) ([]byte, error) {
+	if len(encryptionInfo) < 8 {
+		return nil, fmt.Errorf("EncryptionInfo: %w", internal.ErrTruncated)
+	}
+	major := binary.LittleEndian.Uint16(encryptionInfo)
+	minor := binary.LittleEndian.Uint16(encryptionInfo[2:])
+	switch {
+	case major == 4 && minor == 4:
+		return decryptAgile(encryptionInfo[8:], encryptedPackage, password)
+	case (major == 2 || major == 3 || major == 4) && minor == 2:
+		return decryptStandard(encryptionInfo[8:], encryptedPackage, password)
+	default:
+		return nil, fmt.Errorf("EncryptionInfo版本%d.%d: %w", major, minor, errUnsupportedEncryption)
+	}
+}
+
+// agileEncryption Agile加密的EncryptionInfo XML
+type agileEncryption struct {
+	KeyData       agileKeyData `xml:"keyData"`
+	KeyEncryptors []struct {
+		URI          string       `xml:"uri,attr"`
+		EncryptedKey agileKeyData `xml:"encryptedKey"`
+	} `xml:"keyEncryptors>keyEncryptor"`
+}
+
+// agileKeyData keyData及密码keyEncryptor的加密参数，二进制值为Base64
+type agileKeyData struct {
+	SpinCount       int    `xml:"spinCount,attr"`
+	SaltSize        int    `xml:"saltSize,attr"`
+	BlockSize       int    `xml:"blockSize,attr"`
+	KeyBits         int    `xml:"keyBits,attr"`
+	HashSize        int    `xml:"hashSize,attr"`
+	CipherAlgorithm string `xml:"cipherAlgorithm,attr"`
+	CipherChaining  string `xml:"cipherChaining,attr"`
+	HashAlgorithm   string `xml:"hashAlgorithm,attr"`
+	SaltValue       string `xml:"saltValue,attr"`
+
+	EncryptedVerifierHashInput string `xml:"encryptedVerifierHashInput,attr"`
+	EncryptedVerifierHashValue string `xml:"encryptedVerifierHashValue,attr"`
+	EncryptedKeyValue          string `xml:"encryptedKeyValue,attr"`
+}
+
+// check 检查是否为支持的AES-CBC加密参数
+func (k *agileKeyData) check() error {
+	if k.CipherAlgorithm != "AES" || k.CipherChaining != "ChainingModeCBC" {
+		return fmt.Errorf("%s %s: %w", k.CipherAlgorithm, k.CipherChaining, errUnsupportedEncryption)
+	}
+	if k.BlockSize != aes.BlockSize || (k.KeyBits != 128 && k.KeyBits != 192 && k.KeyBits != 256) {
+		return fmt.Errorf("块大小%d、密钥长度%d: %w", k.BlockSize, k.KeyBits, errUnsupportedEncryption)
+	}
+	if _, err := newHash(k.HashAlgorithm); err != nil {
+		return err
+	}
+	return nil
+}
+
+// decryptAgile 解密Agile加密（Office 2010及以后的默认方式）
+func decryptAgile(info []byte, encryptedPackage []byte, password string) ([]byte, error) {
+	var enc agileEncryption
+	if err := xml.Unmarshal(info, &enc); err != nil {
+		return nil, fmt.Errorf("解析EncryptionInfo失败: %w", err)
+	}
+	var key *agileKeyData
+	for i := range enc.KeyEncryptors {
+		if enc.KeyEncryptors[i].URI == passwordKeyEncryptor {
+			key = &enc.KeyEncryptors[i].EncryptedKey
+			break
+		}
+	}
+	if key == nil {
+		return nil, fmt.Errorf("没有密码方式的keyEncryptor: %w", errUnsupportedEncryption)
+	}
+	if err := key.check(); err != nil {
+		return nil, err
+	}
+	if err := enc.KeyData.check(); err != nil {
+		return nil, err
+	}
+	if key.SpinCount < 0 || key.SpinCount > maxSpinCount {
+		return nil, fmt.Errorf("迭代次数%d: %w", key.SpinCount, errUnsupportedEncryption)
+	}
+
+	salt, err1 := base64.StdEncoding.DecodeString(key.SaltValue)
+	verifierInput, err2 := base64.StdEncoding.DecodeString(key.EncryptedVerifierHashInput)
+	verifierValue, err3 := base64.StdEncoding.DecodeString(key.EncryptedVerifierHashValue)
+	keyValue, err4 := base64.StdEncoding.DecodeString(key.EncryptedKeyValue)
+	keyDataSalt, err5 := base64.StdEncoding.DecodeString(enc.KeyData.SaltValue)
+	if err := errors.Join(err1, err2, err3, err4, err5); err != nil {
+		return nil, fmt.Errorf("解析EncryptionInfo失败: %w", err)
+	}
+
+	// H0 = H(salt + 密码)，Hn = H(n + Hn-1)
+	newKeyHash, _ := newHash(key.HashAlgorithm)
+	h := newKeyHash()
+	h.Write(salt)
+	h.Write(utf16le(password))
+	passwordHash := h.Sum(nil)
+	var iterator [4]byte
+	for i := 0; i < key.SpinCount; i++ {
+		binary.LittleEndian.PutUint32(iterator[:], uint32(i))
+		h.Reset()
+		h.Write(iterator[:])
+		h.Write(passwordHash)
+		passwordHash = h.Sum(passwordHash[:0])
+	}
+	// 各密钥为H(Hn + 块标识)按密钥长度截断或以0x36填充
+	deriveKey := func(block []byte) []byte {
+		h.Reset()
+		h.Write(passwordHash)
+		h.Write(block)
+		return fitSize(h.Sum(nil), key.KeyBits/8, 0x36)
+	}
+	iv := fitSize(salt, key.BlockSize, 0x36)
+
+	input, err := decryptCBC(deriveKey(blockVerifierHashInput), iv, verifierInput)
+	if err != nil {
+		return nil, err
+	}
+	expected, err := decryptCBC(deriveKey(blockVerifierHashValue), iv, verifierValue)
+	if err != nil {
+		return nil, err
+	}
+	h.Reset()
+	h.Write(input[:min(len(input), key.SaltSize)])
+	actual := h.Sum(nil)
+	if len(expected) < len(actual) || subtle.ConstantTimeCompare(actual, expected[:len(actual)]) != 1 {
+		return nil, errWrongPassword
+	}
+
+	secretKey, err := decryptCBC(deriveKey(blockEncryptedKeyValue), iv, keyValue)
+	if err != nil {
+		return nil, err
+	}
+	if len(secretKey) < enc.KeyData.KeyBits/8 {
+		return nil, fmt.Errorf("encryptedKeyValue: %w", internal.ErrTruncated)
+	}
+	secretKey = secretKey[:enc.KeyData.KeyBits/8]
+
+	size, data, err := splitPackage(encryptedPackage)
+	if err != nil {
+		return nil, err
+	}
+	// 每段的IV为H(keyData的salt + 段号)
+	newDataHash, _ := newHash(enc.KeyData.HashAlgorithm)
+	dh := newDataHash()
+	out := make([]byte, 0, len(data))
+	var segmentIndex [4]byte
+	for i := 0; len(data) > 0; i++ {
+		segment := data[:min(len(data), agileSegmentSize)]
+		data = data[len(segment):]
+
+		binary.LittleEndian.PutUint32(segmentIndex[:], uint32(i))
+		dh.Reset()
+		dh.Write(keyDataSalt)
+		dh.Write(segmentIndex[:])
+		plain, err := decryptCBC(secretKey, fitSize(dh.Sum(nil), enc.KeyData.BlockSize, 0x36), segment)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, plain...)
+	}
+	return truncatePackage(out, size)
+}
+
+// decryptStandard 解密Standard加密（Office 2007的AES加密）
+func decryptStandard(info []byte, encryptedPackage []byte, password string) ([]byte, error) {
+	// EncryptionHeader长度(4) EncryptionHeader EncryptionVerifier
+	if len(info) < 4 {
+		return nil, fmt.Errorf("EncryptionInfo: %w", internal.ErrTruncated)
+	}
+	headerSize := int(binary.LittleEndian.Uint32(info))
+	if headerSize < 32 || len(info) < 4+headerSize {
+		return nil, fmt.Errorf("EncryptionHeader: %w", internal.ErrTruncated)
+	}
+	// EncryptionHeader: Flags(4) SizeExtra(4) AlgID(4) AlgIDHash(4) KeySize(4) ...
+	header := info[4 : 4+headerSize]
+	algID := binary.LittleEndian.Uint32(header[8:])
+	keyBits := int(binary.LittleEndian.Uint32(header[16:]))
+	if algID != 0x660E && algID != 0x660F && algID != 0x6610 {
+		return nil, fmt.Errorf("算法0x%04X: %w", algID, errUnsupportedEncryption)
+	}
+	if keyBits != 128 && keyBits != 192 && keyBits != 256 {
+		return nil, fmt.Errorf("密钥长度%d: %w", keyBits, errUnsupportedEncryption)
+	}
+
+	// EncryptionVerifier: SaltSize(4) Salt(16) EncryptedVerifier(16) VerifierHashSize(4) EncryptedVerifierHash(32)
+	verifier := info[4+headerSize:]
+	if len(verifier) < 4+16+16+4+32 {
+		return nil, fmt.Errorf("EncryptionVerifier: %w", internal.ErrTruncated)
+	}
+	salt := verifier[4:20]
+	encryptedVerifier := verifier[20:36]
+	verifierHashSize := int(binary.LittleEndian.Uint32(verifier[36:]))
+	encryptedVerifierHash := verifier[40:72]
+	if verifierHashSize > sha1.Size {
+		return nil, fmt.Errorf("VerifierHashSize %d: %w", verifierHashSize, errUnsupportedEncryption)
+	}
+
+	// H0 = SHA1(salt + 密码)，迭代50000次后Hfinal = SHA1(Hn + 块号0)
+	h := sha1.New()
+	h.Write(salt)
+	h.Write(utf16le(password))
+	passwordHash := h.Sum(nil)
+	var iterator [4]byte
+	for i := 0; i < 50000; i++ {
+		binary.LittleEndian.PutUint32(iterator[:], uint32(i))
+		h.Reset()
+		h.Write(iterator[:])
+		h.Write(passwordHash)
+		passwordHash = h.Sum(passwordHash[:0])
+	}
+	h.Reset()
+	h.Write(passwordHash)
+	h.Write([]byte{0, 0, 0, 0})
+	final := h.Sum(nil)
+
+	// 密钥为SHA1(0x36填充异或Hfinal) + SHA1(0x5C填充异或Hfinal)的前keyBits/8字节
+	derive := func(pad byte) []byte {
+		buf := bytes.Repeat([]byte{pad}, 64)
+		subtle.XORBytes(buf, buf[:len(final)], final)
+		sum := sha1.Sum(buf)
+		return sum[:]
+	}
+	key := append(derive(0x36), derive(0x5C)...)[:keyBits/8]
+
+	plainVerifier, err := decryptECB(key, encryptedVerifier)
+	if err != nil {
+		return nil, err
+	}
+	plainHash, err := decryptECB(key, encryptedVerifierHash)
+	if err != nil {
+		return nil, err
+	}
+	actual := sha1.Sum(plainVerifier)
+	if subtle.ConstantTimeCompare(actual[:verifierHashSize], plainHash[:verifierHashSize]) != 1 {
+		return nil, errWrongPassword
+	}
+
+	size, data, err := splitPackage(encryptedPackage)
+	if err != nil {
+		return nil, err
+	}
+	// 流的长度可能不是块大小的整数倍，多余的字节不属于密文
+	plain, err := decryptECB(key, data[:len(data)/aes.BlockSize*aes.BlockSize])
+	if err != nil {
+		return nil, err
+	}
+	return truncatePackage(plain, size)
+}
+
+// splitPackage 拆分EncryptedPackage流：解密后的长度(8) 密文
+func splitPackage(encryptedPackage []byte) (uint64, []byte, error) {
+	if len(encryptedPackage) < 8 {
+		return 0, nil, fmt.Errorf("EncryptedPackage: %w", internal.ErrTruncated)
+	}
+	return binary.LittleEndian.Uint64(encryptedPackage), encryptedPackage[8:], nil
+}
+
+// truncatePackage 去掉解密结果末尾的填充
+func truncatePackage(plain []byte, size uint64) ([]byte, error) {
+	if size > uint64(len(plain)) {
+		return nil, fmt.Errorf("EncryptedPackage长度%d，解密后为%d字节: %w", size, len(plain), internal.ErrTruncated)
+	}
+	return plain[:size], nil
+}
+
+func decryptCBC(key []byte, iv []byte, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("密文长度%d不是块大小的整数倍: %w", len(data), internal.ErrTruncated)
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+	return plain, nil
+}
+
+func decryptECB(key []byte, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("密文长度%d不是块大小的整数倍: %w", len(data), internal.ErrTruncated)
+	}
+	plain := make([]byte, len(data))
+	for i := 0; i < len(data); i += aes.BlockSize {
+		block.Decrypt(plain[i:], data[i:])
+	}
+	return plain, nil
+}
+
+// newHash 返回Agile加密hashAlgorithm对应的哈希算法
+func newHash(name string) (func() hash.Hash, error) {
+	switch name {
+	case "SHA1":
+		return sha1.New, nil
+	case "SHA256":
+		return sha256.New, nil
+	case "SHA384":
+		return sha512.New384, nil
+	case "SHA512":
+		return sha512.New, nil
+	case "MD5":
+		return md5.New, nil
+	default:
+		return nil, fmt.Errorf("哈希算法%s: %w", name, errUnsupportedEncryption)
+	}
+}
+
+// fitSize 将b截断或以pad填充为size字节
+func fitSize(b []byte, size int, pad byte) []byte {
+	if len(b) >= size {
+		return b[:size]
+	}
+	out := make([]byte, size)
+	copy(out, b)
+	for i := len(b); i < size; i++ {
+		out[i] = pad
+	}
+	return out
+}
+
+// utf16le 密码按UTF-16LE编码参与哈希
+func utf16le(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, 2*len(units))
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(b[2*i:], u)
+	}
+	return b
+}
@@ -0,0 +1,128 @@
+// Package ooxmlcrypt 打开可能加密的OOXML文档（docx、xlsx、pptx、xlsb等）。
+// 设置了打开密码的OOXML不是ZIP，而是OLE复合文档：EncryptionInfo流保存加密参数，EncryptedPackage流为加密后的ZIP包。
+// 使用internal.SetPassword设置的密码解密（支持MS-OFFCRYPTO的Agile及Standard加密），
+// 未设置密码或密码错误时返回internal.ErrEncrypted
+package ooxmlcrypt
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/richardlehane/mscfb"
+
+	"fextra/internal"
+)
+
+// defaultPassword 未设置密码时尝试的密码，Excel仅限制修改（只读推荐）的工作簿使用此密码加密
+const defaultPassword = "VelvetSweatshop"
+
+var oleMagic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// ReadCloser 已打开的OOXML包，加密文档解密后在内存中打开
+type ReadCloser struct {
+	*zip.Reader
+	file *os.File // 未加密时为打开的文件，加密时为nil
+}
+
+// Close 关闭打开的文件
+func (r *ReadCloser) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Close()
+}
+
+// OpenReader 打开OOXML文件，与zip.OpenReader相同；文件为加密的OOXML时解密后打开。
+// kind为文档类型（如DOCX），用于错误信息
+func OpenReader(filename string, kind string) (*ReadCloser, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, internal.ZipOpenError(kind, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, internal.ZipOpenError(kind, err)
+	}
+
+	head := make([]byte, len(oleMagic))
+	n, _ := file.ReadAt(head, 0)
+	if bytes.Equal(head[:n], oleMagic) {
+		defer file.Close()
+		data, err := decryptFile(file, kind)
+		if err != nil {
+			return nil, err
+		}
+		reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, internal.ZipOpenError(kind, err)
+		}
+		return &ReadCloser{Reader: reader}, nil
+	}
+
+	reader, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		file.Close()
+		return nil, internal.ZipOpenError(kind, err)
+	}
+	return &ReadCloser{Reader: reader, file: file}, nil
+}
+
+// NewReader 从内存中的内容打开OOXML包，与zip.NewReader相同；内容为加密的OOXML时解密后打开
+func NewReader(data []byte, kind string) (*zip.Reader, error) {
+	if bytes.HasPrefix(data, oleMagic) {
+		var err error
+		if data, err = decryptFile(bytes.NewReader(data), kind); err != nil {
+			return nil, err
+		}
+	}
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, internal.ZipOpenError(kind, err)
+	}
+	return reader, nil
+}
+
+// decryptFile 读取OLE复合文档中的EncryptionInfo及EncryptedPackage流并解密，返回解密后的ZIP包
+func decryptFile(r io.ReaderAt, kind string) ([]byte, error) {
+	cfb, err := mscfb.New(r)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开%s文件: %w", kind, err)
+	}
+
+	var encryptionInfo, encryptedPackage []byte
+	for _, file := range cfb.File {
+		if len(file.Path) > 0 || (file.Name != "EncryptionInfo" && file.Name != "EncryptedPackage") {
+			continue
+		}
+		content := make([]byte, file.Size)
+		if _, err := io.ReadFull(file, content); err != nil {
+			return nil, fmt.Errorf("读取%s流失败: %w", file.Name, err)
+		}
+		if file.Name == "EncryptionInfo" {
+			encryptionInfo = content
+		} else {
+			encryptedPackage = content
+		}
+	}
+	if encryptionInfo == nil || encryptedPackage == nil {
+		// 如将doc、xls重命名为docx、xlsx
+		return nil, fmt.Errorf("无法打开%s文件: 文件为OLE复合文档而不是ZIP: %w", kind, internal.ErrInvalidSignature)
+	}
+
+	password, ok := internal.Password()
+	if !ok {
+		password = defaultPassword
+	}
+	data, err := Decrypt(encryptionInfo, encryptedPackage, password)
+	if err == errWrongPassword && !ok {
+		return nil, fmt.Errorf("%s文件已加密，需要密码: %w", kind, internal.ErrEncrypted)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s文件已加密: %w", kind, err)
+	}
+	return data, nil
+}
@@ -4,28 +4,82 @@ import (
 	"archive/zip"
 	"bytes"
 	"encoding/xml"
+	"fextra/internal"
 	"fmt"
 	"io"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
+	"strings"
 
 	"fextra/pkg/logger"
+	"fextra/pkg/office/customprops"
+	"fextra/pkg/office/ooxmlcrypt"
 )
 
-type OfficePptxParser struct{}
+type OfficePptxParser struct {
+	IncludeFields bool // 是否提取 a:fld 自动字段（日期、幻灯片编号等）的文本
+	Limit         int  // 最多提取的幻灯片数，用于生成预览，为0时不限制
+
+	// IncludeAltText 在形状文本之后输出幻灯片中图片的替代文字（p:cNvPr的descr属性，没有时为title属性），格式为"[image: 描述]"
+	IncludeAltText bool
+
+	// IncludeCustomProperties 提取docProps/custom.xml及customXml/item*.xml中的自定义属性（如密级标签），
+	// 以"=== 自定义属性 ==="为标题追加到幻灯片文本之后，每个属性一行，格式为"名称: 值"；预览时不提取。
+	// 无论是否设置，ParseWithInfo都在Metadata中返回这些属性
+	IncludeCustomProperties bool
+}
 
 // Parse 提取PPTX文件中的文本内容
 func (p *OfficePptxParser) Parse(filename string) ([]byte, error) {
-	// 打开ZIP文件
-	reader, err := zip.OpenReader(filename)
+	// 打开ZIP文件，加密时解密
+	reader, err := ooxmlcrypt.OpenReader(filename, "PPTX")
 	if err != nil {
-		return []byte{}, fmt.Errorf("无法打开PPTX文件: %v", err)
+		return []byte{}, err
+	}
+	defer reader.Close()
 
+	return p.parseZip(reader.Reader)
+}
+
+// ParseWithInfo 提取文本，并在Metadata中返回自定义属性
+func (p *OfficePptxParser) ParseWithInfo(filename string) ([]byte, internal.ExtractInfo, error) {
+	reader, err := ooxmlcrypt.OpenReader(filename, "PPTX")
+	if err != nil {
+		return []byte{}, internal.ExtractInfo{}, err
 	}
 	defer reader.Close()
 
+	info := internal.ExtractInfo{Metadata: customprops.Metadata(customprops.Read(reader.File))}
+	text, err := p.parseZip(reader.Reader)
+	return text, info, err
+}
+
+// ParsePreview 仅提取PPTX前limit张幻灯片的文本
+func (p *OfficePptxParser) ParsePreview(filename string, limit int) ([]byte, error) {
+	preview := *p
+	preview.Limit = limit
+	return preview.Parse(filename)
+}
+
+// ParseReader 从io.Reader中读取PPTX内容并提取文本
+func (p *OfficePptxParser) ParseReader(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return []byte{}, fmt.Errorf("无法读取PPTX内容: %v", err)
+	}
+
+	reader, err := ooxmlcrypt.NewReader(data, "PPTX")
+	if err != nil {
+		return []byte{}, err
+	}
+
+	return p.parseZip(reader)
+}
+
+// parseZip 从已打开的ZIP中按幻灯片顺序提取文本
+func (p *OfficePptxParser) parseZip(reader *zip.Reader) ([]byte, error) {
 	var textBuffer bytes.Buffer
 
 	// 收集所有幻灯片文件
@@ -41,6 +95,10 @@ func (p *OfficePptxParser) Parse(filename string) ([]byte, error) {
 		}
 	}
 
+	if len(slideFiles) == 0 {
+		return []byte{}, fmt.Errorf("PPTX中没有幻灯片: %w", internal.ErrNoContent)
+	}
+
 	// 按幻灯片编号排序
 	sort.Slice(slideFiles, func(i, j int) bool {
 		numI := extractSlideNumber(slideFiles[i].Name)
@@ -48,8 +106,15 @@ func (p *OfficePptxParser) Parse(filename string) ([]byte, error) {
 		return numI < numJ
 	})
 
+	// 预览时只处理前Limit张幻灯片
+	if p.Limit > 0 && len(slideFiles) > p.Limit {
+		slideFiles = slideFiles[:p.Limit]
+	}
+
 	// 处理排序后的幻灯片文件
-	for _, file := range slideFiles {
+	sections := internal.Sections()
+	var written int // 已输出的幻灯片数
+	for i, file := range slideFiles {
 		logger.Logger.Printf("处理幻灯片文件: %v", file.Name)
 		// 读取幻灯片内容
 		slideContent, err := readZipFile(file)
@@ -59,15 +124,23 @@ func (p *OfficePptxParser) Parse(filename string) ([]byte, error) {
 		}
 
 		// 解析幻灯片XML并提取文本
-		slideText, err := parseSlideXml(slideContent)
+		slideText, err := parseSlideXml(slideContent, p.IncludeFields, p.IncludeAltText)
 		if err != nil {
 			logger.Logger.Printf("无法解析幻灯片XML %s: %v", file.Name, err)
 			continue
 		}
 
-		// 将幻灯片文本添加到结果中，用分页符分隔
+		// 将幻灯片文本作为一节添加到结果中
+		if written > 0 {
+			textBuffer.WriteString(sections.Separator)
+		}
+		written++
+		textBuffer.WriteString(sections.FormatHeader(internal.SectionSlide, i+1, ""))
 		textBuffer.Write(slideText)
-		textBuffer.WriteString("\f") // 使用换页符分隔不同幻灯片
+	}
+
+	if p.IncludeCustomProperties && p.Limit <= 0 {
+		customprops.WriteSection(&textBuffer, customprops.Read(reader.File))
 	}
 
 	return textBuffer.Bytes(), nil
@@ -100,8 +173,9 @@ func readZipFile(zf *zip.File) ([]byte, error) {
 	return content, nil
 }
 
-// parseSlideXml 解析幻灯片XML内容并提取文本
-func parseSlideXml(xmlContent []byte) ([]byte, error) {
+// parseSlideXml 解析幻灯片XML内容并提取文本，includeFields控制是否输出a:fld字段文本，
+// altText控制是否输出图片的替代文字
+func parseSlideXml(xmlContent []byte, includeFields bool, altText bool) ([]byte, error) {
 	var slide slideXml
 	if err := xml.Unmarshal(xmlContent, &slide); err != nil {
 		return []byte{}, err
@@ -129,7 +203,7 @@ func parseSlideXml(xmlContent []byte) ([]byte, error) {
 
 				for _, txBody := range sp.TxBody {
 					for _, p := range txBody.P {
-						paraText := extractParagraphText(p)
+						paraText := extractParagraphText(p, includeFields)
 						if len(paraText) != 0 {
 							textBuffer.Write(paraText)
 							textBuffer.WriteString("\n")
@@ -137,18 +211,31 @@ func parseSlideXml(xmlContent []byte) ([]byte, error) {
 					}
 				}
 			}
+			if altText {
+				for _, pic := range spTree.Pic {
+					if text := pic.altText(); text != "" {
+						textBuffer.WriteString(text + "\n")
+					}
+				}
+			}
 		}
 	}
 
 	return textBuffer.Bytes(), nil
 }
 
-// extractParagraphText 提取段落中的文本内容
-func extractParagraphText(p para) []byte {
+// extractParagraphText 按文档顺序提取段落中 a:r 及（可选）a:fld 的文本内容
+func extractParagraphText(p para, includeFields bool) []byte {
 	var paraBuffer bytes.Buffer
 
-	for _, r := range p.R {
-		for _, t := range r.T {
+	for _, item := range p.Items {
+		if item.XMLName.Space != drawingMLNamespace {
+			continue
+		}
+		if item.XMLName.Local != "r" && !(includeFields && item.XMLName.Local == "fld") {
+			continue
+		}
+		for _, t := range item.T {
 			paraBuffer.WriteString(t.Value)
 		}
 	}
@@ -177,6 +264,30 @@ type cSld struct {
 type spTree struct {
 	XMLName xml.Name `xml:"http://schemas.openxmlformats.org/presentationml/2006/main spTree"`
 	Sp      []sp     `xml:"sp"`
+	Pic     []pic    `xml:"http://schemas.openxmlformats.org/presentationml/2006/main pic"` // 图片
+}
+
+// pic 图片
+type pic struct {
+	CNvPr cNvPr `xml:"http://schemas.openxmlformats.org/presentationml/2006/main nvPicPr>cNvPr"`
+}
+
+// cNvPr 非可视属性，descr、title为替代文字
+type cNvPr struct {
+	Descr string `xml:"descr,attr"`
+	Title string `xml:"title,attr"`
+}
+
+// altText 返回图片的替代文字"[image: 描述]"，没有替代文字时返回空
+func (p *pic) altText() string {
+	text := strings.TrimSpace(p.CNvPr.Descr)
+	if text == "" {
+		text = strings.TrimSpace(p.CNvPr.Title)
+	}
+	if text == "" {
+		return ""
+	}
+	return "[image: " + text + "]"
 }
 
 // sp 形状
@@ -200,14 +311,14 @@ type txBody struct {
 
 // para 段落
 type para struct {
-	XMLName xml.Name `xml:"http://schemas.openxmlformats.org/drawingml/2006/main p"`
-	R       []r      `xml:"http://schemas.openxmlformats.org/drawingml/2006/main r"` // 文本 run
+	XMLName xml.Name   `xml:"http://schemas.openxmlformats.org/drawingml/2006/main p"`
+	Items   []paraItem `xml:",any"` // 按文档顺序保留文本 run(a:r) 与字段(a:fld)
 }
 
-// r 文本 run
-type r struct {
-	XMLName xml.Name `xml:"http://schemas.openxmlformats.org/drawingml/2006/main r"`
-	T       []t      `xml:"http://schemas.openxmlformats.org/drawingml/2006/main t"` // 文本内容
+// paraItem 段落子元素，a:r与a:fld均以a:t保存文本
+type paraItem struct {
+	XMLName xml.Name
+	T       []t `xml:"http://schemas.openxmlformats.org/drawingml/2006/main t"` // 文本内容
 }
 
 // t 文本元素
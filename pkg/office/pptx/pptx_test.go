@@ -0,0 +1,59 @@
+package pptx
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"fextra/internal/ziptest"
+)
+
+// slide 构造只有一个文本框的幻灯片，每个参数为一个段落
+func slide(paragraphs ...string) string {
+	var body strings.Builder
+	for _, p := range paragraphs {
+		fmt.Fprintf(&body, `<a:p><a:r><a:t>%s</a:t></a:r></a:p>`, p)
+	}
+	return `<p:sld xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" ` +
+		`xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">` +
+		`<p:cSld><p:spTree><p:sp><p:txBody>` + body.String() + `</p:txBody></p:sp></p:spTree></p:cSld></p:sld>`
+}
+
+// TestParseReaderSlideOrder 幻灯片按文件名中的编号排序（slide10在slide2之后），而不是按字典序或ZIP中的顺序
+func TestParseReaderSlideOrder(t *testing.T) {
+	data := ziptest.Build([]ziptest.File{
+		{Name: "ppt/slides/slide10.xml", Data: slide("third")},
+		{Name: "ppt/slides/slide1.xml", Data: slide("first", "second line")},
+		{Name: "ppt/slides/slide2.xml", Data: slide("second")},
+		{Name: "ppt/slides/_rels/slide1.xml.rels", Data: "<Relationships/>"},
+	})
+
+	got, err := (&OfficePptxParser{}).ParseReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	text := string(got)
+	first, second, third := strings.Index(text, "first"), strings.Index(text, "second\n"), strings.Index(text, "third")
+	if first < 0 || second < 0 || third < 0 || !(first < second && second < third) {
+		t.Errorf("slides out of order: %q", text)
+	}
+	if !strings.Contains(text, "second line") {
+		t.Errorf("missing second paragraph of slide 1: %q", text)
+	}
+
+	got, err = (&OfficePptxParser{Limit: 1}).ParseReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseReader with Limit: %v", err)
+	}
+	if strings.Contains(string(got), "second\n") || !strings.Contains(string(got), "first") {
+		t.Errorf("Limit 1: got %q", got)
+	}
+}
+
+func TestParseReaderNoSlides(t *testing.T) {
+	data := ziptest.Build([]ziptest.File{{Name: "ppt/presentation.xml", Data: "<presentation/>"}})
+	if _, err := (&OfficePptxParser{}).ParseReader(bytes.NewReader(data)); err == nil {
+		t.Error("expected an error for a presentation without slides")
+	}
+}
@@ -0,0 +1,245 @@
+package rtf
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"fextra/internal"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+)
+
+/*
+	封装HTML的RTF（[MS-OXRTFEX]）：Outlook将HTML邮件保存为RTF时，文件头带有\fromhtml1标记，
+	原始HTML的标签保存在{\*\htmltagN ...}目标组中，标签之间的文本为普通RTF文本，
+	仅用于RTF显示的内容由\htmlrtf ... \htmlrtf0包围，还原HTML时忽略。
+	按普通RTF提取会丢弃htmltag组中的内容（如链接、表格结构），因此先还原HTML，再由HTML解析器提取文本
+*/
+
+// fromHTMLHeaderLen \fromhtml1位于文件头中字体表之前，只在开头查找
+const fromHTMLHeaderLen = 1024
+
+// isEncapsulatedHTML 是否为封装HTML的RTF
+func isEncapsulatedHTML(content string) bool {
+	return strings.HasPrefix(content, `{\rtf`) && strings.Contains(content[:min(len(content), fromHTMLHeaderLen)], `\fromhtml1`)
+}
+
+// htmlSkippedDestinations 还原HTML时整组忽略的目标。不使用StyleFilter：
+// 链接以\field组保存，其\fldrslt中的链接文本由\htmlrtf0标记为HTML内容，不能整组跳过
+var htmlSkippedDestinations = map[string]bool{
+	"fonttbl": true, "colortbl": true, "stylesheet": true, "info": true, "pict": true,
+	"listtable": true, "listoverridetable": true, "rsidtable": true, "header": true, "footer": true,
+}
+
+// htmlState 还原HTML时组内的状态，组结束时恢复为外层组的状态
+type htmlState struct {
+	skip       bool // 字体表、颜色表及\*可忽略目标等不输出的组
+	htmltag    bool // {\*\htmltagN}组，内容为原始HTML
+	htmlrtf    bool // \htmlrtf区域，内容仅用于RTF显示
+	ignorable  bool // 组以\*开始，其目标不是htmltag时整组忽略
+	groupStart bool // 尚未读取组的第一个控制字
+	uc         int  // \ucN，\uN之后替代字符的个数
+}
+
+// htmlDecoder 从封装HTML的RTF中还原HTML
+type htmlDecoder struct {
+	out       strings.Builder
+	pending   []byte // \'hh及8位字符，按codePage解码后写入out
+	codePage  encoding.Encoding
+	state     htmlState
+	stack     []htmlState
+	skipChars int // \uN之后需要跳过的替代字符数
+}
+
+// extractEncapsulatedHTML 还原封装在RTF中的HTML，8位字符按\ansicpgN指定的代码页解码，未指定时按Windows-1252解码
+func extractEncapsulatedHTML(content string) []byte {
+	d := &htmlDecoder{codePage: charmap.Windows1252, state: htmlState{uc: 1}}
+	for i := 0; i < len(content); i++ {
+		switch c := content[i]; c {
+		case '{':
+			d.stack = append(d.stack, d.state)
+			d.state.ignorable = false
+			d.state.groupStart = true
+		case '}':
+			if n := len(d.stack); n > 0 {
+				d.state = d.stack[n-1]
+				d.stack = d.stack[:n-1]
+			}
+		case '\\':
+			i = d.control(content, i+1) - 1
+		case '\r', '\n':
+			// RTF中的换行不是内容
+		default:
+			d.state.groupStart = false
+			d.writeByte(c)
+		}
+	}
+	d.flush()
+	return []byte(d.out.String())
+}
+
+// active 当前位置的文本是否属于原始HTML
+func (d *htmlDecoder) active() bool {
+	return !d.state.skip && (d.state.htmltag || !d.state.htmlrtf)
+}
+
+// control 处理从content[i]开始的控制字或控制符号（不含"\"），返回其后的位置
+func (d *htmlDecoder) control(content string, i int) int {
+	if i >= len(content) {
+		return i
+	}
+	c := content[i]
+	if !isLetter(c) {
+		d.symbol(content, i)
+		if c == '\'' {
+			return min(i+3, len(content))
+		}
+		return i + 1
+	}
+
+	start := i
+	for i < len(content) && isLetter(content[i]) {
+		i++
+	}
+	word := content[start:i]
+	paramStart := i
+	if i < len(content) && content[i] == '-' {
+		i++
+	}
+	for i < len(content) && content[i] >= '0' && content[i] <= '9' {
+		i++
+	}
+	param, hasParam := 0, false
+	if i > paramStart {
+		param, _ = strconv.Atoi(content[paramStart:i])
+		hasParam = true
+	}
+	if i < len(content) && content[i] == ' ' {
+		// 空格为控制字的分隔符
+		i++
+	}
+
+	if word == "bin" {
+		return min(i+max(param, 0), len(content))
+	}
+	d.word(word, param, hasParam)
+	return i
+}
+
+// symbol 处理控制符号
+func (d *htmlDecoder) symbol(content string, i int) {
+	switch c := content[i]; c {
+	case '*':
+		d.state.ignorable = true
+	case '\'':
+		d.state.groupStart = false
+		if i+2 < len(content) {
+			if b, err := strconv.ParseUint(content[i+1:i+3], 16, 8); err == nil {
+				d.writeByte(byte(b))
+			}
+		}
+	case '{', '}', '\\':
+		d.state.groupStart = false
+		d.writeByte(c)
+	case '~':
+		d.writeRune('\u00a0')
+	case '_':
+		d.writeRune('-')
+	case '\r', '\n':
+		d.writeRune('\n')
+	}
+}
+
+// word 处理控制字
+func (d *htmlDecoder) word(word string, param int, hasParam bool) {
+	if d.state.groupStart {
+		d.state.groupStart = false
+		switch {
+		case d.state.ignorable && strings.HasPrefix(word, "htmltag"):
+			d.state.htmltag = true
+			return
+		case d.state.ignorable || htmlSkippedDestinations[word]:
+			d.state.skip = true
+			return
+		}
+	}
+
+	switch word {
+	case "htmlrtf":
+		d.state.htmlrtf = !hasParam || param != 0
+	case "ansicpg":
+		if enc := internal.CodePageEncoding(uint16(param)); enc != nil {
+			d.flush()
+			d.codePage = enc
+		}
+	case "uc":
+		d.state.uc = max(param, 0)
+	case "u":
+		if param < 0 {
+			param += 0x10000
+		}
+		d.writeRune(rune(param))
+		d.skipChars = d.state.uc
+	case "par", "line":
+		d.writeRune('\n')
+	case "tab":
+		d.writeRune('\t')
+	case "emdash":
+		d.writeRune('—')
+	case "endash":
+		d.writeRune('–')
+	case "lquote":
+		d.writeRune('‘')
+	case "rquote":
+		d.writeRune('’')
+	case "ldblquote":
+		d.writeRune('“')
+	case "rdblquote":
+		d.writeRune('”')
+	case "bullet":
+		d.writeRune('•')
+	}
+}
+
+// writeByte 写入8位字符，\uN之后的替代字符跳过
+func (d *htmlDecoder) writeByte(b byte) {
+	if d.skipChars > 0 {
+		d.skipChars--
+		return
+	}
+	if d.active() {
+		d.pending = append(d.pending, b)
+	}
+}
+
+func (d *htmlDecoder) writeRune(r rune) {
+	if !d.active() {
+		return
+	}
+	d.flush()
+	if r < utf8.RuneSelf {
+		d.out.WriteByte(byte(r))
+		return
+	}
+	d.out.WriteRune(r)
+}
+
+// flush 按代码页解码已收集的8位字符
+func (d *htmlDecoder) flush() {
+	if len(d.pending) == 0 {
+		return
+	}
+	decoded, _, err := transform.Bytes(d.codePage.NewDecoder(), d.pending)
+	if err != nil {
+		decoded = d.pending
+	}
+	d.out.WriteString(internal.ReplaceInvalid(string(decoded)))
+	d.pending = d.pending[:0]
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
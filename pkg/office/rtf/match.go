@@ -0,0 +1,49 @@
+package rtf
+
+import (
+	"sort"
+	"strings"
+
+	"fextra/pkg/search/trie"
+)
+
+// KeywordMatch 关键词在RTF文本块中的一次出现及其在原始RTF内容中的位置
+type KeywordMatch struct {
+	Keyword string
+	Spans   []TextPosition // 原始RTF内容中的字节范围，关键词跨越多个文本块（中间有控制字）时为多段
+}
+
+// FindKeywords 在ParseWithPositions返回的文本块中查找关键词，返回每次出现对应的原始RTF字节范围，
+// 调用方可据此直接在原文件中脱敏或高亮；
+// 匹配基于按顺序拼接的文本块，与Parse的输出相比未经过空白清理，跨越文本块的关键词同样可以匹配
+func FindKeywords(positions []TextPosition, m *trie.Matcher) []KeywordMatch {
+	// 拼接文本块，starts[i]为第i个文本块在拼接文本中的起始位置
+	var joined strings.Builder
+	starts := make([]int, len(positions))
+	for i, pos := range positions {
+		starts[i] = joined.Len()
+		joined.WriteString(pos.Text)
+	}
+	text := joined.String()
+
+	var result []KeywordMatch
+	for _, match := range m.FindAll(text) {
+		km := KeywordMatch{Keyword: match.Keyword}
+		// 找到包含匹配起点的文本块，依次截取与匹配重叠的部分
+		i := sort.Search(len(starts), func(j int) bool { return starts[j] > match.Start }) - 1
+		for ; i < len(positions) && starts[i] < match.End; i++ {
+			from := max(match.Start, starts[i]) - starts[i]
+			to := min(match.End-starts[i], len(positions[i].Text))
+			if from >= to {
+				continue
+			}
+			km.Spans = append(km.Spans, TextPosition{
+				Offset: positions[i].Offset + from,
+				Length: to - from,
+				Text:   positions[i].Text[from:to],
+			})
+		}
+		result = append(result, km)
+	}
+	return result
+}
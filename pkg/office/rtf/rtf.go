@@ -2,11 +2,13 @@ package rtf
 
 import (
 	"fextra/pkg/logger"
+	"fextra/pkg/plaintext/plainhtml"
 	"fextra/pkg/search/trie"
 	"fmt"
 	"io"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -29,18 +31,41 @@ func (p *OfficeRtfParser) Parse(filename string) ([]byte, error) {
 	}
 	defer file.Close()
 
+	return p.ParseReader(file)
+}
+
+// ParseReader 从io.Reader中读取RTF内容并提取纯文本
+func (p *OfficeRtfParser) ParseReader(r io.Reader) ([]byte, error) {
 	// 读取文件内容
-	content, err := io.ReadAll(file)
+	content, err := io.ReadAll(r)
 	if err != nil {
 		return []byte{}, fmt.Errorf("无法读取RTF文件: %v", err)
 	}
 
+	// Outlook保存的HTML邮件，还原HTML后按HTML提取
+	if isEncapsulatedHTML(string(content)) {
+		logger.Logger.Printf("RTF中封装了HTML，还原后按HTML提取文本")
+		return (&plainhtml.TextHTMLParser{}).ParseHtml(extractEncapsulatedHTML(string(content)))
+	}
+
 	// 提取纯文本和位置信息
 	extractedText, _ := extractTextWithPositions(string(content))
 
 	return []byte(extractedText), nil
 }
 
+// ParseWithPositions 提取RTF文件中的纯文本，同时返回每个文本块在原始RTF内容中的字节偏移，
+// 便于调用方回溯原文进行高亮或脱敏；封装HTML的RTF同样按RTF文本块定位，不还原HTML
+func (p *OfficeRtfParser) ParseWithPositions(filename string) ([]byte, []TextPosition, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return []byte{}, nil, fmt.Errorf("无法读取RTF文件: %v", err)
+	}
+
+	extractedText, positions := extractTextWithPositions(string(content))
+	return []byte(extractedText), positions, nil
+}
+
 // extractTextWithPositions 从RTF内容中提取纯文本及位置信息
 func extractTextWithPositions(content string) (string, []TextPosition) {
 	var result strings.Builder
@@ -100,16 +125,9 @@ func extractTextWithPositions(content string) (string, []TextPosition) {
 				Length: len(text),
 				Text:   text,
 			})
-			logger.Logger.Printf("111 offset: 0x%x, length: 0x%x, text: %s", currentOffset, len(text), text)
+			logger.Logger.Printf("offset: 0x%x, length: 0x%x, text: %s", currentOffset, len(text), text)
 			result.WriteString(text)
 		}
-		positions = append(positions, TextPosition{
-			Offset: currentOffset,
-			Length: len(text),
-			Text:   text,
-		})
-		logger.Logger.Printf("111 offset: 0x%x, length: 0x%x, text: %s", currentOffset, len(text), text)
-		result.WriteString(text)
 	}
 
 	// 应用文本清理规则
@@ -127,6 +145,7 @@ type parserState struct {
 	textBuf    string      // 文本缓冲区，收集提取的纯文本内容
 	inText     bool        // 是否处于文本内容状态，true表示当前字符为文本内容
 	Offset     int         // 在原始RTF内容中的字节偏移量，用于定位文本位置
+	skipUntil  int         // \binN 二进制数据的结束偏移量，之前的字节全部跳过
 }
 
 type groupInfo struct {
@@ -172,7 +191,7 @@ var (
 		"ud",
 		"upr",
 		"xe",
-		"urtfN",
+		"urtf",
 		"userprops",
 		"vern",
 		"version",
@@ -190,12 +209,39 @@ func init() {
 	Pm = trie.NewPrefixMatcher(StyleFilter)
 }
 
+// checkStyleGroup 判断控制字是否为StyleFilter中的目标组，去除数字参数后按完整名称匹配
+// （按前缀匹配时空控制字及par、i等常见控制字会被误判为样式组，导致正文被整体丢弃）
 func checkStyleGroup(control string) bool {
-	return Pm.HasPrefix(control)
+	word := strings.TrimRight(control, "-0123456789")
+	return word != "" && Pm.Contains(word)
 }
 
 // processChar 处理单个字符并更新解析状态
 func (s *parserState) processChar(c rune) {
+	// 跳过 \binN 之后的二进制数据
+	if s.Offset < s.skipUntil {
+		s.inText = false
+		return
+	}
+
+	// \binN 控制字以非数字字符结束，其后紧跟N字节二进制数据
+	if s.inControl && (c < '0' || c > '9') {
+		if n, ok := binLength(s.controlBuf); ok {
+			s.inControl = false
+			s.controlBuf = ""
+			s.inText = false
+			if c == ' ' {
+				// 空格为控制字分隔符，不计入二进制数据
+				s.skipUntil = s.Offset + 1 + n
+				return
+			}
+			s.skipUntil = s.Offset + n
+			if n > 0 {
+				return
+			}
+		}
+	}
+
 	// 处理组标记
 	if c == '{' {
 		// 处理组开始前先检查是否有未完成的控制字
@@ -265,10 +311,31 @@ func (s *parserState) processChar(c rune) {
 	}
 }
 
+// binLength 解析 \binN 控制字的字节数参数
+func binLength(control string) (int, bool) {
+	digits := strings.TrimPrefix(control, "bin")
+	if digits == control || digits == "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
 // processControlWord 处理RTF控制字
 // true  -- 在样式组内
 // false -- 不在样式组内
 func (s *parserState) processControlWord(control string) {
+	// \* 标记可忽略的目标组，无论目标名是否在StyleFilter中都整组跳过
+	if control == "*" && len(s.groupStack) > 0 {
+		lastIdx := len(s.groupStack) - 1
+		s.groupStack[lastIdx].isStyleGroup = true
+		s.groupStack[lastIdx].typeControl = control
+		return
+	}
+
 	// 区分文本内容和样式控制字
 	// 检查是否为样式组控制字
 	isStyleControl := checkStyleGroup(control)
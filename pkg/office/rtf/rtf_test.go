@@ -0,0 +1,42 @@
+package rtf
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseReaderSkipsDestinations 字体表、颜色表、样式表中的名称不作为正文输出，正文只输出一次
+func TestParseReaderSkipsDestinations(t *testing.T) {
+	src := `{\rtf1\ansi\deff0{\fonttbl{\f0 Times New Roman;}}{\colortbl;\red255\green0\blue0;}` +
+		`{\stylesheet{\s1 Heading 1;}}\pard Hello \b world\b0 .\par Second paragraph\par}`
+
+	got, err := (&OfficeRtfParser{}).ParseReader(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	text := string(got)
+	for _, want := range []string{"Hello world.", "Second paragraph"} {
+		if strings.Count(text, want) != 1 {
+			t.Errorf("want %q exactly once in %q", want, text)
+		}
+	}
+	for _, unwanted := range []string{"Times New Roman", "Heading 1", "red255"} {
+		if strings.Contains(text, unwanted) {
+			t.Errorf("destination text %q leaked into %q", unwanted, text)
+		}
+	}
+}
+
+// TestParseWithPositionsOffsets 文本块的偏移指向原始RTF中的同一段文本
+func TestParseWithPositionsOffsets(t *testing.T) {
+	src := `{\rtf1\ansi\pard First block\par\pard Second block\par}`
+	text, positions := extractTextWithPositions(src)
+	if !strings.Contains(text, "First block") || !strings.Contains(text, "Second block") || len(positions) == 0 {
+		t.Fatalf("got %q", text)
+	}
+	for _, p := range positions {
+		if p.Offset+p.Length > len(src) || src[p.Offset:p.Offset+p.Length] != p.Text {
+			t.Errorf("position %+v does not match the source", p)
+		}
+	}
+}
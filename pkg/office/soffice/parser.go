@@ -0,0 +1,26 @@
+package soffice
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// TextParser 通过LibreOffice转换为UTF-8纯文本提取内容，适用于DOC、RTF、ODT等文字处理格式，
+// 一般作为internal.FallbackParser中纯Go解析器之后的备选；未配置LibreOffice时返回ErrDisabled
+type TextParser struct{}
+
+func (p *TextParser) Parse(filePath string) ([]byte, error) {
+	txtPath, cleanup, err := Convert(filePath, "txt:Text (encoded):UTF8")
+	if err != nil {
+		return []byte{}, err
+	}
+	defer cleanup()
+
+	content, err := os.ReadFile(txtPath)
+	if err != nil {
+		return []byte{}, fmt.Errorf("读取转换结果失败: %w", err)
+	}
+	// 去掉转换结果开头的UTF-8 BOM
+	return bytes.TrimPrefix(content, []byte("\xef\xbb\xbf")), nil
+}
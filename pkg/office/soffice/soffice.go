@@ -0,0 +1,103 @@
+// Package soffice 调用外部LibreOffice（soffice）转换纯Go难以解析的格式（如VSD），
+// 需通过SetBinary显式配置，未配置时转换直接返回ErrDisabled，调用方跳过即可
+package soffice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"fextra/internal"
+	"fextra/pkg/logger"
+)
+
+// DefaultTimeout 单次转换的默认超时时间
+const DefaultTimeout = 60 * time.Second
+
+// ErrDisabled 未配置LibreOffice可执行文件
+var ErrDisabled = errors.New("未配置LibreOffice")
+
+var (
+	binary  atomic.Value // string，为空时不启用
+	timeout atomic.Int64
+)
+
+// SetBinary 设置LibreOffice可执行文件，可为路径或PATH中的名称（如soffice、libreoffice），为空时关闭外部转换
+func SetBinary(path string) {
+	binary.Store(path)
+}
+
+// SetTimeout 设置单次转换的超时时间，小于等于0时使用DefaultTimeout
+func SetTimeout(d time.Duration) {
+	timeout.Store(int64(d))
+}
+
+// Enabled 是否已配置LibreOffice
+func Enabled() bool {
+	path, _ := binary.Load().(string)
+	return path != ""
+}
+
+func currentTimeout() time.Duration {
+	if d := time.Duration(timeout.Load()); d > 0 {
+		return d
+	}
+	return DefaultTimeout
+}
+
+// Convert 将filePath转换为format格式（如pdf、txt），返回转换结果的路径及清理临时目录的函数；
+// 转换在独立的临时目录及用户配置目录中进行，超时后终止进程
+func Convert(filePath string, format string) (string, func(), error) {
+	path, _ := binary.Load().(string)
+	if path == "" {
+		return "", nil, ErrDisabled
+	}
+	bin, err := exec.LookPath(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("查找LibreOffice失败: %w", err)
+	}
+
+	tmpDir, err := internal.MkdirTemp("soffice_")
+	if err != nil {
+		return "", nil, fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	cleanup := func() { os.RemoveAll(tmpDir) }
+
+	outDir := filepath.Join(tmpDir, "out")
+	// 每次转换使用独立的用户配置目录，避免与正在运行的实例或并发转换争用配置锁
+	profile := "file://" + filepath.ToSlash(filepath.Join(tmpDir, "profile"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), currentTimeout())
+	defer cancel()
+	cmd := exec.CommandContext(ctx, bin, "--headless", "--norestore", "-env:UserInstallation="+profile,
+		"--convert-to", format, "--outdir", outDir, filePath)
+	// soffice会启动子进程，超时终止后不再等待其关闭输出
+	cmd.WaitDelay = 2 * time.Second
+
+	logger.Logger.Printf("LibreOffice转换: %s -> %s", filePath, format)
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		cleanup()
+		return "", nil, fmt.Errorf("LibreOffice转换超时(%v)", currentTimeout())
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("LibreOffice转换失败: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	// 输出文件名为原文件名替换后缀，format可能带过滤器名（如txt:Text），只取扩展名部分
+	ext, _, _ := strings.Cut(format, ":")
+	name := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath)) + "." + ext
+	outPath := filepath.Join(outDir, name)
+	if _, err := os.Stat(outPath); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("LibreOffice未生成转换结果: %s: %w", strings.TrimSpace(string(output)), internal.ErrUnsupportedFormat)
+	}
+	return outPath, cleanup, nil
+}
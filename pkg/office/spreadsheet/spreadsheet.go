@@ -0,0 +1,93 @@
+// Package spreadsheet 定义电子表格（XLSX、XLS、XLSB、ODS等）的统一抽象及文本输出格式，
+// 各格式只需按工作表、行给出单元格文本，由Render统一输出
+package spreadsheet
+
+import (
+	"io"
+	"iter"
+	"strings"
+
+	"fextra/internal"
+)
+
+// Spreadsheet 电子表格中的工作表，按工作簿中的顺序排列
+type Spreadsheet interface {
+	Sheets() []Sheet
+}
+
+// Sheet 工作表
+type Sheet interface {
+	// Name 工作表名称，用于输出的工作表标题
+	Name() string
+
+	// Rows 按顺序返回工作表的行，每行为单元格文本，空单元格为空字符串；
+	// 返回的切片可能在下一行复用。读取失败时由实现记录日志并结束遍历
+	Rows() iter.Seq[[]string]
+}
+
+// Workbook 已打开的电子表格文件，使用完毕后需调用Close
+type Workbook interface {
+	Spreadsheet
+	io.Closer
+}
+
+// Render 按统一格式输出电子表格文本：每个工作表为一节，节标题及分隔见internal.Sections，
+// 每行一行，单元格以制表符分隔并去除行尾的空单元格，空行不输出；
+// limit>0时最多输出limit个非空行（所有工作表合计）。返回输出的非空行数
+func Render(w io.Writer, book Spreadsheet, limit int) (int, error) {
+	sections := internal.Sections()
+	var rows int
+	for i, sheet := range book.Sheets() {
+		if limit > 0 && rows >= limit {
+			break
+		}
+		if i > 0 {
+			if _, err := io.WriteString(w, sections.Separator); err != nil {
+				return rows, err
+			}
+		}
+		sheetLimit := 0
+		if limit > 0 {
+			sheetLimit = limit - rows
+		}
+		n, err := RenderSheet(w, i+1, sheet, sheetLimit, false)
+		rows += n
+		if err != nil {
+			return rows, err
+		}
+	}
+	return rows, nil
+}
+
+// RenderSheet 按Render的格式输出第index个（从1开始）工作表的节标题及各行，不含工作表之间的分隔；
+// keepEmptyRows为true时空行同样输出为空行（用于保留行位置），不计入非空行数；
+// limit>0时最多输出limit个非空行。返回输出的非空行数
+func RenderSheet(w io.Writer, index int, sheet Sheet, limit int, keepEmptyRows bool) (int, error) {
+	if _, err := io.WriteString(w, internal.Sections().FormatHeader(internal.SectionSheet, index, sheet.Name())); err != nil {
+		return 0, err
+	}
+
+	var rows int
+	for cells := range sheet.Rows() {
+		end := len(cells)
+		for end > 0 && cells[end-1] == "" {
+			end--
+		}
+		if end == 0 {
+			if keepEmptyRows {
+				if _, err := io.WriteString(w, "\n"); err != nil {
+					return rows, err
+				}
+			}
+			continue
+		}
+		if _, err := io.WriteString(w, strings.Join(cells[:end], "\t")+"\n"); err != nil {
+			return rows, err
+		}
+		rows++
+		if limit > 0 && rows >= limit {
+			break
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,256 @@
+package spreadsheet_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"fextra/internal/cfbtest"
+	"fextra/internal/ziptest"
+	"fextra/pkg/office/odt"
+	"fextra/pkg/office/xls"
+	"fextra/pkg/office/xlsb"
+	"fextra/pkg/office/xlsx"
+)
+
+// grid 各格式共用的工作表：第1行中间有空单元格，第3行为空行，第4行以空单元格开头，"42"为数值单元格
+var grid = [][]string{
+	{"名称", "", "数量"},
+	{"苹果", "x", "42"},
+	{},
+	{"", "尾"},
+}
+
+const sheetName = "数据"
+
+// TestRenderSameGrid 同一张表格在XLSX、XLS、XLSB、ODS中的输出完全相同
+func TestRenderSameGrid(t *testing.T) {
+	dir := t.TempDir()
+	outputs := make(map[string]string)
+	for _, f := range []struct {
+		name  string
+		build func() []byte
+		parse func(path string) ([]byte, error)
+	}{
+		{"book.xlsx", buildXlsx, (&xlsx.OfficeXlsxParser{}).Parse},
+		{"book.xls", buildXls, (&xls.OfficeXlsParser{}).Parse},
+		{"book.xlsb", buildXlsb, (&xlsb.OfficeXlsbParser{}).Parse},
+		{"book.ods", buildOds, (&odt.OfficeOdsParser{}).Parse},
+	} {
+		path := filepath.Join(dir, f.name)
+		if err := os.WriteFile(path, f.build(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		text, err := f.parse(path)
+		if err != nil {
+			t.Fatalf("%s: %v", f.name, err)
+		}
+		outputs[f.name] = string(text)
+	}
+
+	want := outputs["book.xlsx"]
+	if !strings.Contains(want, "名称\t\t数量\n苹果\tx\t42\n\t尾\n") || !strings.Contains(want, sheetName) {
+		t.Fatalf("xlsx: got %q", want)
+	}
+	for name, got := range outputs {
+		if got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func buildXlsx() []byte {
+	var sheet strings.Builder
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range grid {
+		fmt.Fprintf(&sheet, `<row r="%d">`, r+1)
+		for c, value := range row {
+			switch {
+			case value == "":
+			case value == "42":
+				fmt.Fprintf(&sheet, `<c r="%c%d"><v>42</v></c>`, 'A'+c, r+1)
+			default:
+				fmt.Fprintf(&sheet, `<c r="%c%d" t="inlineStr"><v>%s</v></c>`, 'A'+c, r+1, value)
+			}
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	return ziptest.Build([]ziptest.File{
+		{Name: "xl/workbook.xml", Data: `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+			`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="` + sheetName + `" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{Name: "xl/_rels/workbook.xml.rels", Data: `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`},
+		{Name: "xl/worksheets/sheet1.xml", Data: sheet.String()},
+	})
+}
+
+func buildOds() []byte {
+	var body strings.Builder
+	body.WriteString(`<office:spreadsheet><table:table table:name="` + sheetName + `">`)
+	for _, row := range grid {
+		body.WriteString(`<table:table-row>`)
+		for _, value := range row {
+			if value == "" {
+				body.WriteString(`<table:table-cell/>`)
+			} else {
+				body.WriteString(`<table:table-cell><text:p>` + value + `</text:p></table:table-cell>`)
+			}
+		}
+		body.WriteString(`<table:table-cell table:number-columns-repeated="1000"/></table:table-row>`)
+	}
+	body.WriteString(`</table:table></office:spreadsheet>`)
+
+	return ziptest.Build([]ziptest.File{
+		{Name: "mimetype", Data: "application/vnd.oasis.opendocument.spreadsheet"},
+		{Name: "content.xml", Data: `<office:document-content ` +
+			`xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+			`xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" ` +
+			`xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0">` +
+			`<office:body>` + body.String() + `</office:body></office:document-content>`},
+	})
+}
+
+// xlsbRecord 按MS-XLSB的变长记录头编码一条记录
+func xlsbRecord(recordType uint32, data []byte) []byte {
+	var out []byte
+	for _, v := range []uint32{recordType, uint32(len(data))} {
+		for v >= 0x80 {
+			out = append(out, byte(v)|0x80)
+			v >>= 7
+		}
+		out = append(out, byte(v))
+	}
+	return append(out, data...)
+}
+
+func wideString(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	out := binary.LittleEndian.AppendUint32(nil, uint32(len(units)))
+	for _, u := range units {
+		out = binary.LittleEndian.AppendUint16(out, u)
+	}
+	return out
+}
+
+func buildXlsb() []byte {
+	bundle := append(make([]byte, 8), wideString("rId1")...)
+	workbookBin := xlsbRecord(xlsb.BRT_BundleSh, append(bundle, wideString(sheetName)...))
+
+	var sheet []byte
+	for r, row := range grid {
+		sheet = append(sheet, xlsbRecord(xlsb.BRT_RowHdr, binary.LittleEndian.AppendUint32(nil, uint32(r)))...)
+		for c, value := range row {
+			header := binary.LittleEndian.AppendUint32(nil, uint32(c))
+			header = append(header, 0, 0, 0, 0)
+			switch {
+			case value == "":
+			case value == "42":
+				sheet = append(sheet, xlsbRecord(xlsb.BRT_CellReal, binary.LittleEndian.AppendUint64(header, math.Float64bits(42)))...)
+			default:
+				sheet = append(sheet, xlsbRecord(xlsb.BRT_CellIstr, append(header, wideString(value)...))...)
+			}
+		}
+	}
+
+	return ziptest.Build([]ziptest.File{
+		{Name: "xl/workbook.bin", Data: string(workbookBin)},
+		{Name: "xl/_rels/workbook.bin.rels", Data: `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Target="worksheets/sheet1.bin"/></Relationships>`},
+		{Name: "xl/worksheets/sheet1.bin", Data: string(sheet)},
+	})
+}
+
+// biffRecord BIFF8记录：2字节类型、2字节长度及内容
+func biffRecord(id uint16, data []byte) []byte {
+	out := binary.LittleEndian.AppendUint16(nil, id)
+	out = binary.LittleEndian.AppendUint16(out, uint16(len(data)))
+	return append(out, data...)
+}
+
+// biffBOF BIFF8的BOF记录，dt为0x0005（工作簿全局）或0x0010（工作表）
+func biffBOF(dt uint16) []byte {
+	data := binary.LittleEndian.AppendUint16(nil, 0x0600)
+	data = binary.LittleEndian.AppendUint16(data, dt)
+	return biffRecord(0x0809, append(data, make([]byte, 12)...))
+}
+
+// unicodeString BIFF8不压缩的UTF-16字符串（标志0x01），不含长度
+func unicodeString(s string) []byte {
+	out := []byte{0x01}
+	for _, u := range utf16.Encode([]rune(s)) {
+		out = binary.LittleEndian.AppendUint16(out, u)
+	}
+	return out
+}
+
+func buildXls() []byte {
+	// 共享字符串表
+	var strs []string
+	var sst []byte
+	index := make(map[string]uint32)
+	for _, row := range grid {
+		for _, value := range row {
+			if _, ok := index[value]; ok || value == "" || value == "42" {
+				continue
+			}
+			index[value] = uint32(len(strs))
+			strs = append(strs, value)
+			sst = binary.LittleEndian.AppendUint16(sst, uint16(len(utf16.Encode([]rune(value)))))
+			sst = append(sst, unicodeString(value)...)
+		}
+	}
+	counts := binary.LittleEndian.AppendUint32(nil, uint32(len(strs)))
+	counts = binary.LittleEndian.AppendUint32(counts, uint32(len(strs)))
+
+	// 工作表子流
+	sheet := biffBOF(0x0010)
+	for r, row := range grid {
+		if len(row) == 0 {
+			continue
+		}
+		info := binary.LittleEndian.AppendUint16(nil, uint16(r))
+		info = binary.LittleEndian.AppendUint16(info, 0)
+		info = binary.LittleEndian.AppendUint16(info, uint16(len(row))) // colMac为最后一列加1
+		sheet = append(sheet, biffRecord(0x0208, append(info, make([]byte, 10)...))...)
+		for c, value := range row {
+			cell := binary.LittleEndian.AppendUint16(nil, uint16(r))
+			cell = binary.LittleEndian.AppendUint16(cell, uint16(c))
+			cell = binary.LittleEndian.AppendUint16(cell, 0)
+			switch {
+			case value == "":
+			case value == "42":
+				sheet = append(sheet, biffRecord(0x0203, binary.LittleEndian.AppendUint64(cell, math.Float64bits(42)))...)
+			default:
+				sheet = append(sheet, biffRecord(0x00FD, binary.LittleEndian.AppendUint32(cell, index[value]))...)
+			}
+		}
+	}
+	sheet = append(sheet, biffRecord(0x000A, nil)...)
+
+	// 工作簿全局子流，BOUNDSHEET给出工作表子流的偏移
+	name := []byte{byte(len(utf16.Encode([]rune(sheetName))))}
+	name = append(name, unicodeString(sheetName)...)
+	globalsLen := len(biffBOF(0x0005)) + 4 + 6 + len(name) + 4 + len(counts) + len(sst) + 4
+	boundSheet := binary.LittleEndian.AppendUint32(nil, uint32(globalsLen))
+	boundSheet = append(boundSheet, 0, 0)
+	workbook := biffBOF(0x0005)
+	workbook = append(workbook, biffRecord(0x0085, append(boundSheet, name...))...)
+	workbook = append(workbook, biffRecord(0x00FC, append(counts, sst...))...)
+	workbook = append(workbook, biffRecord(0x000A, nil)...)
+	workbook = append(workbook, sheet...)
+
+	// ole2只支持512字节的扇区，Workbook流不小于4096字节，保存在普通扇区中
+	if pad := 4096 - len(workbook); pad > 0 {
+		workbook = append(workbook, bytes.Repeat([]byte{0}, pad)...)
+	}
+	return cfbtest.Build([]cfbtest.Stream{{Name: "Workbook", Data: workbook}}, cfbtest.Options{})
+}
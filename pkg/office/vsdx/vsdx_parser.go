@@ -4,6 +4,7 @@ import (
 	"archive/zip"
 	"bytes"
 	"encoding/xml"
+	"fextra/internal"
 	"fmt"
 	"io"
 	"os"
@@ -38,7 +39,7 @@ type OfficeVsdxParser struct{}
 func (v *OfficeVsdxParser) Parse(filePath string) ([]byte, error) {
 	reader, err := zip.OpenReader(filePath)
 	if err != nil {
-		return []byte{}, fmt.Errorf("无法打开VSDX文件: %v", err)
+		return []byte{}, internal.ZipOpenError("VSDX", err)
 	}
 	defer reader.Close()
 
@@ -114,7 +115,7 @@ func extractTextFromPageXML(file *zip.File) ([]byte, error) {
 func HasImages(filePath string) (bool, error) {
 	reader, err := zip.OpenReader(filePath)
 	if err != nil {
-		return false, fmt.Errorf("无法打开VSDX文件: %v", err)
+		return false, internal.ZipOpenError("VSDX", err)
 	}
 	defer reader.Close()
 
@@ -135,12 +136,12 @@ func HasImages(filePath string) (bool, error) {
 func ExtractImages(filePath string) (int, error) {
 	reader, err := zip.OpenReader(filePath)
 	if err != nil {
-		return 0, fmt.Errorf("无法打开VSDX文件: %v", err)
+		return 0, internal.ZipOpenError("VSDX", err)
 	}
 	defer reader.Close()
 
 	// 创建临时目录
-	tmpDir, err := os.MkdirTemp("", "vsdx_extract_")
+	tmpDir, err := internal.MkdirTemp("vsdx_extract_")
 	if err != nil {
 		return 0, fmt.Errorf("创建临时目录失败: %v", err)
 	}
@@ -0,0 +1,58 @@
+package xlsx
+
+import (
+	"crypto/sha256"
+	"sync"
+)
+
+// defaultSharedStringCacheSize SharedStringCache.Size为0时最多缓存的共享字符串表数
+const defaultSharedStringCacheSize = 4
+
+// SharedStringCache 按sharedStrings.xml内容的SHA-256缓存解析后的共享字符串表，
+// 批量或重复提取同一工作簿（如先Parse再ParseCells）时无需重新解析；可由多个解析器及协程并发使用。
+// 缓存的表为只读，最多保留Size个，超出时淘汰最早加入的表
+type SharedStringCache struct {
+	Size int // 最多缓存的共享字符串表数，为0时为defaultSharedStringCacheSize
+
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte][]string
+	order   [][sha256.Size]byte // 按加入顺序排列的键
+}
+
+// load 返回content对应的共享字符串表，未缓存时调用parse解析并缓存；
+// 多个协程同时解析相同内容时各自解析，结果相同
+func (c *SharedStringCache) load(content []byte, parse func([]byte) ([]string, error)) ([]string, error) {
+	key := sha256.Sum256(content)
+
+	c.mu.Lock()
+	values, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok {
+		return values, nil
+	}
+
+	values, err := parse(content)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; ok {
+		return values, nil
+	}
+	if c.entries == nil {
+		c.entries = make(map[[sha256.Size]byte][]string)
+	}
+	size := c.Size
+	if size <= 0 {
+		size = defaultSharedStringCacheSize
+	}
+	for len(c.order) >= size {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.entries[key] = values
+	c.order = append(c.order, key)
+	return values, nil
+}
@@ -0,0 +1,134 @@
+package xlsx
+
+import (
+	"encoding/xml"
+	"strconv"
+
+	"fextra/pkg/logger"
+	"fextra/pkg/office/ooxmlcrypt"
+)
+
+// Cell 非空单元格的A1样式引用（如B7）及值，值与Parse输出的文本一致
+type Cell struct {
+	Ref   string
+	Value string
+}
+
+// Sheet 工作表名称（与Parse输出的工作表标题一致）及其非空单元格，按文档顺序排列
+type Sheet struct {
+	Name  string
+	Cells []Cell
+}
+
+// ParseCells 提取XLSX各工作表的非空单元格及其引用，稀疏表格可据此还原单元格所在的行列；
+// Limit、ApplyNumberFormats、RawSharedStringIndex、SkipHidden与Parse含义相同
+func (p *OfficeXlsxParser) ParseCells(filename string) ([]Sheet, error) {
+	reader, err := ooxmlcrypt.OpenReader(filename, "XLSX")
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	sharedStrings, numFmts, sheetFiles, err := p.openWorkbook(reader.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var sheets []Sheet
+	var rows int
+	for _, file := range sheetFiles {
+		if p.Limit > 0 && rows >= p.Limit {
+			logger.Logger.Printf("已提取 %d 行，跳过剩余工作表", rows)
+			break
+		}
+
+		opts := sheetOptions{skipHidden: p.SkipHidden, onRow: func([]string) bool {
+			rows++
+			return p.Limit == 0 || rows < p.Limit
+		}}
+		sheet := Sheet{Name: file.name}
+		_, err := parseSheetFile(file.File, sharedStrings, numFmts, opts, func(c Cell) {
+			sheet.Cells = append(sheet.Cells, c)
+		})
+		if err != nil {
+			logger.Logger.Printf("无法解析工作表XML %s: %v", file.Name, err)
+			continue
+		}
+		sheets = append(sheets, sheet)
+	}
+
+	sharedStrings.report()
+	return sheets, nil
+}
+
+// 工作表的行数及列数上限（Excel 2007起为1048576行、16384列即XFD），
+// 保留位置时按r属性补齐的空行、空列不超过该范围，避免构造的超大行号或列号放大输出
+const (
+	maxSheetRows    = 1048576
+	maxSheetColumns = 16384
+)
+
+// cellPosition 跟踪当前行列，用于为省略r属性的行、单元格推算引用（行号、列号从1开始）
+type cellPosition struct {
+	row int
+	col int
+}
+
+// startRow 开始新的一行，r为行号属性，省略时为上一行加1
+func (pos *cellPosition) startRow(r string) {
+	if n, err := strconv.Atoi(r); err == nil && n > 0 {
+		pos.row = n
+	} else {
+		pos.row++
+	}
+	pos.col = 0
+}
+
+// next 返回当前单元格的引用，ref为单元格的r属性，省略时为同一行上一个单元格的下一列
+func (pos *cellPosition) next(ref string) string {
+	if col, row, ok := splitCellRef(ref); ok {
+		pos.col, pos.row = col, row
+		return ref
+	}
+	pos.col++
+	return columnName(pos.col) + strconv.Itoa(max(pos.row, 1))
+}
+
+// splitCellRef 将A1样式引用拆分为列号及行号
+func splitCellRef(ref string) (col int, row int, ok bool) {
+	i := 0
+	for ; i < len(ref); i++ {
+		c := ref[i] | 0x20 // 转为小写
+		if c < 'a' || c > 'z' {
+			break
+		}
+		col = col*26 + int(c-'a'+1)
+	}
+	if i == 0 || i > 3 {
+		return 0, 0, false
+	}
+	row, err := strconv.Atoi(ref[i:])
+	if err != nil || row <= 0 {
+		return 0, 0, false
+	}
+	return col, row, true
+}
+
+// columnName 将从1开始的列号转换为列名（1为A，27为AA）
+func columnName(col int) string {
+	var name []byte
+	for ; col > 0; col = (col - 1) / 26 {
+		name = append([]byte{byte('A' + (col-1)%26)}, name...)
+	}
+	return string(name)
+}
+
+// attrValue 返回元素的指定属性值
+func attrValue(e xml.StartElement, local string) string {
+	for _, attr := range e.Attr {
+		if attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,144 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"fextra/pkg/logger"
+)
+
+// relTypeComments 工作表与批注部件的关系类型
+const relTypeComments = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/comments"
+
+// commentsXml xl/commentsN.xml中的作者列表及批注
+type commentsXml struct {
+	Authors  []string      `xml:"http://schemas.openxmlformats.org/spreadsheetml/2006/main authors>author"`
+	Comments []commentItem `xml:"http://schemas.openxmlformats.org/spreadsheetml/2006/main commentList>comment"`
+}
+
+// commentItem 单元格批注，文本为纯文本<t>或富文本<r><t>
+type commentItem struct {
+	Ref      string `xml:"ref,attr"`
+	AuthorID string `xml:"authorId,attr"`
+	Text     struct {
+		T    t    `xml:"http://schemas.openxmlformats.org/spreadsheetml/2006/main t"`
+		Runs []si `xml:"http://schemas.openxmlformats.org/spreadsheetml/2006/main r"`
+	} `xml:"http://schemas.openxmlformats.org/spreadsheetml/2006/main text"`
+}
+
+// definedName 定义名称，localSheetId为名称所属工作表在<sheets>中的序号，省略时为工作簿级名称
+type definedName struct {
+	Name         string `xml:"name,attr"`
+	LocalSheetID string `xml:"localSheetId,attr"`
+	Hidden       string `xml:"hidden,attr"`
+	Value        string `xml:",chardata"`
+}
+
+// writeComments 开启IncludeComments且不是预览时写入工作表的批注
+func (p *OfficeXlsxParser) writeComments(w io.Writer, reader *zip.Reader, sheetPath string) {
+	if !p.IncludeComments || p.Limit > 0 {
+		return
+	}
+	if comments := readSheetComments(reader, sheetPath); len(comments) > 0 {
+		io.WriteString(w, "\n=== 批注 ===\n")
+		w.Write(comments)
+	}
+}
+
+// writeDefinedNames 开启IncludeDefinedNames且不是预览时在所有工作表之后写入定义名称，separator为与前面工作表之间的分隔
+func (p *OfficeXlsxParser) writeDefinedNames(w io.Writer, reader *zip.Reader, separator string) {
+	if !p.IncludeDefinedNames || p.Limit > 0 {
+		return
+	}
+	if names := readDefinedNames(reader); len(names) > 0 {
+		io.WriteString(w, separator+"=== 定义名称 ===\n")
+		w.Write(names)
+	}
+}
+
+// readSheetComments 读取工作表关联的批注部件，每条批注一行"单元格 作者: 文本"，没有作者时为"单元格: 文本"；
+// 没有批注、读取或解析失败时返回nil，不影响单元格文本
+func readSheetComments(reader *zip.Reader, sheetPath string) []byte {
+	relsPath := path.Join(path.Dir(sheetPath), "_rels", path.Base(sheetPath)+".rels")
+	var rels relationships
+	if err := unmarshalZipFile(reader, relsPath, &rels); err != nil {
+		logger.Logger.Printf("读取%s失败: %v", relsPath, err)
+		return nil
+	}
+
+	var textBuffer bytes.Buffer
+	for _, rel := range rels.Items {
+		if rel.Type != relTypeComments {
+			continue
+		}
+		// Target为相对工作表所在目录的路径，或以/开头的包内绝对路径
+		target := path.Join(path.Dir(sheetPath), rel.Target)
+		if strings.HasPrefix(rel.Target, "/") {
+			target = strings.TrimPrefix(rel.Target, "/")
+		}
+
+		var doc commentsXml
+		if err := unmarshalZipFile(reader, target, &doc); err != nil {
+			logger.Logger.Printf("读取批注%s失败: %v", target, err)
+			continue
+		}
+		for _, c := range doc.Comments {
+			text := c.Text.T.Value
+			for _, run := range c.Text.Runs {
+				text += run.T.Value
+			}
+			text = strings.TrimSpace(text)
+			if text == "" {
+				continue
+			}
+			textBuffer.WriteString(c.Ref)
+			if id, err := strconv.Atoi(c.AuthorID); err == nil && id >= 0 && id < len(doc.Authors) && doc.Authors[id] != "" {
+				textBuffer.WriteString(" " + doc.Authors[id])
+			}
+			textBuffer.WriteString(": " + text + "\n")
+		}
+	}
+	return textBuffer.Bytes()
+}
+
+// readDefinedNames 读取workbook.xml中的定义名称，每个名称一行"名称: 引用"，工作表级名称为"工作表!名称: 引用"；
+// 跳过隐藏的名称及打印区域、筛选区域等内置名称（_xlnm.前缀）。读取或解析失败时返回nil
+func readDefinedNames(reader *zip.Reader) []byte {
+	var wb workbook
+	if err := unmarshalZipFile(reader, "xl/workbook.xml", &wb); err != nil {
+		logger.Logger.Printf("读取定义名称失败: %v", err)
+		return nil
+	}
+
+	var textBuffer bytes.Buffer
+	for _, name := range wb.Names {
+		if isTrue(name.Hidden) || strings.HasPrefix(name.Name, "_xlnm.") {
+			continue
+		}
+		if id, err := strconv.Atoi(name.LocalSheetID); err == nil && id >= 0 && id < len(wb.Sheets) {
+			textBuffer.WriteString(wb.Sheets[id].Name + "!")
+		}
+		textBuffer.WriteString(name.Name + ": " + strings.TrimSpace(name.Value) + "\n")
+	}
+	return textBuffer.Bytes()
+}
+
+// unmarshalZipFile 解析ZIP中的XML部件，部件不存在时不修改v并返回nil
+func unmarshalZipFile(reader *zip.Reader, name string, v any) error {
+	for _, file := range reader.File {
+		if file.Name != name {
+			continue
+		}
+		content, err := readZipFile(file)
+		if err != nil {
+			return err
+		}
+		return xml.Unmarshal(content, v)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// workbook xl/workbook.xml中的工作簿属性、工作表列表及定义名称
+type workbook struct {
+	Properties struct {
+		Date1904 string `xml:"date1904,attr"`
+	} `xml:"http://schemas.openxmlformats.org/spreadsheetml/2006/main workbookPr"`
+	Sheets []workbookSheet `xml:"http://schemas.openxmlformats.org/spreadsheetml/2006/main sheets>sheet"`
+	Names  []definedName   `xml:"http://schemas.openxmlformats.org/spreadsheetml/2006/main definedNames>definedName"`
+}
+
+// workbookSheet 工作表及其可见状态（visible、hidden、veryHidden）
+type workbookSheet struct {
+	Name  string `xml:"name,attr"`
+	State string `xml:"state,attr"`
+	RID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+}
+
+// relationships 关系文件中的关系列表
+type relationships struct {
+	Items []relationship `xml:"Relationship"`
+}
+
+type relationship struct {
+	ID     string `xml:"Id,attr"`
+	Type   string `xml:"Type,attr"`
+	Target string `xml:"Target,attr"`
+}
+
+// readWorkbookSheets 读取workbook.xml中的工作表，返回以其在ZIP中的路径（如xl/worksheets/sheet2.xml）为键的名称及可见状态
+func readWorkbookSheets(reader *zip.Reader) (map[string]workbookSheet, error) {
+	var wb workbook
+	var rels relationships
+	for _, file := range reader.File {
+		var target any
+		switch file.Name {
+		case "xl/workbook.xml":
+			target = &wb
+		case "xl/_rels/workbook.xml.rels":
+			target = &rels
+		default:
+			continue
+		}
+		content, err := readZipFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := xml.Unmarshal(content, target); err != nil {
+			return nil, err
+		}
+	}
+
+	targets := make(map[string]string, len(rels.Items))
+	for _, rel := range rels.Items {
+		// Target为相对xl/的路径，或以/开头的包内绝对路径
+		if strings.HasPrefix(rel.Target, "/") {
+			targets[rel.ID] = strings.TrimPrefix(rel.Target, "/")
+		} else {
+			targets[rel.ID] = path.Join("xl", rel.Target)
+		}
+	}
+
+	sheets := make(map[string]workbookSheet, len(wb.Sheets))
+	for _, sheet := range wb.Sheets {
+		if target, ok := targets[sheet.RID]; ok {
+			sheets[target] = sheet
+		}
+	}
+	return sheets, nil
+}
+
+// hidden 工作表是否为隐藏状态
+func (s workbookSheet) hidden() bool {
+	return s.State == "hidden" || s.State == "veryHidden"
+}
+
+// columnRange 从1开始的列号范围，对应<col min max>
+type columnRange struct {
+	min int
+	max int
+}
+
+// hiddenColumns 工作表中隐藏的列，由<cols>中hidden="1"的<col>给出
+type hiddenColumns []columnRange
+
+// add 记录<col>元素，未隐藏时忽略
+func (h *hiddenColumns) add(e xml.StartElement) {
+	if !isTrue(attrValue(e, "hidden")) {
+		return
+	}
+	lo, err1 := strconv.Atoi(attrValue(e, "min"))
+	hi, err2 := strconv.Atoi(attrValue(e, "max"))
+	if err1 == nil && err2 == nil {
+		*h = append(*h, columnRange{lo, hi})
+	}
+}
+
+// contains 判断列是否隐藏
+func (h hiddenColumns) contains(col int) bool {
+	for _, r := range h {
+		if col >= r.min && col <= r.max {
+			return true
+		}
+	}
+	return false
+}
+
+// isTrue 判断xsd:boolean属性值是否为真
+func isTrue(v string) bool {
+	return v == "1" || v == "true"
+}
@@ -0,0 +1,92 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"iter"
+
+	"fextra/pkg/logger"
+	"fextra/pkg/office/ooxmlcrypt"
+	"fextra/pkg/office/spreadsheet"
+)
+
+// OpenSpreadsheet 打开XLSX文件，按工作表、行读取单元格文本；
+// 工作表名称、单元格值与Parse一致，ApplyNumberFormats、RawSharedStringIndex、PreserveLayout、SkipHidden含义相同
+func (p *OfficeXlsxParser) OpenSpreadsheet(filename string) (spreadsheet.Workbook, error) {
+	reader, err := ooxmlcrypt.OpenReader(filename, "XLSX")
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := p.newWorkbook(reader.Reader)
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	book.reader = reader
+	return book, nil
+}
+
+// newWorkbook 读取共享字符串表、数字格式及工作表列表，返回的工作簿不负责关闭reader
+func (p *OfficeXlsxParser) newWorkbook(reader *zip.Reader) (*xlsxWorkbook, error) {
+	sharedStrings, numFmts, sheetFiles, err := p.openWorkbook(reader)
+	if err != nil {
+		return nil, err
+	}
+	book := &xlsxWorkbook{sharedStrings: sharedStrings}
+	for _, file := range sheetFiles {
+		book.sheets = append(book.sheets, &xlsxSheet{
+			file:          file.File,
+			name:          file.name,
+			sharedStrings: sharedStrings,
+			numFmts:       numFmts,
+			opts:          sheetOptions{layout: p.PreserveLayout, skipHidden: p.SkipHidden},
+		})
+	}
+	return book, nil
+}
+
+// xlsxWorkbook XLSX工作簿
+type xlsxWorkbook struct {
+	reader        *ooxmlcrypt.ReadCloser // 由OpenSpreadsheet打开时不为nil
+	sharedStrings *sharedStringTable
+	sheets        []*xlsxSheet
+}
+
+func (b *xlsxWorkbook) Sheets() []spreadsheet.Sheet {
+	sheets := make([]spreadsheet.Sheet, len(b.sheets))
+	for i, sheet := range b.sheets {
+		sheets[i] = sheet
+	}
+	return sheets
+}
+
+func (b *xlsxWorkbook) Close() error {
+	b.sharedStrings.report()
+	if b.reader == nil {
+		return nil
+	}
+	return b.reader.Close()
+}
+
+// xlsxSheet XLSX工作表
+type xlsxSheet struct {
+	name          string
+	file          *zip.File
+	sharedStrings *sharedStringTable
+	numFmts       *numberFormats
+	opts          sheetOptions
+}
+
+func (s *xlsxSheet) Name() string {
+	return s.name
+}
+
+func (s *xlsxSheet) Rows() iter.Seq[[]string] {
+	return func(yield func([]string) bool) {
+		opts := s.opts
+		opts.onRow = yield
+		if _, err := parseSheetFile(s.file, s.sharedStrings, s.numFmts, opts, nil); err != nil {
+			logger.Logger.Printf("无法解析工作表XML %s: %v", s.file.Name, err)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// numberFormats 单元格样式索引到数字格式的映射，用于识别日期/时间单元格
+type numberFormats struct {
+	xfNumFmtIds []int          // cellXfs中每个样式对应的numFmtId
+	customCodes map[int]string // 自定义格式 numFmtId -> formatCode
+	epoch       time.Time      // 日期序列号的基准日，由workbook.xml的date1904决定
+}
+
+var (
+	// excelEpoch Excel 1900日期系统的基准日（已包含1900年闰年错误的修正）
+	excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	// excel1904Epoch 1904日期系统（早期Mac版Excel，workbookPr的date1904为真）的基准日
+	excel1904Epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// readNumberFormats 读取xl/styles.xml中的数字格式定义，及xl/workbook.xml中使用的日期系统
+func readNumberFormats(reader *zip.Reader) (*numberFormats, error) {
+	nf := &numberFormats{customCodes: make(map[int]string), epoch: excelEpoch}
+	if uses1904Dates(reader) {
+		nf.epoch = excel1904Epoch
+	}
+	for _, file := range reader.File {
+		if file.Name != "xl/styles.xml" {
+			continue
+		}
+
+		content, err := readZipFile(file)
+		if err != nil {
+			return nf, err
+		}
+
+		var ss styleSheet
+		if err := xml.Unmarshal(content, &ss); err != nil {
+			return nf, err
+		}
+
+		for _, f := range ss.NumFmts {
+			nf.customCodes[f.ID] = f.Code
+		}
+		for _, xf := range ss.CellXfs {
+			nf.xfNumFmtIds = append(nf.xfNumFmtIds, xf.NumFmtID)
+		}
+		break
+	}
+	return nf, nil
+}
+
+// uses1904Dates 工作簿是否使用1904日期系统，workbook.xml缺失或无法解析时按1900日期系统处理
+func uses1904Dates(reader *zip.Reader) bool {
+	for _, file := range reader.File {
+		if file.Name != "xl/workbook.xml" {
+			continue
+		}
+		content, err := readZipFile(file)
+		if err != nil {
+			return false
+		}
+		var wb workbook
+		if err := xml.Unmarshal(content, &wb); err != nil {
+			return false
+		}
+		return isTrue(wb.Properties.Date1904)
+	}
+	return false
+}
+
+// format 按单元格样式格式化数值，非日期格式原样返回
+func (nf *numberFormats) format(styleIndex string, value string) string {
+	if nf == nil || styleIndex == "" || value == "" {
+		return value
+	}
+
+	index, err := strconv.Atoi(styleIndex)
+	if err != nil || index < 0 || index >= len(nf.xfNumFmtIds) {
+		return value
+	}
+
+	hasDate, hasTime := nf.dateKind(nf.xfNumFmtIds[index])
+	if !hasDate && !hasTime {
+		return value
+	}
+
+	serial, err := strconv.ParseFloat(value, 64)
+	if err != nil || serial < 0 {
+		return value
+	}
+
+	// 四舍五入到秒，避免浮点误差
+	seconds := math.Round(serial * 86400)
+	t := nf.epoch.Add(time.Duration(seconds) * time.Second)
+	switch {
+	case hasDate && hasTime:
+		return t.Format("2006-01-02 15:04:05")
+	case hasDate:
+		return t.Format("2006-01-02")
+	default:
+		return t.Format("15:04:05")
+	}
+}
+
+// dateKind 判断数字格式是否包含日期和时间部分
+func (nf *numberFormats) dateKind(numFmtId int) (hasDate bool, hasTime bool) {
+	// 内置日期/时间格式
+	switch {
+	case numFmtId >= 14 && numFmtId <= 17, numFmtId >= 27 && numFmtId <= 31, numFmtId >= 50 && numFmtId <= 54:
+		return true, false
+	case numFmtId == 22:
+		return true, true
+	case numFmtId >= 18 && numFmtId <= 21, numFmtId >= 32 && numFmtId <= 36, numFmtId >= 45 && numFmtId <= 47, numFmtId >= 55 && numFmtId <= 58:
+		return false, true
+	}
+
+	code, ok := nf.customCodes[numFmtId]
+	if !ok {
+		return false, false
+	}
+	return parseDateCode(code)
+}
+
+// parseDateCode 分析自定义格式代码中的日期/时间占位符，忽略引号内的文本、方括号内的颜色/条件及转义字符；
+// 方括号内的经过时间（如[h]、[mm]）视为时间
+func parseDateCode(code string) (hasDate bool, hasTime bool) {
+	tokens := formatTokens(strings.ToLower(code))
+	for i := 0; i < len(tokens); i++ {
+		switch c := tokens[i]; {
+		case c == 'y' || c == 'd':
+			hasDate = true
+		case c == 'h' || c == 's':
+			hasTime = true
+		case c == 'm':
+			// m既可表示月也可表示分钟，格式中有h/s时视为分钟
+			if strings.ContainsAny(tokens, "hs") {
+				hasTime = true
+			} else {
+				hasDate = true
+			}
+		}
+	}
+	return hasDate, hasTime
+}
+
+// formatTokens 去除格式代码中引号内的文本、方括号内的内容及转义字符，只保留格式字符；
+// 经过时间（[h]、[mm]、[ss]等）替换为h
+func formatTokens(code string) string {
+	var tokens strings.Builder
+	for i := 0; i < len(code); i++ {
+		switch c := code[i]; c {
+		case '"':
+			end := strings.IndexByte(code[i+1:], '"')
+			if end < 0 {
+				return tokens.String()
+			}
+			i += end + 1
+		case '[':
+			end := strings.IndexByte(code[i+1:], ']')
+			if end < 0 {
+				return tokens.String()
+			}
+			if inner := code[i+1 : i+1+end]; inner != "" && strings.Trim(inner, "hms") == "" {
+				tokens.WriteByte('h')
+			}
+			i += end + 1
+		case '\\', '_', '*':
+			i++ // 跳过转义字符，及_（占位宽度）、*（重复填充）之后的字符
+		default:
+			tokens.WriteByte(c)
+		}
+	}
+	return tokens.String()
+}
+
+// styleSheet xl/styles.xml结构
+type styleSheet struct {
+	XMLName xml.Name `xml:"http://schemas.openxmlformats.org/spreadsheetml/2006/main styleSheet"`
+	NumFmts []numFmt `xml:"http://schemas.openxmlformats.org/spreadsheetml/2006/main numFmts>numFmt"`
+	CellXfs []xf     `xml:"http://schemas.openxmlformats.org/spreadsheetml/2006/main cellXfs>xf"`
+}
+
+// numFmt 自定义数字格式
+type numFmt struct {
+	ID   int    `xml:"numFmtId,attr"`
+	Code string `xml:"formatCode,attr"`
+}
+
+// xf 单元格样式
+type xf struct {
+	NumFmtID int `xml:"numFmtId,attr"`
+}
@@ -4,42 +4,282 @@ import (
 	"archive/zip"
 	"bytes"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
+	"strings"
 
+	"fextra/internal"
 	"fextra/pkg/logger"
+	"fextra/pkg/office/ooxmlcrypt"
+	"fextra/pkg/office/spreadsheet"
 )
 
 // OfficeXlsxParser XLSX文件解析器
-type OfficeXlsxParser struct{}
+type OfficeXlsxParser struct {
+	ApplyNumberFormats bool // 按styles.xml中的数字格式将日期/时间序列号转换为ISO日期
+	MaxOutputBytes     int  // 最大输出字节数，达到后停止解析并截断，为0时不限制
+	Limit              int  // 最多提取的非空行数（所有工作表合计），用于生成预览，为0时不限制
+
+	// RawSharedStringIndex 共享字符串引用无法解析（sharedStrings.xml缺失、损坏或序号越界）时输出原始序号，
+	// 默认输出空值；两种情况均会记录无法解析的单元格数量
+	RawSharedStringIndex bool
+
+	// PreserveLayout 按行的r属性保留行位置：省略的空行输出为空行，使第N行的内容位于工作表输出的第N行；默认不输出空行。
+	// 同一行中跳过的列总是输出为空字段（多个制表符），与XLS、XLSB、ODS一致
+	PreserveLayout bool
+
+	// SkipHidden 跳过隐藏的工作表（workbook.xml中state为hidden/veryHidden）、隐藏的行（hidden="1"）及隐藏的列，
+	// 只输出用户打开文件时可见的内容；默认输出全部内容
+	SkipHidden bool
+
+	// SharedStrings 不为nil时按内容缓存解析后的共享字符串表，批量处理或重复提取同一工作簿时复用；
+	// 复制的解析器（如ParsePreview）共享同一缓存
+	SharedStrings *SharedStringCache
+
+	// IncludeComments 提取单元格批注（xl/commentsN.xml），以"=== 批注 ==="为标题追加到所属工作表的单元格之后，
+	// 每条批注一行"单元格 作者: 文本"；预览时不提取
+	IncludeComments bool
+
+	// IncludeDefinedNames 提取workbook.xml中的定义名称，以"=== 定义名称 ==="为标题追加到所有工作表之后，
+	// 每个名称一行"名称: 引用"；预览时不提取
+	IncludeDefinedNames bool
+}
+
+// sharedStringTable 共享字符串表及无法解析的引用计数
+type sharedStringTable struct {
+	values     []string
+	unresolved int  // 无法解析的共享字符串引用数量
+	raw        bool // 无法解析时输出原始序号
+}
+
+// lookup 按序号查找共享字符串
+func (sst *sharedStringTable) lookup(v string) string {
+	index, err := strconv.Atoi(v)
+	if err == nil && index >= 0 && index < len(sst.values) {
+		return sst.values[index]
+	}
+	sst.unresolved++
+	if sst.raw {
+		return v
+	}
+	return ""
+}
+
+// report 记录无法解析的共享字符串引用数量
+func (sst *sharedStringTable) report() {
+	if sst.unresolved > 0 {
+		logger.Logger.Printf("警告: %d 个单元格引用的共享字符串无法解析（共享字符串表共 %d 项）",
+			sst.unresolved, len(sst.values))
+	}
+}
+
+// sheetOptions 单个工作表的解析选项
+type sheetOptions struct {
+	layout     bool // 按行的r属性回调省略的空行，见PreserveLayout
+	skipHidden bool // 跳过隐藏的行及列
+
+	// onRow 每行回调一次，cells按列号放置（跳过的列为空字符串），返回false时停止解析；
+	// cells在下一次回调时复用
+	onRow func(cells []string) bool
+}
 
 // Parse 提取XLSX文件中的文本内容
 func (p *OfficeXlsxParser) Parse(filename string) ([]byte, error) {
-	// 打开ZIP文件
-	reader, err := zip.OpenReader(filename)
+	// 打开ZIP文件，加密时解密
+	reader, err := ooxmlcrypt.OpenReader(filename, "XLSX")
 	if err != nil {
-		return []byte{}, fmt.Errorf("无法打开XLSX文件: %v", err)
+		return []byte{}, err
 	}
 	defer reader.Close()
 
+	return p.parseZip(reader.Reader)
+}
+
+// ParseTo 提取XLSX文本并逐行写入w，输出与Parse一致；
+// 设置了MaxOutputBytes时需要在末尾追加截断标记，先完整提取再写入
+func (p *OfficeXlsxParser) ParseTo(filename string, w io.Writer) error {
+	if p.MaxOutputBytes > 0 {
+		content, err := p.Parse(filename)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(content)
+		return err
+	}
+
+	reader, err := ooxmlcrypt.OpenReader(filename, "XLSX")
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	return p.render(w, reader.Reader)
+}
+
+// errOutputFull 输出已达到MaxOutputBytes，用于提前结束解析
+var errOutputFull = errors.New("输出已达到上限")
+
+// errWriter 记录第一次写入错误，之后的写入直接返回该错误；max>0时已写入max字节后返回errOutputFull
+type errWriter struct {
+	w      io.Writer
+	err    error
+	max, n int
+}
+
+func (e *errWriter) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	if e.max > 0 && e.n >= e.max {
+		e.err = errOutputFull
+		return 0, e.err
+	}
+	n, err := e.w.Write(p)
+	e.n += n
+	e.err = err
+	return n, err
+}
+
+// ParseLimit 按指定的最大输出字节数提取XLSX文本，达到上限后不再解析剩余行
+func (p *OfficeXlsxParser) ParseLimit(filename string, maxBytes int) ([]byte, error) {
+	limited := *p
+	limited.MaxOutputBytes = maxBytes
+	return limited.Parse(filename)
+}
+
+// ParsePreview 仅提取XLSX前limit个非空行的文本
+func (p *OfficeXlsxParser) ParsePreview(filename string, limit int) ([]byte, error) {
+	preview := *p
+	preview.Limit = limit
+	return preview.Parse(filename)
+}
+
+// ParseReader 从io.Reader中读取XLSX内容并提取文本
+func (p *OfficeXlsxParser) ParseReader(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return []byte{}, fmt.Errorf("无法读取XLSX内容: %v", err)
+	}
+
+	reader, err := ooxmlcrypt.NewReader(data, "XLSX")
+	if err != nil {
+		return []byte{}, err
+	}
+
+	return p.parseZip(reader)
+}
+
+// parseZip 从已打开的ZIP中提取所有工作表文本，超过MaxOutputBytes时截断
+func (p *OfficeXlsxParser) parseZip(reader *zip.Reader) ([]byte, error) {
+	var textBuffer bytes.Buffer
+	if err := p.render(&textBuffer, reader); err != nil {
+		return []byte{}, err
+	}
+	return internal.TruncateOutput(textBuffer.Bytes(), p.MaxOutputBytes), nil
+}
+
+// render 按spreadsheet.RenderSheet逐个工作表输出，与XLS、XLSB、ODS的输出格式一致：
+// 工作表的批注紧跟在其单元格之后，定义名称位于所有工作表之后；
+// 达到Limit或MaxOutputBytes后不再解析剩余的行及工作表，截断由调用方完成
+func (p *OfficeXlsxParser) render(w io.Writer, reader *zip.Reader) error {
+	book, err := p.newWorkbook(reader)
+	if err != nil {
+		return err
+	}
+
+	sections := internal.Sections()
+	out := &errWriter{w: w, max: p.MaxOutputBytes}
+	var rows int
+	for i, sheet := range book.sheets {
+		if p.Limit > 0 && rows >= p.Limit {
+			logger.Logger.Printf("已提取 %d 行，跳过剩余工作表", rows)
+			break
+		}
+
+		logger.Logger.Printf("处理工作表文件: %v", sheet.file.Name)
+		if i > 0 {
+			io.WriteString(out, sections.Separator)
+		}
+		var limit int
+		if p.Limit > 0 {
+			limit = p.Limit - rows
+		}
+		n, _ := spreadsheet.RenderSheet(out, i+1, sheet, limit, p.PreserveLayout)
+		rows += n
+		p.writeComments(out, reader, sheet.file.Name)
+		if out.err != nil {
+			break
+		}
+	}
+	var separator string
+	if len(book.sheets) > 0 {
+		separator = sections.Separator
+	}
+	p.writeDefinedNames(out, reader, separator)
+
+	book.sharedStrings.report()
+	if out.err == errOutputFull {
+		logger.Logger.Printf("输出已达到上限 %d 字节，跳过剩余内容", p.MaxOutputBytes)
+	} else if out.err != nil {
+		return fmt.Errorf("写入输出失败: %w", out.err)
+	}
+	return nil
+}
+
+// sheetFile 工作表文件及其在workbook.xml中的名称
+type sheetFile struct {
+	*zip.File
+	name string
+}
+
+// openWorkbook 读取共享字符串表、数字格式，并按编号返回工作表文件；ZIP中没有任何工作表文件时返回ErrNoContent
+func (p *OfficeXlsxParser) openWorkbook(reader *zip.Reader) (*sharedStringTable, *numberFormats, []sheetFile, error) {
 	// 读取共享字符串表
-	sharedStrings, err := readSharedStrings(reader)
+	values, err := readSharedStrings(reader, p.SharedStrings)
 	if err != nil {
 		// 非致命错误，继续处理
 		logger.Logger.Printf("读取共享字符串表失败: %v", err)
 	}
+	sharedStrings := &sharedStringTable{values: values, raw: p.RawSharedStringIndex}
+
+	// 读取数字格式
+	var numFmts *numberFormats
+	if p.ApplyNumberFormats {
+		if numFmts, err = readNumberFormats(reader); err != nil {
+			// 非致命错误，按原始值输出
+			logger.Logger.Printf("读取数字格式失败: %v", err)
+		}
+	}
+
+	// 读取工作表名称及可见状态
+	workbookSheets, err := readWorkbookSheets(reader)
+	if err != nil {
+		// 非致命错误，以文件名作为工作表名称并输出全部工作表
+		logger.Logger.Printf("读取工作表列表失败: %v", err)
+	}
 
 	// 收集所有工作表文件
-	var sheetFiles []*zip.File
+	var sheetFiles []sheetFile
+	var hiddenCount int
 	for _, file := range reader.File {
 		if filepath.Dir(file.Name) == "xl/worksheets" && filepath.Ext(file.Name) == ".xml" {
+			sheet, ok := workbookSheets[file.Name]
+			if p.SkipHidden && sheet.hidden() {
+				hiddenCount++
+				logger.Logger.Printf("跳过隐藏的工作表: %s", file.Name)
+				continue
+			}
 			// 验证文件名是否符合sheet*.xml模式
 			if matched, _ := regexp.MatchString(`^sheet\d+\.xml$`, filepath.Base(file.Name)); matched {
-				sheetFiles = append(sheetFiles, file)
+				if !ok || sheet.Name == "" {
+					sheet.Name = strings.TrimSuffix(filepath.Base(file.Name), ".xml")
+				}
+				sheetFiles = append(sheetFiles, sheetFile{File: file, name: sheet.Name})
 			} else {
 				logger.Logger.Printf("跳过非标准工作表文件: %s", file.Name)
 			}
@@ -53,100 +293,178 @@ func (p *OfficeXlsxParser) Parse(filename string) ([]byte, error) {
 		return numI < numJ
 	})
 
-	var textBuffer bytes.Buffer
-
-	// 处理排序后的工作表文件
-	for _, file := range sheetFiles {
-		logger.Logger.Printf("处理工作表文件: %v", file.Name)
-		// 读取工作表内容
-		sheetContent, err := readZipFile(file)
-		if err != nil {
-			logger.Logger.Printf("无法读取工作表文件 %s: %v", file.Name, err)
-			continue
-		}
-
-		// 解析工作表XML并提取文本
-		sheetText, err := parseSheetXml(sheetContent, sharedStrings)
-		if err != nil {
-			logger.Logger.Printf("无法解析工作表XML %s: %v", file.Name, err)
-			continue
-		}
-
-		// 将工作表文本添加到结果中，用分页符分隔
-		textBuffer.WriteString(fmt.Sprintf("=== 工作表: %s ===\n", filepath.Base(file.Name)))
-		textBuffer.Write(sheetText)
-		textBuffer.WriteString("\n\f\n") // 使用换页符分隔不同工作表
+	// 工作表均被隐藏时为空的工作簿，而非缺少工作表
+	if len(sheetFiles) == 0 && hiddenCount == 0 {
+		return nil, nil, nil, fmt.Errorf("XLSX中没有工作表: %w", internal.ErrNoContent)
 	}
-
-	return textBuffer.Bytes(), nil
+	return sharedStrings, numFmts, sheetFiles, nil
 }
 
-// readSharedStrings 读取共享字符串表
-func readSharedStrings(reader *zip.ReadCloser) ([]string, error) {
+// readSharedStrings 读取共享字符串表，cache不为nil时优先使用缓存的解析结果
+func readSharedStrings(reader *zip.Reader, cache *SharedStringCache) ([]string, error) {
 	for _, file := range reader.File {
 		if file.Name == "xl/sharedStrings.xml" {
 			content, err := readZipFile(file)
 			if err != nil {
 				return nil, err
 			}
-
-			var sst sharedStrings
-			if err := xml.Unmarshal(content, &sst); err != nil {
-				return nil, err
+			if cache != nil {
+				return cache.load(content, parseSharedStrings)
 			}
-
-			// 提取共享字符串
-			strings := make([]string, len(sst.Si))
-			for i, si := range sst.Si {
-				strings[i] = si.T.Value
-			}
-			return strings, nil
+			return parseSharedStrings(content)
 		}
 	}
 	return []string{}, nil // 没有共享字符串表
 }
 
-// parseSheetXml 解析工作表XML并提取文本
-func parseSheetXml(xmlContent []byte, sharedStrings []string) ([]byte, error) {
-	var worksheet worksheet
-	if err := xml.Unmarshal(xmlContent, &worksheet); err != nil {
-		return []byte{}, err
+// parseSharedStrings 解析sharedStrings.xml的内容
+func parseSharedStrings(content []byte) ([]string, error) {
+	var sst sharedStrings
+	if err := xml.Unmarshal(content, &sst); err != nil {
+		return nil, err
 	}
 
-	var sheetBuffer bytes.Buffer
+	// 提取共享字符串
+	strings := make([]string, len(sst.Si))
+	for i, si := range sst.Si {
+		strings[i] = si.T.Value
+	}
+	return strings, nil
+}
 
-	// 遍历所有行
-	for _, row := range worksheet.SheetData.Row {
-		var rowBuffer bytes.Buffer
-		// 遍历行中的单元格
-		for _, c := range row.C {
-			// 获取单元格值
-			cellValue := getCellValue(c, sharedStrings)
-			if cellValue != "" {
-				if rowBuffer.Len() > 0 {
-					rowBuffer.WriteString("\t") // 使用制表符分隔单元格
+// parseSheetFile 打开工作表文件并流式解析
+func parseSheetFile(file *zip.File, sharedStrings *sharedStringTable, numFmts *numberFormats, opts sheetOptions, onCell func(Cell)) (int, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	return parseSheetXml(rc, sharedStrings, numFmts, opts, onCell)
+}
+
+// parseSheetXml 使用xml.Decoder流式解析工作表XML，逐行回调opts.onRow，
+// 解析过程中仅保留当前行，避免大工作表整体加载到内存
+// 单元格按r属性放置到所在列，跳过的列为空字符串，不超过工作表列数上限；
+// numFmts不为nil时，按数字格式转换日期/时间单元格；
+// opts.layout为true时在每个非空行之前按r属性回调省略的空行（cells为空），不超过工作表行数上限；
+// opts.skipHidden为true时跳过隐藏的行及列；onCell不为nil时，每个输出的非空单元格连同其A1引用回调一次
+// 返回回调的非空行数
+func parseSheetXml(r io.Reader, sharedStrings *sharedStringTable, numFmts *numberFormats, opts sheetOptions, onCell func(Cell)) (int, error) {
+	decoder := xml.NewDecoder(r)
+	onRow := opts.onRow
+	if onRow == nil {
+		onRow = func([]string) bool { return true }
+	}
+	var rows int
+	var pos cellPosition
+	var hiddenCols hiddenColumns
+	var hiddenRow bool
+	var lastRow int // 保留位置时已回调的最后一行
+
+	var rowCells []string
+	var current cell
+	var value bytes.Buffer
+	var inValue bool
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rows, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space != spreadsheetMLNamespace {
+				continue
+			}
+			switch t.Name.Local {
+			case "col":
+				if opts.skipHidden {
+					hiddenCols.add(t)
+				}
+			case "row":
+				rowCells = rowCells[:0]
+				pos.startRow(attrValue(t, "r"))
+				hiddenRow = opts.skipHidden && isTrue(attrValue(t, "hidden"))
+			case "c":
+				current = cell{}
+				for _, attr := range t.Attr {
+					switch attr.Name.Local {
+					case "r":
+						current.R = attr.Value
+					case "t":
+						current.T = attr.Value
+					case "s":
+						current.S = attr.Value
+					}
+				}
+			case "v":
+				inValue = true
+				value.Reset()
+			}
+		case xml.CharData:
+			if inValue {
+				value.Write(t)
+			}
+		case xml.EndElement:
+			if t.Name.Space != spreadsheetMLNamespace {
+				continue
+			}
+			switch t.Name.Local {
+			case "v":
+				inValue = false
+				current.V = value.String()
+			case "c":
+				// 获取单元格值
+				cellValue := getCellValue(current, sharedStrings)
+				if current.T == "" || current.T == "n" {
+					cellValue = numFmts.format(current.S, cellValue)
+				}
+				ref := pos.next(current.R)
+				if hiddenRow || hiddenCols.contains(pos.col) || cellValue == "" {
+					continue
+				}
+				if onCell != nil {
+					onCell(Cell{Ref: ref, Value: cellValue})
+				}
+				// 第N列之前补齐空单元格，不超过工作表列数上限
+				for len(rowCells) < min(pos.col, maxSheetColumns)-1 {
+					rowCells = append(rowCells, "")
+				}
+				rowCells = append(rowCells, cellValue)
+			case "row":
+				if len(rowCells) == 0 {
+					continue
+				}
+				if opts.layout {
+					// 回调省略的空行，不超过工作表行数上限
+					for ; lastRow < min(pos.row, maxSheetRows)-1; lastRow++ {
+						if !onRow(nil) {
+							return rows, nil
+						}
+					}
+					lastRow = max(lastRow, pos.row)
+				}
+				rows++
+				if !onRow(rowCells) {
+					return rows, nil
 				}
-				rowBuffer.WriteString(cellValue)
 			}
-		}
-		// 添加行文本（如果不为空）
-		if rowBuffer.Len() > 0 {
-			sheetBuffer.Write(rowBuffer.Bytes())
-			sheetBuffer.WriteString("\n") // 使用换行符分隔行
 		}
 	}
 
-	return sheetBuffer.Bytes(), nil
+	return rows, nil
 }
 
 // getCellValue 获取单元格值，处理共享字符串引用
-func getCellValue(c cell, sharedStrings []string) string {
+func getCellValue(c cell, sharedStrings *sharedStringTable) string {
 	if c.T == "s" && c.V != "" {
 		// 共享字符串引用
-		index, err := strconv.Atoi(c.V)
-		if err == nil && index >= 0 && index < len(sharedStrings) {
-			return sharedStrings[index]
-		}
+		return sharedStrings.lookup(c.V)
 	}
 	// 直接返回单元格值或其他类型数据
 	return c.V
@@ -183,26 +501,12 @@ func readZipFile(zf *zip.File) ([]byte, error) {
 
 const spreadsheetMLNamespace = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
 
-// worksheet 工作表XML根结构
-type worksheet struct {
-	XMLName   xml.Name  `xml:"http://schemas.openxmlformats.org/spreadsheetml/2006/main worksheet"`
-	SheetData sheetData `xml:"http://schemas.openxmlformats.org/spreadsheetml/2006/main sheetData"`
-}
-
-// sheetData 工作表数据
-type sheetData struct {
-	Row []row `xml:"http://schemas.openxmlformats.org/spreadsheetml/2006/main row"`
-}
-
-// row 行
-type row struct {
-	C []cell `xml:"http://schemas.openxmlformats.org/spreadsheetml/2006/main c"` // 单元格
-}
-
-// cell 单元格
+// cell 单元格，由parseSheetXml流式解析填充
 type cell struct {
-	V string `xml:"http://schemas.openxmlformats.org/spreadsheetml/2006/main v"` // 单元格值
-	T string `xml:"t,attr"`                                                      // 单元格类型 (s表示共享字符串)
+	R string // A1样式的单元格引用，可省略
+	V string // 单元格值
+	T string // 单元格类型 (s表示共享字符串)
+	S string // 样式索引，对应styles.xml中的cellXfs
 }
 
 // sharedStrings 共享字符串表
@@ -0,0 +1,128 @@
+package xlsx
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"fextra/internal"
+	"fextra/internal/ziptest"
+)
+
+const sheetHeader = `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+// sheetPackage 构造只包含工作表的XLSX，工作表名称为文件名sheetN
+func sheetPackage(sheets ...string) []byte {
+	var files []ziptest.File
+	for i, sheet := range sheets {
+		files = append(files, ziptest.File{Name: fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), Data: sheet})
+	}
+	return ziptest.Build(files)
+}
+
+// TestLayoutGapBounded 保留位置时，超大行号、列号补齐的空行及空列不超过工作表范围及输出上限
+func TestLayoutGapBounded(t *testing.T) {
+	data := sheetPackage(sheetHeader +
+		`<row r="1"><c r="A1" t="inlineStr"><v>first</v></c></row>` +
+		`<row r="999999999"><c r="ZZZ999999999"><v>2</v></c></row>` +
+		`</sheetData></worksheet>`)
+	header := internal.Sections().FormatHeader(internal.SectionSheet, 1, "sheet1")
+
+	p := &OfficeXlsxParser{PreserveLayout: true, MaxOutputBytes: 1000}
+	text, err := p.ParseReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	if !bytes.HasPrefix(text, []byte(header+"first\n")) || len(text) > 1000+len(internal.TruncatedMarker) {
+		t.Errorf("got %d bytes, prefix %q", len(text), text[:min(len(text), len(header)+10)])
+	}
+
+	// 不限制输出时按Excel的行列上限补齐
+	text, err = (&OfficeXlsxParser{PreserveLayout: true}).ParseReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	if want := len(header+"first\n") + (maxSheetRows - 2) + (maxSheetColumns - 1) + len("2\n"); len(text) != want {
+		t.Errorf("got %d bytes, want %d", len(text), want)
+	}
+}
+
+// TestLayoutPositions 跳过的列总是输出为空字段，保留位置时空行输出为空行
+func TestLayoutPositions(t *testing.T) {
+	data := sheetPackage(sheetHeader +
+		`<row r="1"><c r="A1"><v>1</v></c><c r="C1"><v>3</v></c></row>` +
+		`<row r="3"><c r="B3"><v>4</v></c></row>` +
+		`</sheetData></worksheet>`)
+	header := internal.Sections().FormatHeader(internal.SectionSheet, 1, "sheet1")
+
+	for _, tt := range []struct {
+		layout bool
+		want   string
+	}{
+		{false, "1\t\t3\n\t4\n"},
+		{true, "1\t\t3\n\n\t4\n"},
+	} {
+		text, err := (&OfficeXlsxParser{PreserveLayout: tt.layout}).ParseReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("ParseReader: %v", err)
+		}
+		if string(text) != header+tt.want {
+			t.Errorf("layout=%v: got %q, want %q", tt.layout, text, header+tt.want)
+		}
+	}
+}
+
+func TestParseDateCode(t *testing.T) {
+	for _, tt := range []struct {
+		code               string
+		wantDate, wantTime bool
+	}{
+		{"yyyy-mm-dd", true, false},
+		{"hh:mm", false, true},
+		{"yyyy-mm-dd hh:mm:ss", true, true},
+		{`yyyy "hours" mm`, true, false},
+		{`mm "sec"`, true, false},
+		{`d\h m`, true, false},
+		{"[$-409]mmm d, yyyy", true, false},
+		{"[Red]mm/dd", true, false},
+		{"[h]:mm", false, true},
+		{"[mm]:ss", false, true},
+		{`_(* #,##0_)`, false, false},
+	} {
+		gotDate, gotTime := parseDateCode(tt.code)
+		if gotDate != tt.wantDate || gotTime != tt.wantTime {
+			t.Errorf("%q: got date=%v time=%v, want date=%v time=%v", tt.code, gotDate, gotTime, tt.wantDate, tt.wantTime)
+		}
+	}
+}
+
+// TestDate1904 workbookPr的date1904为真时按1904日期系统转换日期序列号
+func TestDate1904(t *testing.T) {
+	styles := `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<cellXfs><xf numFmtId="14"/></cellXfs></styleSheet>`
+	sheet := sheetHeader + `<row r="1"><c r="A1" s="0"><v>43831</v></c></row></sheetData></worksheet>`
+
+	for _, tt := range []struct {
+		workbookPr string
+		want       string
+	}{
+		{``, "2020-01-01"},
+		{`<workbookPr date1904="0"/>`, "2020-01-01"},
+		{`<workbookPr date1904="1"/>`, "2024-01-02"},
+		{`<workbookPr date1904="true"/>`, "2024-01-02"},
+	} {
+		data := ziptest.Build([]ziptest.File{
+			{Name: "xl/workbook.xml", Data: `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+				tt.workbookPr + `</workbook>`},
+			{Name: "xl/styles.xml", Data: styles},
+			{Name: "xl/worksheets/sheet1.xml", Data: sheet},
+		})
+		text, err := (&OfficeXlsxParser{ApplyNumberFormats: true}).ParseReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("ParseReader: %v", err)
+		}
+		if !bytes.Contains(text, []byte(tt.want+"\n")) {
+			t.Errorf("%q: got %q, want %s", tt.workbookPr, text, tt.want)
+		}
+	}
+}
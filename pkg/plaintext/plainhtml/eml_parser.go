@@ -0,0 +1,89 @@
+package plainhtml
+
+import (
+	"bufio"
+	"bytes"
+	"fextra/internal"
+	"fmt"
+	"io"
+	"mime"
+	"net/mail"
+	"net/textproto"
+	"os"
+
+	"golang.org/x/net/html/charset"
+
+	"fextra/pkg/logger"
+)
+
+/*
+	EML为RFC 822/5322格式的邮件：头部之后为正文，多部分邮件（multipart/alternative、multipart/mixed）
+	通常同时包含text/plain与text/html两种正文。头部字段中的非ASCII文本使用RFC 2047编码（=?charset?B/Q?...?=）
+*/
+
+// emlHeaders 输出的头部字段及显示名称
+var emlHeaders = []struct {
+	key   string
+	label string
+}{
+	{"Subject", "主题"},
+	{"From", "发件人"},
+	{"To", "收件人"},
+	{"Cc", "抄送"},
+}
+
+// TextEMLParser EML邮件解析器，提取主题、发件人、收件人、日期及正文
+// 优先使用text/plain正文，仅有HTML正文时交由TextHTMLParser提取文本
+type TextEMLParser struct {
+	HTML TextHTMLParser // HTML正文的提取选项
+}
+
+func (p *TextEMLParser) Parse(filePath string) ([]byte, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return []byte{}, fmt.Errorf("读取EML文件 '%s' 失败: %w", filePath, err)
+	}
+	defer file.Close()
+
+	return p.ParseReader(file)
+}
+
+// ParseReader 从io.Reader中读取邮件并提取头部字段及正文
+func (p *TextEMLParser) ParseReader(r io.Reader) ([]byte, error) {
+	msg, err := mail.ReadMessage(bufio.NewReader(r))
+	if err != nil {
+		return []byte{}, fmt.Errorf("解析EML头部失败: %w: %w", internal.ErrInvalidSignature, err)
+	}
+
+	var content bytes.Buffer
+	decoder := &mime.WordDecoder{CharsetReader: charset.NewReaderLabel}
+	for _, h := range emlHeaders {
+		value := msg.Header.Get(h.key)
+		if value == "" {
+			continue
+		}
+		if decoded, err := decoder.DecodeHeader(value); err == nil {
+			value = decoded
+		}
+		fmt.Fprintf(&content, "%s: %s\n", h.label, value)
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		fmt.Fprintf(&content, "日期: %s\n", date.UTC().Format("2006-01-02 15:04:05 UTC"))
+	} else if value := msg.Header.Get("Date"); value != "" {
+		fmt.Fprintf(&content, "日期: %s\n", value)
+	}
+	content.WriteString("\n")
+
+	body, mediaType, err := findBodyPart(textproto.MIMEHeader(msg.Header), msg.Body, "text/plain")
+	if err != nil {
+		logger.Logger.Printf("提取EML正文失败: %v", err)
+		return content.Bytes(), nil
+	}
+	if mediaType == "text/html" {
+		if body, err = p.HTML.ParseHtml(body); err != nil {
+			return content.Bytes(), err
+		}
+	}
+	content.Write(bytes.ReplaceAll(body, []byte("\r\n"), []byte("\n")))
+	return content.Bytes(), nil
+}
@@ -6,12 +6,26 @@ import (
 	"regexp"
 	"strings"
 
+	"io"
 	"os"
 
+	"fextra/internal"
+
 	"golang.org/x/net/html"
 )
 
-type TextHTMLParser struct{}
+type TextHTMLParser struct {
+	// InlineAdjacency 为true时，仅在块级元素边界插入分隔符，行内元素（a、b、span等）与相邻文本直接拼接，
+	// 避免中文/日文等无空格语言的词语被拆开；默认所有文本片段以空格连接
+	InlineAdjacency bool
+
+	// MainContent 为true时只提取正文：删除nav、aside、footer等模板元素，按文本密度选出正文所在的元素
+	// （优先article、main及class/id像是正文的元素），适用于为文章类网页建立索引；无法判断时提取全部文本
+	MainContent bool
+
+	// JoinWithoutSpaceForCJK 为true时，相邻文本片段在连接处两侧均为中文、日文字符时直接拼接，不插入空格
+	JoinWithoutSpaceForCJK bool
+}
 
 // TextHTMLParser 用于解析HTML并提取可视化文本内容
 var (
@@ -35,6 +49,19 @@ func (p *TextHTMLParser) ParseHtml(htmlContent []byte) ([]byte, error) {
 		return []byte{}, fmt.Errorf("html parse error: %w", err)
 	}
 
+	roots := []*html.Node{doc}
+	if p.MainContent {
+		roots = extractMainContent(doc)
+	}
+
+	if p.InlineAdjacency {
+		blocks := make([]string, len(roots))
+		for i, root := range roots {
+			blocks[i] = extractBlockText(root)
+		}
+		return []byte(p.processExtractedText(strings.Join(blocks, " "))), nil
+	}
+
 	// 提取文本内容
 	var textSegments []string
 	var extractText func(*html.Node)
@@ -52,7 +79,7 @@ func (p *TextHTMLParser) ParseHtml(htmlContent []byte) ([]byte, error) {
 
 		// 忽略脚本、样式、头部和元数据标签内容
 		if n.Type == html.ElementNode {
-			if n.Data == "script" || n.Data == "style" || n.Data == "head" || n.Data == "meta" || n.Data == "link" {
+			if invisibleElements[n.Data] {
 				return
 			}
 			// 特别处理br标签为空格
@@ -67,12 +94,70 @@ func (p *TextHTMLParser) ParseHtml(htmlContent []byte) ([]byte, error) {
 		}
 	}
 
-	extractText(doc)
+	for _, root := range roots {
+		extractText(root)
+	}
 
-	extractedText := p.processExtractedText(strings.Join(textSegments, " "))
+	extractedText := p.processExtractedText(internal.JoinCJK(textSegments, " ", p.JoinWithoutSpaceForCJK))
 	return []byte(extractedText), nil
 }
 
+// invisibleElements 脚本、样式、头部和元数据等不输出文本的元素
+var invisibleElements = map[string]bool{
+	"script": true, "style": true, "head": true, "meta": true, "link": true,
+}
+
+// blockElements 块级元素，其边界处需要插入分隔符
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "body": true,
+	"caption": true, "dd": true, "details": true, "div": true, "dl": true, "dt": true,
+	"fieldset": true, "figcaption": true, "figure": true, "footer": true, "form": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hr": true, "html": true, "li": true, "main": true, "nav": true,
+	"ol": true, "option": true, "p": true, "pre": true, "section": true, "summary": true,
+	"table": true, "tbody": true, "td": true, "tfoot": true, "th": true, "thead": true,
+	"title": true, "tr": true, "ul": true,
+}
+
+// extractBlockText 提取文本，仅在块级元素边界和br处插入空格，行内元素保持相邻
+func extractBlockText(doc *html.Node) string {
+	var builder strings.Builder
+	var extractText func(*html.Node)
+
+	extractText = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			// 保留文本节点自身的空白，由processExtractedText统一规范化
+			builder.WriteString(n.Data)
+			return
+		}
+
+		isBlock := false
+		if n.Type == html.ElementNode {
+			if invisibleElements[n.Data] {
+				return
+			}
+			if n.Data == "br" {
+				builder.WriteString(" ")
+				return
+			}
+			isBlock = blockElements[n.Data]
+		}
+
+		if isBlock {
+			builder.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			extractText(c)
+		}
+		if isBlock {
+			builder.WriteString(" ")
+		}
+	}
+
+	extractText(doc)
+	return builder.String()
+}
+
 // ParseFile 从HTML文件中提取可视化文本
 // processExtractedText 处理提取到的文本：去除HTML实体、过滤不可见字符、规范化空白
 func (p *TextHTMLParser) processExtractedText(rawText string) string {
@@ -92,3 +177,13 @@ func (p *TextHTMLParser) Parse(filePath string) ([]byte, error) {
 
 	return p.ParseHtml(fileContent)
 }
+
+// ParseReader 从io.Reader中读取HTML并提取可视化文本
+func (p *TextHTMLParser) ParseReader(r io.Reader) ([]byte, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return []byte{}, fmt.Errorf("读取HTML内容失败: %w", err)
+	}
+
+	return p.ParseHtml(content)
+}
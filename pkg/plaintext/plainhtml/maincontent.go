@@ -0,0 +1,233 @@
+package plainhtml
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+/*
+	正文提取（参考Readability的启发式算法）：
+	1. 删除导航、侧栏、页脚等标签，以及class/id像是模板内容（菜单、评论、广告、分享等）且不像正文的元素
+	2. 对每个段落（p、pre、td及不含块级子元素的div/section）按文本长度和逗号数计分，累加到父元素，减半累加到祖父元素；
+	   候选元素按标签及class/id另有初始分，最终分数乘以(1-链接文本占比)
+	3. 取分数最高的元素，并保留其兄弟元素中分数足够高或链接较少的长段落，没有可计分的段落时返回整个文档
+*/
+
+var (
+	// unlikelyCandidates class/id中出现这些词的元素通常不是正文
+	unlikelyCandidates = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|extra|foot|header|menu|modal|nav|pager|pagination|popup|related|remark|rss|share|shoutbox|sidebar|social|sponsor|ad-break|ads|agegate|subscribe|tweet`)
+	// maybeCandidate 同时出现这些词时保留
+	maybeCandidate = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	// positiveNames、negativeNames 调整候选元素初始分的class/id
+	positiveNames = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+	negativeNames = regexp.MustCompile(`(?i)hidden|banner|combx|comment|com-|contact|foot|footer|footnote|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+)
+
+// boilerplateTags 直接删除的模板标签
+var boilerplateTags = map[string]bool{
+	"nav": true, "aside": true, "footer": true, "form": true, "noscript": true,
+	"iframe": true, "button": true, "select": true, "svg": true,
+}
+
+// minParagraphLen 参与计分的段落的最少字符数
+const minParagraphLen = 25
+
+// extractMainContent 返回正文所在的元素，按文档顺序排列；无法判断时返回整个文档
+func extractMainContent(doc *html.Node) []*html.Node {
+	removeBoilerplate(doc)
+
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node
+	addScore := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = initialScore(n)
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+
+	walkElements(doc, func(n *html.Node) {
+		if !isParagraph(n) {
+			return
+		}
+		text := strings.TrimSpace(nodeText(n))
+		length := utf8.RuneCountInString(text)
+		if length < minParagraphLen {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "，")) + min(float64(length)/100, 3)
+		addScore(n.Parent, score)
+		if n.Parent != nil {
+			addScore(n.Parent.Parent, score/2)
+		}
+	})
+	if len(candidates) == 0 {
+		return []*html.Node{doc}
+	}
+
+	var top *html.Node
+	for _, n := range candidates {
+		scores[n] *= 1 - linkDensity(n)
+		if top == nil || scores[n] > scores[top] {
+			top = n
+		}
+	}
+	if top.Parent == nil {
+		return []*html.Node{top}
+	}
+
+	// 正文可能被拆分到多个兄弟元素中
+	threshold := max(10, scores[top]*0.2)
+	var content []*html.Node
+	for c := top.Parent.FirstChild; c != nil; c = c.NextSibling {
+		if c == top {
+			content = append(content, c)
+			continue
+		}
+		if c.Type != html.ElementNode {
+			continue
+		}
+		if score, ok := scores[c]; ok && score >= threshold {
+			content = append(content, c)
+		} else if c.Data == "p" {
+			text := strings.TrimSpace(nodeText(c))
+			length := utf8.RuneCountInString(text)
+			density := linkDensity(c)
+			if (length > 80 && density < 0.25) || (length > 0 && density == 0 && strings.ContainsAny(text, ".。")) {
+				content = append(content, c)
+			}
+		}
+	}
+	return content
+}
+
+// removeBoilerplate 删除模板标签及class/id像是模板内容的元素
+func removeBoilerplate(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.ElementNode && isBoilerplate(c) {
+			n.RemoveChild(c)
+		} else {
+			removeBoilerplate(c)
+		}
+		c = next
+	}
+}
+
+func isBoilerplate(n *html.Node) bool {
+	if boilerplateTags[n.Data] {
+		return true
+	}
+	switch n.Data {
+	case "html", "body", "article", "main", "a":
+		return false
+	}
+	names := attr(n, "class") + " " + attr(n, "id")
+	return unlikelyCandidates.MatchString(names) && !maybeCandidate.MatchString(names)
+}
+
+// initialScore 候选元素按标签及class/id的初始分
+func initialScore(n *html.Node) float64 {
+	var score float64
+	switch n.Data {
+	case "article", "main":
+		score = 10
+	case "div", "section":
+		score = 5
+	case "pre", "td", "blockquote":
+		score = 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li":
+		score = -3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score = -5
+	}
+	for _, name := range []string{attr(n, "class"), attr(n, "id")} {
+		if name == "" {
+			continue
+		}
+		if negativeNames.MatchString(name) {
+			score -= 25
+		}
+		if positiveNames.MatchString(name) {
+			score += 25
+		}
+	}
+	return score
+}
+
+// isParagraph 判断元素是否为计分的段落：p、pre、td，或不含块级子元素的div、section
+func isParagraph(n *html.Node) bool {
+	switch n.Data {
+	case "p", "pre", "td":
+		return true
+	case "div", "section":
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && blockElements[c.Data] {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// linkDensity 链接文本占元素文本的比例
+func linkDensity(n *html.Node) float64 {
+	length := utf8.RuneCountInString(strings.TrimSpace(nodeText(n)))
+	if length == 0 {
+		return 0
+	}
+	var linkLength int
+	walkElements(n, func(c *html.Node) {
+		if c.Data == "a" {
+			linkLength += utf8.RuneCountInString(strings.TrimSpace(nodeText(c)))
+		}
+	})
+	return min(float64(linkLength)/float64(length), 1)
+}
+
+// walkElements 按文档顺序遍历n下的元素（不含n本身），不进入脚本、样式等不可见元素
+func walkElements(n *html.Node, fn func(*html.Node)) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || invisibleElements[c.Data] {
+			continue
+		}
+		fn(c)
+		walkElements(c, fn)
+	}
+}
+
+// nodeText 返回元素下所有可见文本，不含脚本、样式等
+func nodeText(n *html.Node) string {
+	var sb strings.Builder
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+			return
+		}
+		if n.Type == html.ElementNode && invisibleElements[n.Data] {
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(n)
+	return sb.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
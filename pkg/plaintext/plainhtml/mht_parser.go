@@ -0,0 +1,138 @@
+package plainhtml
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fextra/internal"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+
+	"fextra/pkg/logger"
+)
+
+/*
+	MHT/MHTML（"网页，单个文件"）为MIME格式：
+	通常为multipart/related，首个text/html部分为页面正文，其余部分为内联的图片、样式等资源。
+	正文可能使用quoted-printable或base64传输编码，字符集由Content-Type的charset参数声明
+*/
+
+// TextMHTParser MHT/MHTML网页归档解析器，提取HTML正文后交由TextHTMLParser处理
+type TextMHTParser struct {
+	HTML TextHTMLParser // HTML正文的提取选项
+}
+
+func (p *TextMHTParser) Parse(filePath string) ([]byte, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return []byte{}, fmt.Errorf("读取MHT文件 '%s' 失败: %w", filePath, err)
+	}
+	defer file.Close()
+
+	return p.ParseReader(file)
+}
+
+// ParseReader 从io.Reader中读取MHT内容并提取HTML正文的文本
+func (p *TextMHTParser) ParseReader(r io.Reader) ([]byte, error) {
+	msg, err := mail.ReadMessage(bufio.NewReader(r))
+	if err != nil {
+		return []byte{}, fmt.Errorf("解析MHT头部失败: %w: %w", internal.ErrInvalidSignature, err)
+	}
+
+	content, mediaType, err := findBodyPart(textproto.MIMEHeader(msg.Header), msg.Body, "text/html")
+	if err != nil {
+		return []byte{}, err
+	}
+	if mediaType == "text/plain" {
+		return content, nil
+	}
+	return p.HTML.ParseHtml(content)
+}
+
+// findBodyPart 在MIME实体中查找第一个prefer类型（text/html或text/plain）的部分并解码为UTF-8，
+// 不含该类型时退而使用第一个另一类型的文本部分
+func findBodyPart(header textproto.MIMEHeader, body io.Reader, prefer string) ([]byte, string, error) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		// 未声明Content-Type的实体按prefer类型处理
+		mediaType, params = prefer, nil
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		reader := multipart.NewReader(body, params["boundary"])
+		var fallback []byte
+		var fallbackType string
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, "", fmt.Errorf("读取MIME部分失败: %w", err)
+			}
+			content, partType, err := findBodyPart(part.Header, part, prefer)
+			if err != nil {
+				logger.Logger.Printf("跳过MIME部分: %v", err)
+				continue
+			}
+			if partType == prefer {
+				return content, partType, nil
+			}
+			if (partType == "text/html" || partType == "text/plain") && fallback == nil {
+				fallback, fallbackType = content, partType
+			}
+		}
+		if fallback != nil {
+			return fallback, fallbackType, nil
+		}
+		return nil, "", fmt.Errorf("未找到%s正文: %w", prefer, internal.ErrStreamNotFound)
+	case isAttachment(header):
+		// 以附件形式携带的文本文件不作为正文
+		return nil, "", nil
+	case mediaType == "text/html" || mediaType == "text/plain":
+		content, err := decodePart(header, body, params["charset"])
+		return content, mediaType, err
+	default:
+		return nil, mediaType, nil
+	}
+}
+
+// isAttachment 检查Content-Disposition是否为attachment
+func isAttachment(header textproto.MIMEHeader) bool {
+	disposition, _, err := mime.ParseMediaType(header.Get("Content-Disposition"))
+	return err == nil && disposition == "attachment"
+}
+
+// decodePart 按Content-Transfer-Encoding及charset将部分内容解码为UTF-8
+func decodePart(header textproto.MIMEHeader, body io.Reader, label string) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(header.Get("Content-Transfer-Encoding"))) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body) // 解码器忽略行尾的\r\n
+	}
+
+	if label != "" {
+		decoded, err := charset.NewReaderLabel(label, body)
+		if err != nil {
+			logger.Logger.Printf("不支持的字符集%s: %v，按UTF-8处理", label, err)
+		} else {
+			body = decoded
+		}
+	}
+
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("解码MIME内容失败: %w", err)
+	}
+	return content, nil
+}
@@ -0,0 +1,200 @@
+package plainmd
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"fextra/pkg/logger"
+)
+
+/*
+	静态网站生成器（Hugo、Jekyll、Hexo等）的Markdown文件以front-matter开始：
+	YAML以"---"行开始和结束，TOML以"+++"行开始和结束，其中为标题、标签、日期等元数据。
+	front-matter不属于正文，goldmark会将其解析为分隔线及段落，因此在解析前去除
+*/
+
+// frontMatterFences front-matter的分隔行及对应的格式
+var frontMatterFences = []struct {
+	fence  string
+	format string
+}{
+	{"---", "yaml"},
+	{"+++", "toml"},
+}
+
+// splitFrontMatter 拆分文件开头的front-matter，返回元数据及之后的正文；
+// 没有front-matter（或缺少结束行）时返回nil及原内容，元数据无法解析时只记录日志
+func splitFrontMatter(content []byte) (map[string]string, []byte) {
+	content = bytes.TrimPrefix(content, []byte("\xEF\xBB\xBF"))
+	for _, f := range frontMatterFences {
+		line, rest, ok := cutLine(content)
+		if !ok || strings.TrimRight(line, " \t") != f.fence {
+			continue
+		}
+		for pos := len(content) - len(rest); pos < len(content); {
+			line, next, _ := cutLine(content[pos:])
+			if strings.TrimRight(line, " \t") == f.fence {
+				block := content[len(content)-len(rest) : pos]
+				body := content[len(content)-len(next):]
+				meta, err := parseFrontMatter(block, f.format)
+				if err != nil {
+					logger.Logger.Printf("解析%s front-matter失败: %v", f.format, err)
+				}
+				return meta, body
+			}
+			pos = len(content) - len(next)
+		}
+		return nil, content
+	}
+	return nil, content
+}
+
+// cutLine 读取一行（不含换行符），返回该行及之后的内容，content为空时ok为false
+func cutLine(content []byte) (line string, rest []byte, ok bool) {
+	if len(content) == 0 {
+		return "", content, false
+	}
+	before, after, found := bytes.Cut(content, []byte("\n"))
+	if !found {
+		after = content[len(content):]
+	}
+	return strings.TrimSuffix(string(before), "\r"), after, true
+}
+
+// parseFrontMatter 解析front-matter中的顶层字段，列表以逗号分隔，嵌套的表不输出
+func parseFrontMatter(block []byte, format string) (map[string]string, error) {
+	if format == "toml" {
+		return parseTomlFields(block), nil
+	}
+
+	var fields yaml.MapSlice
+	if err := yaml.Unmarshal(block, &fields); err != nil {
+		return nil, err
+	}
+	meta := make(map[string]string, len(fields))
+	for _, field := range fields {
+		if value, ok := metaValue(field.Value); ok {
+			meta[fmt.Sprint(field.Key)] = value
+		}
+	}
+	return meta, nil
+}
+
+// metaValue 将YAML值转换为字符串，列表中的项以逗号分隔，嵌套的表返回false
+func metaValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", true
+	case string:
+		return v, true
+	case time.Time:
+		return v.Format(time.RFC3339), true
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := metaValue(item); ok && s != "" {
+				items = append(items, s)
+			}
+		}
+		return strings.Join(items, ", "), true
+	case yaml.MapSlice, map[interface{}]interface{}:
+		return "", false
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// parseTomlFields 读取TOML中第一个表（[table]）之前的"键 = 值"，
+// 支持字符串、数组（不跨行）及数字、布尔、日期等字面量
+func parseTomlFields(block []byte) map[string]string {
+	meta := make(map[string]string)
+	for _, line := range strings.Split(string(block), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			break
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = tomlString(strings.TrimSpace(key))
+		value = stripTomlComment(strings.TrimSpace(value))
+		if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+			var items []string
+			for _, item := range splitTomlArray(value[1 : len(value)-1]) {
+				if item = tomlString(strings.TrimSpace(item)); item != "" {
+					items = append(items, item)
+				}
+			}
+			meta[key] = strings.Join(items, ", ")
+			continue
+		}
+		meta[key] = tomlString(value)
+	}
+	return meta
+}
+
+// tomlString 去除字符串字面量的引号，基本字符串按转义规则解码
+func tomlString(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return s[1 : len(s)-1]
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if unquoted, err := strconv.Unquote(s); err == nil {
+			return unquoted
+		}
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// stripTomlComment 去除值之后的注释，忽略引号内的#
+func stripTomlComment(value string) string {
+	var quote byte
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return strings.TrimSpace(value[:i])
+		}
+	}
+	return value
+}
+
+// splitTomlArray 按逗号拆分数组中的项，忽略引号内的逗号
+func splitTomlArray(s string) []string {
+	var items []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, s[start:])
+}
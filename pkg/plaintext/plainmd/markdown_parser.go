@@ -1,7 +1,9 @@
 package plainmd
 
 import (
+	"fextra/internal"
 	"fextra/pkg/logger"
+	"io"
 	"os"
 	"regexp"
 	"strings"
@@ -13,7 +15,23 @@ import (
 	"github.com/yuin/goldmark/text"
 )
 
-type TextMarkdownParser struct{}
+type TextMarkdownParser struct {
+	// CodeBlockMode 代码块（```围栏代码块及缩进代码块）的输出方式，默认与正文一起输出
+	CodeBlockMode CodeBlockMode
+
+	// JoinWithoutSpaceForCJK 为true时，段落内的软换行两侧均为中文、日文字符时直接拼接，
+	// 不输出换行（输出时换行会变为空格，把一个句子拆开）
+	JoinWithoutSpaceForCJK bool
+}
+
+// CodeBlockMode 代码块的输出方式
+type CodeBlockMode int
+
+const (
+	CodeBlockInclude CodeBlockMode = iota // 代码与正文一起输出
+	CodeBlockExclude                      // 不输出代码，适用于只索引正文
+	CodeBlockLabeled                      // 代码前后加标记行，开始行带围栏代码块信息字符串中的语言，如"=== 代码: go ==="
+)
 
 // MarkdownParser 用于提取Markdown文本内容的解析器
 var (
@@ -31,8 +49,9 @@ func init() {
 	newlineRegex = regexp.MustCompile(`\n+`)
 }
 
-// Parse 从Markdown字节内容中提取纯文本
+// Parse 从Markdown字节内容中提取纯文本，开头的front-matter不输出
 func (p *TextMarkdownParser) ParseMd(content []byte) (string, error) {
+	_, content = splitFrontMatter(content)
 	md := goldmark.New()
 	reader := text.NewReader(content)
 	rootNode := md.Parser().Parse(reader) // 生成 AST 根节点
@@ -49,73 +68,18 @@ func (p *TextMarkdownParser) ParseMd(content []byte) (string, error) {
 				// 提取行内代码内容
 				logger.DebugLogger.Printf("CodeSpan: %s", string(n.Text(content)))
 				textSegments = append(textSegments, string(n.Text(content)))
-			case *ast.CodeBlock:
-				// 提取代码块内容（包括```标记内的代码）
-				/*
-					lines := n.Lines()
-					codeContent := make([]byte, 0)
-					for i := 0; i < lines.Len(); i++ {
-						seg := lines.At(i)
-						logger.DebugLogger.Printf("CodeBlock Line[%d]: %s", i, string(seg.Value(content)))
-						codeContent = append(codeContent, seg.Value(content)...)
-						codeContent = append(codeContent, '\n') // 保留原始换行
-					}
-					// 移除末尾多余的换行
-					if len(codeContent) > 0 {
-						codeContent = codeContent[:len(codeContent)-1]
-					}
-				*/
-				logger.DebugLogger.Printf("FencedCodeBlock Line: %s", string(n.Text(content)))
-				codeContent := n.Text(content)
-				textSegments = append(textSegments, string(codeContent))
-			case *ast.FencedCodeBlock:
+			case *ast.CodeBlock, *ast.FencedCodeBlock:
 				// 提取代码块内容（包括```标记内的代码）
-				/*
-					lines := n.Lines()
-					codeContent := make([]byte, 0)
-					for i := 0; i < lines.Len(); i++ {
-						seg := lines.At(i)
-						logger.DebugLogger.Printf("FencedCodeBlock Line[%d]: %s", i, string(seg.Value(content)))
-						codeContent = append(codeContent, seg.Value(content)...)
-						codeContent = append(codeContent, '\n') // 保留原始换行
-					}
-					// 移除末尾多余的换行
-					if len(codeContent) > 0 {
-						codeContent = codeContent[:len(codeContent)-1]
-					}
-				*/
-				logger.DebugLogger.Printf("FencedCodeBlock Line: %s", string(n.Text(content)))
-				codeContent := n.Text(content)
-				textSegments = append(textSegments, string(codeContent))
-			case *ast.Heading:
-				// 提取标题文本（包含所有级别）
-				ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
-					if entering && child.Kind() == ast.KindText {
-						logger.DebugLogger.Printf("Heading Text: %s", string(child.(*ast.Text).Value(content)))
-						textSegments = append(textSegments, string(child.(*ast.Text).Value(content)))
-					}
-					return ast.WalkContinue, nil
-				})
-				return ast.WalkSkipChildren, nil // 跳过子节点避免重复处理
-			case *ast.Paragraph:
-				// 提取段落文本
-				ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
-					if entering && child.Kind() == ast.KindText {
-						logger.DebugLogger.Printf("Paragraph Text: %s", string(child.(*ast.Text).Value(content)))
-						textSegments = append(textSegments, string(child.(*ast.Text).Value(content)))
-					}
-					return ast.WalkContinue, nil
-				})
-				return ast.WalkSkipChildren, nil // 跳过子节点避免重复处理
-			case *ast.ListItem:
-				// 提取列表项文本
-				ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
-					if entering && child.Kind() == ast.KindText {
-						logger.DebugLogger.Printf("ListItem Text: %s", string(child.(*ast.Text).Value(content)))
-						textSegments = append(textSegments, string(child.(*ast.Text).Value(content)))
-					}
-					return ast.WalkContinue, nil
-				})
+				if code, ok := p.codeBlockText(n, content); ok {
+					logger.DebugLogger.Printf("CodeBlock: %s", code)
+					textSegments = append(textSegments, code)
+				}
+				return ast.WalkSkipChildren, nil
+			case *ast.Heading, *ast.Paragraph, *ast.ListItem:
+				// 提取标题、段落及列表项文本，强调、链接、行内代码中的文本与相邻文本拼接为一段
+				inline := inlineText(n, content, p.JoinWithoutSpaceForCJK)
+				logger.DebugLogger.Printf("%s Text: %s", n.Kind(), inline)
+				textSegments = append(textSegments, inline)
 				return ast.WalkSkipChildren, nil // 跳过子节点避免重复处理
 			case *ast.Blockquote:
 				// 继续遍历子节点以处理所有内容
@@ -159,6 +123,65 @@ func (p *TextMarkdownParser) ParseMd(content []byte) (string, error) {
 	return p.processExtractedText(rawText), nil
 }
 
+// inlineText 拼接节点下的文本，软、硬换行及块级子节点（如列表项中的段落）之间换行，
+// 自动链接输出其URL，行内HTML不输出；cjk为true时两侧均为中文、日文字符的软换行直接拼接
+func inlineText(node ast.Node, content []byte, cjk bool) string {
+	var sb strings.Builder
+	softBreak := false // 上一段文本以软换行结束，换行在写入下一段文本时决定是否输出
+	write := func(text string) {
+		if softBreak && !(cjk && internal.CJKAdjacent(sb.String(), text)) {
+			sb.WriteString("\r\n")
+		}
+		softBreak = false
+		sb.WriteString(text)
+	}
+	ast.Walk(node, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			if child != node && child.Type() == ast.TypeBlock {
+				write("\r\n")
+			}
+			return ast.WalkContinue, nil
+		}
+		switch c := child.(type) {
+		case *ast.Text:
+			write(string(c.Value(content)))
+			if c.HardLineBreak() {
+				write("\r\n")
+			} else if c.SoftLineBreak() {
+				softBreak = true
+			}
+		case *ast.String:
+			write(string(c.Value))
+		case *ast.AutoLink:
+			write(string(c.URL(content)))
+			return ast.WalkSkipChildren, nil
+		case *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimRight(sb.String(), "\r\n")
+}
+
+// codeBlockText 按CodeBlockMode返回代码块的输出内容，不输出时ok为false
+func (p *TextMarkdownParser) codeBlockText(node ast.Node, content []byte) (string, bool) {
+	if p.CodeBlockMode == CodeBlockExclude {
+		return "", false
+	}
+	code := string(node.Text(content))
+	if p.CodeBlockMode != CodeBlockLabeled {
+		return code, true
+	}
+
+	label := "=== 代码 ==="
+	if fenced, ok := node.(*ast.FencedCodeBlock); ok {
+		if language := fenced.Language(content); len(language) > 0 {
+			label = fmt.Sprintf("=== 代码: %s ===", language)
+		}
+	}
+	return label + "\n" + strings.TrimRight(code, "\n") + "\n=== 代码结束 ===", true
+}
+
 // processExtractedText 处理提取的文本，移除不可见字符并规范化空白
 func (p *TextMarkdownParser) processExtractedText(text string) string {
 	// 移除不可见字符
@@ -186,3 +209,35 @@ func (p *TextMarkdownParser) Parse(filePath string) ([]byte, error) {
 
 	return []byte(data), nil
 }
+
+// ParseWithInfo 提取纯文本，并返回front-matter中的元数据
+func (p *TextMarkdownParser) ParseWithInfo(filePath string) ([]byte, internal.ExtractInfo, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return []byte{}, internal.ExtractInfo{}, fmt.Errorf("无法读取Markdown文件: %w", err)
+	}
+
+	meta, _ := splitFrontMatter(content)
+	info := internal.ExtractInfo{Metadata: meta}
+	data, err := p.ParseMd(content)
+	if err != nil {
+		return []byte{}, info, fmt.Errorf("无法解析Markdown文件: %w", err)
+	}
+
+	return []byte(data), info, nil
+}
+
+// ParseReader 从io.Reader中读取Markdown并提取纯文本
+func (p *TextMarkdownParser) ParseReader(r io.Reader) ([]byte, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return []byte{}, fmt.Errorf("无法读取Markdown内容: %w", err)
+	}
+
+	data, err := p.ParseMd(content)
+	if err != nil {
+		return []byte{}, fmt.Errorf("无法解析Markdown内容: %w", err)
+	}
+
+	return []byte(data), nil
+}
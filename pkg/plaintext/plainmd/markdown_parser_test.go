@@ -0,0 +1,13 @@
+package plainmd
+
+import "testing"
+
+func TestParseMdJoinsInlineText(t *testing.T) {
+	got, err := (&TextMarkdownParser{}).ParseMd([]byte("**bold** and [link](x) and `code`"))
+	if err != nil {
+		t.Fatalf("ParseMd: %v", err)
+	}
+	if want := "bold and link and code"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,151 @@
+package plaintabular
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"fextra/internal"
+	"fextra/pkg/logger"
+	"fextra/pkg/plaintext/plaintxt"
+)
+
+/*
+	plaintabular 用于解析CSV/TSV等分隔符表格文本：
+	默认与TextPlainParser相同，输出转换为UTF-8后的原始文本；
+	Records时每条记录输出一行，字段以制表符连接；
+	HasHeader时首行作为表头，每条记录输出为"表头: 值"形式的多行，记录之间以空行分隔
+*/
+
+// sniffDelimiters 未指定分隔符时依次尝试的候选分隔符
+var sniffDelimiters = []rune{',', ';', '\t', '|'}
+
+// Parser 分隔符表格文本解析器
+type Parser struct {
+	Delimiter rune // 字段分隔符，为0时根据首行内容在 , ; \t | 中推测
+	Records   bool // 按记录输出，每条记录一行，字段以制表符连接
+	HasHeader bool // 首行是否为表头，为true时按"表头: 值"输出每条记录
+	Comment   rune // 注释行起始字符，为0时不识别注释；设置后按记录输出，不输出注释行
+}
+
+func (p *Parser) Parse(filePath string) ([]byte, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return []byte{}, err
+	}
+	return p.parse(data)
+}
+
+func (p *Parser) ParseReader(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return []byte{}, err
+	}
+	return p.parse(data)
+}
+
+// ParseWithInfo 提取文本并返回检测到的源编码，与TextPlainParser相同
+func (p *Parser) ParseWithInfo(filePath string) ([]byte, internal.ExtractInfo, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return []byte{}, internal.ExtractInfo{}, err
+	}
+	text, info := plaintxt.DecodeText(data)
+	out, err := p.parseText(text)
+	return out, info, err
+}
+
+func (p *Parser) parse(data []byte) ([]byte, error) {
+	// DecodeText已去除BOM，首个表头不会带有不可见字符
+	text, _ := plaintxt.DecodeText(data)
+	return p.parseText(text)
+}
+
+// parseText 按选项输出转换为UTF-8后的文本，未设置Records、HasHeader、Comment时原样返回
+func (p *Parser) parseText(text []byte) ([]byte, error) {
+	if !p.Records && !p.HasHeader && p.Comment == 0 {
+		return text, nil
+	}
+
+	reader := csv.NewReader(bytes.NewReader(text))
+	reader.Comma = p.Delimiter
+	if reader.Comma == 0 {
+		reader.Comma = sniffDelimiter(text, p.Comment)
+	}
+	reader.Comment = p.Comment
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var out bytes.Buffer
+	var header []string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) && out.Len() == 0 {
+				// 不是合法的分隔符表格，按原始文本返回
+				logger.Logger.Printf("表格解析失败: %v，按原始文本返回", err)
+				return text, nil
+			}
+			return out.Bytes(), fmt.Errorf("表格解析失败: %w", err)
+		}
+
+		if !p.HasHeader {
+			out.WriteString(strings.Join(record, "\t"))
+			out.WriteByte('\n')
+			continue
+		}
+		if header == nil {
+			header = record
+			continue
+		}
+		writeRecord(&out, header, record)
+	}
+
+	return out.Bytes(), nil
+}
+
+// writeRecord 按"表头: 值"输出一条记录，忽略空值，超出表头的列以列序号命名
+func writeRecord(out *bytes.Buffer, header []string, record []string) {
+	for i, value := range record {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		key := ""
+		if i < len(header) {
+			key = strings.TrimSpace(header[i])
+		}
+		if key == "" {
+			key = fmt.Sprintf("列%d", i+1)
+		}
+		fmt.Fprintf(out, "%s: %s\n", key, value)
+	}
+	out.WriteByte('\n')
+}
+
+// sniffDelimiter 取首个非空、非注释行中出现次数最多的候选分隔符，均未出现时使用逗号
+func sniffDelimiter(text []byte, comment rune) rune {
+	var line []byte
+	for len(text) > 0 {
+		line, text, _ = bytes.Cut(text, []byte("\n"))
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 && (comment == 0 || !bytes.HasPrefix(line, []byte(string(comment)))) {
+			break
+		}
+	}
+
+	delimiter, best := ',', 0
+	for _, d := range sniffDelimiters {
+		if n := bytes.Count(line, []byte(string(d))); n > best {
+			delimiter, best = d, n
+		}
+	}
+	return delimiter
+}
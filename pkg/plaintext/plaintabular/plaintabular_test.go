@@ -0,0 +1,25 @@
+package plaintabular
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	const csv = "name,city\n\"Li, Wei\",Beijing\n# note\nZhang,\n"
+	for _, tt := range []struct {
+		name   string
+		parser Parser
+		want   string
+	}{
+		{"default keeps the text", Parser{}, csv},
+		{"records", Parser{Records: true}, "name\tcity\nLi, Wei\tBeijing\n# note\nZhang\t\n"},
+		{"comment", Parser{Comment: '#'}, "name\tcity\nLi, Wei\tBeijing\nZhang\t\n"},
+		{"header", Parser{HasHeader: true, Comment: '#'}, "name: Li, Wei\ncity: Beijing\n\nname: Zhang\n\n"},
+	} {
+		got, err := tt.parser.parse([]byte(csv))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
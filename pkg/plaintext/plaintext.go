@@ -4,16 +4,21 @@ import (
 	"fextra/internal"
 	"fextra/pkg/plaintext/plainhtml"
 	"fextra/pkg/plaintext/plainmd"
+	"fextra/pkg/plaintext/plaintabular"
 	"fextra/pkg/plaintext/plaintxt"
 	"fextra/pkg/plaintext/plainxml"
 )
 
 func init() {
-	// html:1 txt:2  xml:3  json:4   csv:5
+	// html:1 txt:2  xml:3  json:4   csv:5  tsv:501  mht:502  eml:503
 	internal.RegisterParser(internal.FileTypeTXT, &plaintxt.TextPlainParser{})
-	internal.RegisterParser(internal.FileTypeCSV, &plaintxt.TextPlainParser{})
+	internal.RegisterParser(internal.FileTypeCSV, &plaintabular.Parser{})
+	internal.RegisterParser(internal.FileTypeTSV, &plaintabular.Parser{Delimiter: '\t'})
 	internal.RegisterParser(internal.FileTypeXML, &plainxml.TextXMLParser{})
 	internal.RegisterParser(internal.FileTypeJSON, &plaintxt.TextPlainParser{})
 	internal.RegisterParser(internal.FileTypeHTML, &plainhtml.TextHTMLParser{})
+	internal.RegisterParser(internal.FileTypeMHT, &plainhtml.TextMHTParser{})
+	internal.RegisterParser(internal.FileTypeEML, &plainhtml.TextEMLParser{})
 	internal.RegisterParser(internal.FileTypeMD, &plainmd.TextMarkdownParser{})
+	internal.RegisterParser(internal.FileTypeSVG, &plainxml.TextSVGParser{})
 }
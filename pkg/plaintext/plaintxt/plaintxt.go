@@ -1,9 +1,103 @@
 package plaintxt
 
-import "os"
+import (
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/saintfish/chardet"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+
+	"fextra/internal"
+	"fextra/pkg/logger"
+)
+
+// detectSampleSize 编码检测使用的最大样本长度，避免大文件检测过慢
+const detectSampleSize = 64 * 1024
 
 type TextPlainParser struct{}
 
 func (p *TextPlainParser) Parse(filePath string) ([]byte, error) {
-	return os.ReadFile(filePath)
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return []byte{}, err
+	}
+	text, _ := decodeText(data)
+	return text, nil
+}
+
+// ParseWithInfo 提取文本并返回检测到的源编码，语言取自编码检测结果
+func (p *TextPlainParser) ParseWithInfo(filePath string) ([]byte, internal.ExtractInfo, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return []byte{}, internal.ExtractInfo{}, err
+	}
+	text, info := decodeText(data)
+	return text, info, nil
+}
+
+func (p *TextPlainParser) ParseReader(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return []byte{}, err
+	}
+	text, _ := decodeText(data)
+	return text, nil
+}
+
+// DecodeText 将文本转换为UTF-8并返回检测到的编码，供CSV等同样需要编码检测的文本解析器使用
+func DecodeText(data []byte) ([]byte, internal.ExtractInfo) {
+	return decodeText(data)
+}
+
+// decodeText 将文本转换为UTF-8，同时返回检测到的编码及语言
+// 以UTF-8、UTF-16 BOM开头时按BOM解码并去除BOM；纯ASCII和合法UTF-8（日志、配置文件等最常见的情况）直接返回，不进行编码检测；
+// 无法解码的字符及无法识别编码时的无效字节按internal.Replacement替换
+func decodeText(data []byte) ([]byte, internal.ExtractInfo) {
+	if text, charset := internal.StripBOM(data); charset != "" {
+		return internal.ReplaceInvalidBytes(text), internal.ExtractInfo{Charset: charset}
+	}
+	// utf8.Valid对ASCII按8字节一组快速检查，无需单独判断纯ASCII
+	if utf8.Valid(data) {
+		return data, internal.ExtractInfo{Charset: "UTF-8"}
+	}
+
+	sample := data
+	if len(sample) > detectSampleSize {
+		sample = sample[:detectSampleSize]
+	}
+
+	result, err := chardet.NewTextDetector().DetectBest(sample)
+	if err != nil {
+		logger.Logger.Printf("编码检测失败: %v，按原始内容返回", err)
+		return internal.ReplaceInvalidBytes(data), internal.ExtractInfo{}
+	}
+	info := internal.ExtractInfo{Charset: result.Charset, Language: result.Language}
+
+	var decoder encoding.Encoding
+	switch strings.ToLower(result.Charset) {
+	case "utf-16le":
+		decoder = unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	case "utf-16be":
+		decoder = unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)
+	case "gbk", "gb2312", "gb18030", "gb-18030":
+		decoder = simplifiedchinese.GB18030
+	case "big5":
+		decoder = traditionalchinese.Big5
+	default:
+		logger.Logger.Printf("不支持的编码格式: %s，按原始内容返回", result.Charset)
+		return internal.ReplaceInvalidBytes(data), info
+	}
+
+	decoded, _, err := transform.Bytes(decoder.NewDecoder(), data)
+	if err != nil {
+		logger.Logger.Printf("文本解码失败(%s): %v，按原始内容返回", result.Charset, err)
+		return internal.ReplaceInvalidBytes(data), info
+	}
+	return internal.ReplaceInvalidBytes(decoded), info
 }
@@ -0,0 +1,26 @@
+package plaintxt
+
+import (
+	"bytes"
+	"testing"
+)
+
+// BenchmarkDecodeText 纯ASCII、合法UTF-8输入直接返回，不进行编码检测
+func BenchmarkDecodeText(b *testing.B) {
+	inputs := []struct {
+		name string
+		data []byte
+	}{
+		{"ASCII", bytes.Repeat([]byte("2026-10-17 12:00:00 INFO request handled in 12ms\n"), 20000)},
+		{"UTF-8", bytes.Repeat([]byte("2026-10-17 12:00:00 信息 请求处理完成，耗时12ms\n"), 20000)},
+		{"GBK", bytes.Repeat([]byte{0xD0, 0xC5, 0xCF, 0xA2, ' ', 0xC7, 0xEB, 0xC7, 0xF3, '\n'}, 100000)},
+	}
+	for _, in := range inputs {
+		b.Run(in.name, func(b *testing.B) {
+			b.SetBytes(int64(len(in.data)))
+			for i := 0; i < b.N; i++ {
+				decodeText(in.data)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package plainxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// TextSVGParser 用于提取SVG中<text>/<tspan>元素的文本（图表标签、注释等）
+type TextSVGParser struct{}
+
+// ParseSvg 从SVG内容中提取文本，每个<text>元素输出一行
+func (p *TextSVGParser) ParseSvg(svgContent []byte) ([]byte, error) {
+	decoder := newDecoder(svgContent)
+	decoder.Strict = false
+
+	var result bytes.Buffer
+	var line strings.Builder
+	textDepth := 0 // 所在<text>元素的嵌套层数
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("svg decode error: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Local == "text":
+				textDepth++
+			case textDepth > 0 && t.Name.Local == "tspan" && hasPosition(t):
+				// 带定位属性的tspan通常为多行标签中的一行，用空格分隔
+				if line.Len() > 0 {
+					line.WriteString(" ")
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "text" && textDepth > 0 {
+				textDepth--
+				if textDepth == 0 {
+					if text := whitespaceRegex.ReplaceAllString(strings.TrimSpace(line.String()), " "); text != "" {
+						result.WriteString(text)
+						result.WriteString("\n")
+					}
+					line.Reset()
+				}
+			}
+		case xml.CharData:
+			if textDepth > 0 {
+				line.Write(t)
+			}
+		}
+	}
+
+	return result.Bytes(), nil
+}
+
+// hasPosition 判断元素是否带有x/y/dy定位属性
+func hasPosition(t xml.StartElement) bool {
+	for _, attr := range t.Attr {
+		if attr.Name.Local == "x" || attr.Name.Local == "y" || attr.Name.Local == "dy" {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse 从SVG文件中提取文本
+func (p *TextSVGParser) Parse(filePath string) ([]byte, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("read svg file error: %w", err)
+	}
+
+	return p.ParseSvg(content)
+}
+
+// ParseReader 从io.Reader中读取SVG并提取文本
+func (p *TextSVGParser) ParseReader(r io.Reader) ([]byte, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read svg content error: %w", err)
+	}
+
+	return p.ParseSvg(content)
+}
@@ -3,6 +3,8 @@ package plainxml
 import (
 	"bytes"
 	"encoding/xml"
+	"errors"
+	"fextra/internal"
 	"fextra/pkg/logger"
 	"fmt"
 	"html"
@@ -12,7 +14,40 @@ import (
 	"strings"
 )
 
-type TextXMLParser struct{}
+type TextXMLParser struct {
+	// 以下限制用于防止不可信XML耗尽内存，为0时使用默认值
+	MaxTokens int // 最大token数量
+	MaxDepth  int // 最大嵌套深度
+	MaxAttrs  int // 单个元素的最大属性数量
+
+	// JoinWithoutSpaceForCJK 为true时，相邻文本片段在连接处两侧均为中文、日文字符时直接拼接，不插入空格
+	JoinWithoutSpaceForCJK bool
+}
+
+// 默认解析限制
+const (
+	DefaultMaxTokens = 10000000
+	DefaultMaxDepth  = 1000
+	DefaultMaxAttrs  = 1000
+)
+
+// ErrXMLLimitExceeded XML超出解析限制
+var ErrXMLLimitExceeded = errors.New("xml exceeds parse limit")
+
+// limits 返回生效的解析限制
+func (p *TextXMLParser) limits() (maxTokens, maxDepth, maxAttrs int) {
+	maxTokens, maxDepth, maxAttrs = p.MaxTokens, p.MaxDepth, p.MaxAttrs
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxTokens
+	}
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+	if maxAttrs <= 0 {
+		maxAttrs = DefaultMaxAttrs
+	}
+	return maxTokens, maxDepth, maxAttrs
+}
 
 // TextXMLParser 用于解析XML并提取纯文本内容
 var (
@@ -26,14 +61,29 @@ func init() {
 	whitespaceRegex = regexp.MustCompile(`[\s\x{A0}\x{2000}-\x{200A}\x{2028}\x{2029}\x{202F}\x{205F}\x{3000}]+`)
 }
 
+// newDecoder 创建XML解码器，开头的UTF-8、UTF-16 BOM先去除，UTF-16内容转换为UTF-8后解码，
+// 此时忽略XML声明中的encoding
+func newDecoder(content []byte) *xml.Decoder {
+	content, charset := internal.StripBOM(content)
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	if strings.HasPrefix(charset, "UTF-16") {
+		decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+			return input, nil
+		}
+	}
+	return decoder
+}
+
 // Parse 从XML内容中提取纯文本
 func (p *TextXMLParser) ParseXml(xmlContent []byte) ([]byte, error) {
-	decoder := xml.NewDecoder(bytes.NewReader(xmlContent))
+	decoder := newDecoder(xmlContent)
 	decoder.Strict = false                // 容忍格式不严格的XML
 	decoder.AutoClose = xml.HTMLAutoClose // 自动关闭常见标签
 
 	var textSegments []string
 	depth := 0
+	tokens := 0
+	maxTokens, maxDepth, maxAttrs := p.limits()
 
 	for {
 		token, err := decoder.Token()
@@ -44,6 +94,11 @@ func (p *TextXMLParser) ParseXml(xmlContent []byte) ([]byte, error) {
 			return nil, fmt.Errorf("xml decode error: %w", err)
 		}
 
+		tokens++
+		if tokens > maxTokens {
+			return nil, fmt.Errorf("%w: token count exceeds %d", ErrXMLLimitExceeded, maxTokens)
+		}
+
 		switch t := token.(type) {
 		case xml.CharData:
 			// 处理文本节点
@@ -54,6 +109,12 @@ func (p *TextXMLParser) ParseXml(xmlContent []byte) ([]byte, error) {
 			logger.Logger.Printf("text: %s", text)
 		case xml.StartElement:
 			depth++
+			if depth > maxDepth {
+				return nil, fmt.Errorf("%w: nesting depth exceeds %d", ErrXMLLimitExceeded, maxDepth)
+			}
+			if len(t.Attr) > maxAttrs {
+				return nil, fmt.Errorf("%w: element %s has more than %d attributes", ErrXMLLimitExceeded, t.Name.Local, maxAttrs)
+			}
 			logger.Logger.Printf("depth: %d, start element: %v", depth, t)
 		case xml.EndElement:
 			if depth > 0 {
@@ -67,7 +128,7 @@ func (p *TextXMLParser) ParseXml(xmlContent []byte) ([]byte, error) {
 	}
 
 	// 处理提取到的文本
-	text := strings.Join(textSegments, " ")
+	text := internal.JoinCJK(textSegments, " ", p.JoinWithoutSpaceForCJK)
 	text = html.UnescapeString(text)
 	text = invisibleCharsRegex.ReplaceAllString(text, "")
 	text = whitespaceRegex.ReplaceAllString(text, " ")
@@ -84,3 +145,13 @@ func (p *TextXMLParser) Parse(filePath string) ([]byte, error) {
 
 	return p.ParseXml(content)
 }
+
+// ParseReader 从io.Reader中读取XML并提取纯文本
+func (p *TextXMLParser) ParseReader(r io.Reader) ([]byte, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read xml content error: %w", err)
+	}
+
+	return p.ParseXml(content)
+}
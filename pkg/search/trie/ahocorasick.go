@@ -0,0 +1,130 @@
+package trie
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Match 关键词在文本中的一次出现，Start、End为字节偏移（左闭右开）
+type Match struct {
+	Keyword string
+	Start   int
+	End     int
+}
+
+// acNode Aho-Corasick自动机节点
+type acNode struct {
+	children map[rune]*acNode
+	fail     *acNode
+	outputs  []int // 在此结束的关键词序号，包含经失败链接可达的后缀关键词
+}
+
+// Matcher 基于Aho-Corasick自动机的多关键词匹配器，一次扫描找出所有关键词的全部出现位置，
+// 用于对提取的文本做脱敏或高亮；创建后只读，可并发使用
+type Matcher struct {
+	root     *acNode
+	keywords []string
+}
+
+// NewMatcher 由关键词创建匹配器，空关键词忽略，重复关键词只保留一个
+func NewMatcher(keywords []string) *Matcher {
+	m := &Matcher{root: &acNode{children: make(map[rune]*acNode)}}
+	seen := make(map[string]bool)
+	for _, key := range keywords {
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		node := m.root
+		for _, ch := range key {
+			if node.children[ch] == nil {
+				node.children[ch] = &acNode{children: make(map[rune]*acNode)}
+			}
+			node = node.children[ch]
+		}
+		node.outputs = append(node.outputs, len(m.keywords))
+		m.keywords = append(m.keywords, key)
+	}
+	m.buildFailLinks()
+	return m
+}
+
+// buildFailLinks 按层次遍历设置失败链接，并合并后缀节点的输出
+func (m *Matcher) buildFailLinks() {
+	queue := make([]*acNode, 0, len(m.root.children))
+	for _, child := range m.root.children {
+		child.fail = m.root
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for ch, child := range node.children {
+			fail := node.fail
+			for fail != nil && fail.children[ch] == nil {
+				fail = fail.fail
+			}
+			if fail == nil {
+				child.fail = m.root
+			} else {
+				child.fail = fail.children[ch]
+			}
+			child.outputs = append(child.outputs, child.fail.outputs...)
+			queue = append(queue, child)
+		}
+	}
+}
+
+// FindAll 返回text中所有关键词的出现位置，包括相互重叠的匹配，按Start、End升序排列
+func (m *Matcher) FindAll(text string) []Match {
+	var matches []Match
+	node := m.root
+	for i := 0; i < len(text); {
+		ch, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		if ch == utf8.RuneError && size == 1 {
+			// 无效的UTF-8字节不属于任何关键词
+			node = m.root
+			continue
+		}
+		for node != m.root && node.children[ch] == nil {
+			node = node.fail
+		}
+		if next := node.children[ch]; next != nil {
+			node = next
+		}
+		for _, k := range node.outputs {
+			key := m.keywords[k]
+			matches = append(matches, Match{Keyword: key, Start: i - len(key), End: i})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Start != matches[j].Start {
+			return matches[i].Start < matches[j].Start
+		}
+		return matches[i].End < matches[j].End
+	})
+	return matches
+}
+
+// Redact 将text中被matches覆盖的每个字符替换为mask，重叠的匹配合并处理
+func Redact(text string, matches []Match, mask string) string {
+	if len(matches) == 0 {
+		return text
+	}
+	var sb strings.Builder
+	sb.Grow(len(text))
+	pos := 0
+	for _, match := range matches {
+		start := max(match.Start, pos)
+		if start >= match.End {
+			continue
+		}
+		sb.WriteString(text[pos:start])
+		sb.WriteString(strings.Repeat(mask, utf8.RuneCountInString(text[start:match.End])))
+		pos = match.End
+	}
+	sb.WriteString(text[pos:])
+	return sb.String()
+}
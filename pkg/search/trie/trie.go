@@ -24,6 +24,18 @@ func NewPrefixMatcher(keys []string) *PrefixMatcher {
 	return &PrefixMatcher{root: root}
 }
 
+// Contains 判断s是否为完整的键
+func (m *PrefixMatcher) Contains(s string) bool {
+	node := m.root
+	for _, ch := range s {
+		if node.children[ch] == nil {
+			return false
+		}
+		node = node.children[ch]
+	}
+	return node.isEnd
+}
+
 func (m *PrefixMatcher) HasPrefix(s string) bool {
 	node := m.root
 	for _, ch := range s {
@@ -0,0 +1,781 @@
+//go:build ignore
+
+// generate 生成testdata下的二进制样本（文本格式的样本为手工编写），在仓库根目录执行：
+//
+//	go run testdata/generate.go
+//
+// 各样本只包含解析器需要的最小结构，内容与extract_test.go中期望的文本对应；
+// bz2样本调用系统的bzip2命令生成
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz"
+
+	"fextra/internal/cfbtest"
+	"fextra/internal/ziptest"
+)
+
+func main() {
+	samples := map[string][]byte{
+		"sample.doc":     buildDoc(),
+		"sample.ppt":     buildPpt(),
+		"sample.xls":     buildXls(),
+		"sample.msg":     buildMsg(),
+		"sample.vsd":     buildVsd(),
+		"sample.docx":    buildDocx(),
+		"sample.pptx":    buildPptx(),
+		"sample.xlsx":    buildXlsx(),
+		"sample.xlsb":    buildXlsb(),
+		"sample.vsdx":    buildVsdx(),
+		"sample.odt":     buildOdt(),
+		"sample.odp":     buildOdp(),
+		"sample.ods":     buildOds(),
+		"sample.epub":    buildEpub(),
+		"sample.pdf":     buildPDF("PDF sample page"),
+		"sample.pages":   iworkPackage("preview.pdf", "Pages preview"),
+		"sample.key":     iworkPackage("QuickLook/Preview.pdf", "Keynote preview"),
+		"sample.numbers": iworkPackage("preview.pdf", "Numbers preview"),
+		"sample.djvu":    buildDjvu(),
+		"sample.png":     buildPng(),
+		"sample.jpg":     buildJpeg(),
+		"sample.tif":     buildTiff("TIFF图片描述"),
+		"sample.webp":    buildWebp(),
+		"sample.zip":     buildZip(map[string]string{"readme.txt": "ZIP成员文本\n", "data.csv": "列一,列二\nZIP表格,1\n"}),
+		"sample.jar":     buildZip(map[string]string{"META-INF/MANIFEST.MF": "Manifest-Version: 1.0\n", "notes.txt": "JAR成员文本\n"}),
+		"sample.war":     buildZip(map[string]string{"WEB-INF/web.xml": "<web-app><display-name>WAR应用</display-name></web-app>\n"}),
+		"sample.tar":     buildTar("member.txt", "TAR成员文本\n"),
+		"sample.txt.gz":  gzipData("sample.txt", []byte("GZ压缩文本\n")),
+		"sample.tar.gz":  gzipData("", buildTar("member.txt", "TAR.GZ成员文本\n")),
+		"sample.txt.bz2": bzip2Data([]byte("BZ2压缩文本\n")),
+		"sample.tar.bz2": bzip2Data(buildTar("member.txt", "TAR.BZ2成员文本\n")),
+		"sample.txt.xz":  xzData([]byte("XZ压缩文本\n")),
+		"sample.tar.xz":  xzData(buildTar("member.txt", "TAR.XZ成员文本\n")),
+		"sample.txt.zst": zstdData([]byte("ZST压缩文本\n")),
+		"sample.txt.lz4": lz4Data([]byte("LZ4压缩文本\n")),
+		"sample.txt.sz":  snappyData([]byte("Snappy压缩文本\n")),
+		"sample.7z":      build7z("member.txt", "7Z member text\n"),
+		"sample.rar":     buildRar("member.txt", "RAR member text\n"),
+	}
+	for name, data := range samples {
+		if err := os.WriteFile(filepath.Join("testdata", name), data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+var le = binary.LittleEndian
+
+func utf16le(s string) []byte {
+	var out []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		out = le.AppendUint16(out, u)
+	}
+	return out
+}
+
+// buildDoc Word 97文档：正文由两个片段组成，第一个为8位压缩的ASCII，第二个为UTF-16，片段表位于1Table
+func buildDoc() []byte {
+	ascii, unicode := "Word 97 sample, ", "中文文档正文\r"
+	n1, n2 := len(ascii), len(utf16.Encode([]rune(unicode)))
+
+	wd := make([]byte, 0x600)
+	le.PutUint16(wd[0x00:], 0xA5EC)
+	le.PutUint16(wd[0x02:], 0x00C1)
+	le.PutUint16(wd[0x0A:], 0x0200) // fWhichTblStm，使用1Table
+	off := 32
+	le.PutUint16(wd[off:], 14) // csw
+	off += 2 + 28
+	le.PutUint16(wd[off:], 22) // cslw
+	le.PutUint32(wd[off+2+3*4:], uint32(n1+n2))
+	off += 2 + 88
+	le.PutUint16(wd[off:], 0x5D) // cbRgFcLcb
+	copy(wd[0x400:], ascii)
+	wd = append(wd, utf16le(unicode)...)
+
+	clx := []byte{0x02, 0, 0, 0, 0}
+	le.PutUint32(clx[1:], 3*4+2*8)
+	for _, cp := range []uint32{0, uint32(n1), uint32(n1 + n2)} {
+		clx = le.AppendUint32(clx, cp)
+	}
+	for _, fc := range []uint32{0x40000000 | 0x400*2, 0x600} {
+		clx = le.AppendUint16(clx, 0)
+		clx = le.AppendUint32(clx, fc)
+		clx = le.AppendUint16(clx, 0)
+	}
+	const fcClx = 0x20
+	le.PutUint32(wd[off+2+66*4:], fcClx)
+	le.PutUint32(wd[off+2+67*4:], uint32(len(clx)))
+
+	return cfbtest.Build([]cfbtest.Stream{
+		{Name: "WordDocument", Data: wd},
+		{Name: "1Table", Data: append(make([]byte, fcClx), clx...)},
+	}, cfbtest.Options{})
+}
+
+// pptRecord PowerPoint记录：ver为0xF时为容器
+func pptRecord(ver, instance, recType uint16, data []byte) []byte {
+	out := le.AppendUint16(nil, instance<<4|ver)
+	out = le.AppendUint16(out, recType)
+	out = le.AppendUint32(out, uint32(len(data)))
+	return append(out, data...)
+}
+
+// buildPpt 幻灯片文本列表中的一张幻灯片：UTF-16的标题及8位的正文
+func buildPpt() []byte {
+	var list []byte
+	list = append(list, pptRecord(0, 0, 0x03F3, make([]byte, 20))...)          // SlidePersistAtom
+	list = append(list, pptRecord(0, 0, 0x003F, le.AppendUint32(nil, 0))...)   // TextHeaderAtom: 标题
+	list = append(list, pptRecord(0, 0, 0x0FA0, utf16le("演示文稿样例"))...)         // TextCharsAtom
+	list = append(list, pptRecord(0, 0, 0x003F, le.AppendUint32(nil, 1))...)   // TextHeaderAtom: 正文
+	list = append(list, pptRecord(0, 0, 0x0FA8, []byte("Slide body text"))...) // TextBytesAtom
+	document := pptRecord(0xF, 0, 0x03E8, pptRecord(0xF, 0, 0x0FF0, list))     // Document、SlideListWithText
+	return cfbtest.Build([]cfbtest.Stream{
+		{Name: "Current User", Data: make([]byte, 20)},
+		{Name: "PowerPoint Document", Data: document},
+	}, cfbtest.Options{})
+}
+
+// grid 各表格样本共用的工作表，"42"为数值单元格
+var grid = [][]string{
+	{"名称", "数量"},
+	{"苹果", "42"},
+}
+
+const sheetName = "数据"
+
+func biffRecord(id uint16, data []byte) []byte {
+	out := le.AppendUint16(nil, id)
+	out = le.AppendUint16(out, uint16(len(data)))
+	return append(out, data...)
+}
+
+func biffBOF(dt uint16) []byte {
+	data := le.AppendUint16(nil, 0x0600)
+	data = le.AppendUint16(data, dt)
+	return biffRecord(0x0809, append(data, make([]byte, 12)...))
+}
+
+// biffString BIFF8不压缩的UTF-16字符串，不含长度
+func biffString(s string) []byte {
+	return append([]byte{0x01}, utf16le(s)...)
+}
+
+// buildXls BIFF8工作簿：全局子流（BOF、BOUNDSHEET、SST、EOF）之后为工作表子流
+func buildXls() []byte {
+	var strs []string
+	var sst []byte
+	index := make(map[string]uint32)
+	for _, row := range grid {
+		for _, value := range row {
+			if _, ok := index[value]; ok || value == "42" {
+				continue
+			}
+			index[value] = uint32(len(strs))
+			strs = append(strs, value)
+			sst = le.AppendUint16(sst, uint16(len(utf16.Encode([]rune(value)))))
+			sst = append(sst, biffString(value)...)
+		}
+	}
+	counts := le.AppendUint32(nil, uint32(len(strs)))
+	counts = le.AppendUint32(counts, uint32(len(strs)))
+
+	sheet := biffBOF(0x0010)
+	for r, row := range grid {
+		info := le.AppendUint16(nil, uint16(r))
+		info = le.AppendUint16(info, 0)
+		info = le.AppendUint16(info, uint16(len(row)))
+		sheet = append(sheet, biffRecord(0x0208, append(info, make([]byte, 10)...))...)
+		for c, value := range row {
+			cell := le.AppendUint16(nil, uint16(r))
+			cell = le.AppendUint16(cell, uint16(c))
+			cell = le.AppendUint16(cell, 0)
+			if value == "42" {
+				sheet = append(sheet, biffRecord(0x0203, le.AppendUint64(cell, math.Float64bits(42)))...)
+			} else {
+				sheet = append(sheet, biffRecord(0x00FD, le.AppendUint32(cell, index[value]))...)
+			}
+		}
+	}
+	sheet = append(sheet, biffRecord(0x000A, nil)...)
+
+	name := []byte{byte(len(utf16.Encode([]rune(sheetName))))}
+	name = append(name, biffString(sheetName)...)
+	globalsLen := len(biffBOF(0x0005)) + 4 + 6 + len(name) + 4 + len(counts) + len(sst) + 4
+	boundSheet := le.AppendUint32(nil, uint32(globalsLen))
+	boundSheet = append(boundSheet, 0, 0)
+	workbook := biffBOF(0x0005)
+	workbook = append(workbook, biffRecord(0x0085, append(boundSheet, name...))...)
+	workbook = append(workbook, biffRecord(0x00FC, append(counts, sst...))...)
+	workbook = append(workbook, biffRecord(0x000A, nil)...)
+	workbook = append(workbook, sheet...)
+
+	// ole2只支持普通扇区中的Workbook流，补齐到4096字节
+	if pad := 4096 - len(workbook); pad > 0 {
+		workbook = append(workbook, make([]byte, pad)...)
+	}
+	return cfbtest.Build([]cfbtest.Stream{{Name: "Workbook", Data: workbook}}, cfbtest.Options{})
+}
+
+func msgProp(id, t uint16, data []byte) cfbtest.Stream {
+	return cfbtest.Stream{Name: fmt.Sprintf("__substg1.0_%04X%04X", id, t), Data: data}
+}
+
+func msgFixed(headerSize int, id uint16, value uint32) cfbtest.Stream {
+	data := make([]byte, headerSize+16)
+	le.PutUint32(data[headerSize:], uint32(id)<<16|0x0003)
+	le.PutUint32(data[headerSize+8:], value)
+	return cfbtest.Stream{Name: "__properties_version1.0", Data: data}
+}
+
+// buildMsg 主题、发件人、正文为UTF-16属性，收件人及抄送各一个收件人存储
+func buildMsg() []byte {
+	recipient := func(index int, kind uint32, name, smtp string) cfbtest.Stream {
+		return cfbtest.Stream{
+			Name: fmt.Sprintf("__recip_version1.0_#%08X", index),
+			Children: []cfbtest.Stream{
+				msgFixed(8, 0x0C15, kind),
+				msgProp(0x3001, 0x001F, utf16le(name)),
+				msgProp(0x39FE, 0x001F, utf16le(smtp)),
+			},
+		}
+	}
+	return cfbtest.Build([]cfbtest.Stream{
+		msgFixed(32, 0x3FFD, 65001),
+		msgProp(0x0037, 0x001F, utf16le("Outlook邮件样例")),
+		msgProp(0x0C1A, 0x001F, utf16le("张三")),
+		msgProp(0x5D01, 0x001F, utf16le("zhangsan@example.com")),
+		msgProp(0x1000, 0x001F, utf16le("MSG正文第一行\r\n第二行")),
+		recipient(0, 1, "李四", "lisi@example.com"),
+		recipient(1, 2, "王五", "wangwu@example.com"),
+	}, cfbtest.Options{})
+}
+
+// buildVsd VSD没有纯Go的结构化解析，未配置LibreOffice时提取文件中的可打印字符
+func buildVsd() []byte {
+	return cfbtest.Build([]cfbtest.Stream{
+		{Name: "VisioDocument", Data: append([]byte{0x56, 0x69, 0x73, 0x69, 0x6F, 0, 0, 0}, "Visio drawing sample"...)},
+	}, cfbtest.Options{})
+}
+
+func buildDocx() []byte {
+	return ziptest.Build([]ziptest.File{
+		{Name: "[Content_Types].xml", Data: `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/></Types>`},
+		{Name: "word/document.xml", Data: `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+			`<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>DOCX标题</w:t></w:r></w:p>` +
+			`<w:p><w:r><w:t>Word文档正文</w:t></w:r></w:p>` +
+			`</w:body></w:document>`},
+	})
+}
+
+func buildPptx() []byte {
+	slide := func(text string) string {
+		return `<p:sld xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" ` +
+			`xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">` +
+			`<p:cSld><p:spTree><p:sp><p:txBody><a:p><a:r><a:t>` + text + `</a:t></a:r></a:p></p:txBody></p:sp></p:spTree></p:cSld></p:sld>`
+	}
+	return ziptest.Build([]ziptest.File{
+		{Name: "ppt/slides/slide1.xml", Data: slide("PPTX第一页")},
+		{Name: "ppt/slides/slide2.xml", Data: slide("PPTX第二页")},
+	})
+}
+
+// buildXlsx 文本单元格保存在共享字符串表中
+func buildXlsx() []byte {
+	var sheet, sst strings.Builder
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	sst.WriteString(`<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	var count int
+	for r, row := range grid {
+		fmt.Fprintf(&sheet, `<row r="%d">`, r+1)
+		for c, value := range row {
+			if value == "42" {
+				fmt.Fprintf(&sheet, `<c r="%c%d"><v>42</v></c>`, 'A'+c, r+1)
+			} else {
+				fmt.Fprintf(&sheet, `<c r="%c%d" t="s"><v>%d</v></c>`, 'A'+c, r+1, count)
+				fmt.Fprintf(&sst, `<si><t>%s</t></si>`, value)
+				count++
+			}
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+	sst.WriteString(`</sst>`)
+
+	return ziptest.Build([]ziptest.File{
+		{Name: "xl/workbook.xml", Data: `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+			`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="` + sheetName + `" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{Name: "xl/_rels/workbook.xml.rels", Data: `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`},
+		{Name: "xl/sharedStrings.xml", Data: sst.String()},
+		{Name: "xl/worksheets/sheet1.xml", Data: sheet.String()},
+	})
+}
+
+// xlsbRecord MS-XLSB记录：变长编码的类型及长度
+func xlsbRecord(recordType uint32, data []byte) []byte {
+	var out []byte
+	for _, v := range []uint32{recordType, uint32(len(data))} {
+		for v >= 0x80 {
+			out = append(out, byte(v)|0x80)
+			v >>= 7
+		}
+		out = append(out, byte(v))
+	}
+	return append(out, data...)
+}
+
+func xlsbString(s string) []byte {
+	return append(le.AppendUint32(nil, uint32(len(utf16.Encode([]rune(s))))), utf16le(s)...)
+}
+
+func buildXlsb() []byte {
+	const (
+		brtRowHdr   = 0
+		brtCellReal = 5
+		brtCellIstr = 6
+		brtBundleSh = 0x9C
+	)
+	bundle := append(make([]byte, 8), xlsbString("rId1")...)
+	workbookBin := xlsbRecord(brtBundleSh, append(bundle, xlsbString(sheetName)...))
+
+	var sheet []byte
+	for r, row := range grid {
+		sheet = append(sheet, xlsbRecord(brtRowHdr, le.AppendUint32(nil, uint32(r)))...)
+		for c, value := range row {
+			header := le.AppendUint32(nil, uint32(c))
+			header = append(header, 0, 0, 0, 0)
+			if value == "42" {
+				sheet = append(sheet, xlsbRecord(brtCellReal, le.AppendUint64(header, math.Float64bits(42)))...)
+			} else {
+				sheet = append(sheet, xlsbRecord(brtCellIstr, append(header, xlsbString(value)...))...)
+			}
+		}
+	}
+
+	return ziptest.Build([]ziptest.File{
+		{Name: "xl/workbook.bin", Data: string(workbookBin)},
+		{Name: "xl/_rels/workbook.bin.rels", Data: `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Target="worksheets/sheet1.bin"/></Relationships>`},
+		{Name: "xl/worksheets/sheet1.bin", Data: string(sheet)},
+	})
+}
+
+func buildVsdx() []byte {
+	return ziptest.Build([]ziptest.File{
+		{Name: "visio/pages/page1.xml", Data: `<PageContents xmlns="http://schemas.microsoft.com/office/visio/2012/main">` +
+			`<Shapes><Shape ID="1"><Text>VSDX形状文字</Text></Shape></Shapes></PageContents>`},
+	})
+}
+
+const odfNamespaces = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+	`xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" ` +
+	`xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" ` +
+	`xmlns:draw="urn:oasis:names:tc:opendocument:xmlns:drawing:1.0"`
+
+func odfPackage(mimetype, body string) []byte {
+	return ziptest.Build([]ziptest.File{
+		{Name: "mimetype", Data: mimetype},
+		{Name: "content.xml", Data: `<office:document-content ` + odfNamespaces + `><office:body>` + body + `</office:body></office:document-content>`},
+	})
+}
+
+func buildOdt() []byte {
+	return odfPackage("application/vnd.oasis.opendocument.text", `<office:text>`+
+		`<text:h text:outline-level="1">ODT标题</text:h>`+
+		`<text:p>ODT正文 <text:span>段落</text:span></text:p>`+
+		`</office:text>`)
+}
+
+func buildOdp() []byte {
+	page := func(text string) string {
+		return `<draw:page><draw:frame><draw:text-box><text:p>` + text + `</text:p></draw:text-box></draw:frame></draw:page>`
+	}
+	return odfPackage("application/vnd.oasis.opendocument.presentation",
+		`<office:presentation>`+page("ODP第一页")+page("ODP第二页")+`</office:presentation>`)
+}
+
+func buildOds() []byte {
+	var body strings.Builder
+	body.WriteString(`<office:spreadsheet><table:table table:name="` + sheetName + `">`)
+	for _, row := range grid {
+		body.WriteString(`<table:table-row>`)
+		for _, value := range row {
+			body.WriteString(`<table:table-cell><text:p>` + value + `</text:p></table:table-cell>`)
+		}
+		body.WriteString(`</table:table-row>`)
+	}
+	body.WriteString(`</table:table></office:spreadsheet>`)
+	return odfPackage("application/vnd.oasis.opendocument.spreadsheet", body.String())
+}
+
+func buildEpub() []byte {
+	chapter := func(text string) string {
+		return `<html xmlns="http://www.w3.org/1999/xhtml"><body><p>` + text + `</p></body></html>`
+	}
+	return ziptest.Build([]ziptest.File{
+		{Name: "mimetype", Data: "application/epub+zip"},
+		{Name: "META-INF/container.xml", Data: `<container><rootfiles>` +
+			`<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`},
+		{Name: "OEBPS/content.opf", Data: `<package xmlns="http://www.idpf.org/2007/opf"><manifest>` +
+			`<item id="c1" href="chapter1.xhtml" media-type="application/xhtml+xml"/>` +
+			`<item id="c2" href="chapter2.xhtml" media-type="application/xhtml+xml"/>` +
+			`</manifest><spine><itemref idref="c1"/><itemref idref="c2"/></spine></package>`},
+		{Name: "OEBPS/chapter1.xhtml", Data: chapter("EPUB第一章")},
+		{Name: "OEBPS/chapter2.xhtml", Data: chapter("EPUB第二章")},
+	})
+}
+
+// buildPDF 一页Helvetica文本的PDF，xref按各对象的实际偏移生成
+func buildPDF(text string) []byte {
+	stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [4 0 R] /Count 1 >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents 5 0 R >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// iworkPackage iWork包中只解析预览PDF，Index下的IWA不参与提取
+func iworkPackage(preview, text string) []byte {
+	return ziptest.Build([]ziptest.File{
+		{Name: "Index/Document.iwa", Data: "\x00"},
+		{Name: preview, Data: string(buildPDF(text))},
+	})
+}
+
+// iffChunk DjVu的IFF块：4字节ID、4字节大端长度，按偶数字节对齐
+func iffChunk(id string, data []byte) []byte {
+	out := append([]byte(id), binary.BigEndian.AppendUint32(nil, uint32(len(data)))...)
+	out = append(out, data...)
+	if len(data)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+// buildDjvu 单页DjVu，INFO块之后为未压缩的文本层TXTa
+func buildDjvu() []byte {
+	text := "DjVu文本层"
+	layer := []byte{byte(len(text) >> 16), byte(len(text) >> 8), byte(len(text))}
+	layer = append(layer, text...)
+	info := []byte{0, 1, 0, 1, 24, 0, 100, 0, 22, 1}
+	page := append([]byte("DJVU"), iffChunk("INFO", info)...)
+	page = append(page, iffChunk("TXTa", layer)...)
+	return append([]byte("AT&T"), iffChunk("FORM", page)...)
+}
+
+func pixel() image.Image {
+	return image.NewGray(image.Rect(0, 0, 1, 1))
+}
+
+// pngChunk PNG数据块：长度、类型、内容及CRC
+func pngChunk(chunkType string, data []byte) []byte {
+	out := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	body := append([]byte(chunkType), data...)
+	out = append(out, body...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(body))
+}
+
+// buildPng 在IEND之前插入tEXt及UTF-8的iTXt文本块
+func buildPng() []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, pixel()); err != nil {
+		log.Fatal(err)
+	}
+	data := buf.Bytes()
+	iend := len(data) - 12
+	var out []byte
+	out = append(out, data[:iend]...)
+	out = append(out, pngChunk("tEXt", []byte("Title\x00PNG title"))...)
+	out = append(out, pngChunk("iTXt", []byte("Description\x00\x00\x00zh\x00\x00PNG图片说明"))...)
+	return append(out, data[iend:]...)
+}
+
+// buildJpeg 在SOI之后插入EXIF（ImageDescription）及COM注释段
+func buildJpeg() []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, pixel(), nil); err != nil {
+		log.Fatal(err)
+	}
+	segment := func(marker byte, data []byte) []byte {
+		out := []byte{0xFF, marker}
+		out = binary.BigEndian.AppendUint16(out, uint16(len(data)+2))
+		return append(out, data...)
+	}
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment(0xE1, append([]byte("Exif\x00\x00"), buildTiff("JPEG图片描述")...))...)
+	out = append(out, segment(0xFE, []byte("JPEG comment"))...)
+	return append(out, data[2:]...)
+}
+
+// buildTiff 1x1灰度TIFF，IFD0中的ImageDescription为description
+func buildTiff(description string) []byte {
+	desc := append([]byte(description), 0)
+	type entry struct {
+		tag, typ uint16
+		count    uint32
+		value    uint32
+	}
+	const entries = 9
+	ifdEnd := 8 + 2 + entries*12 + 4
+	descOffset := ifdEnd
+	pixelOffset := descOffset + len(desc)
+	ifd := []entry{
+		{0x0100, 3, 1, 1}, // ImageWidth
+		{0x0101, 3, 1, 1}, // ImageLength
+		{0x0102, 3, 1, 8}, // BitsPerSample
+		{0x0103, 3, 1, 1}, // Compression
+		{0x0106, 3, 1, 1}, // PhotometricInterpretation
+		{0x010E, 2, uint32(len(desc)), uint32(descOffset)}, // ImageDescription
+		{0x0111, 4, 1, uint32(pixelOffset)},                // StripOffsets
+		{0x0116, 3, 1, 1},                                  // RowsPerStrip
+		{0x0117, 4, 1, 1},                                  // StripByteCounts
+	}
+	out := []byte("II*\x00")
+	out = le.AppendUint32(out, 8)
+	out = le.AppendUint16(out, entries)
+	for _, e := range ifd {
+		out = le.AppendUint16(out, e.tag)
+		out = le.AppendUint16(out, e.typ)
+		out = le.AppendUint32(out, e.count)
+		out = le.AppendUint32(out, e.value)
+	}
+	out = le.AppendUint32(out, 0)
+	out = append(out, desc...)
+	return append(out, 0x80)
+}
+
+// buildWebp 扩展格式的1x1无损WebP：VP8X声明XMP元数据，之后为VP8L图像及XMP块
+func buildWebp() []byte {
+	lossless, err := base64.StdEncoding.DecodeString("UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA==")
+	if err != nil {
+		log.Fatal(err)
+	}
+	chunk := func(fourcc string, data []byte) []byte {
+		out := append([]byte(fourcc), le.AppendUint32(nil, uint32(len(data)))...)
+		out = append(out, data...)
+		if len(data)%2 == 1 {
+			out = append(out, 0)
+		}
+		return out
+	}
+	xmp := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title><rdf:Alt><rdf:li xml:lang="x-default">WebP标题</rdf:li></rdf:Alt></dc:title>` +
+		`</rdf:Description></rdf:RDF></x:xmpmeta>`
+	body := []byte("WEBP")
+	body = append(body, chunk("VP8X", []byte{0x04, 0, 0, 0, 0, 0, 0, 0, 0, 0})...)
+	body = append(body, lossless[12:]...) // VP8L块
+	body = append(body, chunk("XMP ", []byte(xmp))...)
+	return append(append([]byte("RIFF"), le.AppendUint32(nil, uint32(len(body)))...), body...)
+}
+
+func buildZip(files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range sortedKeys(files) {
+		w, err := zw.Create(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		w.Write([]byte(files[name]))
+	}
+	if err := zw.Close(); err != nil {
+		log.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	for i := range keys {
+		for j := i + 1; j < len(keys); j++ {
+			if keys[j] < keys[i] {
+				keys[i], keys[j] = keys[j], keys[i]
+			}
+		}
+	}
+	return keys
+}
+
+func buildTar(name, text string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(text))}); err != nil {
+		log.Fatal(err)
+	}
+	tw.Write([]byte(text))
+	if err := tw.Close(); err != nil {
+		log.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func gzipData(name string, data []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Name = name
+	zw.Write(data)
+	if err := zw.Close(); err != nil {
+		log.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// bzip2Data 标准库只有bzip2解压，压缩使用系统的bzip2命令
+func bzip2Data(data []byte) []byte {
+	cmd := exec.Command("bzip2", "-c")
+	cmd.Stdin = bytes.NewReader(data)
+	out, err := cmd.Output()
+	if err != nil {
+		log.Fatalf("bzip2: %v", err)
+	}
+	return out
+}
+
+func xzData(data []byte) []byte {
+	var buf bytes.Buffer
+	w, err := xz.NewWriter(&buf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	w.Write(data)
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zstdData(data []byte) []byte {
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer enc.Close()
+	return enc.EncodeAll(data, nil)
+}
+
+func lz4Data(data []byte) []byte {
+	var buf bytes.Buffer
+	w := lz4.NewWriter(&buf)
+	w.Write(data)
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// snappyData Snappy帧格式
+func snappyData(data []byte) []byte {
+	var buf bytes.Buffer
+	w := s2.NewWriter(&buf, s2.WriterSnappyCompat())
+	w.Write(data)
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// build7z 只有一个文件的7z，使用Copy方法保存；各数值均小于128，按单字节NUMBER编码
+func build7z(name, text string) []byte {
+	header := []byte{
+		0x01,       // kHeader
+		0x04,       // kMainStreamsInfo
+		0x06, 0, 1, // kPackInfo: packPos, numPackStreams
+		0x09, byte(len(text)), 0x00,
+		0x07, 0x0B, 1, 0, // kUnPackInfo, kFolder: numFolders, external
+		1, 1, 0x00, // numCoders, 简单coder，Copy方法
+		0x0C, byte(len(text)), // kCodersUnPackSize
+		0x0A, 1, // kCRC，全部定义
+	}
+	header = le.AppendUint32(header, crc32.ChecksumIEEE([]byte(text)))
+	header = append(header, 0x00, 0x00) // kUnPackInfo、kMainStreamsInfo结束
+
+	names := utf16le(name + "\x00")
+	header = append(header, 0x05, 1, 0x11, byte(len(names)+1), 0)
+	header = append(header, names...)
+	header = append(header, 0x00, 0x00) // kFilesInfo、kHeader结束
+
+	start := make([]byte, 20)
+	le.PutUint64(start[0:], uint64(len(text)))
+	le.PutUint64(start[8:], uint64(len(header)))
+	le.PutUint32(start[16:], crc32.ChecksumIEEE(header))
+
+	out := []byte{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C, 0, 4}
+	out = le.AppendUint32(out, crc32.ChecksumIEEE(start))
+	out = append(out, start...)
+	out = append(out, text...)
+	return append(out, header...)
+}
+
+// rarBlock RAR 4.x块：HEAD_CRC为其后头部字节CRC32的低16位
+func rarBlock(headType byte, flags uint16, fields []byte) []byte {
+	head := []byte{headType}
+	head = le.AppendUint16(head, flags)
+	head = le.AppendUint16(head, uint16(7+len(fields)))
+	head = append(head, fields...)
+	return append(le.AppendUint16(nil, uint16(crc32.ChecksumIEEE(head))), head...)
+}
+
+// buildRar 只有一个存储（不压缩）文件的RAR 4.x
+func buildRar(name, text string) []byte {
+	out := []byte("Rar!\x1A\x07\x00")
+	out = append(out, rarBlock(0x73, 0, make([]byte, 6))...) // MAIN_HEAD
+
+	file := le.AppendUint32(nil, uint32(len(text))) // PACK_SIZE
+	file = le.AppendUint32(file, uint32(len(text))) // UNP_SIZE
+	file = append(file, 2)                          // HOST_OS: Windows
+	file = le.AppendUint32(file, crc32.ChecksumIEEE([]byte(text)))
+	file = le.AppendUint32(file, 0x5A210000) // FTIME: 2025-01-01
+	file = append(file, 20, 0x30)            // UNP_VER, METHOD: 存储
+	file = le.AppendUint16(file, uint16(len(name)))
+	file = le.AppendUint32(file, 0x20) // ATTR
+	file = append(file, name...)
+	out = append(out, rarBlock(0x74, 0x8000, file)...)
+	out = append(out, text...)
+	return append(out, rarBlock(0x7B, 0x4000, nil)...) // ENDARC_HEAD
+}